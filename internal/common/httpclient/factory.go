@@ -33,6 +33,7 @@ type ClientConfig struct {
 	ProxyConfig     *config.ProxyConfig
 	MaxIdleConns    int
 	MaxIdlePerHost  int
+	MaxConnsPerHost int // 0表示不限制
 	DisableKeepAlive bool
 	InsecureSkipVerify bool
 }
@@ -97,6 +98,9 @@ func (f *Factory) CreateClient(config ClientConfig) (*http.Client, error) {
 		DisableKeepAlives:     config.DisableKeepAlive,
 		MaxIdleConns:          config.MaxIdleConns,
 		MaxIdleConnsPerHost:   config.MaxIdlePerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		// 自定义了 TLS 配置和拨号器，需要显式启用 HTTP/2
+		ForceAttemptHTTP2: true,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: config.InsecureSkipVerify,
 		},
@@ -172,6 +176,9 @@ func (f *Factory) mergeConfigs(defaultConfig, userConfig ClientConfig) ClientCon
 	if userConfig.MaxIdlePerHost != 0 {
 		result.MaxIdlePerHost = userConfig.MaxIdlePerHost
 	}
+	if userConfig.MaxConnsPerHost != 0 {
+		result.MaxConnsPerHost = userConfig.MaxConnsPerHost
+	}
 	if userConfig.ProxyConfig != nil {
 		result.ProxyConfig = userConfig.ProxyConfig
 	}
@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionLimits 连接池大小限制，0 使用客户端类型的默认值（MaxConnsPerHost 为 0 表示不限制）
+type ConnectionLimits struct {
+	MaxIdleConns    int
+	MaxIdlePerHost  int
+	MaxConnsPerHost int
+}
+
+// PoolStats 一个端点连接池的复用统计
+type PoolStats struct {
+	Requests          uint64    `json:"requests"`           // 发出的请求数
+	NewConnections    uint64    `json:"new_connections"`    // 新建的连接数
+	ReusedConnections uint64    `json:"reused_connections"` // 复用已有连接的请求数
+	HTTP2Requests     uint64    `json:"http2_requests"`     // 使用 HTTP/2 的请求数
+	ReuseRate         float64   `json:"reuse_rate"`         // 复用连接的请求比例（0-1）
+	CreatedAt         time.Time `json:"created_at"`         // 连接池创建时间，配置变化后重建
+}
+
+// TransportPool 按端点缓存 HTTP 客户端，同一端点的请求共用连接池
+// 代理、超时或连接限制变化时重建客户端并关闭旧连接池的空闲连接
+type TransportPool struct {
+	factory *Factory
+
+	mu      sync.Mutex
+	limits  ConnectionLimits
+	entries map[string]*pooledClient
+}
+
+type pooledClient struct {
+	key       string
+	client    *http.Client
+	transport *countingTransport
+	createdAt time.Time
+}
+
+// NewTransportPool 创建端点连接池缓存
+func NewTransportPool(factory *Factory) *TransportPool {
+	if factory == nil {
+		factory = NewFactory()
+	}
+	return &TransportPool{
+		factory: factory,
+		entries: make(map[string]*pooledClient),
+	}
+}
+
+// SetLimits 更新连接池大小限制，已有的连接池在下次使用时按新限制重建
+func (p *TransportPool) SetLimits(limits ConnectionLimits) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limits = limits
+}
+
+// Client 返回端点的共享客户端，配置与缓存的不同时重建
+func (p *TransportPool) Client(id string, config ClientConfig) (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = p.limits.MaxIdleConns
+	}
+	if config.MaxIdlePerHost == 0 {
+		config.MaxIdlePerHost = p.limits.MaxIdlePerHost
+	}
+	if config.MaxConnsPerHost == 0 {
+		config.MaxConnsPerHost = p.limits.MaxConnsPerHost
+	}
+	key := poolKey(config)
+
+	if entry, ok := p.entries[id]; ok {
+		if entry.key == key {
+			return entry.client, nil
+		}
+		// 正在进行的请求继续使用旧连接，空闲连接立即关闭
+		entry.transport.base.CloseIdleConnections()
+	}
+
+	client, err := p.factory.CreateClient(config)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transport type %T", client.Transport)
+	}
+	transport := &countingTransport{base: base}
+	client.Transport = transport
+	p.entries[id] = &pooledClient{key: key, client: client, transport: transport, createdAt: time.Now()}
+	return client, nil
+}
+
+// Invalidate 丢弃端点的客户端并关闭其空闲连接
+func (p *TransportPool) Invalidate(id string) {
+	p.mu.Lock()
+	entry, ok := p.entries[id]
+	delete(p.entries, id)
+	p.mu.Unlock()
+	if ok {
+		entry.transport.base.CloseIdleConnections()
+	}
+}
+
+// Stats 返回端点连接池的复用统计，端点尚未发出请求时返回 false
+func (p *TransportPool) Stats(id string) (PoolStats, bool) {
+	p.mu.Lock()
+	entry, ok := p.entries[id]
+	p.mu.Unlock()
+	if !ok {
+		return PoolStats{}, false
+	}
+	return entry.transport.stats(entry.createdAt), true
+}
+
+// CloseIdleConnections 关闭所有连接池的空闲连接
+func (p *TransportPool) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, entry := range p.entries {
+		entry.transport.base.CloseIdleConnections()
+	}
+}
+
+// poolKey 决定能否复用连接池的配置（代理、超时和连接限制）
+func poolKey(config ClientConfig) string {
+	key := fmt.Sprintf("%s|%v|%v|%v|%v|%d|%d|%d|%t|%t", config.Type,
+		config.Timeouts.TLSHandshake, config.Timeouts.ResponseHeader, config.Timeouts.IdleConnection, config.Timeouts.OverallRequest,
+		config.MaxIdleConns, config.MaxIdlePerHost, config.MaxConnsPerHost, config.DisableKeepAlive, config.InsecureSkipVerify)
+	if proxy := config.ProxyConfig; proxy != nil {
+		key += fmt.Sprintf("|%s|%s|%s|%s", proxy.Type, proxy.Address, proxy.Username, proxy.Password)
+	}
+	return key
+}
+
+// countingTransport 统计连接复用情况的 RoundTripper
+type countingTransport struct {
+	base *http.Transport
+
+	requests atomic.Uint64
+	newConns atomic.Uint64
+	reused   atomic.Uint64
+	http2    atomic.Uint64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.reused.Add(1)
+			} else {
+				t.newConns.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.ProtoMajor == 2 {
+		t.http2.Add(1)
+	}
+	return resp, err
+}
+
+// CloseIdleConnections 让 http.Client.CloseIdleConnections 作用到底层连接池
+func (t *countingTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+func (t *countingTransport) stats(createdAt time.Time) PoolStats {
+	stats := PoolStats{
+		Requests:          t.requests.Load(),
+		NewConnections:    t.newConns.Load(),
+		ReusedConnections: t.reused.Load(),
+		HTTP2Requests:     t.http2.Load(),
+		CreatedAt:         createdAt,
+	}
+	if total := stats.NewConnections + stats.ReusedConnections; total > 0 {
+		stats.ReuseRate = float64(stats.ReusedConnections) / float64(total)
+	}
+	return stats
+}
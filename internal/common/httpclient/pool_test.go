@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+func doRequest(t *testing.T, client *http.Client, url string) *http.Response {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestTransportPoolReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	pool := NewTransportPool(nil)
+	cfg := ClientConfig{Type: ClientTypeEndpoint, Timeouts: TimeoutConfig{ResponseHeader: 5 * time.Second}}
+
+	first, err := pool.Client("ep-1", cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	doRequest(t, first, server.URL)
+	second, _ := pool.Client("ep-1", cfg)
+	if first != second {
+		t.Fatal("Expected the same client for an unchanged configuration")
+	}
+	doRequest(t, second, server.URL)
+
+	stats, ok := pool.Stats("ep-1")
+	if !ok {
+		t.Fatal("Expected stats for ep-1")
+	}
+	if stats.Requests != 2 || stats.NewConnections != 1 || stats.ReusedConnections != 1 || stats.ReuseRate != 0.5 {
+		t.Errorf("Expected one new and one reused connection, got %+v", stats)
+	}
+	if _, ok := pool.Stats("ep-2"); ok {
+		t.Error("Expected no stats for an endpoint without requests")
+	}
+}
+
+func TestTransportPoolRebuildsOnConfigChange(t *testing.T) {
+	pool := NewTransportPool(nil)
+	cfg := ClientConfig{Type: ClientTypeEndpoint}
+	original, _ := pool.Client("ep-1", cfg)
+
+	cfg.ProxyConfig = &config.ProxyConfig{Type: "socks5", Address: "127.0.0.1:1080"}
+	withProxy, _ := pool.Client("ep-1", cfg)
+	if withProxy == original {
+		t.Error("Expected a new client after the proxy config changed")
+	}
+
+	pool.SetLimits(ConnectionLimits{MaxConnsPerHost: 4})
+	limited, _ := pool.Client("ep-1", cfg)
+	if limited == withProxy {
+		t.Error("Expected a new client after the connection limits changed")
+	}
+	if transport := limited.Transport.(*countingTransport).base; transport.MaxConnsPerHost != 4 || !transport.ForceAttemptHTTP2 {
+		t.Errorf("Expected limits and HTTP/2 on the transport, got MaxConnsPerHost=%d ForceAttemptHTTP2=%v", transport.MaxConnsPerHost, transport.ForceAttemptHTTP2)
+	}
+
+	pool.Invalidate("ep-1")
+	if _, ok := pool.Stats("ep-1"); ok {
+		t.Error("Expected stats to be removed after Invalidate")
+	}
+}
+
+func TestTransportPoolUsesHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pool := NewTransportPool(nil)
+	client, err := pool.Client("ep-1", ClientConfig{Type: ClientTypeEndpoint, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if resp := doRequest(t, client, server.URL); resp.ProtoMajor != 2 {
+		t.Fatalf("Expected HTTP/2, got %s", resp.Proto)
+	}
+	doRequest(t, client, server.URL)
+
+	stats, _ := pool.Stats("ep-1")
+	if stats.HTTP2Requests != 2 || stats.NewConnections != 1 || stats.ReusedConnections != 1 {
+		t.Errorf("Expected both requests on one HTTP/2 connection, got %+v", stats)
+	}
+}
@@ -24,8 +24,9 @@ type DefaultValues struct {
 
 	// HTTP客户端配置默认值（统一配置）
 	HTTPClient struct {
-		MaxIdleConns   int
-		MaxIdlePerHost int
+		MaxIdleConns    int
+		MaxIdlePerHost  int
+		MaxConnsPerHost int
 	}
 
 	// 健康检查默认值
@@ -47,6 +48,52 @@ type DefaultValues struct {
 		LogResponseBody string
 		LogDirectory    string
 		BodyTruncateSize int
+		RetentionDays   int    // 日志默认保留天数
+		CleanupInterval string // 保留策略默认执行间隔
+		StorageBackend  string // 默认日志存储后端
+		JSONLMaxFileMB  int    // JSONL 日志文件默认轮转体积
+		WriterQueueSize     int    // 异步写入队列默认容量（条数）
+		WriterQueueMaxMB    int    // 异步写入队列中正文的默认内存上限
+		WriterBatchSize     int    // 异步写入默认批量大小
+		WriterFlushInterval string // 异步写入默认刷新间隔
+		WriterOnFull        string // 队列满时的默认策略
+	}
+
+	// 链路追踪配置默认值
+	Tracing struct {
+		Endpoint       string
+		ServiceName    string
+		SampleRatio    float64
+		BatchSize      int
+		ExportInterval string
+		ExportTimeout  string
+	}
+
+	// 延迟评分默认值
+	LatencyScoring struct {
+		Factor     float64
+		MinTTFBGap string
+		MinSamples int
+	}
+
+	// 后台 OAuth 令牌刷新默认值
+	OAuthRefresh struct {
+		CheckInterval string
+		RefreshBefore string
+		RetryBackoff  string
+		MaxBackoff    string
+	}
+
+	// 配置修订历史默认值
+	ConfigHistory struct {
+		MaxRevisions int
+	}
+
+	// 凭据存储默认值
+	Secrets struct {
+		KeystorePath string
+		MasterKeyEnv string
+		Timeout      string
 	}
 
 	// 端点配置默认值
@@ -56,6 +103,13 @@ type DefaultValues struct {
 		Enabled  bool
 	}
 
+	// 端点凭据池默认值
+	Credential struct {
+		Strategy string
+		Weight   int
+		Cooldown string
+	}
+
 	// 国际化配置默认值
 	I18n struct {
 		Enabled         bool
@@ -77,7 +131,10 @@ type DefaultValues struct {
 
 	// 标签配置默认值
 	Tagging struct {
-		PipelineTimeout string
+		PipelineTimeout      string
+		SimulationSampleSize int // tagger试运行默认回放的日志条数
+		SimulationMaxSamples int // tagger试运行允许回放的最大日志条数
+		LongContextTokens    int // context-length tagger 默认的长上下文阈值（估算token数）
 	}
 
 	// 数据库配置默认值
@@ -89,6 +146,8 @@ type DefaultValues struct {
 		MaxIdleConns    int
 		ConnMaxLifetime time.Duration
 		MaxRetries      int
+		BodyInlineThreshold int
+		BodyMigrationBatch  int
 	}
 
 	// 分页默认值
@@ -132,11 +191,13 @@ var Default = DefaultValues{
 	},
 
 	HTTPClient: struct {
-		MaxIdleConns   int
-		MaxIdlePerHost int
+		MaxIdleConns    int
+		MaxIdlePerHost  int
+		MaxConnsPerHost int
 	}{
-		MaxIdleConns:   50,
-		MaxIdlePerHost: 10,
+		MaxIdleConns:    50,
+		MaxIdlePerHost:  10,
+		MaxConnsPerHost: 0,
 	},
 
 	HealthCheck: struct {
@@ -185,6 +246,15 @@ var Default = DefaultValues{
 		LogResponseBody string
 		LogDirectory    string
 		BodyTruncateSize int
+		RetentionDays   int
+		CleanupInterval string
+		StorageBackend  string
+		JSONLMaxFileMB  int
+		WriterQueueSize     int
+		WriterQueueMaxMB    int
+		WriterBatchSize     int
+		WriterFlushInterval string
+		WriterOnFull        string
 	}{
 		Level:                         "info",
 		LogRequestTypes:               "all",
@@ -192,6 +262,69 @@ var Default = DefaultValues{
 		LogResponseBody:               "none",
 		LogDirectory:                  "./logs",
 		BodyTruncateSize:              1000,
+		RetentionDays:                 30,
+		CleanupInterval:               "1h",
+		StorageBackend:                "sqlite",
+		JSONLMaxFileMB:                100,
+		WriterQueueSize:               1000,
+		WriterQueueMaxMB:              64,
+		WriterBatchSize:               100,
+		WriterFlushInterval:           "200ms",
+		WriterOnFull:                  "drop_bodies",
+	},
+
+	Tracing: struct {
+		Endpoint       string
+		ServiceName    string
+		SampleRatio    float64
+		BatchSize      int
+		ExportInterval string
+		ExportTimeout  string
+	}{
+		Endpoint:       "http://localhost:4318/v1/traces",
+		ServiceName:    "claude-code-companion",
+		SampleRatio:    1,
+		BatchSize:      512,
+		ExportInterval: "5s",
+		ExportTimeout:  "10s",
+	},
+
+	LatencyScoring: struct {
+		Factor     float64
+		MinTTFBGap string
+		MinSamples int
+	}{
+		Factor:     3,
+		MinTTFBGap: "2s",
+		MinSamples: 5,
+	},
+
+	OAuthRefresh: struct {
+		CheckInterval string
+		RefreshBefore string
+		RetryBackoff  string
+		MaxBackoff    string
+	}{
+		CheckInterval: "30s",
+		RefreshBefore: "10m",
+		RetryBackoff:  "30s",
+		MaxBackoff:    "5m",
+	},
+
+	ConfigHistory: struct {
+		MaxRevisions int
+	}{
+		MaxRevisions: 50,
+	},
+
+	Secrets: struct {
+		KeystorePath string
+		MasterKeyEnv string
+		Timeout      string
+	}{
+		KeystorePath: "secrets.enc",
+		MasterKeyEnv: "CCC_MASTER_KEY",
+		Timeout:      "10s",
 	},
 
 	Endpoint: struct {
@@ -204,6 +337,16 @@ var Default = DefaultValues{
 		Enabled:  true,
 	},
 
+	Credential: struct {
+		Strategy string
+		Weight   int
+		Cooldown string
+	}{
+		Strategy: "round_robin",
+		Weight:   1,
+		Cooldown: "60s",
+	},
+
 	I18n: struct {
 		Enabled         bool
 		DefaultLanguage string
@@ -239,9 +382,15 @@ var Default = DefaultValues{
 	},
 
 	Tagging: struct {
-		PipelineTimeout string
+		PipelineTimeout      string
+		SimulationSampleSize int
+		SimulationMaxSamples int
+		LongContextTokens    int
 	}{
-		PipelineTimeout: "5s",
+		PipelineTimeout:      "5s",
+		SimulationSampleSize: 20,
+		SimulationMaxSamples: 200,
+		LongContextTokens:    150000,
 	},
 
 	Database: struct {
@@ -252,6 +401,8 @@ var Default = DefaultValues{
 		MaxIdleConns    int
 		ConnMaxLifetime time.Duration
 		MaxRetries      int
+		BodyInlineThreshold int
+		BodyMigrationBatch  int
 	}{
 		CacheSize:       10000,
 		MmapSize:        268435456, // 256MB
@@ -260,6 +411,8 @@ var Default = DefaultValues{
 		MaxIdleConns:    5,
 		ConnMaxLifetime: 5 * time.Minute,
 		MaxRetries:      3,
+		BodyInlineThreshold: 512, // 小于512字节的请求/响应体直接存储在日志行中
+		BodyMigrationBatch:  200, // 迁移旧日志请求体时每批处理的行数
 	},
 
 	Pagination: struct {
@@ -0,0 +1,341 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	historyIndexFile = "revisions.json"
+	// 对比行数乘积超过该值时不再逐行计算最长公共子序列，整段按删除和新增展示
+	maxDiffCells = 4000000
+	// 对比结果中变化行前后保留的上下文行数
+	diffContextLines = 3
+)
+
+// ConfigRevision 一次配置修订的元数据，配置内容保存在历史目录下的 <id>.yaml
+type ConfigRevision struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Author     string    `json:"author"`                // 修改来源，管理界面请求记录客户端地址
+	Sections   []string  `json:"sections"`              // 与上一修订相比发生变化的顶层配置段
+	RollbackOf int       `json:"rollback_of,omitempty"` // 回滚产生的修订记录回滚到的修订号
+	Size       int       `json:"size"`
+}
+
+// ConfigDiffLine 配置对比结果中的一行，Op 为 " " 未变化、"+" 新增、"-" 删除、"..." 省略的未变化行
+type ConfigDiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text,omitempty"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Skipped int    `json:"skipped,omitempty"`
+}
+
+// ConfigHistory 在数据目录中保存配置修订，按数量清理最早的修订
+type ConfigHistory struct {
+	dir          string
+	maxRevisions int
+	mutex        sync.Mutex
+}
+
+func NewConfigHistory(dir string, maxRevisions int) *ConfigHistory {
+	return &ConfigHistory{dir: dir, maxRevisions: maxRevisions}
+}
+
+// SetMaxRevisions 更新保留的修订数量，下次记录修订时清理
+func (h *ConfigHistory) SetMaxRevisions(maxRevisions int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.maxRevisions = maxRevisions
+}
+
+// Record 保存一份配置快照，内容与最新修订相同时不产生新修订并返回 nil
+func (h *ConfigHistory) Record(data []byte, author string, rollbackOf int) (*ConfigRevision, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	revisions, err := h.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []byte
+	nextID := 1
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		nextID = latest.ID + 1
+		if previous, err = os.ReadFile(h.revisionPath(latest.ID)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read revision %d: %v", latest.ID, err)
+		}
+		if previous != nil && bytes.Equal(previous, data) {
+			return nil, nil
+		}
+	}
+
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config history directory: %v", err)
+	}
+	revision := ConfigRevision{
+		ID:         nextID,
+		Timestamp:  time.Now(),
+		Author:     author,
+		Sections:   ChangedConfigSections(previous, data),
+		RollbackOf: rollbackOf,
+		Size:       len(data),
+	}
+	// 配置中包含凭据，快照只允许当前用户读取
+	if err := os.WriteFile(h.revisionPath(revision.ID), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write revision %d: %v", revision.ID, err)
+	}
+
+	revisions = append(revisions, revision)
+	if h.maxRevisions > 0 && len(revisions) > h.maxRevisions {
+		for _, pruned := range revisions[:len(revisions)-h.maxRevisions] {
+			os.Remove(h.revisionPath(pruned.ID))
+		}
+		revisions = revisions[len(revisions)-h.maxRevisions:]
+	}
+	if err := h.writeIndex(revisions); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// List 返回所有修订，最新的在前
+func (h *ConfigHistory) List() ([]ConfigRevision, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	revisions, err := h.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions, nil
+}
+
+// Load 读取指定修订的元数据和配置内容
+func (h *ConfigHistory) Load(id int) (*ConfigRevision, []byte, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	revisions, err := h.readIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range revisions {
+		if revisions[i].ID != id {
+			continue
+		}
+		data, err := os.ReadFile(h.revisionPath(id))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read revision %d: %v", id, err)
+		}
+		return &revisions[i], data, nil
+	}
+	return nil, nil, fmt.Errorf("revision %d not found", id)
+}
+
+func (h *ConfigHistory) revisionPath(id int) string {
+	return filepath.Join(h.dir, fmt.Sprintf("%06d.yaml", id))
+}
+
+func (h *ConfigHistory) readIndex() ([]ConfigRevision, error) {
+	data, err := os.ReadFile(filepath.Join(h.dir, historyIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config history index: %v", err)
+	}
+	var revisions []ConfigRevision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to parse config history index: %v", err)
+	}
+	return revisions, nil
+}
+
+// writeIndex 先写临时文件再重命名，避免中途失败留下损坏的索引
+func (h *ConfigHistory) writeIndex(revisions []ConfigRevision) error {
+	data, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config history index: %v", err)
+	}
+	indexPath := filepath.Join(h.dir, historyIndexFile)
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config history index: %v", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return fmt.Errorf("failed to write config history index: %v", err)
+	}
+	return nil
+}
+
+// ParseConfigRevision 解析修订中保存的配置，展开环境变量并补全默认值
+// 修订保存的是配置文件原文，启动时记录的修订可能包含 ${VAR} 占位符，与 LoadConfig 一样先展开
+func ParseConfigRevision(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse revision: %v", err)
+	}
+	if err := expandEnvironmentVariables(&config); err != nil {
+		return nil, fmt.Errorf("failed to expand environment variables: %v", err)
+	}
+	if err := validateConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	return &config, nil
+}
+
+// ChangedConfigSections 比较两份配置的顶层配置段，返回发生变化的段名；previous 为空时返回新配置的所有段
+func ChangedConfigSections(previous, current []byte) []string {
+	var oldSections, newSections map[string]interface{}
+	yaml.Unmarshal(previous, &oldSections)
+	yaml.Unmarshal(current, &newSections)
+
+	var sections []string
+	for name, value := range newSections {
+		if !reflect.DeepEqual(oldSections[name], value) {
+			sections = append(sections, name)
+		}
+	}
+	for name := range oldSections {
+		if _, ok := newSections[name]; !ok {
+			sections = append(sections, name)
+		}
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// DiffConfigs 逐行对比两份配置，只保留变化行及其前后的上下文
+func DiffConfigs(from, to []byte) []ConfigDiffLine {
+	oldLines := splitConfigLines(from)
+	newLines := splitConfigLines(to)
+
+	// 去掉公共前缀和后缀后再计算最长公共子序列
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var lines []ConfigDiffLine
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, ConfigDiffLine{Op: " ", Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	lines = append(lines, diffLineRange(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix], prefix)...)
+	for i := suffix; i > 0; i-- {
+		oldIndex, newIndex := len(oldLines)-i, len(newLines)-i
+		lines = append(lines, ConfigDiffLine{Op: " ", Text: oldLines[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+	return collapseDiffContext(lines)
+}
+
+func splitConfigLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLineRange 用最长公共子序列对比中间变化的部分，offset 为该部分之前的行数
+func diffLineRange(oldLines, newLines []string, offset int) []ConfigDiffLine {
+	var lines []ConfigDiffLine
+	n, m := len(oldLines), len(newLines)
+	if n*m > maxDiffCells {
+		for i, text := range oldLines {
+			lines = append(lines, ConfigDiffLine{Op: "-", Text: text, OldLine: offset + i + 1})
+		}
+		for i, text := range newLines {
+			lines = append(lines, ConfigDiffLine{Op: "+", Text: text, NewLine: offset + i + 1})
+		}
+		return lines
+	}
+
+	// lcs[i][j] 为 oldLines[i:] 和 newLines[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			lines = append(lines, ConfigDiffLine{Op: " ", Text: oldLines[i], OldLine: offset + i + 1, NewLine: offset + j + 1})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, ConfigDiffLine{Op: "+", Text: newLines[j], NewLine: offset + j + 1})
+			j++
+		default:
+			lines = append(lines, ConfigDiffLine{Op: "-", Text: oldLines[i], OldLine: offset + i + 1})
+			i++
+		}
+	}
+	return lines
+}
+
+// collapseDiffContext 把距离变化行超过 diffContextLines 的未变化行合并为一行省略标记
+func collapseDiffContext(lines []ConfigDiffLine) []ConfigDiffLine {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.Op == " " {
+			continue
+		}
+		for k := i - diffContextLines; k <= i+diffContextLines; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+
+	var result []ConfigDiffLine
+	skipped := 0
+	for i, line := range lines {
+		if keep[i] {
+			if skipped > 0 {
+				result = append(result, ConfigDiffLine{Op: "...", Skipped: skipped})
+				skipped = 0
+			}
+			result = append(result, line)
+			continue
+		}
+		skipped++
+	}
+	if skipped > 0 {
+		result = append(result, ConfigDiffLine{Op: "...", Skipped: skipped})
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigHistoryRecordAndPrune(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config_history")
+	history := NewConfigHistory(dir, 3)
+
+	first := "server:\n  port: 8080\nendpoints: []\n"
+	revision, err := history.Record([]byte(first), "startup", 0)
+	if err != nil || revision == nil || revision.ID != 1 {
+		t.Fatalf("Expected first revision, got %+v, %v", revision, err)
+	}
+	if strings.Join(revision.Sections, ",") != "endpoints,server" {
+		t.Errorf("Expected all sections for the first revision, got %v", revision.Sections)
+	}
+
+	// 内容没有变化时不产生新修订
+	if revision, err := history.Record([]byte(first), "127.0.0.1", 0); err != nil || revision != nil {
+		t.Errorf("Expected unchanged config to be skipped, got %+v, %v", revision, err)
+	}
+
+	revision, _ = history.Record([]byte("server:\n  port: 9090\nendpoints: []\n"), "127.0.0.1", 0)
+	if revision == nil || revision.ID != 2 || strings.Join(revision.Sections, ",") != "server" || revision.Author != "127.0.0.1" {
+		t.Errorf("Unexpected second revision: %+v", revision)
+	}
+	history.Record([]byte("server:\n  port: 9090\nendpoints: []\nlogging:\n  level: debug\n"), "127.0.0.1", 0)
+	revision, _ = history.Record([]byte(first), "127.0.0.1", 1)
+	if revision == nil || revision.RollbackOf != 1 || strings.Join(revision.Sections, ",") != "logging,server" {
+		t.Errorf("Unexpected rollback revision: %+v", revision)
+	}
+
+	revisions, err := history.List()
+	if err != nil {
+		t.Fatalf("Failed to list revisions: %v", err)
+	}
+	if len(revisions) != 3 || revisions[0].ID != 4 || revisions[2].ID != 2 {
+		t.Errorf("Expected the 3 newest revisions, newest first, got %+v", revisions)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "000001.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected pruned revision file to be removed, got %v", err)
+	}
+	if _, _, err := history.Load(1); err == nil {
+		t.Error("Expected pruned revision to be unavailable")
+	}
+	if _, data, err := history.Load(4); err != nil || string(data) != first {
+		t.Errorf("Unexpected revision content: %q, %v", data, err)
+	}
+}
+
+func TestDiffConfigs(t *testing.T) {
+	var from, to []string
+	for i := 0; i < 20; i++ {
+		from = append(from, "line")
+	}
+	to = append(to, from...)
+	from[10] = "  port: 8080"
+	to[10] = "  port: 9090"
+	to = append(to, "tracing:", "  enabled: true")
+
+	lines := DiffConfigs([]byte(strings.Join(from, "\n")+"\n"), []byte(strings.Join(to, "\n")+"\n"))
+
+	var ops []string
+	for _, line := range lines {
+		ops = append(ops, line.Op)
+	}
+	want := []string{"...", " ", " ", " ", "-", "+", " ", " ", " ", "...", " ", " ", " ", "+", "+"}
+	if strings.Join(ops, "|") != strings.Join(want, "|") {
+		t.Fatalf("Unexpected diff ops: %q", ops)
+	}
+	if lines[0].Skipped != 7 || lines[4].Text != "  port: 8080" || lines[4].OldLine != 11 || lines[5].NewLine != 11 {
+		t.Errorf("Unexpected changed lines: %+v %+v %+v", lines[0], lines[4], lines[5])
+	}
+	if last := lines[len(lines)-1]; last.Text != "  enabled: true" || last.NewLine != 22 {
+		t.Errorf("Unexpected appended line: %+v", last)
+	}
+
+	if lines := DiffConfigs([]byte("a\nb\n"), []byte("a\nb\n")); len(lines) != 1 || lines[0].Op != "..." {
+		t.Errorf("Expected identical configs to collapse into one skipped block, got %+v", lines)
+	}
+}
+
+func TestParseConfigRevision(t *testing.T) {
+	cfg, err := ParseConfigRevision([]byte("server:\n  host: 127.0.0.1\n  port: 8080\nendpoints:\n  - name: a\n    url: https://a.example.com\n    auth_type: api_key\n    auth_value: k\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse revision: %v", err)
+	}
+	if cfg.ConfigHistory.MaxRevisions != Default.ConfigHistory.MaxRevisions {
+		t.Errorf("Expected defaults to be applied, got %d", cfg.ConfigHistory.MaxRevisions)
+	}
+	if _, err := ParseConfigRevision([]byte("server:\n  port: -1\n")); err == nil {
+		t.Error("Expected invalid revision to be rejected")
+	}
+
+	// 启动时记录的修订是配置文件原文，回滚时与加载配置一样展开环境变量
+	t.Setenv("TEST_REVISION_KEY", "sk-from-env")
+	cfg, err = ParseConfigRevision([]byte("server:\n  host: 127.0.0.1\n  port: 8080\nendpoints:\n  - name: a\n    url: https://a.example.com\n    auth_type: api_key\n    auth_value: ${TEST_REVISION_KEY}\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse revision: %v", err)
+	}
+	if cfg.Endpoints[0].AuthValue != "sk-from-env" {
+		t.Errorf("Expected environment variables to be expanded, got %s", cfg.Endpoints[0].AuthValue)
+	}
+}
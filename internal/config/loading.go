@@ -99,6 +99,10 @@ func generateDefaultConfig(filename string) error {
 			LogRequestBody:  "truncated",
 			LogResponseBody: "truncated",
 			LogDirectory:    "./logs",
+			Retention: LogRetentionConfig{
+				MaxAgeDays:      30,
+				CleanupInterval: "1h",
+			},
 		},
 		Validation: ValidationConfig{},
 		Tagging: TaggingConfig{
@@ -181,6 +185,9 @@ func expandEnvironmentVariables(config *Config) error {
 
 		// 展开基本字段
 		endpoint.AuthValue = expandString(endpoint.AuthValue)
+		for j := range endpoint.Credentials {
+			endpoint.Credentials[j].Value = expandString(endpoint.Credentials[j].Value)
+		}
 		endpoint.URL = expandString(endpoint.URL)
 		endpoint.Name = expandString(endpoint.Name)
 
@@ -219,6 +226,11 @@ func expandEnvironmentVariables(config *Config) error {
 	// 展开其他配置字段
 	config.Server.Host = expandString(config.Server.Host)
 	config.Logging.LogDirectory = expandString(config.Logging.LogDirectory)
+	config.Logging.Storage.DSN = expandString(config.Logging.Storage.DSN)
+	config.Tracing.Endpoint = expandString(config.Tracing.Endpoint)
+	for key, value := range config.Tracing.Headers {
+		config.Tracing.Headers[key] = expandString(value)
+	}
 
 	return nil
 }
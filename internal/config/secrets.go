@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SecretReferencePrefix 凭据引用前缀，值写成 secret://名称 时从凭据存储读取
+const SecretReferencePrefix = "secret://"
+
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// SecretReferenceName 解析凭据引用，返回凭据名称；不是凭据引用时返回 false
+func SecretReferenceName(value string) (string, bool) {
+	if !strings.HasPrefix(value, SecretReferencePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, SecretReferencePrefix), true
+}
+
+// ValidateSecretName 验证凭据名称，名称会作为文件名和命令参数使用，只允许字母、数字和 . _ -（导出函数）
+func ValidateSecretName(name string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name '%s': only letters, digits, '.', '_' and '-' are allowed and it cannot start with '.' or '-'", name)
+	}
+	return nil
+}
+
+// SecretField 配置中可以写成凭据引用的字段
+type SecretField struct {
+	Location    string  // 字段位置，用于错误信息，如 endpoints[main].auth_value
+	Value       *string // 指向配置中的字段值
+	WrittenBack bool    // 运行时会写回新值（OAuth 令牌刷新），引用只能指向可写的存储
+}
+
+// SecretFields 返回配置中所有可以写成凭据引用的字段
+func (c *Config) SecretFields() []SecretField {
+	var fields []SecretField
+	for i := range c.Endpoints {
+		ep := &c.Endpoints[i]
+		prefix := fmt.Sprintf("endpoints[%s]", ep.Name)
+		fields = append(fields, SecretField{prefix + ".auth_value", &ep.AuthValue, false})
+		for j := range ep.Credentials {
+			fields = append(fields, SecretField{fmt.Sprintf("%s.credentials[%s]", prefix, ep.Credentials[j].Name), &ep.Credentials[j].Value, false})
+		}
+		if ep.OAuthConfig != nil {
+			fields = append(fields,
+				SecretField{prefix + ".oauth_config.access_token", &ep.OAuthConfig.AccessToken, true},
+				SecretField{prefix + ".oauth_config.refresh_token", &ep.OAuthConfig.RefreshToken, true})
+		}
+		if ep.Proxy != nil {
+			fields = append(fields, SecretField{prefix + ".proxy.password", &ep.Proxy.Password, false})
+		}
+	}
+	return fields
+}
+
+func validateSecretsConfig(config *SecretsConfig, fields []SecretField) error {
+	switch config.Backend {
+	case "":
+	case "keystore":
+		if config.KeystorePath == "" {
+			config.KeystorePath = Default.Secrets.KeystorePath
+		}
+		if config.MasterKeyEnv == "" {
+			config.MasterKeyEnv = Default.Secrets.MasterKeyEnv
+		}
+	case "dir":
+		if config.Directory == "" {
+			return fmt.Errorf("directory is required for the dir backend")
+		}
+	case "exec":
+		if len(config.Command) == 0 || config.Command[0] == "" {
+			return fmt.Errorf("command is required for the exec backend")
+		}
+		if config.Timeout == "" {
+			config.Timeout = Default.Secrets.Timeout
+		}
+		if d, err := time.ParseDuration(config.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout '%s'", config.Timeout)
+		}
+	default:
+		return fmt.Errorf("invalid backend '%s', must be one of: keystore, dir, exec", config.Backend)
+	}
+
+	for _, field := range fields {
+		name, ok := SecretReferenceName(*field.Value)
+		if !ok {
+			continue
+		}
+		if err := ValidateSecretName(name); err != nil {
+			return fmt.Errorf("%s: %v", field.Location, err)
+		}
+		if config.Backend == "" {
+			return fmt.Errorf("%s references secret '%s' but no secrets backend is configured", field.Location, name)
+		}
+		// 刷新后的令牌无法写回 dir/exec，重启后会使用存储中已经失效的旧令牌
+		if field.WrittenBack && config.Backend != "keystore" {
+			return fmt.Errorf("%s references secret '%s' but refreshed OAuth tokens can only be written back to the keystore backend, '%s' is read-only", field.Location, name, config.Backend)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSecretsConfigRequiresKeystoreForOAuthTokens(t *testing.T) {
+	cfg := &Config{Endpoints: []EndpointConfig{{
+		Name:        "oauth",
+		AuthType:    "oauth",
+		OAuthConfig: &OAuthConfig{AccessToken: "plain-access", RefreshToken: "secret://refresh"},
+	}}}
+
+	// 刷新后的令牌无法写回只读存储
+	for _, backend := range []SecretsConfig{
+		{Backend: "dir", Directory: "/run/secrets"},
+		{Backend: "exec", Command: []string{"vault", "{name}"}},
+	} {
+		err := validateSecretsConfig(&backend, cfg.SecretFields())
+		if err == nil || !strings.Contains(err.Error(), "endpoints[oauth].oauth_config.refresh_token") {
+			t.Errorf("Expected %s backend to be rejected for OAuth tokens, got %v", backend.Backend, err)
+		}
+	}
+	if err := validateSecretsConfig(&SecretsConfig{Backend: "keystore"}, cfg.SecretFields()); err != nil {
+		t.Errorf("Expected keystore backend to be accepted, got %v", err)
+	}
+
+	// 其他字段可以引用只读存储
+	cfg.Endpoints[0].OAuthConfig.RefreshToken = "plain-refresh"
+	cfg.Endpoints[0].AuthValue = "secret://api-key"
+	if err := validateSecretsConfig(&SecretsConfig{Backend: "dir", Directory: "/run/secrets"}, cfg.SecretFields()); err != nil {
+		t.Errorf("Expected auth_value reference to a read-only backend to be accepted, got %v", err)
+	}
+}
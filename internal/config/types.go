@@ -8,6 +8,65 @@ type Config struct {
 	Tagging     TaggingConfig     `yaml:"tagging"`     // 标签系统配置（永远启用）
 	Timeouts    TimeoutConfig     `yaml:"timeouts"`    // 超时配置
 	I18n        I18nConfig        `yaml:"i18n"`        // 国际化配置
+	Tracing     TracingConfig     `yaml:"tracing"`     // 链路追踪配置
+	HTTPClient  HTTPClientConfig  `yaml:"http_client"` // 上游连接池配置
+	LatencyScoring LatencyScoringConfig `yaml:"latency_scoring"` // 基于真实流量的延迟评分配置
+	Secrets     SecretsConfig     `yaml:"secrets"`     // 凭据存储配置
+	OAuthRefresh OAuthRefreshConfig `yaml:"oauth_refresh"` // 后台 OAuth 令牌刷新配置
+	ConfigHistory ConfigHistoryConfig `yaml:"config_history"` // 配置修订历史
+}
+
+// ConfigHistoryConfig 配置修订历史，管理界面的每次修改都在数据目录（log_directory）下保存一份配置快照，可以对比和回滚
+type ConfigHistoryConfig struct {
+	Disabled     bool `yaml:"disabled" json:"disabled"`           // 关闭修订历史，只保留 config.yaml.backup
+	MaxRevisions int  `yaml:"max_revisions" json:"max_revisions"` // 保留的修订数量，超出后删除最早的修订，默认50
+}
+
+// OAuthRefreshConfig 后台 OAuth 令牌刷新配置，auto_refresh 为 true 的端点在令牌过期前由后台刷新，请求不必等待刷新
+type OAuthRefreshConfig struct {
+	Disabled      bool   `yaml:"disabled" json:"disabled"`             // 关闭后台刷新，令牌只在请求时即将过期或上游返回 401/403 后刷新
+	CheckInterval string `yaml:"check_interval" json:"check_interval"` // 检查令牌过期时间的间隔，默认30s
+	RefreshBefore string `yaml:"refresh_before" json:"refresh_before"` // 提前多久刷新，默认10m；请求路径在过期前5分钟同步刷新，应大于5m
+	RetryBackoff  string `yaml:"retry_backoff" json:"retry_backoff"`   // 刷新失败后首次重试的等待时间，之后每次翻倍，默认30s
+	MaxBackoff    string `yaml:"max_backoff" json:"max_backoff"`       // 重试等待时间上限，默认5m
+}
+
+// SecretsConfig 凭据存储配置，认证值、OAuth 令牌、代理密码和凭据池中写成 secret://名称 的值从这里读取
+type SecretsConfig struct {
+	Backend      string   `yaml:"backend,omitempty" json:"backend,omitempty"`               // "" 不启用 | "keystore" 加密密钥库文件 | "dir" 每个凭据一个文件 | "exec" 外部命令
+	KeystorePath string   `yaml:"keystore_path,omitempty" json:"keystore_path,omitempty"`   // 加密密钥库文件路径，默认 secrets.enc
+	MasterKeyEnv string   `yaml:"master_key_env,omitempty" json:"master_key_env,omitempty"` // 保存主密钥的环境变量名，默认 CCC_MASTER_KEY
+	Directory    string   `yaml:"directory,omitempty" json:"directory,omitempty"`           // 凭据目录（如 Docker/K8s 的 /run/secrets），文件名即凭据名
+	Command      []string `yaml:"command,omitempty" json:"command,omitempty"`               // 获取凭据的命令和参数，{name} 替换为凭据名，没有 {name} 时凭据名作为最后一个参数；凭据值从标准输出读取
+	Timeout      string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`               // 命令执行超时，默认10s
+}
+
+// LatencyScoringConfig 基于真实流量的延迟评分配置
+// 端点首字节时间或输出吞吐量明显差于同组端点时视为降级，选择端点时排在同一标签层级的其他端点之后
+type LatencyScoringConfig struct {
+	Disabled   bool    `yaml:"disabled" json:"disabled"`         // 关闭降级判定，仅记录和展示延迟数据
+	Factor     float64 `yaml:"factor" json:"factor"`             // 首字节时间超过同组中位数的倍数（或吞吐量低于中位数的几分之一）视为降级，默认3
+	MinTTFBGap string  `yaml:"min_ttfb_gap" json:"min_ttfb_gap"` // 首字节时间至少比中位数慢多少才视为降级，默认2s
+	MinSamples int     `yaml:"min_samples" json:"min_samples"`   // 端点至少有多少个成功请求样本才参与比较，默认5
+}
+
+// HTTPClientConfig 上游连接池配置，每个端点按代理和超时配置复用独立的连接池（支持 HTTP/2）
+type HTTPClientConfig struct {
+	MaxIdleConns        int `yaml:"max_idle_conns" json:"max_idle_conns"`                   // 每个端点连接池的最大空闲连接数，默认50
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host"` // 每个主机的最大空闲连接数，默认10
+	MaxConnsPerHost     int `yaml:"max_conns_per_host" json:"max_conns_per_host"`           // 每个主机的最大连接数（含使用中的连接），0表示不限制
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置，span 通过 OTLP/HTTP 导出
+type TracingConfig struct {
+	Enabled        bool              `yaml:"enabled" json:"enabled"`
+	Endpoint       string            `yaml:"endpoint" json:"endpoint"`                   // OTLP/HTTP traces 接收地址，默认 http://localhost:4318/v1/traces
+	Headers        map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"` // 导出请求附加的头部（如 collector 认证），支持环境变量
+	ServiceName    string            `yaml:"service_name" json:"service_name"`           // resource 中的 service.name，默认 claude-code-companion
+	SampleRatio    float64           `yaml:"sample_ratio" json:"sample_ratio"`           // 没有上游 traceparent 时的采样比例（0-1），0 使用默认值 1
+	BatchSize      int               `yaml:"batch_size" json:"batch_size"`               // 每次导出的最大 span 数，默认512
+	ExportInterval string            `yaml:"export_interval" json:"export_interval"`     // 未凑满一批时的最长等待时间，默认5s
+	ExportTimeout  string            `yaml:"export_timeout" json:"export_timeout"`       // 单次导出超时，默认10s
 }
 
 // I18nConfig 国际化配置
@@ -41,6 +100,35 @@ type EndpointConfig struct {
 	RateLimitReset      *int64            `yaml:"rate_limit_reset,omitempty" json:"rate_limit_reset,omitempty"`       // Anthropic-Ratelimit-Unified-Reset
 	RateLimitStatus     *string           `yaml:"rate_limit_status,omitempty" json:"rate_limit_status,omitempty"`     // Anthropic-Ratelimit-Unified-Status
 	EnhancedProtection  bool              `yaml:"enhanced_protection,omitempty" json:"enhanced_protection,omitempty"` // 官方帐号增强保护：allowed_warning时即禁用端点
+	MaxContextTokens    int               `yaml:"max_context_tokens,omitempty" json:"max_context_tokens,omitempty"`   // 端点可容纳的最大上下文长度（token），0表示不限制
+	DisableBodyLogging  bool              `yaml:"disable_body_logging,omitempty" json:"disable_body_logging,omitempty"` // 不记录该端点的请求/响应体，只保留元数据
+	HealthCheck         *EndpointHealthCheckConfig `yaml:"health_check,omitempty" json:"health_check,omitempty"` // 端点健康检查探测配置，为空时使用默认的消息探测
+	Credentials         []EndpointCredentialConfig `yaml:"credentials,omitempty" json:"credentials,omitempty"`   // 凭据池，配置后轮换使用，auth_value 可以为空
+	CredentialStrategy  string                     `yaml:"credential_strategy,omitempty" json:"credential_strategy,omitempty"` // "round_robin"（默认）| "least_used"
+	DisabledReason      string                     `yaml:"disabled_reason,omitempty" json:"disabled_reason,omitempty"`         // 端点被自动禁用的原因（如 OAuth 刷新令牌被拒绝），重新启用时清除
+}
+
+// EndpointCredentialConfig 凭据池中的一个凭据，认证方式与端点的 auth_type 相同（不支持 oauth）
+// 返回 401/403 的凭据被单独拉黑，返回 429 的凭据在冷却期内不再使用，不影响端点的其他凭据
+type EndpointCredentialConfig struct {
+	Name     string `yaml:"name" json:"name"`                             // 凭据名称，在端点内唯一，用于日志和管理界面
+	Value    string `yaml:"value" json:"value"`                           // API key 或 token，支持环境变量
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"` // 停用该凭据
+	Weight   int    `yaml:"weight,omitempty" json:"weight,omitempty"`     // 轮换权重，默认1
+	Cooldown string `yaml:"cooldown,omitempty" json:"cooldown,omitempty"` // 429 且响应没有 Retry-After 时的冷却时长，默认60s
+}
+
+// EndpointHealthCheckConfig 端点健康检查探测配置
+// passive 模式不发送探测请求，只根据真实请求的结果判断端点状态
+type EndpointHealthCheckConfig struct {
+	Mode           string `yaml:"mode,omitempty" json:"mode,omitempty"`                       // "active"（默认）| "passive"
+	Probe          string `yaml:"probe,omitempty" json:"probe,omitempty"`                     // "message"（默认）| "count_tokens" | "models"
+	Model          string `yaml:"model,omitempty" json:"model,omitempty"`                     // 探测使用的模型，为空时使用从实际请求中提取的模型
+	Prompt         string `yaml:"prompt,omitempty" json:"prompt,omitempty"`                   // 探测消息内容，为空时使用默认提示词
+	ExpectStatus   []int  `yaml:"expect_status,omitempty" json:"expect_status,omitempty"`     // 视为健康的状态码，为空时接受所有2xx
+	ExpectContains string `yaml:"expect_contains,omitempty" json:"expect_contains,omitempty"` // 响应体必须包含的文本
+	Interval       string `yaml:"interval,omitempty" json:"interval,omitempty"`               // 检查间隔，为空时使用 timeouts.check_interval
+	Timeout        string `yaml:"timeout,omitempty" json:"timeout,omitempty"`                 // 探测请求超时，为空时使用 timeouts.health_check_timeout
 }
 
 // 新增：代理配置结构
@@ -60,6 +148,11 @@ type OAuthConfig struct {
 	ClientID     string   `yaml:"client_id,omitempty" json:"client_id,omitempty"`       // 客户端ID
 	Scopes       []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`             // 权限范围
 	AutoRefresh  bool     `yaml:"auto_refresh" json:"auto_refresh"`                     // 是否自动刷新
+
+	// 管理界面登录（可选）：配置后访问令牌和刷新令牌可以留空，在端点列表中完成授权
+	AuthorizeURL           string `yaml:"authorize_url,omitempty" json:"authorize_url,omitempty"`                       // 授权码（PKCE）流程的授权地址
+	DeviceAuthorizationURL string `yaml:"device_authorization_url,omitempty" json:"device_authorization_url,omitempty"` // 设备码流程的授权地址
+	RedirectURI            string `yaml:"redirect_uri,omitempty" json:"redirect_uri,omitempty"`                         // 授权码流程的回调地址，默认使用管理界面的 /admin/oauth/callback；提供商只允许固定回调地址时填写，授权后手动粘贴授权码
 }
 
 // 新增：模型重写配置结构
@@ -75,11 +168,49 @@ type ModelRewriteRule struct {
 }
 
 type LoggingConfig struct {
-	Level           string `yaml:"level"`
-	LogRequestTypes string `yaml:"log_request_types"`
-	LogRequestBody  string `yaml:"log_request_body"`
-	LogResponseBody string `yaml:"log_response_body"`
-	LogDirectory    string `yaml:"log_directory"`
+	Level           string             `yaml:"level" json:"level"`
+	LogRequestTypes string             `yaml:"log_request_types" json:"log_request_types"`
+	LogRequestBody  string             `yaml:"log_request_body" json:"log_request_body"`
+	LogResponseBody string             `yaml:"log_response_body" json:"log_response_body"`
+	LogDirectory    string             `yaml:"log_directory" json:"log_directory"`
+	Retention       LogRetentionConfig `yaml:"retention" json:"retention"` // 日志保留策略
+	Storage         LogStorageConfig   `yaml:"storage" json:"storage"`     // 日志存储后端
+	Writer          LogWriterConfig    `yaml:"writer" json:"writer"`       // 异步写入队列
+	Redaction       LogRedactionConfig `yaml:"redaction" json:"redaction"` // 敏感信息脱敏
+}
+
+// LogRedactionConfig 日志脱敏配置，内置规则覆盖常见的认证头、API Key 和 OAuth 令牌格式
+type LogRedactionConfig struct {
+	Disabled bool     `yaml:"disabled,omitempty" json:"disabled,omitempty"` // 关闭脱敏，仅用于排查认证问题
+	Headers  []string `yaml:"headers,omitempty" json:"headers,omitempty"`   // 额外需要整体脱敏的请求/响应头
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"` // 额外的正则表达式，匹配的内容替换为 [REDACTED]
+}
+
+// LogWriterConfig 日志异步写入配置，日志先进入有界队列，由后台协程批量写入存储
+type LogWriterConfig struct {
+	Sync          bool   `yaml:"sync" json:"sync"`                     // 在请求路径上同步写入（关闭异步队列）
+	QueueSize     int    `yaml:"queue_size" json:"queue_size"`         // 队列最多容纳的日志条数，默认1000
+	QueueMaxMB    int    `yaml:"queue_max_mb" json:"queue_max_mb"`     // 队列中请求/响应体的最大内存占用，默认64
+	BatchSize     int    `yaml:"batch_size" json:"batch_size"`         // 每个事务写入的最大条数，默认100
+	FlushInterval string `yaml:"flush_interval" json:"flush_interval"` // 未凑满一批时的最长等待时间，默认200ms
+	OnFull        string `yaml:"on_full" json:"on_full"`               // 队列满时的策略：drop_bodies（默认，丢弃正文保留元数据）| block（阻塞请求）
+}
+
+// LogStorageConfig 日志存储后端配置
+type LogStorageConfig struct {
+	Backend       string `yaml:"backend" json:"backend"`                                       // sqlite（默认）| postgres | jsonl
+	DSN           string `yaml:"dsn,omitempty" json:"dsn,omitempty"`                           // postgres 连接串，支持环境变量
+	MaxFileSizeMB int    `yaml:"max_file_size_mb,omitempty" json:"max_file_size_mb,omitempty"` // jsonl 单个文件的最大体积，超过后轮转，默认100
+}
+
+// LogRetentionConfig 日志保留策略，各项限制同时生效
+type LogRetentionConfig struct {
+	MaxAgeDays        int    `yaml:"max_age_days" json:"max_age_days"`                 // 日志保留天数，0 使用默认值，-1 表示不按时间清理
+	FailedMaxAgeDays  int    `yaml:"failed_max_age_days" json:"failed_max_age_days"`   // 失败请求保留天数，0 表示与 max_age_days 相同
+	BodyMaxAgeDays    int    `yaml:"body_max_age_days" json:"body_max_age_days"`       // 请求/响应体保留天数，超过后只保留元数据，0 表示不单独清理
+	MaxRows           int64  `yaml:"max_rows" json:"max_rows"`                         // 最多保留的日志条数，0 表示不限制
+	MaxDatabaseSizeMB int    `yaml:"max_database_size_mb" json:"max_database_size_mb"` // 数据库最大体积（MB），0 表示不限制
+	CleanupInterval   string `yaml:"cleanup_interval" json:"cleanup_interval"`         // 清理间隔，默认1h
 }
 
 type ValidationConfig struct {
@@ -148,8 +279,23 @@ func (tc *TimeoutConfig) ToHealthCheckTimeoutConfig() HealthCheckTimeoutConfig {
 
 // Tag系统配置结构 (永远启用)
 type TaggingConfig struct {
-	PipelineTimeout string          `yaml:"pipeline_timeout"`
-	Taggers         []TaggerConfig  `yaml:"taggers"`
+	PipelineTimeout string                `yaml:"pipeline_timeout"`
+	Taggers         []TaggerConfig        `yaml:"taggers"`
+	FallbackChains  []FallbackChainConfig `yaml:"fallback_chains,omitempty"` // tag回退链：匹配tag的端点全部失败后逐级替换或放宽tag
+}
+
+// FallbackChainConfig tag回退链配置
+// 请求包含 Tags 中的全部tag时生效，按顺序尝试每一级 Steps，最后才回退到万用端点
+type FallbackChainConfig struct {
+	Name  string               `yaml:"name" json:"name"`
+	Tags  []string             `yaml:"tags" json:"tags"`   // 触发回退链的tag
+	Steps []FallbackStepConfig `yaml:"steps" json:"steps"` // 回退级别，按顺序尝试
+}
+
+// FallbackStepConfig 回退链中的一级
+type FallbackStepConfig struct {
+	Tags  []string `yaml:"tags" json:"tags"`                       // 替换触发tag的tag集合，为空表示放宽（去掉触发tag）
+	Model string   `yaml:"model,omitempty" json:"model,omitempty"` // 可选：该级别使用的模型
 }
 
 type TaggerConfig struct {
@@ -159,5 +305,7 @@ type TaggerConfig struct {
 	Tag         string                 `yaml:"tag"`          // 标记的tag名称
 	Enabled     bool                   `yaml:"enabled"`
 	Priority    int                    `yaml:"priority"`     // 执行优先级(未使用，因为并发执行)
+	Stage       int                    `yaml:"stage,omitempty"`      // 执行阶段，数字小的先执行，同一阶段内并发执行
+	DependsOn   []string               `yaml:"depends_on,omitempty"` // 依赖的tagger名称，依赖执行完成后才会执行
 	Config      map[string]interface{} `yaml:"config"`       // tagger特定配置
 }
\ No newline at end of file
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -82,6 +84,41 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("invalid log_response_body '%s', must be one of: none, truncated, full", config.Logging.LogResponseBody)
 	}
 
+	// 验证日志保留策略
+	if err := validateLogRetentionConfig(&config.Logging.Retention); err != nil {
+		return fmt.Errorf("log retention configuration error: %v", err)
+	}
+
+	// 验证日志存储后端
+	if err := validateLogStorageConfig(&config.Logging.Storage); err != nil {
+		return fmt.Errorf("log storage configuration error: %v", err)
+	}
+
+	// 验证日志写入队列
+	if err := validateLogWriterConfig(&config.Logging.Writer); err != nil {
+		return fmt.Errorf("log writer configuration error: %v", err)
+	}
+
+	// 验证日志脱敏规则
+	if err := validateLogRedactionConfig(&config.Logging.Redaction); err != nil {
+		return fmt.Errorf("log redaction configuration error: %v", err)
+	}
+
+	// 验证链路追踪配置
+	if err := validateTracingConfig(&config.Tracing); err != nil {
+		return fmt.Errorf("tracing configuration error: %v", err)
+	}
+
+	// 验证上游连接池配置
+	if err := validateHTTPClientConfig(&config.HTTPClient); err != nil {
+		return fmt.Errorf("http_client configuration error: %v", err)
+	}
+
+	// 验证延迟评分配置
+	if err := validateLatencyScoringConfig(&config.LatencyScoring); err != nil {
+		return fmt.Errorf("latency_scoring configuration error: %v", err)
+	}
+
 	// 验证Tagging配置
 	if err := validateTaggingConfig(&config.Tagging); err != nil {
 		return fmt.Errorf("tagging configuration error: %v", err)
@@ -112,6 +149,21 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("oauth configuration error: %v", err)
 	}
 
+	// 验证凭据存储配置和凭据引用
+	if err := validateSecretsConfig(&config.Secrets, config.SecretFields()); err != nil {
+		return fmt.Errorf("secrets configuration error: %v", err)
+	}
+
+	// 验证后台 OAuth 令牌刷新配置
+	if err := validateOAuthRefreshConfig(&config.OAuthRefresh); err != nil {
+		return fmt.Errorf("oauth_refresh configuration error: %v", err)
+	}
+
+	// 验证配置修订历史配置
+	if err := validateConfigHistoryConfig(&config.ConfigHistory); err != nil {
+		return fmt.Errorf("config_history configuration error: %v", err)
+	}
+
 	return nil
 }
 
@@ -148,7 +200,7 @@ func validateTaggingConfig(config *TaggingConfig) error {
 		
 		// 验证内置tagger类型
 		if tagger.Type == "builtin" {
-			validBuiltinTypes := []string{"path", "header", "body-json", "query", "user-message", "model", "thinking"}
+			validBuiltinTypes := []string{"path", "header", "body-json", "query", "user-message", "model", "thinking", "project", "context-length"}
 			validType := false
 			for _, vt := range validBuiltinTypes {
 				if tagger.BuiltinType == vt {
@@ -176,8 +228,326 @@ func validateTaggingConfig(config *TaggingConfig) error {
 				return fmt.Errorf("tagger[%d] '%s': starlark tagger requires either script_file or script in config", i, tagger.Name)
 			}
 		}
+		
+		if tagger.Stage < 0 {
+			return fmt.Errorf("tagger[%d] '%s': stage cannot be negative", i, tagger.Name)
+		}
+	}
+	
+	// 验证tagger依赖：依赖的tagger必须存在，且不能形成循环
+	for i, tagger := range config.Taggers {
+		for _, dependency := range tagger.DependsOn {
+			if dependency == tagger.Name {
+				return fmt.Errorf("tagger[%d] '%s': cannot depend on itself", i, tagger.Name)
+			}
+			if !tagNames[dependency] {
+				return fmt.Errorf("tagger[%d] '%s': depends on unknown tagger '%s'", i, tagger.Name, dependency)
+			}
+		}
+	}
+	if _, err := ResolveTaggerStages(config.Taggers); err != nil {
+		return err
+	}
+
+	return validateFallbackChains(config.FallbackChains)
+}
+
+// ResolveTaggerStages 计算每个tagger的实际执行阶段
+// 实际阶段取配置的 stage 与所有依赖的阶段+1 中的最大值；不在列表中的依赖（如已禁用的tagger）会被忽略
+// 依赖形成循环时返回错误
+func ResolveTaggerStages(taggers []TaggerConfig) (map[string]int, error) {
+	byName := make(map[string]TaggerConfig, len(taggers))
+	for _, tagger := range taggers {
+		byName[tagger.Name] = tagger
+	}
+	
+	const (
+		visiting = 1
+		resolved = 2
+	)
+	state := make(map[string]int, len(taggers))
+	stages := make(map[string]int, len(taggers))
+	
+	var resolve func(name string, path []string) error
+	resolve = func(name string, path []string) error {
+		switch state[name] {
+		case resolved:
+			return nil
+		case visiting:
+			return fmt.Errorf("tagger dependency cycle detected: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		
+		state[name] = visiting
+		tagger := byName[name]
+		stage := tagger.Stage
+		for _, dependency := range tagger.DependsOn {
+			if _, exists := byName[dependency]; !exists {
+				continue
+			}
+			dependencyPath := append(append([]string{}, path...), name)
+			if err := resolve(dependency, dependencyPath); err != nil {
+				return err
+			}
+			if stages[dependency]+1 > stage {
+				stage = stages[dependency] + 1
+			}
+		}
+		stages[name] = stage
+		state[name] = resolved
+		return nil
+	}
+	
+	for _, tagger := range taggers {
+		if err := resolve(tagger.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	
+	return stages, nil
+}
+
+// validateFallbackChains 验证tag回退链配置
+func validateFallbackChains(chains []FallbackChainConfig) error {
+	chainNames := make(map[string]bool)
+	for i, chain := range chains {
+		if chain.Name == "" {
+			return fmt.Errorf("fallback_chains[%d]: name is required", i)
+		}
+		
+		if chainNames[chain.Name] {
+			return fmt.Errorf("fallback_chains[%d]: duplicate name '%s'", i, chain.Name)
+		}
+		chainNames[chain.Name] = true
+		
+		if len(chain.Tags) == 0 {
+			return fmt.Errorf("fallback_chains[%d] '%s': tags is required", i, chain.Name)
+		}
+		
+		if len(chain.Steps) == 0 {
+			return fmt.Errorf("fallback_chains[%d] '%s': at least one step is required", i, chain.Name)
+		}
+		
+		for j, step := range chain.Steps {
+			for _, tag := range step.Tags {
+				if strings.TrimSpace(tag) == "" {
+					return fmt.Errorf("fallback_chains[%d] '%s' step %d: tags cannot contain empty values", i, chain.Name, j+1)
+				}
+			}
+		}
+	}
+	
+	return nil
+}
+
+func validateLogRetentionConfig(config *LogRetentionConfig) error {
+	if config.MaxAgeDays == 0 {
+		config.MaxAgeDays = Default.Logging.RetentionDays
+	}
+	if config.CleanupInterval == "" {
+		config.CleanupInterval = Default.Logging.CleanupInterval
+	}
+
+	if config.MaxAgeDays < -1 {
+		return fmt.Errorf("max_age_days must be positive, 0 (default) or -1 (unlimited)")
+	}
+	if config.FailedMaxAgeDays < 0 || config.BodyMaxAgeDays < 0 || config.MaxRows < 0 || config.MaxDatabaseSizeMB < 0 {
+		return fmt.Errorf("failed_max_age_days, body_max_age_days, max_rows and max_database_size_mb cannot be negative")
+	}
+
+	interval, err := time.ParseDuration(config.CleanupInterval)
+	if err != nil {
+		return fmt.Errorf("invalid cleanup_interval '%s': %v", config.CleanupInterval, err)
+	}
+	if interval < time.Minute {
+		return fmt.Errorf("cleanup_interval must be at least 1m")
+	}
+	return nil
+}
+
+func validateLogStorageConfig(config *LogStorageConfig) error {
+	if config.Backend == "" {
+		config.Backend = Default.Logging.StorageBackend
+	}
+
+	switch config.Backend {
+	case "sqlite":
+	case "postgres":
+		if config.DSN == "" {
+			return fmt.Errorf("dsn is required for postgres backend")
+		}
+	case "jsonl":
+		if config.MaxFileSizeMB < 0 {
+			return fmt.Errorf("max_file_size_mb cannot be negative")
+		}
+		if config.MaxFileSizeMB == 0 {
+			config.MaxFileSizeMB = Default.Logging.JSONLMaxFileMB
+		}
+	default:
+		return fmt.Errorf("invalid backend '%s', must be one of: sqlite, postgres, jsonl", config.Backend)
+	}
+	return nil
+}
+
+func validateLogWriterConfig(config *LogWriterConfig) error {
+	if config.QueueSize == 0 {
+		config.QueueSize = Default.Logging.WriterQueueSize
+	}
+	if config.QueueMaxMB == 0 {
+		config.QueueMaxMB = Default.Logging.WriterQueueMaxMB
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = Default.Logging.WriterBatchSize
+	}
+	if config.FlushInterval == "" {
+		config.FlushInterval = Default.Logging.WriterFlushInterval
+	}
+	if config.OnFull == "" {
+		config.OnFull = Default.Logging.WriterOnFull
+	}
+
+	if config.QueueSize < 0 || config.QueueMaxMB < 0 || config.BatchSize < 0 {
+		return fmt.Errorf("queue_size, queue_max_mb and batch_size cannot be negative")
+	}
+	if interval, err := time.ParseDuration(config.FlushInterval); err != nil || interval <= 0 {
+		return fmt.Errorf("invalid flush_interval '%s'", config.FlushInterval)
+	}
+	if config.OnFull != "drop_bodies" && config.OnFull != "block" {
+		return fmt.Errorf("invalid on_full '%s', must be one of: drop_bodies, block", config.OnFull)
+	}
+	return nil
+}
+
+func validateLogRedactionConfig(config *LogRedactionConfig) error {
+	for _, header := range config.Headers {
+		if strings.TrimSpace(header) == "" {
+			return fmt.Errorf("header name cannot be empty")
+		}
+	}
+	for _, pattern := range config.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateHTTPClientConfig(config *HTTPClientConfig) error {
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = Default.HTTPClient.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = Default.HTTPClient.MaxIdlePerHost
+	}
+	if config.MaxIdleConns < 0 || config.MaxIdleConnsPerHost < 0 || config.MaxConnsPerHost < 0 {
+		return fmt.Errorf("connection limits cannot be negative")
+	}
+	if config.MaxConnsPerHost > 0 && config.MaxIdleConnsPerHost > config.MaxConnsPerHost {
+		return fmt.Errorf("max_idle_conns_per_host (%d) cannot exceed max_conns_per_host (%d)", config.MaxIdleConnsPerHost, config.MaxConnsPerHost)
+	}
+	return nil
+}
+
+func validateLatencyScoringConfig(config *LatencyScoringConfig) error {
+	if config.Factor == 0 {
+		config.Factor = Default.LatencyScoring.Factor
+	}
+	if config.MinTTFBGap == "" {
+		config.MinTTFBGap = Default.LatencyScoring.MinTTFBGap
+	}
+	if config.MinSamples == 0 {
+		config.MinSamples = Default.LatencyScoring.MinSamples
+	}
+	if config.Factor <= 1 {
+		return fmt.Errorf("factor must be greater than 1, got %g", config.Factor)
+	}
+	if config.MinSamples < 0 {
+		return fmt.Errorf("min_samples cannot be negative")
+	}
+	if d, err := time.ParseDuration(config.MinTTFBGap); err != nil {
+		return fmt.Errorf("invalid min_ttfb_gap '%s': %v", config.MinTTFBGap, err)
+	} else if d < 0 {
+		return fmt.Errorf("min_ttfb_gap cannot be negative")
+	}
+	return nil
+}
+
+func validateOAuthRefreshConfig(config *OAuthRefreshConfig) error {
+	if config.CheckInterval == "" {
+		config.CheckInterval = Default.OAuthRefresh.CheckInterval
+	}
+	if config.RefreshBefore == "" {
+		config.RefreshBefore = Default.OAuthRefresh.RefreshBefore
+	}
+	if config.RetryBackoff == "" {
+		config.RetryBackoff = Default.OAuthRefresh.RetryBackoff
+	}
+	if config.MaxBackoff == "" {
+		config.MaxBackoff = Default.OAuthRefresh.MaxBackoff
+	}
+	for name, value := range map[string]string{
+		"check_interval": config.CheckInterval,
+		"refresh_before": config.RefreshBefore,
+		"retry_backoff":  config.RetryBackoff,
+		"max_backoff":    config.MaxBackoff,
+	} {
+		if d, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s '%s': %v", name, value, err)
+		} else if d <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
+	return nil
+}
+
+func validateConfigHistoryConfig(config *ConfigHistoryConfig) error {
+	if config.MaxRevisions < 0 {
+		return fmt.Errorf("max_revisions cannot be negative")
+	}
+	if config.MaxRevisions == 0 {
+		config.MaxRevisions = Default.ConfigHistory.MaxRevisions
+	}
+	return nil
+}
+
+func validateTracingConfig(config *TracingConfig) error {
+	if config.Endpoint == "" {
+		config.Endpoint = Default.Tracing.Endpoint
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = Default.Tracing.ServiceName
+	}
+	if config.SampleRatio == 0 {
+		config.SampleRatio = Default.Tracing.SampleRatio
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = Default.Tracing.BatchSize
+	}
+	if config.ExportInterval == "" {
+		config.ExportInterval = Default.Tracing.ExportInterval
+	}
+	if config.ExportTimeout == "" {
+		config.ExportTimeout = Default.Tracing.ExportTimeout
 	}
 
+	parsed, err := url.Parse(config.Endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("endpoint must be an http(s) URL, got '%s'", config.Endpoint)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio must be between 0 and 1")
+	}
+	if config.BatchSize < 0 {
+		return fmt.Errorf("batch_size cannot be negative")
+	}
+	for name, value := range map[string]string{"export_interval": config.ExportInterval, "export_timeout": config.ExportTimeout} {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s '%s': %v", name, value, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
 	return nil
 }
 
@@ -244,14 +614,25 @@ func ValidateOAuthConfig(config *OAuthConfig, context string) error {
 
 // validateOAuthConfig 验证单个OAuth配置
 func validateOAuthConfig(config *OAuthConfig, context string) error {
-	if config.AccessToken == "" {
+	// 配置了登录地址时令牌可以为空，在管理界面完成授权后写入
+	canLogin := config.AuthorizeURL != "" || config.DeviceAuthorizationURL != ""
+	if config.AccessToken == "" && !canLogin {
 		return fmt.Errorf("%s: oauth access_token is required", context)
 	}
 	
-	if config.RefreshToken == "" {
+	if config.RefreshToken == "" && !canLogin {
 		return fmt.Errorf("%s: oauth refresh_token is required", context)
 	}
 	
+	for name, value := range map[string]string{"authorize_url": config.AuthorizeURL, "device_authorization_url": config.DeviceAuthorizationURL, "redirect_uri": config.RedirectURI} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: oauth %s must be an http or https URL", context, name)
+		}
+	}
+	
 	// ExpiresAt can be 0 to trigger automatic refresh, or positive timestamp
 	if config.ExpiresAt < 0 {
 		return fmt.Errorf("%s: oauth expires_at must be 0 (for auto-refresh) or a valid positive timestamp (milliseconds)", context)
@@ -356,8 +737,8 @@ func validateOpenAIEndpoints(endpoints []EndpointConfig) error {
 				if endpoint.OAuthConfig == nil {
 					return fmt.Errorf("endpoint[%d] '%s': OpenAI endpoints with oauth auth_type require oauth_config", i, endpoint.Name)
 				}
-			} else if endpoint.AuthValue == "" {
-				return fmt.Errorf("endpoint[%d] '%s': OpenAI endpoints with auth_token require auth_value to be specified", i, endpoint.Name)
+			} else if endpoint.AuthValue == "" && len(endpoint.Credentials) == 0 {
+				return fmt.Errorf("endpoint[%d] '%s': OpenAI endpoints with auth_token require auth_value or credentials to be specified", i, endpoint.Name)
 			}
 			
 			// OpenAI 端点必须配置 path_prefix
@@ -469,10 +850,98 @@ func validateEndpoint(endpoint EndpointConfig, index int) error {
 		return fmt.Errorf("endpoint %d: invalid auth_type '%s', must be 'api_key', 'auth_token', or 'oauth'", index, endpoint.AuthType)
 	}
 	
-	// OAuth 认证不需要 auth_value，其他认证类型需要
-	if endpoint.AuthType != "oauth" && endpoint.AuthValue == "" {
+	// OAuth 认证不需要 auth_value，其他认证类型需要（配置了凭据池时可以为空）
+	if endpoint.AuthType != "oauth" && endpoint.AuthValue == "" && len(endpoint.Credentials) == 0 {
 		return fmt.Errorf("endpoint %d: auth_value cannot be empty for non-oauth authentication", index)
 	}
 	
+	if err := ValidateEndpointCredentials(endpoint.Credentials, endpoint.CredentialStrategy, endpoint.AuthType); err != nil {
+		return fmt.Errorf("endpoint %d: %v", index, err)
+	}
+	
+	if endpoint.MaxContextTokens < 0 {
+		return fmt.Errorf("endpoint %d: max_context_tokens cannot be negative", index)
+	}
+	
+	if endpoint.HealthCheck != nil {
+		if err := ValidateEndpointHealthCheck(endpoint.HealthCheck, endpoint.EndpointType); err != nil {
+			return fmt.Errorf("endpoint %d: %v", index, err)
+		}
+	}
+	
+	return nil
+}
+
+// ValidateEndpointCredentials 验证端点凭据池配置（导出函数）
+func ValidateEndpointCredentials(credentials []EndpointCredentialConfig, strategy, authType string) error {
+	switch strategy {
+	case "", "round_robin", "least_used":
+	default:
+		return fmt.Errorf("invalid credential_strategy '%s', must be 'round_robin' or 'least_used'", strategy)
+	}
+	if len(credentials) == 0 {
+		return nil
+	}
+	if authType == "oauth" {
+		return fmt.Errorf("credentials are not supported with auth_type 'oauth'")
+	}
+	
+	seen := make(map[string]bool)
+	for i, cred := range credentials {
+		if cred.Name == "" {
+			return fmt.Errorf("credentials[%d]: name cannot be empty", i)
+		}
+		if seen[cred.Name] {
+			return fmt.Errorf("credentials[%d]: duplicate name '%s'", i, cred.Name)
+		}
+		seen[cred.Name] = true
+		if cred.Value == "" {
+			return fmt.Errorf("credential '%s': value cannot be empty", cred.Name)
+		}
+		if cred.Weight < 0 {
+			return fmt.Errorf("credential '%s': weight cannot be negative", cred.Name)
+		}
+		if cred.Cooldown != "" {
+			if d, err := time.ParseDuration(cred.Cooldown); err != nil || d <= 0 {
+				return fmt.Errorf("credential '%s': invalid cooldown '%s'", cred.Name, cred.Cooldown)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateEndpointHealthCheck 验证端点健康检查探测配置（导出函数）
+func ValidateEndpointHealthCheck(hc *EndpointHealthCheckConfig, endpointType string) error {
+	switch hc.Mode {
+	case "", "active", "passive":
+	default:
+		return fmt.Errorf("invalid health_check.mode '%s', must be 'active' or 'passive'", hc.Mode)
+	}
+	
+	switch hc.Probe {
+	case "", "message", "models":
+	case "count_tokens":
+		if endpointType == "openai" {
+			return fmt.Errorf("health_check.probe 'count_tokens' is only supported by anthropic endpoints")
+		}
+	default:
+		return fmt.Errorf("invalid health_check.probe '%s', must be 'message', 'count_tokens' or 'models'", hc.Probe)
+	}
+	
+	for _, status := range hc.ExpectStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid health_check.expect_status %d", status)
+		}
+	}
+	
+	for field, value := range map[string]string{"interval": hc.Interval, "timeout": hc.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid health_check.%s '%s'", field, value)
+		}
+	}
+	
 	return nil
 }
\ No newline at end of file
@@ -12,20 +12,24 @@ func intPtr(i int) *int {
 	return &i
 }
 
-func getTestLogger() *logger.Logger {
+func getTestLogger(t *testing.T) *logger.Logger {
 	// Create a simple test logger
-	testLogger, _ := logger.NewLogger(logger.LogConfig{
+	testLogger, err := logger.NewLogger(logger.LogConfig{
 		Level:           "debug",
 		LogRequestTypes: "all",
-		LogDirectory:    "", // Empty to avoid file operations in tests
+		LogDirectory:    t.TempDir(),
 	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { testLogger.Close() })
 	return testLogger
 }
 
 // 集成测试：完整的请求-响应循环
 func TestFullConversionCycle(t *testing.T) {
-	reqConverter := NewRequestConverter(getTestLogger())
-	respConverter := NewResponseConverter(getTestLogger())
+	reqConverter := NewRequestConverter(getTestLogger(t))
+	respConverter := NewResponseConverter(getTestLogger(t))
 
 	// 1. Anthropic 请求
 	anthReq := AnthropicRequest{
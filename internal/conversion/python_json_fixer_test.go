@@ -14,12 +14,13 @@ func createTestLogger(t *testing.T) *logger.Logger {
 		LogRequestTypes: "none",
 		LogRequestBody:  "none",
 		LogResponseBody: "none",
-		LogDirectory:    "none", // Use "none" to disable file logging
+		LogDirectory:    t.TempDir(),
 	}
 	log, err := logger.NewLogger(logConfig)
 	if err != nil {
 		t.Fatalf("Failed to create logger: %v", err)
 	}
+	t.Cleanup(func() { log.Close() })
 	return log
 }
 
@@ -399,8 +400,9 @@ func TestPythonJSONFixer_isValidJSON(t *testing.T) {
 
 // Benchmark tests for performance
 func BenchmarkPythonJSONFixer_DetectPythonStyle(b *testing.B) {
-	logConfig := logger.LogConfig{Level: "error", LogRequestTypes: "none", LogRequestBody: "none", LogResponseBody: "none", LogDirectory: "none"}
+	logConfig := logger.LogConfig{Level: "error", LogRequestTypes: "none", LogRequestBody: "none", LogResponseBody: "none", LogDirectory: b.TempDir()}
 	log, _ := logger.NewLogger(logConfig)
+	defer log.Close()
 	fixer := NewPythonJSONFixer(log)
 	input := "{'content': 'test content with some length', 'id': '1', 'status': 'in_progress'}"
 
@@ -411,8 +413,9 @@ func BenchmarkPythonJSONFixer_DetectPythonStyle(b *testing.B) {
 }
 
 func BenchmarkPythonJSONFixer_FixPythonStyleJSON(b *testing.B) {
-	logConfig := logger.LogConfig{Level: "error", LogRequestTypes: "none", LogRequestBody: "none", LogResponseBody: "none", LogDirectory: "none"}
+	logConfig := logger.LogConfig{Level: "error", LogRequestTypes: "none", LogRequestBody: "none", LogResponseBody: "none", LogDirectory: b.TempDir()}
 	log, _ := logger.NewLogger(logConfig)
+	defer log.Close()
 	fixer := NewPythonJSONFixer(log)
 	input := "{'todos': [{'content': 'task1', 'id': '1', 'status': 'pending'}, {'content': 'task2', 'id': '2', 'status': 'in_progress'}]}"
 
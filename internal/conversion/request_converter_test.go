@@ -6,7 +6,7 @@ import (
 )
 
 func TestConvertAnthropicRequestToOpenAI_SimpleText(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 
 	if converter == nil {
 		t.Fatal("Converter should not be nil")
@@ -70,7 +70,7 @@ func TestConvertAnthropicRequestToOpenAI_SimpleText(t *testing.T) {
 }
 
 func TestConvertAnthropicRequestToOpenAI_WithTools(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 
 	anthReq := AnthropicRequest{
 		Model: "claude-3-sonnet-20240229",
@@ -139,7 +139,7 @@ func TestConvertAnthropicRequestToOpenAI_WithTools(t *testing.T) {
 }
 
 func TestConvertAnthropicRequestToOpenAI_WithToolUse(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 
 	anthReq := AnthropicRequest{
 		Model: "claude-3-sonnet-20240229",
@@ -233,7 +233,7 @@ func TestConvertAnthropicRequestToOpenAI_WithToolUse(t *testing.T) {
 }
 
 func TestConvertAnthropicRequestToOpenAI_WithToolResult(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 	
 
 	anthReq := AnthropicRequest{
@@ -291,7 +291,7 @@ func TestConvertAnthropicRequestToOpenAI_WithToolResult(t *testing.T) {
 }
 
 func TestConvertAnthropicRequestToOpenAI_WithImage(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 	
 
 	anthReq := AnthropicRequest{
@@ -401,7 +401,7 @@ func TestConvertAnthropicRequestToOpenAI_WithImage(t *testing.T) {
 }
 
 func TestToolChoiceMapping(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 	
 
 	testCases := []struct {
@@ -487,7 +487,7 @@ func TestToolChoiceMapping(t *testing.T) {
 }
 
 func TestSystemMessageHandling(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 	
 
 	testCases := []struct {
@@ -575,7 +575,7 @@ func TestSystemMessageHandling(t *testing.T) {
 }
 
 func TestToolChoiceOnlyWhenToolsPresent(t *testing.T) {
-	converter := NewRequestConverter(getTestLogger())
+	converter := NewRequestConverter(getTestLogger(t))
 
 	// 测试用例1：没有工具时，不应该设置tool_choice
 	t.Run("no_tools_no_tool_choice", func(t *testing.T) {
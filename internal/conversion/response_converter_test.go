@@ -6,7 +6,7 @@ import (
 )
 
 func TestConvertOpenAIResponseToAnthropic_SimpleText(t *testing.T) {
-	converter := NewResponseConverter(getTestLogger())
+	converter := NewResponseConverter(getTestLogger(t))
 
 	oaResp := OpenAIResponse{
 		ID:    "chatcmpl-123",
@@ -87,7 +87,7 @@ func TestConvertOpenAIResponseToAnthropic_SimpleText(t *testing.T) {
 }
 
 func TestConvertOpenAIResponseToAnthropic_WithToolCalls(t *testing.T) {
-	converter := NewResponseConverter(getTestLogger())
+	converter := NewResponseConverter(getTestLogger(t))
 
 	oaResp := OpenAIResponse{
 		ID:    "chatcmpl-123",
@@ -8,7 +8,7 @@ import (
 
 // TestSSEProtocolFixes_RealData tests the SSE protocol fixes with real OpenAI data format
 func TestSSEProtocolFixes_RealData(t *testing.T) {
-	converter := NewResponseConverter(getTestLogger())
+	converter := NewResponseConverter(getTestLogger(t))
 
 	// Simulate real OpenAI SSE stream like the one in a.txt
 	openaiSSE := `data: {"id":"2025081814023662804f889e1d4242","created":1755496956,"model":"glm-4.5","choices":[{"index":0,"delta":{"role":"assistant","content":"你好"}}]}
@@ -143,7 +143,7 @@ data: [DONE]
 
 // TestSSEProtocolFixes_CompareWithOldVersion compares new vs old architecture output
 func TestSSEProtocolFixes_CompareWithOldVersion(t *testing.T) {
-	converter := NewResponseConverter(getTestLogger())
+	converter := NewResponseConverter(getTestLogger(t))
 
 	// Simple test data
 	openaiSSE := `data: {"id":"test-123","object":"chat.completion.chunk","created":1234567890,"model":"gpt-4","choices":[{"delta":{"content":"Hello"},"index":0,"finish_reason":null}]}
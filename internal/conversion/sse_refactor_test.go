@@ -8,7 +8,7 @@ import (
 
 // Test the MessageAggregator component with usage accumulation
 func TestMessageAggregator_UsageAccumulation(t *testing.T) {
-	aggregator := NewMessageAggregator(getTestLogger())
+	aggregator := NewMessageAggregator(getTestLogger(t))
 
 	// Create test chunks with multiple usage info to test accumulation
 	chunks := []OpenAIStreamChunk{
@@ -103,7 +103,7 @@ func TestMessageAggregator_UsageAccumulation(t *testing.T) {
 
 // Test the MessageAggregator component
 func TestMessageAggregator_SimpleText(t *testing.T) {
-	aggregator := NewMessageAggregator(getTestLogger())
+	aggregator := NewMessageAggregator(getTestLogger(t))
 
 	// Create test chunks for simple text
 	chunks := []OpenAIStreamChunk{
@@ -186,7 +186,7 @@ func TestMessageAggregator_SimpleText(t *testing.T) {
 }
 
 func TestMessageAggregator_WithToolCalls(t *testing.T) {
-	aggregator := NewMessageAggregator(getTestLogger())
+	aggregator := NewMessageAggregator(getTestLogger(t))
 
 	// Create test chunks with tool calls
 	chunks := []OpenAIStreamChunk{
@@ -291,7 +291,7 @@ func TestMessageAggregator_WithToolCalls(t *testing.T) {
 
 // Test the UnifiedConverter component
 func TestUnifiedConverter_SimpleText(t *testing.T) {
-	converter := NewUnifiedConverter(getTestLogger())
+	converter := NewUnifiedConverter(getTestLogger(t))
 
 	// Create aggregated message
 	msg := &AggregatedMessage{
@@ -362,7 +362,7 @@ func TestUnifiedConverter_SimpleText(t *testing.T) {
 }
 
 func TestUnifiedConverter_WithToolCalls(t *testing.T) {
-	converter := NewUnifiedConverter(getTestLogger())
+	converter := NewUnifiedConverter(getTestLogger(t))
 
 	// Create aggregated message with tool calls
 	msg := &AggregatedMessage{
@@ -416,7 +416,7 @@ func TestUnifiedConverter_WithToolCalls(t *testing.T) {
 
 // Test the complete refactored streaming conversion
 func TestRefactoredStreamingConversion_Complete(t *testing.T) {
-	converter := NewResponseConverter(getTestLogger())
+	converter := NewResponseConverter(getTestLogger(t))
 
 	// Create OpenAI SSE stream with text and tool calls
 	openaiSSE := `data: {"id":"chatcmpl-123","object":"chat.completion.chunk","created":1234567890,"model":"gpt-4","choices":[{"delta":{"content":"I'll help"},"index":0,"finish_reason":null}]}
@@ -506,7 +506,7 @@ data: [DONE]
 
 // Test edge cases
 func TestMessageAggregator_EmptyChunks(t *testing.T) {
-	aggregator := NewMessageAggregator(getTestLogger())
+	aggregator := NewMessageAggregator(getTestLogger(t))
 
 	// Test with empty chunks
 	_, err := aggregator.AggregateChunks([]OpenAIStreamChunk{})
@@ -516,7 +516,7 @@ func TestMessageAggregator_EmptyChunks(t *testing.T) {
 }
 
 func TestMessageAggregator_FinishReasonMapping(t *testing.T) {
-	aggregator := NewMessageAggregator(getTestLogger())
+	aggregator := NewMessageAggregator(getTestLogger(t))
 
 	testCases := []struct {
 		openaiReason     string
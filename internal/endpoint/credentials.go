@@ -0,0 +1,394 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+// 凭据状态
+const (
+	CredentialStateActive      = "active"
+	CredentialStateCooldown    = "cooldown"
+	CredentialStateBlacklisted = "blacklisted"
+	CredentialStateDisabled    = "disabled"
+)
+
+// Credential 凭据池中的一个凭据，Name 和 Value 创建后不再修改，其余状态由凭据池加锁维护
+// 凭据引用在存储中轮换后用新的 Credential 替换池中的旧凭据
+type Credential struct {
+	Name  string
+	Value string
+
+	reference string // 配置中的原始值，可能是凭据引用
+
+	weight   int
+	cooldown time.Duration
+	disabled bool
+
+	requests      int64
+	successes     int64
+	failures      int64
+	lastUsed      time.Time
+	cooldownUntil time.Time
+	blacklistedAt time.Time
+	lastError     string
+	currentWeight int // 平滑加权轮询的当前权重
+}
+
+// CredentialStatus 凭据的运行状态，供管理界面展示，不包含凭据值
+type CredentialStatus struct {
+	Name          string     `json:"name"`
+	State         string     `json:"state"`
+	Weight        int        `json:"weight"`
+	Requests      int64      `json:"requests"`
+	Successes     int64      `json:"successes"`
+	Failures      int64      `json:"failures"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	BlacklistedAt *time.Time `json:"blacklisted_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// credentialPool 端点的凭据池，配置更新时按名称和值继承运行状态
+type credentialPool struct {
+	mutex       sync.Mutex
+	strategy    string
+	credentials []*Credential
+}
+
+// newCredentialPool 根据配置创建凭据池并解析凭据引用，没有配置凭据时返回 nil
+func newCredentialPool(endpointName string, cfgs []config.EndpointCredentialConfig, strategy string) *credentialPool {
+	if len(cfgs) == 0 {
+		return nil
+	}
+	defaultCooldown, _ := time.ParseDuration(config.Default.Credential.Cooldown)
+	pool := &credentialPool{strategy: config.GetStringWithDefault(strategy, config.Default.Credential.Strategy)}
+	for _, cfg := range cfgs {
+		cooldown := defaultCooldown
+		if d, err := time.ParseDuration(cfg.Cooldown); err == nil && d > 0 {
+			cooldown = d
+		}
+		pool.credentials = append(pool.credentials, &Credential{
+			Name:      cfg.Name,
+			Value:     resolveSecret(endpointName, "credential "+cfg.Name, cfg.Value),
+			reference: cfg.Value,
+			weight:    config.GetIntWithDefault(cfg.Weight, config.Default.Credential.Weight),
+			cooldown:  cooldown,
+			disabled:  cfg.Disabled,
+		})
+	}
+	return pool
+}
+
+func (c *Credential) usable(now time.Time) bool {
+	return !c.disabled && c.blacklistedAt.IsZero() && !now.Before(c.cooldownUntil)
+}
+
+func (c *Credential) state(now time.Time) string {
+	switch {
+	case c.disabled:
+		return CredentialStateDisabled
+	case !c.blacklistedAt.IsZero():
+		return CredentialStateBlacklisted
+	case now.Before(c.cooldownUntil):
+		return CredentialStateCooldown
+	default:
+		return CredentialStateActive
+	}
+}
+
+// acquire 按轮换策略选择一个可用且不在 exclude 中的凭据并计入使用次数，没有可用凭据时返回 nil
+func (p *credentialPool) acquire(exclude map[string]bool) *Credential {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	var candidates []*Credential
+	for _, cred := range p.credentials {
+		if cred.usable(now) && !exclude[cred.Name] {
+			candidates = append(candidates, cred)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var selected *Credential
+	if p.strategy == "least_used" {
+		// 按权重折算后的使用次数最少的凭据
+		for _, cred := range candidates {
+			if selected == nil || float64(cred.requests)/float64(cred.weight) < float64(selected.requests)/float64(selected.weight) {
+				selected = cred
+			}
+		}
+	} else {
+		// 平滑加权轮询：权重高的凭据被更频繁地选中，但不会连续集中使用
+		total := 0
+		for _, cred := range candidates {
+			cred.currentWeight += cred.weight
+			total += cred.weight
+			if selected == nil || cred.currentWeight > selected.currentWeight {
+				selected = cred
+			}
+		}
+		selected.currentWeight -= total
+	}
+
+	selected.requests++
+	selected.lastUsed = now
+	return selected
+}
+
+// report 记录凭据的请求结果：401/403 拉黑凭据，429 使凭据进入冷却
+// 返回 true 表示失败是凭据本身导致的，可以换一个凭据重试
+func (p *credentialPool) report(cred *Credential, statusCode int, retryAfter time.Duration) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if statusCode >= 200 && statusCode < 300 {
+		cred.successes++
+		return false
+	}
+	cred.failures++
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		cred.blacklistedAt = time.Now()
+		cred.lastError = fmt.Sprintf("HTTP %d", statusCode)
+		return true
+	case http.StatusTooManyRequests:
+		cooldown := cred.cooldown
+		if retryAfter > 0 {
+			cooldown = retryAfter
+		}
+		cred.cooldownUntil = time.Now().Add(cooldown)
+		cred.lastError = fmt.Sprintf("HTTP %d", statusCode)
+		return true
+	}
+	return false
+}
+
+// replace 用值已轮换的新凭据替换池中的 cred，继承其计数和状态；cred 已不在池中时返回 false
+func (p *credentialPool) replace(cred *Credential, value string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, current := range p.credentials {
+		if current == cred {
+			fresh := *cred
+			fresh.Value = value
+			p.credentials[i] = &fresh
+			return true
+		}
+	}
+	return false
+}
+
+// hasUsable 检查是否还有可用且不在 exclude 中的凭据
+func (p *credentialPool) hasUsable(exclude map[string]bool) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	for _, cred := range p.credentials {
+		if cred.usable(now) && !exclude[cred.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// probeValue 返回健康检查使用的凭据值：优先使用可用凭据，全部不可用时使用第一个未停用的凭据
+func (p *credentialPool) probeValue() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	fallback := ""
+	for _, cred := range p.credentials {
+		if cred.usable(now) {
+			return cred.Value
+		}
+		if !cred.disabled && fallback == "" {
+			fallback = cred.Value
+		}
+	}
+	return fallback
+}
+
+// reset 解除凭据的拉黑和冷却
+func (p *credentialPool) reset(name string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, cred := range p.credentials {
+		if cred.Name == name {
+			cred.blacklistedAt = time.Time{}
+			cred.cooldownUntil = time.Time{}
+			cred.lastError = ""
+			return nil
+		}
+	}
+	return fmt.Errorf("credential '%s' not found", name)
+}
+
+// inherit 从旧凭据池继承名称和值都没有变化的凭据的计数、拉黑和冷却状态
+func (p *credentialPool) inherit(old *credentialPool) {
+	if old == nil {
+		return
+	}
+	old.mutex.Lock()
+	defer old.mutex.Unlock()
+	previous := make(map[string]*Credential, len(old.credentials))
+	for _, cred := range old.credentials {
+		previous[cred.Name] = cred
+	}
+	for _, cred := range p.credentials {
+		if prev, ok := previous[cred.Name]; ok && prev.Value == cred.Value {
+			cred.requests = prev.requests
+			cred.successes = prev.successes
+			cred.failures = prev.failures
+			cred.lastUsed = prev.lastUsed
+			cred.cooldownUntil = prev.cooldownUntil
+			cred.blacklistedAt = prev.blacklistedAt
+			cred.lastError = prev.lastError
+		}
+	}
+}
+
+func (p *credentialPool) status() []CredentialStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	result := make([]CredentialStatus, 0, len(p.credentials))
+	for _, cred := range p.credentials {
+		status := CredentialStatus{
+			Name:      cred.Name,
+			State:     cred.state(now),
+			Weight:    cred.weight,
+			Requests:  cred.requests,
+			Successes: cred.successes,
+			Failures:  cred.failures,
+			LastError: cred.lastError,
+		}
+		if !cred.lastUsed.IsZero() {
+			lastUsed := cred.lastUsed
+			status.LastUsed = &lastUsed
+		}
+		if now.Before(cred.cooldownUntil) {
+			cooldownUntil := cred.cooldownUntil
+			status.CooldownUntil = &cooldownUntil
+		}
+		if !cred.blacklistedAt.IsZero() {
+			blacklistedAt := cred.blacklistedAt
+			status.BlacklistedAt = &blacklistedAt
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// HasCredentials 端点是否配置了凭据池
+func (e *Endpoint) HasCredentials() bool {
+	return e.credentials != nil
+}
+
+// AcquireCredential 从凭据池中选择一个凭据，exclude 为本次请求已经失败过的凭据名称
+// 没有配置凭据池时返回 nil, nil
+func (e *Endpoint) AcquireCredential(exclude map[string]bool) (*Credential, error) {
+	if e.credentials == nil {
+		return nil, nil
+	}
+	if cred := e.credentials.acquire(exclude); cred != nil {
+		return cred, nil
+	}
+	return nil, fmt.Errorf("no usable credentials for endpoint %s", e.Name)
+}
+
+// ReportCredentialResult 记录凭据的请求结果，retryAfter 为响应的 Retry-After 头部
+// 返回 true 表示凭据被拉黑或进入冷却，可以换一个凭据重试
+func (e *Endpoint) ReportCredentialResult(cred *Credential, statusCode int, retryAfter string) bool {
+	if e.credentials == nil || cred == nil {
+		return false
+	}
+	return e.credentials.report(cred, statusCode, parseRetryAfter(retryAfter))
+}
+
+// ReloadSecret 上游拒绝认证（401/403）后重新从凭据存储读取凭据引用，cred 为 nil 时读取 auth_value
+// 返回 true 表示存储中的值已经轮换，可以用新值重试；不是引用或值未变化时返回 false
+func (e *Endpoint) ReloadSecret(cred *Credential) bool {
+	if cred != nil {
+		value, changed := reloadSecret(e.Name, "credential "+cred.Name, cred.reference, cred.Value)
+		return changed && e.credentials != nil && e.credentials.replace(cred, value)
+	}
+
+	e.mutex.RLock()
+	reference, current := e.AuthValue, e.authSecret
+	e.mutex.RUnlock()
+	value, changed := reloadSecret(e.Name, "auth_value", reference, current)
+	if !changed {
+		return false
+	}
+	e.mutex.Lock()
+	e.authSecret = value
+	e.mutex.Unlock()
+	return true
+}
+
+// HasUsableCredential 检查是否还有可用且不在 exclude 中的凭据，没有配置凭据池时返回 false
+func (e *Endpoint) HasUsableCredential(exclude map[string]bool) bool {
+	return e.credentials != nil && e.credentials.hasUsable(exclude)
+}
+
+// ResetCredential 解除凭据的拉黑和冷却
+func (e *Endpoint) ResetCredential(name string) error {
+	if e.credentials == nil {
+		return fmt.Errorf("endpoint %s has no credentials", e.Name)
+	}
+	return e.credentials.reset(name)
+}
+
+// CredentialStatus 返回凭据池中各凭据的状态，没有配置凭据池时返回 nil
+func (e *Endpoint) CredentialStatus() []CredentialStatus {
+	if e.credentials == nil {
+		return nil
+	}
+	return e.credentials.status()
+}
+
+// parseRetryAfter 解析 Retry-After 头部（秒数或 HTTP 日期），无法解析时返回 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// CredentialStats 返回配置了凭据池的端点的凭据状态，以端点名为键
+func (m *Manager) CredentialStats() map[string][]CredentialStatus {
+	result := make(map[string][]CredentialStatus)
+	for _, endpoint := range m.GetAllEndpoints() {
+		if status := endpoint.CredentialStatus(); status != nil {
+			result[endpoint.Name] = status
+		}
+	}
+	return result
+}
+
+// ResetCredential 解除端点中指定凭据的拉黑和冷却
+func (m *Manager) ResetCredential(endpointName, credentialName string) error {
+	for _, endpoint := range m.GetAllEndpoints() {
+		if endpoint.Name == endpointName {
+			return endpoint.ResetCredential(credentialName)
+		}
+	}
+	return fmt.Errorf("endpoint not found: %s", endpointName)
+}
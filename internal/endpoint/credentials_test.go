@@ -0,0 +1,149 @@
+package endpoint
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+func newCredentialEndpoint(strategy string, creds ...config.EndpointCredentialConfig) *Endpoint {
+	return NewEndpoint(config.EndpointConfig{
+		Name:               "pool",
+		URL:                "https://api.example.com",
+		AuthType:           "api_key",
+		Enabled:            true,
+		Credentials:        creds,
+		CredentialStrategy: strategy,
+	})
+}
+
+func TestAcquireCredentialWeightedRoundRobin(t *testing.T) {
+	ep := newCredentialEndpoint("",
+		config.EndpointCredentialConfig{Name: "a", Value: "sk-a", Weight: 2},
+		config.EndpointCredentialConfig{Name: "b", Value: "sk-b"},
+	)
+
+	counts := map[string]int{}
+	var sequence string
+	for i := 0; i < 6; i++ {
+		cred, err := ep.AcquireCredential(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		counts[cred.Name]++
+		sequence += cred.Name
+	}
+	if counts["a"] != 4 || counts["b"] != 2 {
+		t.Errorf("Expected 4:2 split by weight, got %v", counts)
+	}
+	// 平滑加权轮询不会把高权重凭据连续集中使用
+	if sequence != "abaaba" {
+		t.Errorf("Unexpected rotation order: %s", sequence)
+	}
+}
+
+func TestAcquireCredentialLeastUsed(t *testing.T) {
+	ep := newCredentialEndpoint("least_used",
+		config.EndpointCredentialConfig{Name: "a", Value: "sk-a"},
+		config.EndpointCredentialConfig{Name: "b", Value: "sk-b"},
+	)
+	for i := 0; i < 3; i++ {
+		ep.AcquireCredential(map[string]bool{"b": true})
+	}
+	cred, _ := ep.AcquireCredential(nil)
+	if cred.Name != "b" {
+		t.Errorf("Expected least used credential b, got %s", cred.Name)
+	}
+}
+
+func TestReportCredentialResult(t *testing.T) {
+	ep := newCredentialEndpoint("",
+		config.EndpointCredentialConfig{Name: "a", Value: "sk-a"},
+		config.EndpointCredentialConfig{Name: "b", Value: "sk-b"},
+		config.EndpointCredentialConfig{Name: "c", Value: "sk-c", Disabled: true},
+	)
+	a := ep.credentials.credentials[0]
+	b := ep.credentials.credentials[1]
+
+	if ep.ReportCredentialResult(a, http.StatusOK, "") {
+		t.Error("Expected success not to reject the credential")
+	}
+	if ep.ReportCredentialResult(a, http.StatusInternalServerError, "") {
+		t.Error("Expected 5xx not to be treated as a credential failure")
+	}
+	if !ep.ReportCredentialResult(a, http.StatusUnauthorized, "") {
+		t.Error("Expected 401 to blacklist the credential")
+	}
+	if !ep.ReportCredentialResult(b, http.StatusTooManyRequests, "120") {
+		t.Error("Expected 429 to cool down the credential")
+	}
+
+	status := ep.CredentialStatus()
+	if status[0].State != CredentialStateBlacklisted || status[0].Successes != 1 || status[0].Failures != 2 {
+		t.Errorf("Unexpected status for a: %+v", status[0])
+	}
+	if status[1].State != CredentialStateCooldown || status[1].CooldownUntil == nil || time.Until(*status[1].CooldownUntil) < 100*time.Second {
+		t.Errorf("Expected b to cool down for Retry-After, got %+v", status[1])
+	}
+	if status[2].State != CredentialStateDisabled {
+		t.Errorf("Expected c to be disabled, got %s", status[2].State)
+	}
+
+	if ep.IsAvailable() {
+		t.Error("Expected endpoint to be unavailable when no credential is usable")
+	}
+	if _, err := ep.AcquireCredential(nil); err == nil {
+		t.Error("Expected error when all credentials are exhausted")
+	}
+	if ep.ProbeAuthValue() != "sk-a" {
+		t.Errorf("Expected probe to fall back to the first enabled credential, got %s", ep.ProbeAuthValue())
+	}
+
+	if err := ep.ResetCredential("b"); err != nil {
+		t.Fatalf("Unexpected reset error: %v", err)
+	}
+	if !ep.IsAvailable() || !ep.HasUsableCredential(map[string]bool{"a": true}) {
+		t.Error("Expected reset credential to be usable again")
+	}
+	if err := ep.ResetCredential("missing"); err == nil {
+		t.Error("Expected error when resetting an unknown credential")
+	}
+}
+
+func TestCredentialPoolInherit(t *testing.T) {
+	old := newCredentialEndpoint("",
+		config.EndpointCredentialConfig{Name: "a", Value: "sk-a"},
+		config.EndpointCredentialConfig{Name: "b", Value: "sk-b"},
+	)
+	old.ReportCredentialResult(old.credentials.credentials[0], http.StatusForbidden, "")
+	old.ReportCredentialResult(old.credentials.credentials[1], http.StatusForbidden, "")
+
+	// a 的值没有变化，继承拉黑状态；b 更换了值，视为新凭据
+	updated := newCredentialEndpoint("",
+		config.EndpointCredentialConfig{Name: "a", Value: "sk-a"},
+		config.EndpointCredentialConfig{Name: "b", Value: "sk-b2"},
+	)
+	updated.credentials.inherit(old.credentials)
+
+	status := updated.CredentialStatus()
+	if status[0].State != CredentialStateBlacklisted {
+		t.Errorf("Expected a to stay blacklisted, got %s", status[0].State)
+	}
+	if status[1].State != CredentialStateActive || status[1].Failures != 0 {
+		t.Errorf("Expected b to start fresh, got %+v", status[1])
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("30"); d != 30*time.Second {
+		t.Errorf("Expected 30s, got %v", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d <= 0 || d > time.Minute {
+		t.Errorf("Expected HTTP date to parse, got %v", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("Expected invalid value to be ignored, got %v", d)
+	}
+}
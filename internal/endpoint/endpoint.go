@@ -2,6 +2,7 @@ package endpoint
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -35,6 +36,9 @@ type BlacklistReason struct {
 	ErrorSummary string `json:"error_summary"`
 }
 
+// StatusChangeFunc 端点状态切换时的回调，reason 仅在因连续失败被拉黑时非空
+type StatusChangeFunc func(ep *Endpoint, from, to Status, reason *BlacklistReason)
+
 // 删除不再需要的 RequestRecord 定义，因为已经移到 utils 包
 
 type Endpoint struct {
@@ -57,6 +61,12 @@ type Endpoint struct {
 	RateLimitReset      *int64                 `json:"rate_limit_reset,omitempty"`      // Anthropic-Ratelimit-Unified-Reset
 	RateLimitStatus     *string                `json:"rate_limit_status,omitempty"`     // Anthropic-Ratelimit-Unified-Status
 	EnhancedProtection  bool                   `json:"enhanced_protection,omitempty"`   // 官方帐号增强保护：allowed_warning时即禁用端点
+	MaxContextTokens    int                    `json:"max_context_tokens,omitempty"`    // 端点可容纳的最大上下文长度（token），0表示不限制
+	DisableBodyLogging  bool                   `json:"disable_body_logging,omitempty"`  // 不记录该端点的请求/响应体
+	HealthCheck         *config.EndpointHealthCheckConfig `json:"health_check,omitempty"` // 健康检查探测配置
+	Credentials         []config.EndpointCredentialConfig `json:"credentials,omitempty"` // 凭据池配置
+	CredentialStrategy  string                   `json:"credential_strategy,omitempty"` // 凭据轮换策略
+	DisabledReason      string                   `json:"disabled_reason,omitempty"` // 端点被自动禁用的原因
 	Status              Status                   `json:"status"`
 	LastCheck           time.Time                `json:"last_check"`
 	FailureCount        int                      `json:"failure_count"`
@@ -75,6 +85,29 @@ type Endpoint struct {
 	// 新增：上次记录跳过健康检查日志的时间（用于减少日志频率）
 	lastSkipLogTime time.Time `json:"-"`
 	
+	// 共享的连接池缓存，由 Manager 设置；为空时每次请求创建新的客户端
+	clients *httpclient.TransportPool
+	
+	// 状态切换回调，由 Manager 设置，用于记录健康历史
+	onStatusChange StatusChangeFunc
+	
+	// 近期成功请求的延迟和吞吐量统计，有独立的锁
+	perf *endpointPerformance
+	
+	// 凭据池运行状态，没有配置凭据时为空，有独立的锁
+	credentials *credentialPool
+	
+	// 解析凭据引用后实际使用的认证值、代理和 OAuth 配置；导出字段保留配置中的引用，供管理界面展示和保存
+	authSecret  string
+	proxy       *config.ProxyConfig
+	oauth       *config.OAuthConfig
+	
+	// 进行中的 OAuth 令牌刷新，同一端点同时只刷新一次
+	refreshMutex sync.Mutex
+	refreshCall  *oauthRefreshCall
+	// 串行化令牌的持久化和替换（刷新、重新授权），持有期间会调用持久化回调，不能在持有 mutex 时获取
+	tokenMutex sync.Mutex
+	
 	mutex               sync.RWMutex
 }
 
@@ -102,9 +135,20 @@ func NewEndpoint(cfg config.EndpointConfig) *Endpoint {
 		RateLimitReset:      cfg.RateLimitReset,      // 新增：从配置加载rate limit reset状态
 		RateLimitStatus:     cfg.RateLimitStatus,     // 新增：从配置加载rate limit status状态
 		EnhancedProtection:  cfg.EnhancedProtection,  // 新增：从配置加载官方帐号增强保护设置
+		MaxContextTokens:    cfg.MaxContextTokens,    // 从配置加载最大上下文长度
+		DisableBodyLogging:  cfg.DisableBodyLogging,  // 从配置加载正文记录开关
+		HealthCheck:         cfg.HealthCheck,         // 从配置加载健康检查探测配置
+		Credentials:         cfg.Credentials,         // 从配置加载凭据池
+		CredentialStrategy:  cfg.CredentialStrategy,  // 从配置加载凭据轮换策略
+		DisabledReason:      cfg.DisabledReason,      // 从配置加载自动禁用原因
 		Status:            StatusActive,
 		LastCheck:         time.Now(),
 		RequestHistory:    utils.NewCircularBuffer(100, 140*time.Second), // 100个记录，140秒窗口
+		perf:              newEndpointPerformance(),
+		credentials:       newCredentialPool(cfg.Name, cfg.Credentials, cfg.CredentialStrategy),
+		authSecret:        resolveSecret(cfg.Name, "auth_value", cfg.AuthValue),
+		proxy:             resolveProxyConfig(cfg.Name, cfg.Proxy),
+		oauth:             resolveOAuthConfig(cfg.Name, cfg.OAuthConfig),
 	}
 }
 
@@ -127,25 +171,49 @@ func (e *Endpoint) GetAuthHeader() (string, error) {
 
 	switch e.AuthType {
 	case "api_key":
-		return e.AuthValue, nil // api_key 直接返回值，会用 x-api-key 头部
+		return e.authValue(), nil // api_key 直接返回值，会用 x-api-key 头部
 	case "auth_token":
-		return "Bearer " + e.AuthValue, nil // auth_token 使用 Bearer 前缀
+		return "Bearer " + e.authValue(), nil // auth_token 使用 Bearer 前缀
 	case "oauth":
-		if e.OAuthConfig == nil {
+		if e.oauth == nil {
 			return "", fmt.Errorf("oauth config is required for oauth auth_type")
 		}
 		
 		// 检查 token 是否需要刷新
-		if oauth.IsTokenExpired(e.OAuthConfig) {
+		if oauth.IsTokenExpired(e.oauth) {
 			return "", fmt.Errorf("oauth token expired, refresh required")
 		}
 		
-		return oauth.GetAuthorizationHeader(e.OAuthConfig), nil
+		return oauth.GetAuthorizationHeader(e.oauth), nil
 	default:
-		return e.AuthValue, nil
+		return e.authValue(), nil
 	}
 }
 
+// authValue 返回不经过轮换的认证值：配置了凭据池时使用其中一个可用凭据，供健康检查等非代理请求使用
+func (e *Endpoint) authValue() string {
+	if e.credentials != nil {
+		if value := e.credentials.probeValue(); value != "" {
+			return value
+		}
+	}
+	return e.authSecret
+}
+
+// ResolvedAuthValue 返回解析凭据引用后的 auth_value
+func (e *Endpoint) ResolvedAuthValue() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.authSecret
+}
+
+// ProbeAuthValue 返回健康检查使用的认证值
+func (e *Endpoint) ProbeAuthValue() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.authValue()
+}
+
 func (e *Endpoint) GetTags() []string {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -156,6 +224,14 @@ func (e *Endpoint) GetTags() []string {
 	return tags
 }
 
+// CanHandleContext 检查端点能否容纳估算的上下文长度，未配置 max_context_tokens 时不限制
+func (e *Endpoint) CanHandleContext(estimatedTokens int) bool {
+	if e.MaxContextTokens <= 0 || estimatedTokens <= 0 {
+		return true
+	}
+	return estimatedTokens <= e.MaxContextTokens
+}
+
 // GetHeaderOverrides 安全地获取Header覆盖配置的副本
 func (e *Endpoint) GetHeaderOverrides() map[string]string {
 	e.mutex.RLock()
@@ -235,7 +311,15 @@ func (e *Endpoint) IsAvailable() bool {
 	status := e.Status
 	e.mutex.RUnlock()
 	
-	return enabled && status == StatusActive
+	if !enabled || status != StatusActive {
+		return false
+	}
+	// 等待授权的 OAuth 端点没有令牌，请求必然失败
+	if e.AwaitingOAuthAuthorization() {
+		return false
+	}
+	// 凭据池中的凭据全部被拉黑或在冷却中时端点暂时不可用，但不影响端点本身的状态
+	return e.credentials == nil || e.credentials.hasUsable(nil)
 }
 
 func (e *Endpoint) RecordRequest(success bool, requestID string) {
@@ -281,35 +365,52 @@ func (e *Endpoint) RecordRequest(success bool, requestID string) {
 
 func (e *Endpoint) MarkInactive() {
 	e.mutex.Lock()
-	defer e.mutex.Unlock()
+	from := e.Status
 	e.Status = StatusInactive
+	e.mutex.Unlock()
+	e.notifyStatusChange(from, StatusInactive, nil)
 }
 
 // MarkInactiveWithReason 标记端点为失效并记录原因
 func (e *Endpoint) MarkInactiveWithReason() {
 	e.mutex.Lock()
-	defer e.mutex.Unlock()
+	if e.Status != StatusActive {
+		e.mutex.Unlock()
+		return
+	}
+	e.Status = StatusInactive
 	
-	if e.Status == StatusActive {
-		e.Status = StatusInactive
-		
-		// 从循环缓冲区获取导致失效的请求ID
-		failedRequestIDs := e.RequestHistory.GetRecentFailureRequestIDs(time.Now())
-		
-		// 构建失效原因记录
-		e.blacklistMutex.Lock()
-		e.BlacklistReason = &BlacklistReason{
-			BlacklistedAt:     time.Now(),
-			CausingRequestIDs: failedRequestIDs,
-			ErrorSummary:      fmt.Sprintf("Endpoint failed due to %d consecutive failures", len(failedRequestIDs)),
-		}
-		e.blacklistMutex.Unlock()
+	// 从循环缓冲区获取导致失效的请求ID
+	failedRequestIDs := e.RequestHistory.GetRecentFailureRequestIDs(time.Now())
+	
+	// 构建失效原因记录
+	reason := &BlacklistReason{
+		BlacklistedAt:     time.Now(),
+		CausingRequestIDs: failedRequestIDs,
+		ErrorSummary:      fmt.Sprintf("Endpoint failed due to %d consecutive failures", len(failedRequestIDs)),
+	}
+	e.blacklistMutex.Lock()
+	e.BlacklistReason = reason
+	e.blacklistMutex.Unlock()
+	e.mutex.Unlock()
+	
+	e.notifyStatusChange(StatusActive, StatusInactive, reason)
+}
+
+// notifyStatusChange 在状态实际发生变化时调用状态切换回调，调用时不能持有端点的锁
+func (e *Endpoint) notifyStatusChange(from, to Status, reason *BlacklistReason) {
+	if from != to && e.onStatusChange != nil {
+		e.onStatusChange(e, from, to, reason)
 	}
 }
 
 func (e *Endpoint) MarkActive() {
 	e.mutex.Lock()
-	defer e.mutex.Unlock()
+	from := e.Status
+	defer func() {
+		e.mutex.Unlock()
+		e.notifyStatusChange(from, StatusActive, nil)
+	}()
 	e.Status = StatusActive
 	e.FailureCount = 0
 	e.SuccessiveSuccesses = 0 // 重置连续成功次数
@@ -326,6 +427,13 @@ func (e *Endpoint) MarkActive() {
 	e.RequestHistory.Clear()
 }
 
+// GetStatus 安全地获取端点状态
+func (e *Endpoint) GetStatus() Status {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.Status
+}
+
 func (e *Endpoint) GetSuccessiveSuccesses() int {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -349,13 +457,12 @@ func parseDuration(durationStr string, defaultDuration time.Duration) time.Durat
 	return defaultDuration
 }
 
-// CreateProxyClient 为这个端点创建支持代理的HTTP客户端
+// CreateProxyClient 返回这个端点支持代理的HTTP客户端
 func (e *Endpoint) CreateProxyClient(timeoutConfig config.ProxyTimeoutConfig) (*http.Client, error) {
 	e.mutex.RLock()
-	proxyConfig := e.Proxy
+	proxyConfig := e.proxy
 	e.mutex.RUnlock()
 	
-	factory := httpclient.NewFactory()
 	clientConfig := httpclient.ClientConfig{
 		Type: httpclient.ClientTypeEndpoint,
 		Timeouts: httpclient.TimeoutConfig{
@@ -367,13 +474,42 @@ func (e *Endpoint) CreateProxyClient(timeoutConfig config.ProxyTimeoutConfig) (*
 		ProxyConfig: proxyConfig,
 	}
 	
-	return factory.CreateClient(clientConfig)
+	// 同一端点的请求复用连接池，避免每次请求重新建立 TCP/TLS 连接
+	if e.clients != nil {
+		return e.clients.Client(e.ID, clientConfig)
+	}
+	return httpclient.NewFactory().CreateClient(clientConfig)
+}
+
+// ConnectionStats 返回端点连接池的复用统计，尚未发出请求时返回 false
+func (e *Endpoint) ConnectionStats() (httpclient.PoolStats, bool) {
+	if e.clients == nil {
+		return httpclient.PoolStats{}, false
+	}
+	return e.clients.Stats(e.ID)
+}
+
+// IsPassiveHealthCheck 端点是否只根据真实请求判断健康状态，不发送探测请求
+func (e *Endpoint) IsPassiveHealthCheck() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.HealthCheck != nil && e.HealthCheck.Mode == "passive"
+}
+
+// HealthCheckInterval 返回端点的健康检查间隔，未配置时使用传入的全局间隔
+func (e *Endpoint) HealthCheckInterval(defaultInterval time.Duration) time.Duration {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if e.HealthCheck == nil {
+		return defaultInterval
+	}
+	return config.GetTimeoutDuration(e.HealthCheck.Interval, defaultInterval)
 }
 
 // CreateHealthClient 为健康检查创建HTTP客户端（使用与代理相同的配置，但超时较短）
 func (e *Endpoint) CreateHealthClient(timeoutConfig config.HealthCheckTimeoutConfig) (*http.Client, error) {
 	e.mutex.RLock()
-	proxyConfig := e.Proxy
+	proxyConfig := e.proxy
 	e.mutex.RUnlock()
 	
 	factory := httpclient.NewFactory()
@@ -396,16 +532,45 @@ func (e *Endpoint) RefreshOAuthToken(timeoutConfig config.ProxyTimeoutConfig) er
 	return e.RefreshOAuthTokenWithCallback(timeoutConfig, nil)
 }
 
+// oauthRefreshCall 进行中的一次令牌刷新，并发的刷新请求等待它的结果
+type oauthRefreshCall struct {
+	done chan struct{}
+	err  error
+}
+
 // RefreshOAuthTokenWithCallback 刷新 OAuth token 并可选地调用回调函数
-func (e *Endpoint) RefreshOAuthTokenWithCallback(timeoutConfig config.ProxyTimeoutConfig, onTokenRefreshed func(*Endpoint) error) error {
-	e.mutex.Lock()
-	defer e.mutex.Unlock()
+// 同一端点同时只发出一次刷新请求，令牌过期时并发到达的请求共享同一次刷新的结果，避免同时刷新
+func (e *Endpoint) RefreshOAuthTokenWithCallback(timeoutConfig config.ProxyTimeoutConfig, onTokenRefreshed func(*Endpoint, *config.OAuthConfig) error) error {
+	e.refreshMutex.Lock()
+	if call := e.refreshCall; call != nil {
+		e.refreshMutex.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &oauthRefreshCall{done: make(chan struct{})}
+	e.refreshCall = call
+	e.refreshMutex.Unlock()
 	
-	if e.AuthType != "oauth" {
+	call.err = e.refreshOAuthToken(timeoutConfig, onTokenRefreshed)
+	
+	e.refreshMutex.Lock()
+	e.refreshCall = nil
+	e.refreshMutex.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// refreshOAuthToken 向授权服务器刷新令牌，请求期间不持有端点的锁，其他请求仍可使用当前令牌
+func (e *Endpoint) refreshOAuthToken(timeoutConfig config.ProxyTimeoutConfig, onTokenRefreshed func(*Endpoint, *config.OAuthConfig) error) error {
+	e.mutex.RLock()
+	authType, current, proxy := e.AuthType, e.oauth, e.proxy
+	e.mutex.RUnlock()
+	
+	if authType != "oauth" {
 		return fmt.Errorf("endpoint is not configured for oauth authentication")
 	}
 	
-	if e.OAuthConfig == nil {
+	if current == nil {
 		return fmt.Errorf("oauth config is nil")
 	}
 	
@@ -419,7 +584,7 @@ func (e *Endpoint) RefreshOAuthTokenWithCallback(timeoutConfig config.ProxyTimeo
 			IdleConnection: parseDuration(timeoutConfig.IdleConnection, 90*time.Second),
 			OverallRequest: parseDuration(timeoutConfig.OverallRequest, 30*time.Second),
 		},
-		ProxyConfig: e.Proxy,
+		ProxyConfig: proxy,
 	}
 	
 	client, err := factory.CreateClient(clientConfig)
@@ -428,23 +593,98 @@ func (e *Endpoint) RefreshOAuthTokenWithCallback(timeoutConfig config.ProxyTimeo
 	}
 	
 	// 刷新token
-	newOAuthConfig, err := oauth.RefreshToken(e.OAuthConfig, client)
+	newOAuthConfig, err := oauth.RefreshToken(current, client)
 	if err != nil {
-		return fmt.Errorf("failed to refresh oauth token: %v", err)
+		return fmt.Errorf("failed to refresh oauth token: %w", err)
 	}
 	
-	// 更新配置
-	e.OAuthConfig = newOAuthConfig
+	return e.commitOAuthTokens(func(latest *config.OAuthConfig) (*config.OAuthConfig, error) {
+		// 刷新期间令牌已被替换（如在管理界面重新授权），丢弃基于旧刷新令牌得到的结果
+		if latest != current {
+			return nil, nil
+		}
+		return newOAuthConfig, nil
+	}, onTokenRefreshed)
+}
+
+// SetOAuthTokens 设置管理界面授权流程获取的令牌，与刷新令牌一样通过回调持久化
+// tokens 中只使用访问令牌、刷新令牌和过期时间，没有返回刷新令牌时保留原来的刷新令牌
+func (e *Endpoint) SetOAuthTokens(tokens *config.OAuthConfig, onTokenRefreshed func(*Endpoint, *config.OAuthConfig) error) error {
+	e.mutex.RLock()
+	authType := e.AuthType
+	e.mutex.RUnlock()
+	if authType != "oauth" {
+		return fmt.Errorf("endpoint is not configured for oauth authentication")
+	}
+	
+	return e.commitOAuthTokens(func(latest *config.OAuthConfig) (*config.OAuthConfig, error) {
+		if latest == nil {
+			return nil, fmt.Errorf("endpoint is not configured for oauth authentication")
+		}
+		newOAuthConfig := *latest
+		newOAuthConfig.AccessToken = tokens.AccessToken
+		if tokens.RefreshToken != "" {
+			newOAuthConfig.RefreshToken = tokens.RefreshToken
+		}
+		newOAuthConfig.ExpiresAt = tokens.ExpiresAt
+		return &newOAuthConfig, nil
+	}, onTokenRefreshed)
+}
+
+// commitOAuthTokens 由 build 根据当前令牌生成新令牌，先通过回调持久化，再替换内存中的令牌；build 返回 nil 表示放弃
+// 回调在不持有端点锁时调用：持久化回调会获取配置锁，而热更新在配置锁内读取端点状态
+// 先持久化再替换，并发的热更新要么读到已持久化的新令牌重建端点，要么在持久化之前完成，不会用旧令牌覆盖新令牌
+func (e *Endpoint) commitOAuthTokens(build func(latest *config.OAuthConfig) (*config.OAuthConfig, error), onTokenRefreshed func(*Endpoint, *config.OAuthConfig) error) error {
+	e.tokenMutex.Lock()
+	defer e.tokenMutex.Unlock()
+	
+	e.mutex.RLock()
+	latest, stored := e.oauth, e.OAuthConfig
+	e.mutex.RUnlock()
+	
+	newOAuthConfig, err := build(latest)
+	if err != nil || newOAuthConfig == nil {
+		return err
+	}
 	
-	// 如果提供了回调函数，调用它来处理配置持久化
+	// 令牌是凭据引用时新令牌写回凭据存储，导出的配置中仍保留引用
+	persisted := persistOAuthConfig(e.Name, stored, newOAuthConfig)
+	var persistErr error
 	if onTokenRefreshed != nil {
-		if err := onTokenRefreshed(e); err != nil {
-			// 回调失败，但token已经刷新成功，只记录错误
-			return fmt.Errorf("oauth token refreshed successfully but failed to persist to config file: %v", err)
+		if err := onTokenRefreshed(e, persisted); err != nil {
+			// 回调失败，但token已经刷新成功，仍然使用新令牌，只返回错误
+			persistErr = fmt.Errorf("oauth token refreshed successfully but failed to persist to config file: %v", err)
 		}
 	}
 	
-	return nil
+	e.mutex.Lock()
+	e.oauth = newOAuthConfig
+	e.OAuthConfig = persisted
+	e.mutex.Unlock()
+	return persistErr
+}
+
+// needsBackgroundRefresh 启用的 OAuth 端点开启了自动刷新，且令牌将在 refreshBefore 内过期
+func (e *Endpoint) needsBackgroundRefresh(refreshBefore time.Duration) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.Enabled && e.AuthType == "oauth" && e.oauth != nil && e.oauth.AutoRefresh &&
+		e.oauth.RefreshToken != "" && oauth.ShouldRefreshTokenBefore(e.oauth, refreshBefore)
+}
+
+// DisableWithReason 禁用端点并记录原因，调用方负责把禁用状态写回配置文件
+func (e *Endpoint) DisableWithReason(reason string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Enabled = false
+	e.DisabledReason = reason
+}
+
+// AwaitingOAuthAuthorization OAuth 端点还没有访问令牌，需要在管理界面完成授权
+func (e *Endpoint) AwaitingOAuthAuthorization() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.AuthType == "oauth" && (e.oauth == nil || e.oauth.AccessToken == "")
 }
 
 // GetAuthHeaderWithRefresh 获取认证头部，如果需要会自动刷新OAuth token
@@ -453,40 +693,43 @@ func (e *Endpoint) GetAuthHeaderWithRefresh(timeoutConfig config.ProxyTimeoutCon
 }
 
 // GetAuthHeaderWithRefreshCallback 获取认证头部，如果需要会自动刷新OAuth token，支持回调
-func (e *Endpoint) GetAuthHeaderWithRefreshCallback(timeoutConfig config.ProxyTimeoutConfig, onTokenRefreshed func(*Endpoint) error) (string, error) {
-	// 首先尝试获取认证头部
-	authHeader, err := e.GetAuthHeader()
-	
-	if e.AuthType == "oauth" {
-		if err != nil {
-			// 如果获取失败且token确实过期，尝试刷新
-			if oauth.IsTokenExpired(e.OAuthConfig) {
-				if refreshErr := e.RefreshOAuthTokenWithCallback(timeoutConfig, onTokenRefreshed); refreshErr != nil {
-					return "", fmt.Errorf("failed to refresh oauth token: %v", refreshErr)
-				}
-				// 重新获取认证头部
-				return e.GetAuthHeader()
-			}
-			// 如果不是因为过期导致的错误，直接返回错误
-			return "", err
+// 令牌已过期时同步刷新；即将过期时当前令牌仍然可用，不阻塞请求
+func (e *Endpoint) GetAuthHeaderWithRefreshCallback(timeoutConfig config.ProxyTimeoutConfig, onTokenRefreshed func(*Endpoint, *config.OAuthConfig) error) (string, error) {
+	e.mutex.RLock()
+	authType, current := e.AuthType, e.oauth
+	e.mutex.RUnlock()
+	if authType != "oauth" {
+		return e.GetAuthHeader()
+	}
+	if current == nil {
+		return "", fmt.Errorf("oauth config is required for oauth auth_type")
+	}
+	
+	if oauth.HasTokenExpired(current) {
+		if refreshErr := e.RefreshOAuthTokenWithCallback(timeoutConfig, onTokenRefreshed); refreshErr != nil {
+			return "", fmt.Errorf("failed to refresh oauth token: %w", refreshErr)
 		}
-		
-		// 即使获取成功，也检查是否应该主动刷新
-		if oauth.ShouldRefreshToken(e.OAuthConfig) {
-			// 主动刷新，但如果失败不影响当前请求
+		// 重新获取认证头部
+		return e.GetAuthHeader()
+	}
+	
+	// 即将过期时主动刷新：开启 auto_refresh 的端点由后台刷新调度处理，其他端点在后台刷新，失败不影响当前请求
+	if !current.AutoRefresh && oauth.ShouldRefreshToken(current) {
+		go func() {
 			if refreshErr := e.RefreshOAuthTokenWithCallback(timeoutConfig, onTokenRefreshed); refreshErr != nil {
-				// 刷新失败，记录日志但继续使用当前token
-				// 这里可以添加日志记录
-			} else {
-				// 刷新成功，获取新的认证头部
-				if newAuthHeader, newErr := e.GetAuthHeader(); newErr == nil {
-					return newAuthHeader, nil
-				}
+				log.Printf("WARNING: Proactive OAuth refresh failed for endpoint %s, keep using the current token: %v", e.Name, refreshErr)
 			}
-		}
+		}()
 	}
 	
-	return authHeader, err
+	return oauth.GetAuthorizationHeader(current), nil
+}
+
+// OAuthTokenExpired OAuth 端点的令牌已经过期，获取认证头时需要先同步刷新
+func (e *Endpoint) OAuthTokenExpired() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.AuthType == "oauth" && oauth.HasTokenExpired(e.oauth)
 }
 
 // GetBlacklistReason 安全地获取被拉黑原因信息
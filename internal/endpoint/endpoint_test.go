@@ -0,0 +1,53 @@
+package endpoint
+
+import (
+	"testing"
+
+	"claude-code-companion/internal/config"
+)
+
+func TestSetOAuthTokens(t *testing.T) {
+	ep := NewEndpoint(config.EndpointConfig{
+		Name:     "oauth",
+		URL:      "https://api.example.com",
+		AuthType: "oauth",
+		Enabled:  true,
+		OAuthConfig: &config.OAuthConfig{
+			TokenURL:     "https://auth.example.com/token",
+			AuthorizeURL: "https://auth.example.com/authorize",
+		},
+	})
+	ep.Status = StatusActive
+
+	// 还没有令牌的端点不参与选择
+	if !ep.AwaitingOAuthAuthorization() || ep.IsAvailable() {
+		t.Fatal("Expected endpoint without tokens to await authorization")
+	}
+
+	var persisted *config.OAuthConfig
+	err := ep.SetOAuthTokens(&config.OAuthConfig{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: 123}, func(e *Endpoint, oauthConfig *config.OAuthConfig) error {
+		// 回调在端点锁之外执行，持久化时可以再访问端点
+		if !e.mutex.TryLock() {
+			t.Error("Expected the token callback to run without holding the endpoint lock")
+		} else {
+			e.mutex.Unlock()
+		}
+		persisted = oauthConfig
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to set tokens: %v", err)
+	}
+	if persisted == nil || persisted.AccessToken != "access" || persisted.RefreshToken != "refresh" || persisted.AuthorizeURL == "" {
+		t.Errorf("Expected tokens to be persisted with the rest of the config, got %+v", persisted)
+	}
+	if ep.AwaitingOAuthAuthorization() || !ep.IsAvailable() {
+		t.Error("Expected endpoint to be available after authorization")
+	}
+
+	// 没有返回新的刷新令牌时保留原来的
+	ep.SetOAuthTokens(&config.OAuthConfig{AccessToken: "access-2", ExpiresAt: 456}, nil)
+	if ep.OAuthConfig.AccessToken != "access-2" || ep.OAuthConfig.RefreshToken != "refresh" {
+		t.Errorf("Unexpected tokens after re-authorization: %+v", ep.OAuthConfig)
+	}
+}
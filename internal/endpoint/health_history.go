@@ -0,0 +1,142 @@
+package endpoint
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"claude-code-companion/internal/statistics"
+)
+
+// uptimeSpan 和 uptimeBuckets 决定端点列表中可用率时间条的范围和格数
+const (
+	uptimeSpan    = 24 * time.Hour
+	uptimeBuckets = 48
+)
+
+// EndpointAvailability 端点的可用率统计
+type EndpointAvailability struct {
+	Name    string                          `json:"name"`
+	Status  Status                          `json:"status"`
+	Windows []statistics.WindowAvailability `json:"windows"` // 1h/24h/7d 可用率和 MTTR
+	Uptime  []statistics.UptimeBucket       `json:"uptime"`  // 最近24小时的可用率时间条
+}
+
+// recordStatusChange 将端点状态切换持久化到健康历史
+func (m *Manager) recordStatusChange(ep *Endpoint, from, to Status, reason *BlacklistReason) {
+	event := &statistics.HealthEvent{
+		EndpointID: ep.ID,
+		Type:       statistics.HealthEventTransition,
+		FromStatus: string(from),
+		ToStatus:   string(to),
+	}
+	if reason != nil {
+		event.CreatedAt = reason.BlacklistedAt.UTC()
+		event.Reason = reason.ErrorSummary
+		event.CausingRequestIDs = strings.Join(reason.CausingRequestIDs, ",")
+	}
+	m.saveHealthEvent(ep, event)
+}
+
+// recordHealthProbe 将一次健康检查探测的结果持久化到健康历史
+func (m *Manager) recordHealthProbe(ep *Endpoint, latency time.Duration, probeErr error) {
+	event := &statistics.HealthEvent{
+		EndpointID: ep.ID,
+		Type:       statistics.HealthEventProbe,
+		Success:    probeErr == nil,
+		LatencyMs:  latency.Milliseconds(),
+	}
+	if probeErr != nil {
+		event.Error = truncateError(probeErr.Error(), 1000)
+	}
+	m.saveHealthEvent(ep, event)
+}
+
+func (m *Manager) saveHealthEvent(ep *Endpoint, event *statistics.HealthEvent) {
+	if m.statisticsManager == nil {
+		return
+	}
+	if err := m.statisticsManager.RecordHealthEvent(event); err != nil {
+		log.Printf("WARNING: Failed to record health event for endpoint %s: %v", ep.Name, err)
+	}
+}
+
+// closeOpenDowntimes 启动时所有端点都是可用状态，为上次退出前仍不可用的端点补记恢复，
+// 避免把服务停止期间和重启之后的时间计为不可用
+func (m *Manager) closeOpenDowntimes() {
+	if m.statisticsManager == nil {
+		return
+	}
+	since := time.Now().Add(-statistics.HealthEventRetention)
+	for _, ep := range m.endpoints {
+		events, err := m.statisticsManager.GetHealthEvents(ep.ID, since, 0)
+		if err != nil {
+			log.Printf("WARNING: Failed to load health events for endpoint %s: %v", ep.Name, err)
+			continue
+		}
+		for _, event := range events {
+			if event.Type != statistics.HealthEventTransition {
+				continue
+			}
+			if event.ToStatus == string(StatusInactive) {
+				m.saveHealthEvent(ep, &statistics.HealthEvent{
+					EndpointID: ep.ID,
+					Type:       statistics.HealthEventTransition,
+					FromStatus: string(StatusInactive),
+					ToStatus:   string(StatusActive),
+					Reason:     "Service restarted",
+				})
+			}
+			break
+		}
+	}
+}
+
+// Availability 返回所有端点的可用率统计
+func (m *Manager) Availability() ([]EndpointAvailability, error) {
+	if m.statisticsManager == nil {
+		return nil, fmt.Errorf("statistics manager is not available")
+	}
+	now := time.Now().UTC()
+	since := now.Add(-statistics.AvailabilityWindows[len(statistics.AvailabilityWindows)-1].Duration)
+
+	endpoints := m.GetAllEndpoints()
+	result := make([]EndpointAvailability, 0, len(endpoints))
+	for _, ep := range endpoints {
+		events, err := m.statisticsManager.GetHealthEvents(ep.ID, since, 0)
+		if err != nil {
+			return nil, err
+		}
+		status := ep.GetStatus()
+		active := status != StatusInactive
+		result = append(result, EndpointAvailability{
+			Name:    ep.Name,
+			Status:  status,
+			Windows: statistics.ComputeAvailability(events, active, now),
+			Uptime:  statistics.ComputeUptimeBuckets(events, active, now, uptimeSpan, uptimeBuckets),
+		})
+	}
+	return result, nil
+}
+
+// HealthHistory 返回端点最近的健康探测和状态切换记录，按时间倒序
+func (m *Manager) HealthHistory(endpointName string, limit int) ([]*statistics.HealthEvent, error) {
+	if m.statisticsManager == nil {
+		return nil, fmt.Errorf("statistics manager is not available")
+	}
+	for _, ep := range m.GetAllEndpoints() {
+		if ep.Name == endpointName {
+			return m.statisticsManager.GetHealthEvents(ep.ID, time.Now().Add(-statistics.HealthEventRetention), limit)
+		}
+	}
+	return nil, fmt.Errorf("endpoint not found: %s", endpointName)
+}
+
+// truncateError 截断过长的错误信息
+func truncateError(message string, maxLen int) string {
+	if len(message) <= maxLen {
+		return message
+	}
+	return strings.ToValidUTF8(message[:maxLen], "")
+}
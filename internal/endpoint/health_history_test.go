@@ -0,0 +1,46 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/statistics"
+)
+
+func TestManagerRecordsStatusTransitions(t *testing.T) {
+	stats := statistics.NewMemoryManager()
+	ep := NewEndpoint(config.EndpointConfig{Name: "primary", URL: "https://example.com", AuthType: "api_key", AuthValue: "sk", Enabled: true})
+	manager := &Manager{selector: NewSelector([]*Endpoint{ep}), endpoints: []*Endpoint{ep}, statisticsManager: stats}
+	ep.onStatusChange = manager.recordStatusChange
+
+	ep.RecordRequest(false, "req-1")
+	ep.RecordRequest(false, "req-2")
+	manager.recordHealthProbe(ep, 120*time.Millisecond, nil)
+	ep.RecordRequest(true, "health-check")
+	// 已经是可用状态，不应重复记录
+	ep.MarkActive()
+
+	events, err := manager.HealthHistory("primary", 0)
+	if err != nil {
+		t.Fatalf("Failed to load health history: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 2 transitions and 1 probe, got %d events", len(events))
+	}
+	recovered, probe, failed := events[0], events[1], events[2]
+	if failed.ToStatus != "inactive" || failed.CausingRequestIDs != "req-2,req-1" || failed.Reason == "" {
+		t.Errorf("Unexpected inactive transition: %+v", failed)
+	}
+	if probe.Type != statistics.HealthEventProbe || !probe.Success || probe.LatencyMs != 120 {
+		t.Errorf("Unexpected probe event: %+v", probe)
+	}
+	if recovered.FromStatus != "inactive" || recovered.ToStatus != "active" {
+		t.Errorf("Unexpected recovery transition: %+v", recovered)
+	}
+
+	availability, err := manager.Availability()
+	if err != nil || len(availability) != 1 || availability[0].Windows[0].Incidents != 1 {
+		t.Errorf("Expected one incident in availability, got %+v (%v)", availability, err)
+	}
+}
@@ -3,9 +3,11 @@ package endpoint
 import (
 	"fmt"
 	"log"
+	"reflect"
 	"sync"
 	"time"
 
+	"claude-code-companion/internal/common/httpclient"
 	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/statistics"
 )
@@ -22,6 +24,7 @@ type Manager struct {
 	healthChecker     HealthChecker
 	healthTickers     map[string]*time.Ticker
 	statisticsManager statistics.StatisticsManager
+	clients           *httpclient.TransportPool // 按端点缓存的上游连接池
 }
 
 func NewManager(cfg *config.Config) (*Manager, error) {
@@ -38,9 +41,13 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		return nil, fmt.Errorf("failed to initialize statistics manager: %w", err)
 	}
 
+	clients := httpclient.NewTransportPool(nil)
+	clients.SetLimits(connectionLimits(cfg.HTTPClient))
+
 	endpoints := make([]*Endpoint, 0, len(cfg.Endpoints))
 	for _, endpointConfig := range cfg.Endpoints {
 		endpoint := NewEndpoint(endpointConfig)
+		endpoint.clients = clients
 		
 		// Initialize or inherit statistics data
 		if err := initializeEndpointStatistics(endpoint, statisticsManager); err != nil {
@@ -59,7 +66,13 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		healthChecker:     nil, // 稍后设置
 		healthTickers:     make(map[string]*time.Ticker),
 		statisticsManager: statisticsManager,
+		clients:           clients,
+	}
+	for _, endpoint := range endpoints {
+		endpoint.onStatusChange = manager.recordStatusChange
 	}
+	manager.SetDegradationPolicy(cfg.LatencyScoring)
+	manager.closeOpenDowntimes()
 
 	return manager, nil
 }
@@ -73,6 +86,11 @@ func (m *Manager) GetEndpointWithTags(tags []string) (*Endpoint, error) {
 	return m.selector.SelectEndpointWithTags(tags)
 }
 
+// GetEndpointForContext 根据tags和估算的上下文长度选择endpoint，model 用于比较各端点处理该模型的性能
+func (m *Manager) GetEndpointForContext(tags []string, estimatedTokens int, model string) (*Endpoint, error) {
+	return m.selector.SelectEndpointForContext(tags, estimatedTokens, model)
+}
+
 func (m *Manager) GetAllEndpoints() []*Endpoint {
 	return m.selector.GetAllEndpoints()
 }
@@ -120,6 +138,8 @@ func (m *Manager) UpdateEndpoints(endpointConfigs []config.EndpointConfig) {
 		} else {
 			// New endpoint - create fresh with inherited statistics from database
 			endpoint := NewEndpoint(cfg)
+			endpoint.clients = m.clients
+			endpoint.onStatusChange = m.recordStatusChange
 			if m.statisticsManager != nil {
 				if err := initializeEndpointStatistics(endpoint, m.statisticsManager); err != nil {
 					log.Printf("WARNING: Failed to load statistics for new endpoint %s: %v", 
@@ -137,6 +157,7 @@ func (m *Manager) UpdateEndpoints(endpointConfigs []config.EndpointConfig) {
 	if m.statisticsManager != nil {
 		m.cleanupRemovedEndpoints(endpointConfigs)
 	}
+	m.closeRemovedEndpointClients(endpointConfigs)
 
 	// 停止旧的健康检查
 	m.stopHealthChecks()
@@ -185,10 +206,11 @@ func (m *Manager) startHealthChecks() {
 	
 	for _, endpoint := range m.endpoints {
 		if endpoint.Enabled {
-			ticker := time.NewTicker(interval)
+			endpointInterval := endpoint.HealthCheckInterval(interval)
+			ticker := time.NewTicker(endpointInterval)
 			m.healthTickers[endpoint.ID] = ticker
 			
-			go m.runHealthCheck(endpoint, ticker)
+			go m.runHealthCheck(endpoint, ticker, endpointInterval)
 		}
 	}
 }
@@ -200,7 +222,7 @@ func (m *Manager) stopHealthChecks() {
 	m.healthTickers = make(map[string]*time.Ticker)
 }
 
-func (m *Manager) runHealthCheck(endpoint *Endpoint, ticker *time.Ticker) {
+func (m *Manager) runHealthCheck(endpoint *Endpoint, ticker *time.Ticker, interval time.Duration) {
 	// 获取恢复阈值配置，使用统一默认值
 	recoveryThreshold := config.GetIntWithDefault(m.config.Timeouts.RecoveryThreshold, config.Default.Timeouts.RecoveryThreshold)
 	
@@ -210,6 +232,17 @@ func (m *Manager) runHealthCheck(endpoint *Endpoint, ticker *time.Ticker) {
 			continue
 		}
 		
+		// 被动模式不发送探测请求：失效超过一个检查间隔后恢复为可用，由真实请求验证
+		// 如果端点仍然异常，请求历史中的连续失败会很快再次将其标记为不可用
+		if endpoint.IsPassiveHealthCheck() {
+			reason := endpoint.GetBlacklistReason()
+			if reason == nil || time.Since(reason.BlacklistedAt) >= interval {
+				log.Printf("DEBUG: Passive health check re-enabling endpoint %s for real traffic", endpoint.Name)
+				endpoint.MarkActive()
+			}
+			continue
+		}
+		
 		// Anthropic官方端点特例：在rate limit reset时间之前跳过健康检查
 		if endpoint.ShouldSkipHealthCheckUntilReset() {
 			// 只在合适的时机记录日志，避免过于频繁
@@ -230,7 +263,10 @@ func (m *Manager) runHealthCheck(endpoint *Endpoint, ticker *time.Ticker) {
 			}
 		}
 		
-		if err := m.healthChecker.CheckEndpoint(endpoint); err != nil {
+		start := time.Now()
+		err := m.healthChecker.CheckEndpoint(endpoint)
+		m.recordHealthProbe(endpoint, time.Since(start), err)
+		if err != nil {
 			// 健康检查失败，重置连续成功次数
 			endpoint.RecordRequest(false, "health-check")
 		} else {
@@ -269,6 +305,13 @@ func initializeEndpointStatistics(endpoint *Endpoint, statisticsManager statisti
 func (m *Manager) updateExistingEndpoint(existingEndpoint *Endpoint, newConfig config.EndpointConfig) *Endpoint {
 	// Create new endpoint with updated configuration but preserve statistics
 	newEndpoint := NewEndpoint(newConfig)
+	newEndpoint.clients = m.clients
+	newEndpoint.onStatusChange = m.recordStatusChange
+
+	// 代理配置变化后旧连接不能再使用，关闭连接池，下次请求时重建
+	if !reflect.DeepEqual(existingEndpoint.Proxy, newConfig.Proxy) {
+		m.clients.Invalidate(existingEndpoint.ID)
+	}
 	
 	// Copy statistics from existing endpoint to preserve accumulated data
 	existingEndpoint.mutex.RLock()
@@ -283,6 +326,14 @@ func (m *Manager) updateExistingEndpoint(existingEndpoint *Endpoint, newConfig c
 	
 	// Preserve request history for health checking
 	newEndpoint.RequestHistory = existingEndpoint.RequestHistory
+	newEndpoint.perf = existingEndpoint.perf
+	if newEndpoint.credentials != nil {
+		newEndpoint.credentials.inherit(existingEndpoint.credentials)
+	}
+	// OAuth 配置没有变化时沿用已刷新的令牌，凭据存储只读时刷新后的令牌只保存在内存中
+	if existingEndpoint.oauth != nil && reflect.DeepEqual(existingEndpoint.OAuthConfig, newConfig.OAuthConfig) {
+		newEndpoint.oauth = existingEndpoint.oauth
+	}
 	newEndpoint.mutex.Unlock()
 	existingEndpoint.mutex.RUnlock()
 
@@ -299,6 +350,43 @@ func (m *Manager) updateExistingEndpoint(existingEndpoint *Endpoint, newConfig c
 	return newEndpoint
 }
 
+// closeRemovedEndpointClients 关闭已删除端点的连接池
+func (m *Manager) closeRemovedEndpointClients(newConfigs []config.EndpointConfig) {
+	newEndpointNames := make(map[string]bool)
+	for _, cfg := range newConfigs {
+		newEndpointNames[cfg.Name] = true
+	}
+	for _, endpoint := range m.endpoints {
+		if !newEndpointNames[endpoint.Name] {
+			m.clients.Invalidate(endpoint.ID)
+		}
+	}
+}
+
+// SetConnectionLimits 更新连接池大小限制，各端点的连接池在下次请求时按新限制重建
+func (m *Manager) SetConnectionLimits(cfg config.HTTPClientConfig) {
+	m.clients.SetLimits(connectionLimits(cfg))
+}
+
+// ConnectionStats 返回各端点连接池的复用统计，以端点名为键，尚未发出请求的端点不包含在内
+func (m *Manager) ConnectionStats() map[string]httpclient.PoolStats {
+	result := make(map[string]httpclient.PoolStats)
+	for _, endpoint := range m.GetAllEndpoints() {
+		if stats, ok := endpoint.ConnectionStats(); ok {
+			result[endpoint.Name] = stats
+		}
+	}
+	return result
+}
+
+func connectionLimits(cfg config.HTTPClientConfig) httpclient.ConnectionLimits {
+	return httpclient.ConnectionLimits{
+		MaxIdleConns:    cfg.MaxIdleConns,
+		MaxIdlePerHost:  cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost: cfg.MaxConnsPerHost,
+	}
+}
+
 // cleanupRemovedEndpoints removes statistics for endpoints that are no longer in configuration
 func (m *Manager) cleanupRemovedEndpoints(newConfigs []config.EndpointConfig) {
 	// Create set of new endpoint names
@@ -0,0 +1,194 @@
+package endpoint
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/oauth"
+)
+
+// OAuthRefresher 在后台提前刷新 OAuth 令牌，请求不必在令牌即将过期时同步等待刷新
+// 刷新失败时按指数退避重试；刷新令牌被授权服务器拒绝时禁用端点并记录原因，需要在管理界面重新授权
+type OAuthRefresher struct {
+	manager     *Manager
+	onRefreshed func(*Endpoint, *config.OAuthConfig) error // 新令牌的持久化回调，与请求路径上的刷新使用同一个回调
+	onRejected  func(ep *Endpoint, reason string)          // 刷新令牌被拒绝时调用，负责禁用端点并保存到配置
+
+	mutex    sync.Mutex
+	settings oauthRefreshSettings
+	retries  map[string]*oauthRetryState // 以端点名为键，热更新重建端点后仍然有效
+	stop     chan struct{}
+}
+
+type oauthRefreshSettings struct {
+	disabled      bool
+	checkInterval time.Duration
+	refreshBefore time.Duration
+	retryBackoff  time.Duration
+	maxBackoff    time.Duration
+	timeouts      config.ProxyTimeoutConfig
+}
+
+// oauthRetryState 刷新失败后的退避状态
+type oauthRetryState struct {
+	failures    int
+	nextAttempt time.Time
+}
+
+// NewOAuthRefresher 创建后台令牌刷新器，调用 Configure 设置参数后 Start
+func NewOAuthRefresher(manager *Manager, onRefreshed func(*Endpoint, *config.OAuthConfig) error, onRejected func(*Endpoint, string)) *OAuthRefresher {
+	return &OAuthRefresher{
+		manager:     manager,
+		onRefreshed: onRefreshed,
+		onRejected:  onRejected,
+		retries:     make(map[string]*oauthRetryState),
+	}
+}
+
+// Configure 更新刷新参数，热更新时调用，下一次检查开始生效
+func (r *OAuthRefresher) Configure(cfg config.OAuthRefreshConfig, timeouts config.ProxyTimeoutConfig) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.settings = oauthRefreshSettings{
+		disabled:      cfg.Disabled,
+		checkInterval: config.GetTimeoutDuration(cfg.CheckInterval, config.GetTimeoutDuration(config.Default.OAuthRefresh.CheckInterval, 30*time.Second)),
+		refreshBefore: config.GetTimeoutDuration(cfg.RefreshBefore, config.GetTimeoutDuration(config.Default.OAuthRefresh.RefreshBefore, 10*time.Minute)),
+		retryBackoff:  config.GetTimeoutDuration(cfg.RetryBackoff, config.GetTimeoutDuration(config.Default.OAuthRefresh.RetryBackoff, 30*time.Second)),
+		maxBackoff:    config.GetTimeoutDuration(cfg.MaxBackoff, config.GetTimeoutDuration(config.Default.OAuthRefresh.MaxBackoff, 5*time.Minute)),
+		timeouts:      timeouts,
+	}
+}
+
+// Start 启动后台检查
+func (r *OAuthRefresher) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	go r.run(r.stop)
+}
+
+// Stop 停止后台检查，进行中的刷新会继续完成
+func (r *OAuthRefresher) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+func (r *OAuthRefresher) run(stop chan struct{}) {
+	for {
+		r.mutex.Lock()
+		interval := r.settings.checkInterval
+		r.mutex.Unlock()
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		r.refreshDue()
+	}
+}
+
+// refreshDue 并发刷新所有即将过期的令牌，等待本轮刷新全部完成后返回
+func (r *OAuthRefresher) refreshDue() {
+	r.mutex.Lock()
+	settings := r.settings
+	r.mutex.Unlock()
+	if settings.disabled {
+		return
+	}
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	for _, ep := range r.manager.GetAllEndpoints() {
+		if !ep.needsBackgroundRefresh(settings.refreshBefore) {
+			continue
+		}
+		r.mutex.Lock()
+		retry := r.retries[ep.Name]
+		r.mutex.Unlock()
+		if retry != nil && now.Before(retry.nextAttempt) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ep *Endpoint) {
+			defer wg.Done()
+			r.refresh(ep, settings)
+		}(ep)
+	}
+	wg.Wait()
+}
+
+func (r *OAuthRefresher) refresh(ep *Endpoint, settings oauthRefreshSettings) {
+	err := ep.RefreshOAuthTokenWithCallback(settings.timeouts, r.onRefreshed)
+	if err == nil {
+		r.mutex.Lock()
+		delete(r.retries, ep.Name)
+		r.mutex.Unlock()
+		log.Printf("[OAuth] Background refresh succeeded for endpoint %s", ep.Name)
+		return
+	}
+	if r.HandleRefreshError(ep, err) {
+		return
+	}
+
+	r.mutex.Lock()
+	retry := r.retries[ep.Name]
+	if retry == nil {
+		retry = &oauthRetryState{}
+		r.retries[ep.Name] = retry
+	}
+	retry.failures++
+	delay := backoffDelay(settings.retryBackoff, settings.maxBackoff, retry.failures)
+	retry.nextAttempt = time.Now().Add(delay)
+	failures := retry.failures
+	r.mutex.Unlock()
+
+	log.Printf("WARNING: Background OAuth refresh failed for endpoint %s (attempt %d), retrying in %v: %v", ep.Name, failures, delay, err)
+}
+
+// HandleRefreshError 处理刷新失败：刷新令牌被拒绝时禁用端点，返回是否已禁用
+// 请求路径上的刷新失败也通过这里处理，避免端点带着失效的令牌继续参与选择
+func (r *OAuthRefresher) HandleRefreshError(ep *Endpoint, err error) bool {
+	if err == nil || !oauth.IsRefreshTokenRejected(err) {
+		return false
+	}
+
+	r.mutex.Lock()
+	delete(r.retries, ep.Name)
+	r.mutex.Unlock()
+
+	reason := fmt.Sprintf("OAuth refresh token was rejected by the authorization server (%v); re-authorize the endpoint to enable it again", err)
+	if r.onRejected != nil {
+		r.onRejected(ep, reason)
+	} else {
+		log.Printf("WARNING: Disabling endpoint %s: %s", ep.Name, reason)
+		ep.DisableWithReason(reason)
+	}
+	return true
+}
+
+// backoffDelay 第 failures 次失败后的等待时间：initial 每次翻倍，不超过 max
+func backoffDelay(initial, max time.Duration, failures int) time.Duration {
+	delay := initial
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,201 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+// newTokenServer 模拟令牌端点，handler 返回状态码和响应体
+func newTokenServer(t *testing.T, hits *int32, handler func() (int, string)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		status, body := handler()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newExpiringOAuthEndpoint(tokenURL string, expiresIn time.Duration) *Endpoint {
+	return NewEndpoint(config.EndpointConfig{
+		Name:     "oauth",
+		URL:      "https://api.example.com",
+		AuthType: "oauth",
+		Enabled:  true,
+		OAuthConfig: &config.OAuthConfig{
+			AccessToken:  "old-access",
+			RefreshToken: "old-refresh",
+			ExpiresAt:    time.Now().Add(expiresIn).UnixMilli(),
+			TokenURL:     tokenURL,
+			AutoRefresh:  true,
+		},
+	})
+}
+
+func newTestRefresher(ep *Endpoint, onRejected func(*Endpoint, string)) *OAuthRefresher {
+	manager := &Manager{selector: NewSelector([]*Endpoint{ep}), endpoints: []*Endpoint{ep}}
+	refresher := NewOAuthRefresher(manager, nil, onRejected)
+	refresher.Configure(config.OAuthRefreshConfig{RefreshBefore: "10m", RetryBackoff: "1m", MaxBackoff: "3m"}, config.ProxyTimeoutConfig{})
+	return refresher
+}
+
+func TestRefreshOAuthTokenSingleFlight(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := newTokenServer(t, &hits, func() (int, string) {
+		<-release
+		return http.StatusOK, `{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`
+	})
+	ep := newExpiringOAuthEndpoint(server.URL, -time.Minute)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ep.RefreshOAuthTokenWithCallback(config.ProxyTimeoutConfig{}, nil)
+		}(i)
+	}
+	// 等所有调用都进入刷新后再放行令牌响应
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected refresh error: %v", err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Expected a single refresh request, got %d", hits)
+	}
+	if ep.OAuthConfig.AccessToken != "new-access" {
+		t.Errorf("Expected new access token, got %s", ep.OAuthConfig.AccessToken)
+	}
+}
+
+func TestOAuthRefresherRefreshesAheadOfExpiry(t *testing.T) {
+	var hits int32
+	server := newTokenServer(t, &hits, func() (int, string) {
+		return http.StatusOK, `{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`
+	})
+
+	// 距离过期还很久的令牌不刷新
+	ep := newExpiringOAuthEndpoint(server.URL, time.Hour)
+	newTestRefresher(ep, nil).refreshDue()
+	if hits != 0 {
+		t.Fatalf("Expected no refresh for a fresh token, got %d requests", hits)
+	}
+
+	ep = newExpiringOAuthEndpoint(server.URL, 5*time.Minute)
+	newTestRefresher(ep, nil).refreshDue()
+	if hits != 1 || ep.OAuthConfig.AccessToken != "new-access" {
+		t.Errorf("Expected token to be refreshed ahead of expiry, got %d requests and token %s", hits, ep.OAuthConfig.AccessToken)
+	}
+}
+
+func TestOAuthRefresherBacksOffAfterFailure(t *testing.T) {
+	var hits int32
+	server := newTokenServer(t, &hits, func() (int, string) {
+		return http.StatusInternalServerError, `{"error":"server_error"}`
+	})
+	ep := newExpiringOAuthEndpoint(server.URL, time.Minute)
+	refresher := newTestRefresher(ep, nil)
+
+	refresher.refreshDue()
+	attempts := atomic.LoadInt32(&hits)
+	if attempts == 0 {
+		t.Fatal("Expected a refresh attempt")
+	}
+	if !ep.IsEnabled() {
+		t.Error("Expected transient failures to keep the endpoint enabled")
+	}
+
+	// 退避期间不再请求
+	refresher.refreshDue()
+	if atomic.LoadInt32(&hits) != attempts {
+		t.Errorf("Expected no refresh during backoff, got %d requests", hits)
+	}
+	if retry := refresher.retries[ep.Name]; retry == nil || retry.failures != 1 || time.Until(retry.nextAttempt) <= 0 {
+		t.Errorf("Unexpected retry state: %+v", retry)
+	}
+
+	if d := backoffDelay(time.Minute, 3*time.Minute, 1); d != time.Minute {
+		t.Errorf("Expected initial backoff of 1m, got %v", d)
+	}
+	if d := backoffDelay(time.Minute, 3*time.Minute, 3); d != 3*time.Minute {
+		t.Errorf("Expected backoff to be capped at 3m, got %v", d)
+	}
+}
+
+func TestOAuthRefresherDisablesEndpointOnRejectedRefreshToken(t *testing.T) {
+	var hits int32
+	server := newTokenServer(t, &hits, func() (int, string) {
+		return http.StatusBadRequest, `{"error":"invalid_grant","error_description":"refresh token revoked"}`
+	})
+	ep := newExpiringOAuthEndpoint(server.URL, time.Minute)
+
+	var rejectedReason string
+	refresher := newTestRefresher(ep, func(e *Endpoint, reason string) {
+		rejectedReason = reason
+		e.DisableWithReason(reason)
+	})
+	refresher.refreshDue()
+
+	if hits != 1 {
+		t.Errorf("Expected the rejected refresh token not to be retried with another format, got %d requests", hits)
+	}
+	if rejectedReason == "" || ep.IsEnabled() || ep.DisabledReason != rejectedReason {
+		t.Errorf("Expected endpoint to be disabled with a reason, got enabled=%v reason=%q", ep.IsEnabled(), ep.DisabledReason)
+	}
+
+	// 禁用后的端点不再刷新
+	refresher.refreshDue()
+	if hits != 1 {
+		t.Errorf("Expected disabled endpoint to be skipped, got %d requests", hits)
+	}
+}
+
+func TestProactiveRefreshDoesNotBlockRequest(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := newTokenServer(t, &hits, func() (int, string) {
+		<-release
+		return http.StatusOK, `{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`
+	})
+	ep := newExpiringOAuthEndpoint(server.URL, 2*time.Minute)
+	ep.OAuthConfig.AutoRefresh = false
+	ep.oauth.AutoRefresh = false
+
+	persisted := make(chan *config.OAuthConfig, 1)
+	header, err := ep.GetAuthHeaderWithRefreshCallback(config.ProxyTimeoutConfig{}, func(e *Endpoint, oauthConfig *config.OAuthConfig) error {
+		persisted <- oauthConfig
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// 令牌服务器还没有响应，请求仍使用当前令牌
+	if header != "Bearer old-access" {
+		t.Errorf("Expected the current token while refreshing in background, got %q", header)
+	}
+
+	close(release)
+	select {
+	case cfg := <-persisted:
+		if cfg.AccessToken != "new-access" || cfg.RefreshToken != "new-refresh" {
+			t.Errorf("Unexpected persisted tokens: %+v", cfg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected background refresh to persist the new tokens")
+	}
+}
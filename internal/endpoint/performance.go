@@ -0,0 +1,219 @@
+package endpoint
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/utils"
+)
+
+const (
+	performanceEWMAAlpha  = 0.2 // 约等于最近 10 个请求的权重
+	performanceWindowSize = 200 // 每个序列用于计算分位数的最近样本数
+	maxPerformanceModels  = 50  // 每个端点最多单独统计的模型数，超出后只计入端点总体
+
+	// 超过该时间没有新样本的端点不参与降级比较：被降级的端点收不到请求，
+	// 统计过期后恢复原有顺序，由新的请求重新评估
+	performanceStaleAfter = 10 * time.Minute
+)
+
+// PerformanceStats 端点（或端点下某个模型）近期成功请求的延迟和吞吐量统计
+type PerformanceStats struct {
+	Samples         int     `json:"samples"`
+	TTFBMs          float64 `json:"ttfb_ms"` // 首字节时间 EWMA
+	TTFBP50Ms       float64 `json:"ttfb_p50_ms"`
+	TTFBP95Ms       float64 `json:"ttfb_p95_ms"`
+	DurationMs      float64 `json:"duration_ms"` // 总耗时 EWMA
+	DurationP50Ms   float64 `json:"duration_p50_ms"`
+	DurationP95Ms   float64 `json:"duration_p95_ms"`
+	TokensPerSecond float64 `json:"tokens_per_second"` // 输出吞吐量 EWMA，只统计返回了输出 token 数的请求
+	TokensPerSecP50 float64 `json:"tokens_per_second_p50"`
+}
+
+// EndpointPerformance 端点总体和按模型的性能统计，Degraded 表示明显慢于同组端点
+type EndpointPerformance struct {
+	Name     string                      `json:"name"`
+	Overall  PerformanceStats            `json:"overall"`
+	Models   map[string]PerformanceStats `json:"models,omitempty"`
+	Degraded bool                        `json:"degraded"`
+}
+
+// latencySeries 一组请求的首字节时间、总耗时和吞吐量序列
+type latencySeries struct {
+	lastSample       time.Time
+	ttfb             *utils.EWMA
+	duration         *utils.EWMA
+	throughput       *utils.EWMA
+	ttfbWindow       *utils.SampleWindow
+	durationWindow   *utils.SampleWindow
+	throughputWindow *utils.SampleWindow
+}
+
+func newLatencySeries() *latencySeries {
+	return &latencySeries{
+		ttfb:             utils.NewEWMA(performanceEWMAAlpha),
+		duration:         utils.NewEWMA(performanceEWMAAlpha),
+		throughput:       utils.NewEWMA(performanceEWMAAlpha),
+		ttfbWindow:       utils.NewSampleWindow(performanceWindowSize),
+		durationWindow:   utils.NewSampleWindow(performanceWindowSize),
+		throughputWindow: utils.NewSampleWindow(performanceWindowSize),
+	}
+}
+
+func (s *latencySeries) add(ttfb, duration time.Duration, tokensPerSecond float64) {
+	ttfbMs := float64(ttfb) / float64(time.Millisecond)
+	durationMs := float64(duration) / float64(time.Millisecond)
+	s.lastSample = time.Now()
+	s.ttfb.Add(ttfbMs)
+	s.ttfbWindow.Add(ttfbMs)
+	s.duration.Add(durationMs)
+	s.durationWindow.Add(durationMs)
+	if tokensPerSecond > 0 {
+		s.throughput.Add(tokensPerSecond)
+		s.throughputWindow.Add(tokensPerSecond)
+	}
+}
+
+func (s *latencySeries) stats() PerformanceStats {
+	return PerformanceStats{
+		Samples:         s.ttfbWindow.Count(),
+		TTFBMs:          s.ttfb.Value(),
+		TTFBP50Ms:       s.ttfbWindow.Quantile(0.5),
+		TTFBP95Ms:       s.ttfbWindow.Quantile(0.95),
+		DurationMs:      s.duration.Value(),
+		DurationP50Ms:   s.durationWindow.Quantile(0.5),
+		DurationP95Ms:   s.durationWindow.Quantile(0.95),
+		TokensPerSecond: s.throughput.Value(),
+		TokensPerSecP50: s.throughputWindow.Quantile(0.5),
+	}
+}
+
+// endpointPerformance 端点的性能统计，配置更新时随端点统计一起保留
+type endpointPerformance struct {
+	mutex   sync.Mutex
+	overall *latencySeries
+	models  map[string]*latencySeries
+}
+
+func newEndpointPerformance() *endpointPerformance {
+	return &endpointPerformance{
+		overall: newLatencySeries(),
+		models:  make(map[string]*latencySeries),
+	}
+}
+
+// RecordPerformance 记录一次成功请求的首字节时间、总耗时和输出 token 数
+func (e *Endpoint) RecordPerformance(model string, ttfb, duration time.Duration, outputTokens int) {
+	if ttfb <= 0 || duration <= 0 {
+		return
+	}
+	var tokensPerSecond float64
+	if outputTokens > 0 {
+		tokensPerSecond = float64(outputTokens) / duration.Seconds()
+	}
+
+	p := e.perf
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.overall.add(ttfb, duration, tokensPerSecond)
+	if model == "" {
+		return
+	}
+	series, ok := p.models[model]
+	if !ok {
+		if len(p.models) >= maxPerformanceModels {
+			return
+		}
+		series = newLatencySeries()
+		p.models[model] = series
+	}
+	series.add(ttfb, duration, tokensPerSecond)
+}
+
+// Performance 返回端点总体和按模型的性能统计快照
+func (e *Endpoint) Performance() (PerformanceStats, map[string]PerformanceStats) {
+	p := e.perf
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	models := make(map[string]PerformanceStats, len(p.models))
+	for model, series := range p.models {
+		models[model] = series.stats()
+	}
+	return p.overall.stats(), models
+}
+
+// RecentPerformance 实现 utils.PerformanceReporter，供端点选择时判断是否降级
+// model 为空时返回总体统计；没有该模型的样本或统计过期时返回 0 个样本，使端点不参与比较
+func (e *Endpoint) RecentPerformance(model string) (time.Duration, float64, int) {
+	p := e.perf
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	series := p.overall
+	if model != "" {
+		series = p.models[model]
+	}
+	if series == nil || time.Since(series.lastSample) > performanceStaleAfter {
+		return 0, 0, 0
+	}
+	ttfb := time.Duration(series.ttfb.Value() * float64(time.Millisecond))
+	return ttfb, series.throughput.Value(), series.ttfbWindow.Count()
+}
+
+// DegradationPolicyFromConfig 将延迟评分配置转换为端点选择使用的降级判定策略
+func DegradationPolicyFromConfig(cfg config.LatencyScoringConfig) utils.DegradationPolicy {
+	gap, _ := time.ParseDuration(cfg.MinTTFBGap)
+	return utils.DegradationPolicy{
+		Enabled:    !cfg.Disabled,
+		Factor:     cfg.Factor,
+		MinTTFBGap: gap,
+		MinSamples: cfg.MinSamples,
+	}
+}
+
+// SetDegradationPolicy 更新端点降级判定策略，立即对后续请求生效
+func (m *Manager) SetDegradationPolicy(cfg config.LatencyScoringConfig) {
+	m.selector.SetDegradationPolicy(DegradationPolicyFromConfig(cfg))
+}
+
+// DegradationPolicy 返回当前的端点降级判定策略，供代理的回退排序使用
+func (m *Manager) DegradationPolicy() utils.DegradationPolicy {
+	return m.selector.DegradationPolicy()
+}
+
+// PerformanceOverview 返回所有端点的性能统计，并按当前策略标记降级端点
+// 降级按模型分别判定，端点处理任一模型时明显慢于同样处理该模型的其他端点即视为降级
+func (m *Manager) PerformanceOverview() []EndpointPerformance {
+	endpoints := m.GetAllEndpoints()
+	sorters := make([]utils.EndpointSorter, len(endpoints))
+	for i, ep := range endpoints {
+		sorters[i] = ep
+	}
+
+	result := make([]EndpointPerformance, 0, len(endpoints))
+	models := make(map[string]bool)
+	for _, ep := range endpoints {
+		overall, byModel := ep.Performance()
+		for model := range byModel {
+			models[model] = true
+		}
+		result = append(result, EndpointPerformance{
+			Name:    ep.Name,
+			Overall: overall,
+			Models:  byModel,
+		})
+	}
+	policy := m.DegradationPolicy()
+	for model := range models {
+		for ep := range utils.DegradedEndpoints(sorters, model, policy) {
+			for i := range result {
+				if result[i].Name == ep.(*Endpoint).Name {
+					result[i].Degraded = true
+				}
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,157 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+var testLatencyScoring = config.LatencyScoringConfig{Factor: 3, MinTTFBGap: "1s", MinSamples: 3}
+
+func recordSamples(ep *Endpoint, ttfb time.Duration, count int) {
+	for i := 0; i < count; i++ {
+		ep.RecordPerformance("claude-sonnet-4", ttfb, ttfb+2*time.Second, 100)
+	}
+}
+
+func TestRecordPerformance(t *testing.T) {
+	ep := newTestEndpoint("perf", 1, 0)
+	ep.RecordPerformance("claude-sonnet-4", 500*time.Millisecond, 2*time.Second, 100)
+	ep.RecordPerformance("", 500*time.Millisecond, 2*time.Second, 0)
+
+	overall, models := ep.Performance()
+	if overall.Samples != 2 || overall.TTFBMs != 500 || overall.TokensPerSecond != 50 {
+		t.Errorf("Unexpected overall stats: %+v", overall)
+	}
+	if len(models) != 1 || models["claude-sonnet-4"].Samples != 1 {
+		t.Errorf("Expected one sample for claude-sonnet-4, got %+v", models)
+	}
+}
+
+func TestSelectorDemotesDegradedEndpoint(t *testing.T) {
+	slow := newTestEndpoint("slow", 1, 0)
+	fast := newTestEndpoint("fast", 2, 0)
+	other := newTestEndpoint("other", 3, 0)
+	recordSamples(slow, 8*time.Second, 5)
+	recordSamples(fast, 400*time.Millisecond, 5)
+	recordSamples(other, 600*time.Millisecond, 5)
+
+	selector := NewSelector([]*Endpoint{slow, fast, other})
+	selected, _ := selector.SelectEndpointForContext(nil, 0, "claude-sonnet-4")
+	if selected.Name != "slow" {
+		t.Fatalf("Expected priority order without a policy, got %s", selected.Name)
+	}
+
+	selector.SetDegradationPolicy(DegradationPolicyFromConfig(testLatencyScoring))
+	selected, _ = selector.SelectEndpointForContext(nil, 0, "claude-sonnet-4")
+	if selected.Name != "fast" {
+		t.Errorf("Expected degraded endpoint to be skipped, got %s", selected.Name)
+	}
+
+	// 降级只是降低顺序，其他端点都不可用时仍然选择它
+	fast.MarkInactive()
+	other.MarkInactive()
+	selected, _ = selector.SelectEndpointForContext(nil, 0, "claude-sonnet-4")
+	if selected == nil || selected.Name != "slow" {
+		t.Errorf("Expected degraded endpoint when it is the only one available, got %v", selected)
+	}
+}
+
+func TestSelectorKeepsTagTierOverDegradation(t *testing.T) {
+	tagged := NewEndpoint(config.EndpointConfig{Name: "tagged", URL: "https://tagged.example.com", Enabled: true, Priority: 1, Tags: []string{"vision"}})
+	universal := newTestEndpoint("universal", 2, 0)
+	third := newTestEndpoint("third", 3, 0)
+	recordSamples(tagged, 8*time.Second, 5)
+	recordSamples(universal, 400*time.Millisecond, 5)
+	recordSamples(third, 500*time.Millisecond, 5)
+
+	selector := NewSelector([]*Endpoint{universal, third, tagged})
+	selector.SetDegradationPolicy(DegradationPolicyFromConfig(testLatencyScoring))
+	selected, _ := selector.SelectEndpointForContext([]string{"vision"}, 0, "claude-sonnet-4")
+	if selected.Name != "tagged" {
+		t.Errorf("Expected tagged endpoint to stay ahead of universal endpoints, got %s", selected.Name)
+	}
+}
+
+func TestDegradationNeedsEnoughSamplesAndGap(t *testing.T) {
+	a := newTestEndpoint("a", 1, 0)
+	b := newTestEndpoint("b", 2, 0)
+	c := newTestEndpoint("c", 3, 0)
+	// 慢 4 倍但绝对差距只有 300ms，不视为降级
+	recordSamples(a, 400*time.Millisecond, 5)
+	recordSamples(b, 100*time.Millisecond, 5)
+	recordSamples(c, 100*time.Millisecond, 5)
+
+	selector := NewSelector([]*Endpoint{a, b, c})
+	selector.SetDegradationPolicy(DegradationPolicyFromConfig(testLatencyScoring))
+	if selected, _ := selector.SelectEndpointForContext(nil, 0, "claude-sonnet-4"); selected.Name != "a" {
+		t.Errorf("Expected small absolute gap to be ignored, got %s", selected.Name)
+	}
+
+	d := newTestEndpoint("d", 0, 0)
+	recordSamples(d, 10*time.Second, 2)
+	selector.UpdateEndpoints([]*Endpoint{d, a, b, c})
+	if selected, _ := selector.SelectEndpointForContext(nil, 0, "claude-sonnet-4"); selected.Name != "d" {
+		t.Errorf("Expected endpoint with too few samples to keep its priority, got %s", selected.Name)
+	}
+}
+
+func TestStalePerformanceIsIgnored(t *testing.T) {
+	ep := newTestEndpoint("stale", 1, 0)
+	recordSamples(ep, time.Second, 5)
+	if _, _, samples := ep.RecentPerformance("claude-sonnet-4"); samples != 5 {
+		t.Fatalf("Expected 5 recent samples, got %d", samples)
+	}
+	ep.perf.models["claude-sonnet-4"].lastSample = time.Now().Add(-performanceStaleAfter - time.Minute)
+	if _, _, samples := ep.RecentPerformance("claude-sonnet-4"); samples != 0 {
+		t.Errorf("Expected stale samples to be ignored, got %d", samples)
+	}
+}
+
+func TestDegradationOrdersByTierThenDegradedThenPriority(t *testing.T) {
+	taggedSlow := NewEndpoint(config.EndpointConfig{Name: "tagged-slow", URL: "https://slow.example.com", Enabled: true, Priority: 1, Tags: []string{"vision"}})
+	taggedFast := NewEndpoint(config.EndpointConfig{Name: "tagged-fast", URL: "https://fast.example.com", Enabled: true, Priority: 2, Tags: []string{"vision"}})
+	universal := newTestEndpoint("universal", 0, 0)
+	recordSamples(taggedSlow, 8*time.Second, 5)
+	recordSamples(taggedFast, 400*time.Millisecond, 5)
+	recordSamples(universal, 500*time.Millisecond, 5)
+
+	selector := NewSelector([]*Endpoint{universal, taggedSlow, taggedFast})
+	selector.SetDegradationPolicy(DegradationPolicyFromConfig(testLatencyScoring))
+	if selected, _ := selector.SelectEndpointForContext([]string{"vision"}, 0, "claude-sonnet-4"); selected.Name != "tagged-fast" {
+		t.Errorf("Expected the healthy tagged endpoint first, got %s", selected.Name)
+	}
+	// 降级的端点仍排在下一层级的万用端点之前
+	taggedFast.MarkInactive()
+	if selected, _ := selector.SelectEndpointForContext([]string{"vision"}, 0, "claude-sonnet-4"); selected.Name != "tagged-slow" {
+		t.Errorf("Expected degraded tagged endpoint ahead of universal endpoints, got %s", selected.Name)
+	}
+}
+
+func TestDegradationComparesSameModel(t *testing.T) {
+	opus := newTestEndpoint("opus", 1, 0)
+	haikuA := newTestEndpoint("haiku-a", 2, 0)
+	haikuB := newTestEndpoint("haiku-b", 3, 0)
+	// 大模型本身更慢，不应因此被判定为慢于只处理小模型的端点
+	for i := 0; i < 5; i++ {
+		opus.RecordPerformance("claude-opus-4", 8*time.Second, 20*time.Second, 100)
+		haikuA.RecordPerformance("claude-haiku-3", 400*time.Millisecond, time.Second, 100)
+		haikuB.RecordPerformance("claude-haiku-3", 500*time.Millisecond, time.Second, 100)
+	}
+
+	selector := NewSelector([]*Endpoint{opus, haikuA, haikuB})
+	selector.SetDegradationPolicy(DegradationPolicyFromConfig(testLatencyScoring))
+	for _, model := range []string{"claude-opus-4", "claude-haiku-3"} {
+		if selected, _ := selector.SelectEndpointForContext(nil, 0, model); selected.Name != "opus" {
+			t.Errorf("Expected priority order for %s without comparable samples, got %s", model, selected.Name)
+		}
+	}
+
+	manager := &Manager{selector: selector}
+	for _, perf := range manager.PerformanceOverview() {
+		if perf.Degraded {
+			t.Errorf("Expected no endpoint to be degraded when models differ, got %s", perf.Name)
+		}
+	}
+}
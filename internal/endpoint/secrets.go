@@ -0,0 +1,77 @@
+package endpoint
+
+import (
+	"log"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/secrets"
+)
+
+// resolveSecret 解析凭据引用，失败时记录警告并返回空值，使用该值的请求会因认证失败而故障转移
+func resolveSecret(endpointName, field, value string) string {
+	resolved, err := secrets.Resolve(value)
+	if err != nil {
+		log.Printf("WARNING: Failed to resolve %s of endpoint %s: %v", field, endpointName, err)
+		return ""
+	}
+	return resolved
+}
+
+// reloadSecret 清除凭据引用的缓存并重新从存储读取，存储中的值与 current 不同时返回新值和 true
+func reloadSecret(endpointName, field, reference, current string) (string, bool) {
+	if _, ok := config.SecretReferenceName(reference); !ok {
+		return "", false
+	}
+	secrets.Invalidate(reference)
+	resolved, err := secrets.Resolve(reference)
+	if err != nil {
+		log.Printf("WARNING: Failed to reload %s of endpoint %s: %v", field, endpointName, err)
+		return "", false
+	}
+	return resolved, resolved != current
+}
+
+// resolveProxyConfig 返回解析代理密码后的代理配置，没有引用时直接返回原配置
+func resolveProxyConfig(endpointName string, proxy *config.ProxyConfig) *config.ProxyConfig {
+	if proxy == nil {
+		return nil
+	}
+	if _, ok := config.SecretReferenceName(proxy.Password); !ok {
+		return proxy
+	}
+	resolved := *proxy
+	resolved.Password = resolveSecret(endpointName, "proxy password", proxy.Password)
+	return &resolved
+}
+
+// resolveOAuthConfig 返回解析令牌后的 OAuth 配置副本，刷新令牌时不会修改配置中的引用
+func resolveOAuthConfig(endpointName string, oauthConfig *config.OAuthConfig) *config.OAuthConfig {
+	if oauthConfig == nil {
+		return nil
+	}
+	resolved := *oauthConfig
+	resolved.AccessToken = resolveSecret(endpointName, "oauth access token", oauthConfig.AccessToken)
+	resolved.RefreshToken = resolveSecret(endpointName, "oauth refresh token", oauthConfig.RefreshToken)
+	return &resolved
+}
+
+// persistOAuthConfig 返回刷新后应写入配置文件的 OAuth 配置：原来是凭据引用的令牌写入凭据存储并保留引用
+func persistOAuthConfig(endpointName string, current, refreshed *config.OAuthConfig) *config.OAuthConfig {
+	persisted := *refreshed
+	if current == nil {
+		return &persisted
+	}
+	persisted.AccessToken = persistSecret(endpointName, "oauth access token", current.AccessToken, refreshed.AccessToken)
+	persisted.RefreshToken = persistSecret(endpointName, "oauth refresh token", current.RefreshToken, refreshed.RefreshToken)
+	return &persisted
+}
+
+func persistSecret(endpointName, field, reference, value string) string {
+	persisted, err := secrets.Persist(reference, value)
+	if err == secrets.ErrReadOnly {
+		log.Printf("WARNING: Refreshed %s of endpoint %s cannot be written back to the read-only secrets backend, it is only kept in memory", field, endpointName)
+	} else if err != nil {
+		log.Printf("WARNING: Failed to store refreshed %s of endpoint %s: %v", field, endpointName, err)
+	}
+	return persisted
+}
@@ -0,0 +1,104 @@
+package endpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/secrets"
+)
+
+func TestNewEndpointResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "api-key"), []byte("sk-real\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "proxy-pass"), []byte("hunter2"), 0600)
+	if err := secrets.Configure(config.SecretsConfig{Backend: "dir", Directory: dir}); err != nil {
+		t.Fatalf("Failed to configure secrets: %v", err)
+	}
+	defer secrets.Configure(config.SecretsConfig{})
+
+	ep := NewEndpoint(config.EndpointConfig{
+		Name:        "secret",
+		URL:         "https://api.example.com",
+		AuthType:    "api_key",
+		AuthValue:   "secret://api-key",
+		Enabled:     true,
+		Proxy:       &config.ProxyConfig{Type: "http", Address: "127.0.0.1:8080", Username: "u", Password: "secret://proxy-pass"},
+		Credentials: []config.EndpointCredentialConfig{{Name: "a", Value: "secret://api-key"}},
+	})
+
+	// 导出字段保留引用，管理界面和配置文件中不会出现明文
+	if ep.AuthValue != "secret://api-key" || ep.Proxy.Password != "secret://proxy-pass" {
+		t.Errorf("Expected exported fields to keep references, got %s %s", ep.AuthValue, ep.Proxy.Password)
+	}
+	if ep.ResolvedAuthValue() != "sk-real" || ep.proxy.Password != "hunter2" {
+		t.Errorf("Expected resolved values, got %s %s", ep.ResolvedAuthValue(), ep.proxy.Password)
+	}
+	if cred, _ := ep.AcquireCredential(nil); cred.Value != "sk-real" {
+		t.Errorf("Expected credential value to be resolved, got %s", cred.Value)
+	}
+}
+
+func TestPersistOAuthConfigKeepsReferences(t *testing.T) {
+	t.Setenv("TEST_MASTER_KEY", "master")
+	if err := secrets.Configure(config.SecretsConfig{Backend: "keystore", KeystorePath: filepath.Join(t.TempDir(), "secrets.enc"), MasterKeyEnv: "TEST_MASTER_KEY"}); err != nil {
+		t.Fatalf("Failed to configure secrets: %v", err)
+	}
+	defer secrets.Configure(config.SecretsConfig{})
+
+	current := &config.OAuthConfig{AccessToken: "secret://access", RefreshToken: "plain-refresh", ExpiresAt: 1}
+	refreshed := &config.OAuthConfig{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresAt: 2}
+	persisted := persistOAuthConfig("oauth", current, refreshed)
+
+	if persisted.AccessToken != "secret://access" || persisted.RefreshToken != "new-refresh" || persisted.ExpiresAt != 2 {
+		t.Errorf("Unexpected persisted config: %+v", persisted)
+	}
+	if value, _ := secrets.Resolve("secret://access"); value != "new-access" {
+		t.Errorf("Expected refreshed token in keystore, got %s", value)
+	}
+}
+
+func TestReloadSecretPicksUpRotatedValue(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "api-key"), []byte("sk-old"), 0600)
+	os.WriteFile(filepath.Join(dir, "pool-key"), []byte("sk-pool-old"), 0600)
+	if err := secrets.Configure(config.SecretsConfig{Backend: "dir", Directory: dir}); err != nil {
+		t.Fatalf("Failed to configure secrets: %v", err)
+	}
+	defer secrets.Configure(config.SecretsConfig{})
+
+	ep := NewEndpoint(config.EndpointConfig{
+		Name:        "rotated",
+		URL:         "https://api.example.com",
+		AuthType:    "api_key",
+		AuthValue:   "secret://api-key",
+		Enabled:     true,
+		Credentials: []config.EndpointCredentialConfig{{Name: "a", Value: "secret://pool-key"}, {Name: "plain", Value: "sk-plain"}},
+	})
+	if ep.ReloadSecret(nil) {
+		t.Error("Expected no reload when the stored value has not changed")
+	}
+
+	// 缓存的旧值在上游拒绝后被清除，重新读取到轮换后的值
+	os.WriteFile(filepath.Join(dir, "api-key"), []byte("sk-new"), 0600)
+	os.WriteFile(filepath.Join(dir, "pool-key"), []byte("sk-pool-new"), 0600)
+	if !ep.ReloadSecret(nil) || ep.ResolvedAuthValue() != "sk-new" {
+		t.Errorf("Expected auth_value to be reloaded, got %s", ep.ResolvedAuthValue())
+	}
+
+	stale, _ := ep.AcquireCredential(map[string]bool{"plain": true})
+	if !ep.ReloadSecret(stale) {
+		t.Fatal("Expected the rotated credential to be reloaded")
+	}
+	if stale.Value != "sk-pool-old" {
+		t.Errorf("Expected the credential held by the in-flight request to stay unchanged, got %s", stale.Value)
+	}
+	if fresh, _ := ep.AcquireCredential(map[string]bool{"plain": true}); fresh.Value != "sk-pool-new" || fresh.Name != "a" {
+		t.Errorf("Expected the pool to use the rotated value, got %s", fresh.Value)
+	}
+	plain, _ := ep.AcquireCredential(map[string]bool{"a": true})
+	if ep.ReloadSecret(plain) {
+		t.Error("Expected plain credentials not to be reloaded")
+	}
+}
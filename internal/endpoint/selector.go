@@ -9,6 +9,7 @@ import (
 
 type Selector struct {
 	endpoints []*Endpoint
+	policy    utils.DegradationPolicy // 降级判定策略，默认不启用
 	mutex     sync.RWMutex
 }
 
@@ -50,7 +51,7 @@ func (s *Selector) SelectEndpointWithTags(tags []string) (*Endpoint, error) {
 	}
 
 	// 使用新的标签匹配选择逻辑
-	selected := utils.SelectBestEndpointWithTags(sorterEndpoints, tags)
+	selected := utils.SelectBestEndpointWithPolicy(sorterEndpoints, tags, "", s.policy)
 	if selected == nil {
 		return nil, fmt.Errorf("no available endpoints match the required tags: %v", tags)
 	}
@@ -59,6 +60,39 @@ func (s *Selector) SelectEndpointWithTags(tags []string) (*Endpoint, error) {
 	return selected.(*Endpoint), nil
 }
 
+// SelectEndpointForContext 根据tags和估算的上下文长度选择endpoint，降级判定使用各端点处理 model 的统计
+// 优先选择 max_context_tokens 能容纳请求的端点；没有任何端点能容纳时退回到不考虑上下文长度的选择，由上游决定是否接受
+func (s *Selector) SelectEndpointForContext(tags []string, estimatedTokens int, model string) (*Endpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var fitting []utils.EndpointSorter
+	for _, ep := range s.endpoints {
+		if ep.CanHandleContext(estimatedTokens) {
+			fitting = append(fitting, ep)
+		}
+	}
+
+	if selected := utils.SelectBestEndpointWithPolicy(fitting, tags, model, s.policy); selected != nil {
+		return selected.(*Endpoint), nil
+	}
+
+	if len(fitting) < len(s.endpoints) {
+		all := make([]utils.EndpointSorter, len(s.endpoints))
+		for i, ep := range s.endpoints {
+			all[i] = ep
+		}
+		if selected := utils.SelectBestEndpointWithPolicy(all, tags, model, s.policy); selected != nil {
+			return selected.(*Endpoint), nil
+		}
+	}
+
+	if len(tags) > 0 {
+		return nil, fmt.Errorf("no available endpoints match the required tags: %v", tags)
+	}
+	return nil, fmt.Errorf("no available endpoints found")
+}
+
 func (s *Selector) GetAllEndpoints() []*Endpoint {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -72,4 +106,18 @@ func (s *Selector) UpdateEndpoints(endpoints []*Endpoint) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.endpoints = endpoints
-}
\ No newline at end of file
+}
+
+// SetDegradationPolicy 设置降级判定策略，明显慢于同组端点的端点在同一标签层级内排在后面
+func (s *Selector) SetDegradationPolicy(policy utils.DegradationPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.policy = policy
+}
+
+// DegradationPolicy 返回当前的降级判定策略
+func (s *Selector) DegradationPolicy() utils.DegradationPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.policy
+}
@@ -0,0 +1,47 @@
+package endpoint
+
+import (
+	"testing"
+
+	"claude-code-companion/internal/config"
+)
+
+func newTestEndpoint(name string, priority, maxContextTokens int) *Endpoint {
+	return NewEndpoint(config.EndpointConfig{
+		Name:             name,
+		URL:              "https://" + name + ".example.com",
+		AuthType:         "api_key",
+		AuthValue:        "test",
+		Enabled:          true,
+		Priority:         priority,
+		MaxContextTokens: maxContextTokens,
+	})
+}
+
+func TestSelectEndpointForContext(t *testing.T) {
+	selector := NewSelector([]*Endpoint{
+		newTestEndpoint("small", 1, 128000),
+		newTestEndpoint("large", 2, 1000000),
+	})
+
+	selected, err := selector.SelectEndpointForContext(nil, 50000, "")
+	if err != nil || selected.Name != "small" {
+		t.Fatalf("Expected small endpoint for short request, got %v (err=%v)", selected, err)
+	}
+
+	selected, err = selector.SelectEndpointForContext(nil, 180000, "")
+	if err != nil || selected.Name != "large" {
+		t.Fatalf("Expected large endpoint for long request, got %v (err=%v)", selected, err)
+	}
+}
+
+func TestSelectEndpointForContextFallsBackWhenNothingFits(t *testing.T) {
+	selector := NewSelector([]*Endpoint{
+		newTestEndpoint("small", 1, 128000),
+	})
+
+	selected, err := selector.SelectEndpointForContext(nil, 500000, "")
+	if err != nil || selected.Name != "small" {
+		t.Fatalf("Expected fallback to small endpoint, got %v (err=%v)", selected, err)
+	}
+}
@@ -0,0 +1,222 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type 请求生命周期事件类型
+type Type string
+
+const (
+	RequestReceived  Type = "request_received"  // 收到客户端请求
+	RequestTagged    Type = "request_tagged"    // 标签处理完成
+	AttemptStarted   Type = "attempt_started"   // 开始向端点发送请求
+	AttemptFailed    Type = "attempt_failed"    // 本次尝试失败，可能重试或切换端点
+	StreamProgress   Type = "stream_progress"   // 正在接收上游响应
+	RequestCompleted Type = "request_completed" // 请求处理结束（成功或失败）
+	LogWritten       Type = "log_written"       // 请求日志已提交写入
+)
+
+// Event 推送给管理界面的事件，按类型只填充相关字段
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+
+	Method   string   `json:"method,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Endpoint string   `json:"endpoint,omitempty"`
+	Attempt  int      `json:"attempt,omitempty"`
+
+	StatusCode   int    `json:"status_code,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Success      bool   `json:"success,omitempty"`
+	Streaming    bool   `json:"streaming,omitempty"`
+	Bytes        int64  `json:"bytes,omitempty"`
+	InputTokens  int    `json:"input_tokens,omitempty"`
+	OutputTokens int    `json:"output_tokens,omitempty"`
+	DurationMs   int64  `json:"duration_ms,omitempty"`
+}
+
+// RequestState 正在处理的请求的当前状态，由事件累积得到
+type RequestState struct {
+	RequestID    string    `json:"request_id"`
+	StartTime    time.Time `json:"start_time"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Model        string    `json:"model,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Endpoint     string    `json:"endpoint,omitempty"`
+	Attempt      int       `json:"attempt,omitempty"`
+	Streaming    bool      `json:"streaming,omitempty"`
+	Bytes        int64     `json:"bytes,omitempty"`
+	InputTokens  int       `json:"input_tokens,omitempty"`
+	OutputTokens int       `json:"output_tokens,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// Subscription 一个订阅者的事件通道
+type Subscription struct {
+	C <-chan Event
+
+	hub     *Hub
+	ch      chan Event
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Dropped 因订阅者处理过慢而丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅并关闭通道
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subscribers, s)
+		s.hub.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Hub 将代理和日志发布的事件广播给所有订阅者，并维护正在处理的请求
+// 发布不会阻塞：订阅者的缓冲区满时丢弃事件
+type Hub struct {
+	bufferSize int
+
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+	inFlight    map[string]*RequestState
+}
+
+// NewHub 创建事件中心，bufferSize 为每个订阅者的缓冲事件数
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	return &Hub{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+		inFlight:    make(map[string]*RequestState),
+	}
+}
+
+// Subscribe 获取正在处理的请求并订阅之后发布的事件，两者原子地完成，快照与事件流之间不会遗漏
+// 使用完毕后需要调用 Close
+func (h *Hub) Subscribe() ([]RequestState, *Subscription) {
+	ch := make(chan Event, h.bufferSize)
+	sub := &Subscription{C: ch, hub: h, ch: ch}
+
+	h.mu.Lock()
+	states := h.snapshotLocked()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return states, sub
+}
+
+// SubscriberCount 当前订阅者数量
+func (h *Hub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Publish 发布事件；Hub 为 nil 时不做任何事，调用方无需判断是否启用
+func (h *Hub) Publish(event Event) {
+	if h == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// 持有写锁完成编号、状态更新和分发，保证订阅者看到的事件顺序与状态一致
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	event.ID = h.nextID
+	h.track(event)
+	for sub := range h.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// track 根据事件更新正在处理的请求
+func (h *Hub) track(event Event) {
+	if event.RequestID == "" {
+		return
+	}
+	switch event.Type {
+	case RequestReceived:
+		h.inFlight[event.RequestID] = &RequestState{
+			RequestID: event.RequestID,
+			StartTime: event.Time,
+			Method:    event.Method,
+			Path:      event.Path,
+			Model:     event.Model,
+		}
+		return
+	case RequestCompleted:
+		delete(h.inFlight, event.RequestID)
+		return
+	}
+
+	state, ok := h.inFlight[event.RequestID]
+	if !ok {
+		return
+	}
+	switch event.Type {
+	case RequestTagged:
+		state.Tags = event.Tags
+	case AttemptStarted:
+		state.Endpoint = event.Endpoint
+		state.Attempt = event.Attempt
+		state.Streaming = false
+		state.Bytes = 0
+		state.InputTokens = 0
+		state.OutputTokens = 0
+	case AttemptFailed:
+		state.LastError = event.Error
+	case StreamProgress:
+		state.Streaming = event.Streaming
+		state.Bytes = event.Bytes
+		state.InputTokens = event.InputTokens
+		state.OutputTokens = event.OutputTokens
+	}
+}
+
+// InFlight 返回正在处理的请求，按开始时间排序
+func (h *Hub) InFlight() []RequestState {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.snapshotLocked()
+}
+
+// snapshotLocked 复制正在处理的请求，调用方需持有锁
+func (h *Hub) snapshotLocked() []RequestState {
+	states := make([]RequestState, 0, len(h.inFlight))
+	for _, state := range h.inFlight {
+		copied := *state
+		copied.Tags = append([]string(nil), state.Tags...)
+		states = append(states, copied)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartTime.Before(states[j].StartTime)
+	})
+	return states
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHubTracksInFlightRequests(t *testing.T) {
+	hub := NewHub(16)
+	start := time.Now()
+	hub.Publish(Event{Type: RequestReceived, Time: start, RequestID: "req-1", Method: "POST", Path: "/messages", Model: "claude-sonnet-4"})
+	hub.Publish(Event{Type: RequestReceived, Time: start.Add(time.Second), RequestID: "req-2"})
+	hub.Publish(Event{Type: RequestTagged, RequestID: "req-1", Tags: []string{"thinking"}})
+	hub.Publish(Event{Type: AttemptStarted, RequestID: "req-1", Endpoint: "primary", Attempt: 1})
+	hub.Publish(Event{Type: StreamProgress, RequestID: "req-1", Streaming: true, Bytes: 128, InputTokens: 10, OutputTokens: 3})
+	hub.Publish(Event{Type: RequestCompleted, RequestID: "req-2"})
+	// 未知请求的事件不应创建状态
+	hub.Publish(Event{Type: StreamProgress, RequestID: "unknown", Bytes: 1})
+
+	states := hub.InFlight()
+	if len(states) != 1 {
+		t.Fatalf("Expected 1 in-flight request, got %d", len(states))
+	}
+	state := states[0]
+	if state.RequestID != "req-1" || state.Model != "claude-sonnet-4" || state.Endpoint != "primary" || state.Attempt != 1 {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+	if len(state.Tags) != 1 || state.Tags[0] != "thinking" {
+		t.Errorf("Expected tags to be tracked, got %v", state.Tags)
+	}
+	if !state.Streaming || state.Bytes != 128 || state.OutputTokens != 3 {
+		t.Errorf("Expected stream progress to be tracked, got %+v", state)
+	}
+
+	// 新的尝试会清空上一次尝试的进度
+	hub.Publish(Event{Type: AttemptStarted, RequestID: "req-1", Endpoint: "backup", Attempt: 2})
+	state = hub.InFlight()[0]
+	if state.Endpoint != "backup" || state.Bytes != 0 || state.OutputTokens != 0 {
+		t.Errorf("Expected progress to reset on a new attempt, got %+v", state)
+	}
+}
+
+func TestHubSubscribeReceivesSnapshotAndEvents(t *testing.T) {
+	hub := NewHub(16)
+	hub.Publish(Event{Type: RequestReceived, RequestID: "req-1"})
+
+	states, sub := hub.Subscribe()
+	defer sub.Close()
+	if len(states) != 1 || states[0].RequestID != "req-1" {
+		t.Fatalf("Expected snapshot with req-1, got %+v", states)
+	}
+	if hub.SubscriberCount() != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", hub.SubscriberCount())
+	}
+
+	hub.Publish(Event{Type: RequestCompleted, RequestID: "req-1", StatusCode: 200})
+	select {
+	case event := <-sub.C:
+		if event.Type != RequestCompleted || event.RequestID != "req-1" || event.ID == 0 || event.Time.IsZero() {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected to receive the published event")
+	}
+
+	sub.Close()
+	if hub.SubscriberCount() != 0 {
+		t.Errorf("Expected subscriber to be removed after Close, got %d", hub.SubscriberCount())
+	}
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected subscription channel to be closed")
+	}
+}
+
+func TestHubDropsEventsForSlowSubscribers(t *testing.T) {
+	hub := NewHub(2)
+	_, sub := hub.Subscribe()
+	defer sub.Close()
+
+	for i := 0; i < 5; i++ {
+		hub.Publish(Event{Type: LogWritten, RequestID: "req"})
+	}
+	if sub.Dropped() != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", sub.Dropped())
+	}
+	first := <-sub.C
+	second := <-sub.C
+	if first.ID >= second.ID {
+		t.Errorf("Expected events in publish order, got IDs %d and %d", first.ID, second.ID)
+	}
+}
+
+func TestNilHubPublishIsNoop(t *testing.T) {
+	var hub *Hub
+	hub.Publish(Event{Type: RequestReceived, RequestID: "req"})
+	if hub.InFlight() != nil {
+		t.Error("Expected nil hub to have no in-flight requests")
+	}
+}
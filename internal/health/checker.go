@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/conversion"
@@ -33,23 +34,98 @@ func (c *Checker) GetExtractor() *RequestExtractor {
 	return c.extractor
 }
 
+// defaultHealthCheckSystemPrompt 默认消息探测使用的系统提示词，与 Claude Code 的话题检测请求一致
+const defaultHealthCheckSystemPrompt = "Analyze if this message indicates a new conversation topic. If it does, extract a 2-3 word title that captures the new topic. Format your response as a JSON object with two fields: 'isNewTopic' (boolean) and 'title' (string, or null if isNewTopic is false). Only include these fields, no other text."
+
+// CheckEndpoint 按端点的探测配置发送健康检查请求并校验响应
 func (c *Checker) CheckEndpoint(ep *endpoint.Endpoint) error {
+	probe := ep.HealthCheck
+	if probe == nil {
+		probe = &config.EndpointHealthCheckConfig{}
+	}
 	requestInfo := c.extractor.GetRequestInfo()
 	
+	var req *http.Request
+	var err error
+	switch probe.Probe {
+	case "models":
+		req, err = http.NewRequest("GET", modelsURL(ep), nil)
+	case "count_tokens":
+		req, err = c.buildCountTokensRequest(ep, probe, requestInfo)
+	default:
+		req, err = c.buildMessageRequest(ep, probe, requestInfo)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 复制从实际请求中提取的头部（包含默认值）
+	for key, value := range requestInfo.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// 单独设置认证头部（不包含在默认headers中）
+	if ep.AuthType == "api_key" {
+		req.Header.Set("x-api-key", ep.ProbeAuthValue())
+	} else {
+		authHeader, err := ep.GetAuthHeader()
+		if err != nil {
+			return fmt.Errorf("failed to get auth header: %v", err)
+		}
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	// 执行请求 - 使用端点特定的HTTP客户端，探测配置了超时则覆盖整体超时
+	healthTimeouts := c.healthTimeouts
+	if probe.Timeout != "" {
+		healthTimeouts.OverallRequest = probe.Timeout
+		healthTimeouts.ResponseHeader = probe.Timeout
+	}
+	client, err := ep.CreateHealthClient(healthTimeouts)
+	if err != nil {
+		return fmt.Errorf("failed to create health client for endpoint: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read health check response: %v", err)
+	}
+	
+	// 检查状态码
+	if !statusExpected(resp.StatusCode, probe.ExpectStatus) {
+		return fmt.Errorf("health check failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if probe.ExpectContains != "" {
+		if !bytes.Contains(body, []byte(probe.ExpectContains)) {
+			return fmt.Errorf("health check response does not contain %q", probe.ExpectContains)
+		}
+		return nil
+	}
+
+	// 未配置断言时，消息探测沿用原有的响应格式校验
+	if probe.Probe == "" || probe.Probe == "message" {
+		return validateMessageResponse(body)
+	}
+	return nil
+}
+
+// buildMessageRequest 构造完整的消息探测请求，经过模型重写和格式转换
+func (c *Checker) buildMessageRequest(ep *endpoint.Endpoint, probe *config.EndpointHealthCheckConfig, requestInfo *RequestInfo) (*http.Request, error) {
 	// 构造健康检查请求
 	healthCheckRequest := map[string]interface{}{
-		"model":       requestInfo.Model,
+		"model":       config.GetStringWithDefault(probe.Model, requestInfo.Model),
 		"max_tokens":  config.Default.HealthCheck.MaxTokens,
 		"messages": []map[string]interface{}{
 			{
 				"role":    "user",
-				"content": "hello",
-			},
-		},
-		"system": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Analyze if this message indicates a new conversation topic. If it does, extract a 2-3 word title that captures the new topic. Format your response as a JSON object with two fields: 'isNewTopic' (boolean) and 'title' (string, or null if isNewTopic is false). Only include these fields, no other text.",
+				"content": config.GetStringWithDefault(probe.Prompt, "hello"),
 			},
 		},
 		"temperature": config.Default.HealthCheck.Temperature,
@@ -58,37 +134,21 @@ func (c *Checker) CheckEndpoint(ep *endpoint.Endpoint) error {
 		},
 		"stream": config.Default.HealthCheck.StreamMode,
 	}
-
-	// 将请求序列化为JSON
-	requestBody, err := json.Marshal(healthCheckRequest)
-	if err != nil {
-		return fmt.Errorf("failed to marshal health check request: %v", err)
+	// 自定义提示词时不附加默认的系统提示词
+	if probe.Prompt == "" {
+		healthCheckRequest["system"] = []map[string]interface{}{
+			{
+				"type": "text",
+				"text": defaultHealthCheckSystemPrompt,
+			},
+		}
 	}
 
 	// 获取目标URL（稍后可能会被格式转换修改）
 	targetURL := ep.GetFullURL("/messages")
-	
-	// 创建临时HTTP请求用于模型重写处理
-	tempReq, err := http.NewRequest("POST", targetURL, bytes.NewReader(requestBody))
-	if err != nil {
-		return fmt.Errorf("failed to create temporary request for model rewrite: %v", err)
-	}
-
-	// 复制从实际请求中提取的头部（用于模型重写）
-	for key, value := range requestInfo.Headers {
-		tempReq.Header.Set(key, value)
-	}
-
-	// 应用模型重写（如果配置了）
-	_, _, err = c.modelRewriter.RewriteRequestWithTags(tempReq, ep.ModelRewrite, ep.Tags)
+	finalRequestBody, err := c.rewriteModel(ep, targetURL, healthCheckRequest, requestInfo)
 	if err != nil {
-		return fmt.Errorf("model rewrite failed during health check: %v", err)
-	}
-
-	// 如果进行了模型重写，获取重写后的请求体
-	finalRequestBody, err := io.ReadAll(tempReq.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read rewritten request body: %v", err)
+		return nil, err
 	}
 
 	// 格式转换（在模型重写之后）
@@ -101,7 +161,7 @@ func (c *Checker) CheckEndpoint(ep *endpoint.Endpoint) error {
 		
 		convertedBody, _, err := c.converter.ConvertRequest(finalRequestBody, endpointInfo)
 		if err != nil {
-			return fmt.Errorf("request format conversion failed during health check: %v", err)
+			return nil, fmt.Errorf("request format conversion failed during health check: %v", err)
 		}
 		finalRequestBody = convertedBody
 		
@@ -112,49 +172,97 @@ func (c *Checker) CheckEndpoint(ep *endpoint.Endpoint) error {
 	// 构造最终的HTTP请求
 	req, err := http.NewRequest("POST", targetURL, bytes.NewReader(finalRequestBody))
 	if err != nil {
-		return fmt.Errorf("failed to create final health check request: %v", err)
+		return nil, fmt.Errorf("failed to create final health check request: %v", err)
 	}
+	return req, nil
+}
 
-	// 复制从实际请求中提取的头部（包含默认值）
-	for key, value := range requestInfo.Headers {
-		req.Header.Set(key, value)
+// buildCountTokensRequest 构造 count_tokens 探测请求，只计算 token 不生成内容
+func (c *Checker) buildCountTokensRequest(ep *endpoint.Endpoint, probe *config.EndpointHealthCheckConfig, requestInfo *RequestInfo) (*http.Request, error) {
+	if ep.EndpointType == "openai" {
+		return nil, fmt.Errorf("count_tokens probe is not supported by openai endpoints")
 	}
-
-	// 单独设置认证头部（不包含在默认headers中）
-	if ep.AuthType == "api_key" {
-		req.Header.Set("x-api-key", ep.AuthValue)
-	} else {
-		authHeader, err := ep.GetAuthHeader()
-		if err != nil {
-			return fmt.Errorf("failed to get auth header: %v", err)
-		}
-		req.Header.Set("Authorization", authHeader)
+	countRequest := map[string]interface{}{
+		"model": config.GetStringWithDefault(probe.Model, requestInfo.Model),
+		"messages": []map[string]interface{}{
+			{
+				"role":    "user",
+				"content": config.GetStringWithDefault(probe.Prompt, "hello"),
+			},
+		},
 	}
-
-	// 执行请求 - 使用端点特定的HTTP客户端
-	client, err := ep.CreateHealthClient(c.healthTimeouts)
+	targetURL := ep.GetFullURL("/messages/count_tokens")
+	body, err := c.rewriteModel(ep, targetURL, countRequest, requestInfo)
 	if err != nil {
-		return fmt.Errorf("failed to create health client for endpoint: %v", err)
+		return nil, err
 	}
+	req, err := http.NewRequest("POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create count_tokens health check request: %v", err)
+	}
+	return req, nil
+}
 
-	resp, err := client.Do(req)
+// rewriteModel 序列化探测请求并应用端点的模型重写规则
+func (c *Checker) rewriteModel(ep *endpoint.Endpoint, targetURL string, request map[string]interface{}, requestInfo *RequestInfo) ([]byte, error) {
+	// 将请求序列化为JSON
+	requestBody, err := json.Marshal(request)
 	if err != nil {
-		return fmt.Errorf("health check request failed: %v", err)
+		return nil, fmt.Errorf("failed to marshal health check request: %v", err)
 	}
-	defer resp.Body.Close()
 	
-	// 检查状态码
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("health check failed with status %d: %s", resp.StatusCode, string(body))
+	// 创建临时HTTP请求用于模型重写处理
+	tempReq, err := http.NewRequest("POST", targetURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary request for model rewrite: %v", err)
 	}
 
-	// 读取响应体验证是否为有效流式响应
-	body, err := io.ReadAll(resp.Body)
+	// 复制从实际请求中提取的头部（用于模型重写）
+	for key, value := range requestInfo.Headers {
+		tempReq.Header.Set(key, value)
+	}
+
+	// 应用模型重写（如果配置了）
+	_, _, err = c.modelRewriter.RewriteRequestWithTags(tempReq, ep.ModelRewrite, ep.Tags)
 	if err != nil {
-		return fmt.Errorf("failed to read health check response: %v", err)
+		return nil, fmt.Errorf("model rewrite failed during health check: %v", err)
 	}
 
+	// 如果进行了模型重写，获取重写后的请求体
+	finalRequestBody, err := io.ReadAll(tempReq.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rewritten request body: %v", err)
+	}
+	return finalRequestBody, nil
+}
+
+// modelsURL 返回端点的模型列表地址
+// OpenAI 端点的路径前缀指向 chat/completions，替换为同级的 models
+func modelsURL(ep *endpoint.Endpoint) string {
+	if ep.EndpointType == "openai" {
+		if prefix, ok := strings.CutSuffix(ep.PathPrefix, "/chat/completions"); ok {
+			return ep.URL + prefix + "/models"
+		}
+		return ep.URL + "/v1/models"
+	}
+	return ep.GetFullURL("/models")
+}
+
+// statusExpected 检查状态码是否符合探测配置，未配置时接受所有2xx
+func statusExpected(status int, expected []int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range expected {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMessageResponse 校验消息探测的响应是有效的流式响应或包含基本字段的JSON
+func validateMessageResponse(body []byte) error {
 	// 简单验证：检查是否包含SSE格式的流式响应
 	if !bytes.Contains(body, []byte("event:")) && !bytes.Contains(body, []byte("data:")) {
 		// 如果不是流式响应，检查是否为有效的JSON响应
@@ -172,4 +280,4 @@ func (c *Checker) CheckEndpoint(ep *endpoint.Endpoint) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/conversion"
+	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/logger"
+	"claude-code-companion/internal/modelrewrite"
+)
+
+func newTestChecker(t *testing.T) *Checker {
+	log, err := logger.NewLogger(logger.LogConfig{
+		Level:           "error",
+		LogRequestTypes: "failed",
+		LogRequestBody:  "none",
+		LogResponseBody: "none",
+		LogDirectory:    t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return NewChecker(config.HealthCheckTimeoutConfig{}, modelrewrite.NewRewriter(*log), conversion.NewConverter(log))
+}
+
+func newTestEndpoint(url string, probe *config.EndpointHealthCheckConfig) *endpoint.Endpoint {
+	return endpoint.NewEndpoint(config.EndpointConfig{
+		Name:        "probe-test",
+		URL:         url,
+		AuthType:    "api_key",
+		AuthValue:   "sk-test",
+		Enabled:     true,
+		HealthCheck: probe,
+	})
+}
+
+func TestCheckEndpointProbes(t *testing.T) {
+	var lastPath string
+	var lastBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath = r.Method + " " + r.URL.Path
+		lastBody = nil
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			json.Unmarshal(body, &lastBody)
+		}
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Write([]byte(`{"data":[{"id":"claude-sonnet-4"}]}`))
+		case "/v1/messages/count_tokens":
+			w.Write([]byte(`{"input_tokens":8}`))
+		default:
+			w.Write([]byte(`{"content":[{"type":"text","text":"pong"}]}`))
+		}
+	}))
+	defer server.Close()
+	checker := newTestChecker(t)
+
+	if err := checker.CheckEndpoint(newTestEndpoint(server.URL, &config.EndpointHealthCheckConfig{Probe: "models"})); err != nil {
+		t.Errorf("Expected models probe to pass, got %v", err)
+	}
+	if lastPath != "GET /v1/models" {
+		t.Errorf("Expected GET /v1/models, got %s", lastPath)
+	}
+
+	err := checker.CheckEndpoint(newTestEndpoint(server.URL, &config.EndpointHealthCheckConfig{Probe: "count_tokens", Model: "claude-haiku-4"}))
+	if err != nil {
+		t.Errorf("Expected count_tokens probe to pass, got %v", err)
+	}
+	if lastPath != "POST /v1/messages/count_tokens" || lastBody["model"] != "claude-haiku-4" {
+		t.Errorf("Unexpected count_tokens request: %s %v", lastPath, lastBody)
+	}
+
+	probe := &config.EndpointHealthCheckConfig{Prompt: "ping", ExpectContains: "pong"}
+	if err := checker.CheckEndpoint(newTestEndpoint(server.URL, probe)); err != nil {
+		t.Errorf("Expected message probe to pass, got %v", err)
+	}
+	if _, hasSystem := lastBody["system"]; hasSystem {
+		t.Error("Expected custom prompt to replace the default system prompt")
+	}
+
+	probe.ExpectContains = "missing"
+	if err := checker.CheckEndpoint(newTestEndpoint(server.URL, probe)); err == nil {
+		t.Error("Expected expect_contains assertion to fail")
+	}
+}
+
+func TestCheckEndpointExpectStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"type":"authentication_error"}}`))
+	}))
+	defer server.Close()
+	checker := newTestChecker(t)
+
+	if err := checker.CheckEndpoint(newTestEndpoint(server.URL, &config.EndpointHealthCheckConfig{Probe: "models"})); err == nil {
+		t.Error("Expected 401 to fail without expect_status")
+	}
+	// 只验证连通性时可以接受认证失败
+	probe := &config.EndpointHealthCheckConfig{Probe: "models", ExpectStatus: []int{200, 401}}
+	if err := checker.CheckEndpoint(newTestEndpoint(server.URL, probe)); err != nil {
+		t.Errorf("Expected 401 to pass with expect_status, got %v", err)
+	}
+}
+
+func TestModelsURLForOpenAIEndpoint(t *testing.T) {
+	ep := endpoint.NewEndpoint(config.EndpointConfig{Name: "oa", URL: "https://api.example.com", EndpointType: "openai", PathPrefix: "/api/v1/chat/completions"})
+	if got := modelsURL(ep); got != "https://api.example.com/api/v1/models" {
+		t.Errorf("Unexpected models URL: %s", got)
+	}
+}
+
+func TestRequestExtractorAdoptsModelFromTraffic(t *testing.T) {
+	extractor := NewRequestExtractor()
+	extractor.ExtractFromRequest([]byte(`{"model":"claude-sonnet-4-20250514","messages":[]}`), http.Header{})
+
+	if info := extractor.GetRequestInfo(); info.Model != "claude-sonnet-4-20250514" || !info.Extracted {
+		t.Errorf("Expected probe model to follow real traffic, got %q (extracted %v)", info.Model, info.Extracted)
+	}
+}
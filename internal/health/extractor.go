@@ -2,7 +2,6 @@ package health
 
 import (
 	"net/http"
-	"strings"
 	"sync"
 
 	"claude-code-companion/internal/config"
@@ -39,9 +38,9 @@ func (re *RequestExtractor) ExtractFromRequest(body []byte, headers http.Header)
 	// 总是尝试从请求中提取信息来覆盖默认值
 	extracted := false
 
-	// 提取模型信息
+	// 提取模型信息，探测请求沿用实际流量中的模型
 	model := re.extractModel(body)
-	if model != "" && strings.HasPrefix(model, "claude-3-5") {
+	if model != "" {
 		re.requestInfo.Model = model
 		extracted = true
 	}
@@ -0,0 +1,285 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// batchSaver 支持在一个事务中批量写入的存储后端
+type batchSaver interface {
+	SaveLogs(logs []*RequestLog) error
+}
+
+// WriterStats 异步写入队列的运行指标
+type WriterStats struct {
+	QueueDepth    int   `json:"queue_depth"`
+	QueueCapacity int   `json:"queue_capacity"`
+	QueuedBytes   int64 `json:"queued_bytes"`   // 队列中请求/响应体占用的字节数
+	Written       int64 `json:"written"`        // 成功写入的日志条数
+	BodiesDropped int64 `json:"bodies_dropped"` // 队列满时被丢弃正文的日志条数
+	Blocked       int64 `json:"blocked"`        // 队列满时阻塞请求的次数
+	WriteErrors   int64 `json:"write_errors"`   // 写入失败的日志条数
+	Dropped       int64 `json:"dropped"`        // 关闭后仍提交而被丢弃的日志条数
+}
+
+// queuedLog 队列中的日志及其正文大小
+type queuedLog struct {
+	log  *RequestLog
+	size int64
+}
+
+// asyncStorage 在存储后端前增加有界队列，由后台协程批量写入，请求路径不再等待数据库
+// 读取和清理等其他操作直接转发给底层存储
+type asyncStorage struct {
+	StorageInterface
+
+	queue         chan queuedLog
+	flushRequests chan chan struct{}
+	done          chan struct{}
+
+	batchSize     int
+	flushInterval time.Duration
+	maxBytes      int64
+	dropBodies    bool
+	logger        *logrus.Logger
+	redactor      *atomic.Pointer[Redactor] // 写入前在后台协程中脱敏，为 nil 时不脱敏
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	queuedBytes   atomic.Int64
+	written       atomic.Int64
+	bodiesDropped atomic.Int64
+	blocked       atomic.Int64
+	writeErrors   atomic.Int64
+	dropped       atomic.Int64
+}
+
+// newAsyncStorage 为存储后端创建异步写入队列，写入前用 redactor 当前的规则脱敏，写入失败通过 logger 报告
+func newAsyncStorage(storage StorageInterface, config appconfig.LogWriterConfig, logger *logrus.Logger,
+	redactor *atomic.Pointer[Redactor]) *asyncStorage {
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = appconfig.Default.Logging.WriterQueueSize
+	}
+	queueMaxMB := config.QueueMaxMB
+	if queueMaxMB <= 0 {
+		queueMaxMB = appconfig.Default.Logging.WriterQueueMaxMB
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = appconfig.Default.Logging.WriterBatchSize
+	}
+	flushInterval, err := time.ParseDuration(config.FlushInterval)
+	if err != nil || flushInterval <= 0 {
+		flushInterval, _ = time.ParseDuration(appconfig.Default.Logging.WriterFlushInterval)
+	}
+	onFull := config.OnFull
+	if onFull == "" {
+		onFull = appconfig.Default.Logging.WriterOnFull
+	}
+
+	a := &asyncStorage{
+		StorageInterface: storage,
+		queue:            make(chan queuedLog, queueSize),
+		flushRequests:    make(chan chan struct{}),
+		done:             make(chan struct{}),
+		batchSize:        batchSize,
+		flushInterval:    flushInterval,
+		maxBytes:         int64(queueMaxMB) * 1024 * 1024,
+		dropBodies:       onFull == "drop_bodies",
+		logger:           logger,
+		redactor:         redactor,
+	}
+	go a.run()
+	return a
+}
+
+// SaveLog 将日志放入队列
+// drop_bodies 模式下队列已满或正文超过内存上限时丢弃正文，只保留元数据；
+// 队列条数已满时仍会等待，保证元数据不丢失
+func (a *asyncStorage) SaveLog(log *RequestLog) {
+	// 复制一份，避免调用方后续修改影响后台写入
+	entry := *log
+	size := logBodySize(&entry)
+
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	if a.dropBodies && size > 0 && (len(a.queue) == cap(a.queue) || a.queuedBytes.Load()+size > a.maxBytes) {
+		dropLogBodies(&entry)
+		size = 0
+		a.bodiesDropped.Add(1)
+	}
+
+	item := queuedLog{log: &entry, size: size}
+	a.queuedBytes.Add(size)
+	select {
+	case a.queue <- item:
+	default:
+		a.blocked.Add(1)
+		a.queue <- item
+	}
+}
+
+// run 后台写入协程：凑满一批或到达刷新间隔时写入
+func (a *asyncStorage) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*RequestLog, 0, a.batchSize)
+	add := func(item queuedLog) {
+		a.queuedBytes.Add(-item.size)
+		// 脱敏耗时随正文大小增长，放在写入协程中执行，不增加请求延迟
+		if a.redactor != nil {
+			a.redactor.Load().RedactLog(item.log)
+		}
+		batch = append(batch, item.log)
+		if len(batch) >= a.batchSize {
+			a.write(batch)
+			batch = batch[:0]
+		}
+	}
+	flush := func() {
+		if len(batch) > 0 {
+			a.write(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			add(item)
+		case <-ticker.C:
+			flush()
+		case ack := <-a.flushRequests:
+			for drained := false; !drained; {
+				select {
+				case item, ok := <-a.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					add(item)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(ack)
+		}
+	}
+}
+
+// write 写入一批日志；整批失败时逐条重试，避免一条异常数据导致整批丢失
+func (a *asyncStorage) write(batch []*RequestLog) {
+	saver, ok := a.StorageInterface.(batchSaver)
+	if !ok {
+		for _, log := range batch {
+			a.StorageInterface.SaveLog(log)
+		}
+		a.written.Add(int64(len(batch)))
+		return
+	}
+
+	err := saver.SaveLogs(batch)
+	if err == nil {
+		a.written.Add(int64(len(batch)))
+		return
+	}
+	if len(batch) == 1 {
+		a.fail(err, 1)
+		return
+	}
+
+	for _, log := range batch {
+		if err := saver.SaveLogs([]*RequestLog{log}); err != nil {
+			a.fail(err, 1)
+		} else {
+			a.written.Add(1)
+		}
+	}
+}
+
+// fail 记录写入失败的日志条数并通过 logger 报告
+func (a *asyncStorage) fail(err error, count int) {
+	a.writeErrors.Add(int64(count))
+	a.logger.WithFields(logrus.Fields{"count": count}).WithError(err).Error("Failed to write request logs")
+}
+
+// Flush 等待队列中已有的日志全部写入
+func (a *asyncStorage) Flush() {
+	ack := make(chan struct{})
+	select {
+	case a.flushRequests <- ack:
+		<-ack
+	case <-a.done:
+	}
+}
+
+// Stats 返回队列运行指标
+func (a *asyncStorage) Stats() WriterStats {
+	return WriterStats{
+		QueueDepth:    len(a.queue),
+		QueueCapacity: cap(a.queue),
+		QueuedBytes:   a.queuedBytes.Load(),
+		Written:       a.written.Load(),
+		BodiesDropped: a.bodiesDropped.Load(),
+		Blocked:       a.blocked.Load(),
+		WriteErrors:   a.writeErrors.Load(),
+		Dropped:       a.dropped.Load(),
+	}
+}
+
+// GetStats 在存储统计中附加写入队列指标
+func (a *asyncStorage) GetStats() (map[string]interface{}, error) {
+	stats, err := a.StorageInterface.GetStats()
+	if err != nil {
+		return nil, err
+	}
+	stats["writer"] = a.Stats()
+	return stats, nil
+}
+
+// Close 停止接收新日志，写完队列中剩余的日志后关闭底层存储
+func (a *asyncStorage) Close() error {
+	a.closeMu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.closeMu.Unlock()
+
+	<-a.done
+	return a.StorageInterface.Close()
+}
+
+// logBodySize 返回日志中所有正文的字节数
+func logBodySize(log *RequestLog) int64 {
+	return int64(len(log.RequestBody) + len(log.ResponseBody) +
+		len(log.OriginalRequestBody) + len(log.OriginalResponseBody) +
+		len(log.FinalRequestBody) + len(log.FinalResponseBody))
+}
+
+// dropLogBodies 清空日志中的所有正文
+func dropLogBodies(log *RequestLog) {
+	log.RequestBody, log.ResponseBody = "", ""
+	log.OriginalRequestBody, log.OriginalResponseBody = "", ""
+	log.FinalRequestBody, log.FinalResponseBody = "", ""
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// recordingStorage 记录每次批量写入，写入前可被阻塞
+type recordingStorage struct {
+	StorageInterface
+
+	mu      sync.Mutex
+	batches [][]*RequestLog
+	gate    chan struct{}
+	err     error
+}
+
+func (r *recordingStorage) SaveLogs(logs []*RequestLog) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]*RequestLog(nil), logs...))
+	return nil
+}
+
+func (r *recordingStorage) Close() error {
+	return nil
+}
+
+func (r *recordingStorage) saved() []*RequestLog {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logs []*RequestLog
+	for _, batch := range r.batches {
+		logs = append(logs, batch...)
+	}
+	return logs
+}
+
+func TestAsyncStorageBatchesWrites(t *testing.T) {
+	backend := &recordingStorage{}
+	writer := newAsyncStorage(backend, appconfig.LogWriterConfig{BatchSize: 4, FlushInterval: "1h"}, logrus.New(), nil)
+
+	for i := 0; i < 10; i++ {
+		writer.SaveLog(&RequestLog{RequestID: "req", AttemptNumber: i})
+	}
+	writer.Flush()
+
+	saved := backend.saved()
+	if len(saved) != 10 {
+		t.Fatalf("Expected 10 logs after flush, got %d", len(saved))
+	}
+	if len(backend.batches) != 3 || len(backend.batches[0]) != 4 {
+		t.Errorf("Expected batches of at most 4 logs, got %d batches", len(backend.batches))
+	}
+	for i, log := range saved {
+		if log.AttemptNumber != i {
+			t.Errorf("Expected logs in submission order, got attempt %d at %d", log.AttemptNumber, i)
+		}
+	}
+	if stats := writer.Stats(); stats.Written != 10 || stats.QueueDepth != 0 || stats.QueuedBytes != 0 {
+		t.Errorf("Unexpected writer stats: %+v", stats)
+	}
+}
+
+func TestAsyncStorageDropsBodiesWhenFull(t *testing.T) {
+	backend := &recordingStorage{gate: make(chan struct{})}
+	writer := newAsyncStorage(backend, appconfig.LogWriterConfig{QueueSize: 2, BatchSize: 1, FlushInterval: "1h"}, logrus.New(), nil)
+
+	body := strings.Repeat("x", 100)
+	// 第一条被后台协程取走并阻塞在写入中，随后两条填满队列
+	writer.SaveLog(&RequestLog{RequestID: "req-0", RequestBody: body})
+	for writer.Stats().QueueDepth != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	writer.SaveLog(&RequestLog{RequestID: "req-1", RequestBody: body})
+	writer.SaveLog(&RequestLog{RequestID: "req-2", RequestBody: body})
+
+	// 队列已满，正文被丢弃，元数据等待队列空出后写入
+	done := make(chan struct{})
+	go func() {
+		writer.SaveLog(&RequestLog{RequestID: "req-3", RequestBody: body})
+		close(done)
+	}()
+	for writer.Stats().Blocked == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.gate)
+	<-done
+	writer.Close()
+
+	saved := backend.saved()
+	if len(saved) != 4 {
+		t.Fatalf("Expected all 4 logs to be written, got %d", len(saved))
+	}
+	if saved[3].RequestID != "req-3" || saved[3].RequestBody != "" {
+		t.Errorf("Expected req-3 to be written without body, got %s with %d bytes", saved[3].RequestID, len(saved[3].RequestBody))
+	}
+	if saved[1].RequestBody != body {
+		t.Error("Expected bodies to be kept while the queue has room")
+	}
+	if stats := writer.Stats(); stats.BodiesDropped != 1 || stats.Blocked != 1 {
+		t.Errorf("Unexpected writer stats: %+v", stats)
+	}
+}
+
+func TestAsyncStorageCloseDrainsQueue(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	writer := newAsyncStorage(storage, appconfig.LogWriterConfig{FlushInterval: "1h"}, logrus.New(), nil)
+
+	for i := 0; i < 25; i++ {
+		writer.SaveLog(&RequestLog{Timestamp: time.Now(), RequestID: "req", StatusCode: 200, RequestBody: "hello"})
+	}
+	writer.Flush()
+	if _, total, err := writer.GetLogs(100, 0, false); err != nil || total != 25 {
+		t.Errorf("Expected 25 logs after flush, got %d (err %v)", total, err)
+	}
+
+	writer.SaveLog(&RequestLog{Timestamp: time.Now(), RequestID: "last"})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	writer.SaveLog(&RequestLog{RequestID: "after-close"})
+	if stats := writer.Stats(); stats.Written != 26 || stats.Dropped != 1 {
+		t.Errorf("Unexpected writer stats: %+v", stats)
+	}
+}
+
+func TestAsyncStorageReportsWriteErrorsThroughLogger(t *testing.T) {
+	backend := &recordingStorage{err: errors.New("disk full")}
+	log, hook := logtest.NewNullLogger()
+	writer := newAsyncStorage(backend, appconfig.LogWriterConfig{BatchSize: 2, FlushInterval: "1h"}, log, nil)
+
+	writer.SaveLog(&RequestLog{RequestID: "req-0"})
+	writer.SaveLog(&RequestLog{RequestID: "req-1"})
+	writer.Close()
+
+	// 整批失败后逐条重试，每条失败各报告一次
+	if stats := writer.Stats(); stats.WriteErrors != 2 || stats.Written != 0 {
+		t.Errorf("Unexpected writer stats: %+v", stats)
+	}
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 logged write errors, got %d", len(entries))
+	}
+	if entries[0].Level != logrus.ErrorLevel || entries[0].Data[logrus.ErrorKey] != backend.err {
+		t.Errorf("Expected write error to be logged with its cause, got %v", entries[0].Data)
+	}
+}
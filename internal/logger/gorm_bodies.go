@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// bodyEncodingGzip 正文压缩格式
+const bodyEncodingGzip = "gzip"
+
+// GormLogBody 内容寻址的请求/响应体存储
+// Claude Code 每轮都会重发完整对话，相同内容只保存一份压缩数据，由多条日志（以及同一条日志的原始/最终版本）共享
+type GormLogBody struct {
+	Hash      string    `gorm:"column:hash;primaryKey;size:64"`
+	Encoding  string    `gorm:"column:encoding;size:16;not null"`
+	Size      int       `gorm:"column:size;default:0"` // 解压后的字节数
+	Data      []byte    `gorm:"column:data;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+
+	// 解压结果缓存，同一批查询中共享同一内容的多条日志只解压一次
+	decodeOnce sync.Once
+	decoded    string
+	decodeErr  error
+}
+
+// TableName 指定表名
+func (*GormLogBody) TableName() string {
+	return "log_bodies"
+}
+
+// text 返回解压后的正文
+func (b *GormLogBody) text() (string, error) {
+	b.decodeOnce.Do(func() {
+		b.decoded, b.decodeErr = decompressBody(b.Encoding, b.Data)
+	})
+	return b.decoded, b.decodeErr
+}
+
+// bodyField 日志行中的一个正文字段及其外置引用
+type bodyField struct {
+	column string
+	text   *string
+	ref    *string
+}
+
+// bodyFields 返回日志行中所有可以外置的正文字段
+func (l *GormRequestLog) bodyFields() []bodyField {
+	return []bodyField{
+		{"request_body", &l.RequestBody, &l.RequestBodyRef},
+		{"response_body", &l.ResponseBody, &l.ResponseBodyRef},
+		{"original_request_body", &l.OriginalRequestBody, &l.OriginalRequestBodyRef},
+		{"original_response_body", &l.OriginalResponseBody, &l.OriginalResponseBodyRef},
+		{"final_request_body", &l.FinalRequestBody, &l.FinalRequestBodyRef},
+		{"final_response_body", &l.FinalResponseBody, &l.FinalResponseBodyRef},
+	}
+}
+
+// resolveBody 返回正文内容：未外置时直接返回行内文本，否则从已加载的 Blobs 中解压
+func (l *GormRequestLog) resolveBody(text, ref string) string {
+	if ref == "" {
+		return text
+	}
+	blob := l.Blobs[ref]
+	if blob == nil {
+		return fmt.Sprintf("[body %s not loaded]", ref)
+	}
+	decoded, err := blob.text()
+	if err != nil {
+		return fmt.Sprintf("[body %s unreadable: %v]", ref, err)
+	}
+	return decoded
+}
+
+// externalizeBodies 将超过阈值的正文移出日志行，返回需要写入 log_bodies 的内容
+// 同一行中相同的正文（如原始请求体和最终请求体一致时）只产生一份数据
+func externalizeBodies(log *GormRequestLog) ([]*GormLogBody, error) {
+	threshold := appconfig.Default.Database.BodyInlineThreshold
+	var blobs []*GormLogBody
+	seen := make(map[string]bool)
+
+	for _, field := range log.bodyFields() {
+		if *field.ref != "" || len(*field.text) < threshold {
+			continue
+		}
+
+		hash := bodyHash(*field.text)
+		if !seen[hash] {
+			data, err := compressBody(*field.text)
+			if err != nil {
+				return nil, err
+			}
+			blob := &GormLogBody{
+				Hash:     hash,
+				Encoding: bodyEncodingGzip,
+				Size:     len(*field.text),
+				Data:     data,
+			}
+			// 全文索引需要明文，直接缓存，避免再解压一次
+			text := *field.text
+			blob.decodeOnce.Do(func() { blob.decoded = text })
+			blobs = append(blobs, blob)
+			seen[hash] = true
+		}
+
+		*field.ref = hash
+		*field.text = ""
+	}
+	return blobs, nil
+}
+
+// saveBodies 写入外置正文，已存在的内容直接复用
+func saveBodies(tx *gorm.DB, blobs []*GormLogBody) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blobs).Error
+}
+
+// loadBodies 批量加载日志行引用的外置正文
+func loadBodies(db *gorm.DB, logs []GormRequestLog) error {
+	var hashes []string
+	seen := make(map[string]bool)
+	for i := range logs {
+		for _, field := range logs[i].bodyFields() {
+			if ref := *field.ref; ref != "" && !seen[ref] {
+				seen[ref] = true
+				hashes = append(hashes, ref)
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	var blobs []*GormLogBody
+	if err := db.Where("hash IN ?", hashes).Find(&blobs).Error; err != nil {
+		return fmt.Errorf("failed to load log bodies: %v", err)
+	}
+
+	byHash := make(map[string]*GormLogBody, len(blobs))
+	for _, blob := range blobs {
+		byHash[blob.Hash] = blob
+	}
+	for i := range logs {
+		logs[i].Blobs = byHash
+	}
+	return nil
+}
+
+// deleteOrphanBodies 删除不再被任何日志引用的外置正文
+func deleteOrphanBodies(db *gorm.DB) (int64, error) {
+	result := db.Exec(`DELETE FROM log_bodies WHERE hash NOT IN (
+		SELECT request_body_ref FROM request_logs WHERE request_body_ref != ''
+		UNION SELECT response_body_ref FROM request_logs WHERE response_body_ref != ''
+		UNION SELECT original_request_body_ref FROM request_logs WHERE original_request_body_ref != ''
+		UNION SELECT original_response_body_ref FROM request_logs WHERE original_response_body_ref != ''
+		UNION SELECT final_request_body_ref FROM request_logs WHERE final_request_body_ref != ''
+		UNION SELECT final_response_body_ref FROM request_logs WHERE final_response_body_ref != ''
+	)`)
+	return result.RowsAffected, result.Error
+}
+
+func bodyHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func compressBody(text string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return nil, fmt.Errorf("failed to compress log body: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress log body: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressBody(encoding string, data []byte) (string, error) {
+	if encoding != bodyEncodingGzip {
+		return "", fmt.Errorf("unsupported body encoding %q", encoding)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogBodiesAreDeduplicatedAndCompressed(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	requestBody := `{"messages":[{"role":"user","content":"` + strings.Repeat("refactor the parser ", 200) + `"}]}`
+	for i, endpoint := range []string{"ep-a", "ep-b"} {
+		storage.SaveLog(&RequestLog{
+			Timestamp:           time.Now(),
+			RequestID:           "req-1",
+			Endpoint:            endpoint,
+			AttemptNumber:       i + 1,
+			RequestBody:         requestBody,
+			OriginalRequestBody: requestBody,
+			FinalRequestBody:    requestBody,
+			ResponseBody:        `{"error":"overloaded"}`,
+		})
+	}
+
+	var bodies []GormLogBody
+	if err := storage.db.Find(&bodies).Error; err != nil {
+		t.Fatalf("Failed to query log bodies: %v", err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("Expected identical request bodies to share one blob, got %d", len(bodies))
+	}
+	if len(bodies[0].Data) >= len(requestBody) {
+		t.Errorf("Expected compressed blob to be smaller than %d bytes, got %d", len(requestBody), len(bodies[0].Data))
+	}
+
+	var inline GormRequestLog
+	storage.db.First(&inline)
+	if inline.RequestBody != "" || inline.RequestBodyRef != bodies[0].Hash || inline.ResponseBody == "" {
+		t.Errorf("Expected large bodies to be stored by reference and small ones inline, got %+v", inline)
+	}
+
+	logs, err := storage.GetAllLogsByRequestID("req-1")
+	if err != nil {
+		t.Fatalf("GetAllLogsByRequestID failed: %v", err)
+	}
+	for _, log := range logs {
+		if log.RequestBody != requestBody || log.OriginalRequestBody != requestBody || log.FinalRequestBody != requestBody {
+			t.Errorf("Expected bodies to be decompressed transparently")
+		}
+	}
+
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, Text: "refactor the parser"}); total != 2 {
+		t.Errorf("Expected full-text search to match externalized bodies, got %d", total)
+	}
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, Text: "overloaded"}); total != 2 {
+		t.Errorf("Expected full-text search to match inline bodies, got %d", total)
+	}
+	// 两条日志共享的正文只索引一次
+	var indexedBodies int64
+	storage.db.Raw("SELECT COUNT(*) FROM log_body_fts_ids").Scan(&indexedBodies)
+	if indexedBodies != 1 {
+		t.Errorf("Expected the shared body to be indexed once, got %d", indexedBodies)
+	}
+
+	if _, err := storage.CleanupLogsByDays(0); err != nil {
+		t.Fatalf("CleanupLogsByDays failed: %v", err)
+	}
+	var remaining int64
+	storage.db.Model(&GormLogBody{}).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected orphan bodies to be removed, %d left", remaining)
+	}
+}
+
+func TestMigrateInlineBodies(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+	storage.maintenance.Wait()
+
+	// 模拟迁移前写入的行内正文
+	largeBody := strings.Repeat("x", 4096)
+	legacy := &GormRequestLog{
+		Timestamp:           time.Now(),
+		RequestID:           "legacy",
+		Endpoint:            "ep-a",
+		Method:              "POST",
+		Path:                "/v1/messages",
+		RequestBody:         largeBody,
+		OriginalRequestBody: largeBody,
+		ResponseBody:        "ok",
+	}
+	if err := storage.db.Create(legacy).Error; err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	storage.db.Where("key = ?", bodyStoreVersionKey).Delete(&GormStorageMeta{})
+
+	if migrated, err := migrateInlineBodies(storage.db, nil); err != nil || migrated != 1 {
+		t.Fatalf("migrateInlineBodies failed: migrated %d, %v", migrated, err)
+	}
+
+	var migrated GormRequestLog
+	storage.db.First(&migrated, legacy.ID)
+	if migrated.RequestBody != "" || migrated.RequestBodyRef == "" || migrated.OriginalRequestBodyRef != migrated.RequestBodyRef {
+		t.Errorf("Expected legacy bodies to be moved to blob storage, got %+v", migrated)
+	}
+	if migrated.ResponseBody != "ok" {
+		t.Errorf("Expected small body to stay inline, got %q", migrated.ResponseBody)
+	}
+
+	logs, err := storage.GetAllLogsByRequestID("legacy")
+	if err != nil || len(logs) != 1 || logs[0].RequestBody != largeBody {
+		t.Errorf("Expected migrated body to be readable, err=%v", err)
+	}
+}
+
+func TestValidateTableCompatibilityAddsBodyRefColumns(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	// 模拟迁移中途的旧表：original_response_body_ref 已由 ALTER TABLE 添加，response_body_ref 尚未添加
+	for _, sql := range []string{
+		"ALTER TABLE request_logs DROP COLUMN response_body_ref",
+		"ALTER TABLE request_logs DROP COLUMN original_response_body_ref",
+		"ALTER TABLE request_logs ADD COLUMN original_response_body_ref VARCHAR(64) DEFAULT ''",
+	} {
+		if err := storage.db.Exec(sql).Error; err != nil {
+			t.Fatalf("Failed to prepare legacy table: %v", err)
+		}
+	}
+	if err := validateTableCompatibility(storage.db); err != nil {
+		t.Fatalf("validateTableCompatibility failed: %v", err)
+	}
+
+	columnTypes, err := storage.db.Migrator().ColumnTypes(&GormRequestLog{})
+	if err != nil {
+		t.Fatalf("Failed to read columns: %v", err)
+	}
+	found := false
+	for _, columnType := range columnTypes {
+		if columnType.Name() == "response_body_ref" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected response_body_ref column to be added back")
+	}
+}
+
+func TestBackgroundMaintenanceMigratesAndIndexesLegacyLogs(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewGORMStorage(dir, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	storage.maintenance.Wait()
+	// 模拟旧版本的数据库：行内存储的大正文，没有迁移和索引版本标记
+	largeBody := strings.Repeat("kubernetes rollout ", 300)
+	legacy := &GormRequestLog{Timestamp: time.Now(), RequestID: "legacy", Endpoint: "ep-a", Method: "POST", Path: "/v1/messages", RequestBody: largeBody}
+	if err := storage.db.Create(legacy).Error; err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	storage.db.Where("key IN ?", []string{bodyStoreVersionKey, ftsVersionKey}).Delete(&GormStorageMeta{})
+	storage.Close()
+
+	storage, err = NewGORMStorage(dir, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer storage.Close()
+	storage.maintenance.Wait()
+
+	if !storage.ftsEnabled.Load() {
+		t.Fatal("Expected full-text search to be enabled after the background rebuild")
+	}
+	var migrated GormRequestLog
+	storage.db.First(&migrated, legacy.ID)
+	if migrated.RequestBody != "" || migrated.RequestBodyRef == "" {
+		t.Errorf("Expected the legacy body to be moved to blob storage, got ref %q", migrated.RequestBodyRef)
+	}
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, Text: "kubernetes rollout"}); total != 1 {
+		t.Errorf("Expected the rebuilt index to match the migrated body, got %d", total)
+	}
+	var indexedRows int64
+	storage.db.Raw("SELECT COUNT(*) FROM request_logs_fts WHERE request_logs_fts MATCH ?", ftsPhrase("kubernetes rollout")).Scan(&indexedRows)
+	if indexedRows != 0 {
+		t.Errorf("Expected externalized bodies to be indexed by hash only, got %d row entries", indexedRows)
+	}
+}
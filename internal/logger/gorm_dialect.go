@@ -0,0 +1,86 @@
+package logger
+
+import "fmt"
+
+// GORM 存储支持的数据库类型
+const (
+	dialectSQLite   = "sqlite"
+	dialectPostgres = "postgres"
+)
+
+// groupConcatDistinct 返回将列的不同取值以逗号拼接的聚合表达式
+func (g *GORMStorage) groupConcatDistinct(column string) string {
+	if g.dialect == dialectPostgres {
+		return fmt.Sprintf("STRING_AGG(DISTINCT %s, ',')", column)
+	}
+	return fmt.Sprintf("GROUP_CONCAT(DISTINCT %s)", column)
+}
+
+// tagCondition 返回 tags（JSON 数组文本）包含指定 tag 的查询条件
+func (g *GORMStorage) tagCondition() string {
+	if g.dialect == dialectPostgres {
+		return "EXISTS (SELECT 1 FROM json_array_elements_text(request_logs.tags::json) AS t(value) WHERE t.value = ?)"
+	}
+	return "EXISTS (SELECT 1 FROM json_each(request_logs.tags) WHERE json_each.value = ?)"
+}
+
+// databaseSize 返回数据库文件（或日志相关表）占用的字节数
+func (g *GORMStorage) databaseSize() (int64, error) {
+	if g.dialect == dialectPostgres {
+		var size int64
+		err := g.db.Raw("SELECT pg_total_relation_size('request_logs') + pg_total_relation_size('log_bodies')").Scan(&size).Error
+		return size, err
+	}
+
+	var pageCount, pageSize int64
+	if err := g.db.Raw("PRAGMA page_count").Scan(&pageCount).Error; err != nil {
+		return 0, err
+	}
+	if err := g.db.Raw("PRAGMA page_size").Scan(&pageSize).Error; err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
+
+// usedDatabaseSize 返回数据实际占用的字节数（SQLite 不含空闲页）
+// PostgreSQL 删除后表文件不会缩小，因此统计现存行的大小
+func (g *GORMStorage) usedDatabaseSize() (int64, error) {
+	if g.dialect == dialectPostgres {
+		var size int64
+		err := g.db.Raw(`SELECT COALESCE((SELECT SUM(pg_column_size(r.*)) FROM request_logs r), 0)
+			+ COALESCE((SELECT SUM(pg_column_size(b.*)) FROM log_bodies b), 0)`).Scan(&size).Error
+		return size, err
+	}
+
+	size, err := g.databaseSize()
+	if err != nil {
+		return 0, err
+	}
+	var freelistCount, pageSize int64
+	if err := g.db.Raw("PRAGMA freelist_count").Scan(&freelistCount).Error; err != nil {
+		return 0, err
+	}
+	if err := g.db.Raw("PRAGMA page_size").Scan(&pageSize).Error; err != nil {
+		return 0, err
+	}
+	return size - freelistCount*pageSize, nil
+}
+
+// reclaimSpace 在删除日志后回收空间
+func (g *GORMStorage) reclaimSpace() {
+	if g.dialect == dialectPostgres {
+		// 普通 VACUUM 不锁表，释放的空间供后续写入复用
+		if err := g.db.Exec("VACUUM request_logs, log_bodies").Error; err != nil {
+			g.logger.WithError(err).Error("Failed to vacuum database")
+		}
+		return
+	}
+
+	// 增量 VACUUM 只归还空闲页，不会像完整 VACUUM 那样重写整个数据库
+	if err := g.db.Exec("PRAGMA incremental_vacuum").Error; err != nil {
+		g.logger.WithError(err).Error("Failed to run incremental vacuum")
+	}
+	if err := g.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+		g.logger.WithError(err).Error("Failed to checkpoint WAL")
+	}
+}
@@ -1,9 +1,12 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"gorm.io/gorm"
+	
+	appconfig "claude-code-companion/internal/config"
 )
 
 // createOptimizedIndexes 创建基于现有查询模式的优化索引
@@ -29,6 +32,19 @@ func createOptimizedIndexes(db *gorm.DB) error {
 		// 失败日志查询优化
 		"CREATE INDEX IF NOT EXISTS idx_request_logs_status_code_time ON request_logs(status_code, timestamp DESC)",
 		
+		// 基于项目的查询优化
+		"CREATE INDEX IF NOT EXISTS idx_request_logs_project_time ON request_logs(project, timestamp DESC)",
+		
+		// 结构化查询优化：端点+状态码（如"某端点昨天的5xx"）
+		"CREATE INDEX IF NOT EXISTS idx_request_logs_endpoint_status_time ON request_logs(endpoint, status_code, timestamp DESC)",
+		
+		// 基于会话和原始模型的查询优化
+		"CREATE INDEX IF NOT EXISTS idx_request_logs_session_time ON request_logs(session_id, timestamp DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_request_logs_original_model_time ON request_logs(original_model, timestamp DESC)",
+		
+		// 耗时范围查询优化
+		"CREATE INDEX IF NOT EXISTS idx_request_logs_duration ON request_logs(duration_ms)",
+		
 		// 错误字段索引
 		"CREATE INDEX IF NOT EXISTS idx_request_logs_error_time ON request_logs(timestamp DESC) WHERE error != ''",
 	}
@@ -72,10 +88,34 @@ func validateTableCompatibility(db *gorm.DB) error {
 		"blacklist_causing_request_ids": "blacklist_causing_request_ids TEXT DEFAULT '[]'",
 		"endpoint_blacklisted_at": "endpoint_blacklisted_at DATETIME",
 		"endpoint_blacklist_reason": "endpoint_blacklist_reason TEXT DEFAULT ''",
+		"project": "project VARCHAR(200) DEFAULT ''",
+		"fallback_chain": "fallback_chain VARCHAR(100) DEFAULT ''",
+		"fallback_level": "fallback_level INTEGER DEFAULT 0",
+		"input_tokens": "input_tokens INTEGER DEFAULT 0",
+		"output_tokens": "output_tokens INTEGER DEFAULT 0",
+		"cache_creation_input_tokens": "cache_creation_input_tokens INTEGER DEFAULT 0",
+		"cache_read_input_tokens": "cache_read_input_tokens INTEGER DEFAULT 0",
+		"request_body_ref": "request_body_ref VARCHAR(64) DEFAULT ''",
+		"response_body_ref": "response_body_ref VARCHAR(64) DEFAULT ''",
+		"original_request_body_ref": "original_request_body_ref VARCHAR(64) DEFAULT ''",
+		"original_response_body_ref": "original_response_body_ref VARCHAR(64) DEFAULT ''",
+		"final_request_body_ref": "final_request_body_ref VARCHAR(64) DEFAULT ''",
+		"final_response_body_ref": "final_response_body_ref VARCHAR(64) DEFAULT ''",
 	}
 	
+	// SQLite 的 HasColumn 按 SQL 文本模糊匹配，response_body_ref 会被 original_response_body_ref 误判为已存在，
+	// 因此按实际列名精确比较
+	columnTypes, err := db.Migrator().ColumnTypes(&GormRequestLog{})
+	if err != nil {
+		return fmt.Errorf("failed to read request_logs columns: %v", err)
+	}
+	existingColumns := make(map[string]bool, len(columnTypes))
+	for _, columnType := range columnTypes {
+		existingColumns[columnType.Name()] = true
+	}
+
 	for column, definition := range optionalColumns {
-		if !db.Migrator().HasColumn(&GormRequestLog{}, column) {
+		if !existingColumns[column] {
 			// 添加缺失的列
 			sql := fmt.Sprintf("ALTER TABLE request_logs ADD COLUMN %s", definition)
 			if err := db.Exec(sql).Error; err != nil {
@@ -86,4 +126,115 @@ func validateTableCompatibility(db *gorm.DB) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// GormStorageMeta 日志存储的元数据（记录一次性迁移的完成状态等）
+type GormStorageMeta struct {
+	Key   string `gorm:"column:key;primaryKey;size:100"`
+	Value string `gorm:"column:value;type:text"`
+}
+
+// TableName 指定表名
+func (GormStorageMeta) TableName() string {
+	return "log_storage_meta"
+}
+
+// bodyStoreVersionKey 正文外置存储迁移完成标记
+const bodyStoreVersionKey = "body_store_version"
+
+// errMaintenanceStopped 存储关闭时中止后台迁移，未完成的迁移在下次启动时继续
+var errMaintenanceStopped = errors.New("maintenance stopped")
+
+// maintenanceStopped 判断后台迁移是否需要中止
+func maintenanceStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// migrateInlineBodies 一次性将旧日志中的行内正文迁移到 log_bodies 表，返回迁移的日志条数
+// 在后台按ID分批处理，每批一个事务，批次之间让出连接给日志写入；stop 关闭时中止，重启后从头扫描，已迁移的行不会重复处理
+func migrateInlineBodies(db *gorm.DB, stop <-chan struct{}) (int, error) {
+	var meta GormStorageMeta
+	if err := db.Where("key = ?", bodyStoreVersionKey).Limit(1).Find(&meta).Error; err != nil {
+		return 0, err
+	}
+	if meta.Value == "1" {
+		return 0, nil
+	}
+
+	batch := appconfig.Default.Database.BodyMigrationBatch
+	migrated := 0
+	var lastID uint
+	for {
+		if maintenanceStopped(stop) {
+			return migrated, errMaintenanceStopped
+		}
+		var rows []GormRequestLog
+		if err := db.Where("id > ?", lastID).Order("id ASC").Limit(batch).Find(&rows).Error; err != nil {
+			return migrated, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i := range rows {
+				row := &rows[i]
+				blobs, err := externalizeBodies(row)
+				if err != nil {
+					return err
+				}
+				if len(blobs) == 0 {
+					continue
+				}
+				if err := saveBodies(tx, blobs); err != nil {
+					return err
+				}
+
+				updates := make(map[string]interface{})
+				for _, field := range row.bodyFields() {
+					if *field.ref != "" {
+						updates[field.column] = *field.text
+						updates[field.column+"_ref"] = *field.ref
+					}
+				}
+				if err := tx.Model(&GormRequestLog{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+				migrated++
+			}
+			return nil
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate log bodies: %v", err)
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	return migrated, db.Save(&GormStorageMeta{Key: bodyStoreVersionKey, Value: "1"}).Error
+}
+
+// enableIncrementalVacuum 将已有数据库切换为增量 auto_vacuum 模式，返回是否执行了切换
+// 已经是增量模式（包括建表前设置了模式的新数据库）时直接返回；否则需要执行一次完整 VACUUM 才能切换
+func enableIncrementalVacuum(db *gorm.DB) (bool, error) {
+	var mode int
+	if err := db.Raw("PRAGMA auto_vacuum").Scan(&mode).Error; err != nil {
+		return false, err
+	}
+	// 0 = NONE, 1 = FULL, 2 = INCREMENTAL
+	if mode == 2 {
+		return false, nil
+	}
+
+	if err := db.Exec("PRAGMA auto_vacuum = INCREMENTAL").Error; err != nil {
+		return false, err
+	}
+	if err := db.Exec("VACUUM").Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -35,6 +35,16 @@ type GormRequestLog struct {
 	Tags                 string `gorm:"column:tags;type:text;default:'[]'"` // JSON array
 	ContentTypeOverride  string `gorm:"column:content_type_override;size:100;default:''"`
 	SessionID            string `gorm:"column:session_id;size:100;default:''"`
+	Project              string `gorm:"column:project;size:200;default:''"`
+	FallbackChain        string `gorm:"column:fallback_chain;size:100;default:''"`
+	FallbackLevel        int    `gorm:"column:fallback_level;default:0"`
+	Credential           string `gorm:"column:credential;size:100;default:''"`
+	
+	// Token 用量字段
+	InputTokens              int `gorm:"column:input_tokens;default:0"`
+	OutputTokens             int `gorm:"column:output_tokens;default:0"`
+	CacheCreationInputTokens int `gorm:"column:cache_creation_input_tokens;default:0"`
+	CacheReadInputTokens     int `gorm:"column:cache_read_input_tokens;default:0"`
 	
 	// 模型重写字段
 	OriginalModel       string `gorm:"column:original_model;size:100;default:''"`
@@ -64,8 +74,19 @@ type GormRequestLog struct {
 	EndpointBlacklistedAt      *time.Time `gorm:"column:endpoint_blacklisted_at"`
 	EndpointBlacklistReason    string     `gorm:"column:endpoint_blacklist_reason;type:text;default:''"`
 	
+	// 外置请求/响应体的内容哈希（对应 log_bodies 表），非空时对应的正文字段为空
+	RequestBodyRef          string `gorm:"column:request_body_ref;size:64;default:''"`
+	ResponseBodyRef         string `gorm:"column:response_body_ref;size:64;default:''"`
+	OriginalRequestBodyRef  string `gorm:"column:original_request_body_ref;size:64;default:''"`
+	OriginalResponseBodyRef string `gorm:"column:original_response_body_ref;size:64;default:''"`
+	FinalRequestBodyRef     string `gorm:"column:final_request_body_ref;size:64;default:''"`
+	FinalResponseBodyRef    string `gorm:"column:final_response_body_ref;size:64;default:''"`
+	
 	// 创建时间（现有字段）
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	
+	// 查询时批量加载的外置正文，按哈希索引
+	Blobs map[string]*GormLogBody `gorm:"-"`
 }
 
 // 指定表名，与现有数据库表完全一致
@@ -93,6 +114,14 @@ func ConvertToGormRequestLog(log *RequestLog) *GormRequestLog {
 		Error:                   log.Error,
 		ContentTypeOverride:     log.ContentTypeOverride,
 		SessionID:               log.SessionID,
+		Project:                 log.Project,
+		FallbackChain:           log.FallbackChain,
+		FallbackLevel:           log.FallbackLevel,
+		Credential:              log.Credential,
+		InputTokens:             log.InputTokens,
+		OutputTokens:            log.OutputTokens,
+		CacheCreationInputTokens: log.CacheCreationInputTokens,
+		CacheReadInputTokens:    log.CacheReadInputTokens,
 		OriginalModel:           log.OriginalModel,
 		RewrittenModel:          log.RewrittenModel,
 		ModelRewriteApplied:     log.ModelRewriteApplied,
@@ -132,26 +161,34 @@ func ConvertFromGormRequestLog(gormLog *GormRequestLog) *RequestLog {
 		StatusCode:              gormLog.StatusCode,
 		DurationMs:              gormLog.DurationMs,
 		AttemptNumber:           gormLog.AttemptNumber,
-		RequestBody:             gormLog.RequestBody,
+		RequestBody:             gormLog.resolveBody(gormLog.RequestBody, gormLog.RequestBodyRef),
 		RequestBodySize:         gormLog.RequestBodySize,
-		ResponseBody:            gormLog.ResponseBody,
+		ResponseBody:            gormLog.resolveBody(gormLog.ResponseBody, gormLog.ResponseBodyRef),
 		ResponseBodySize:        gormLog.ResponseBodySize,
 		IsStreaming:             gormLog.IsStreaming,
 		Model:                   gormLog.Model,
 		Error:                   gormLog.Error,
 		ContentTypeOverride:     gormLog.ContentTypeOverride,
 		SessionID:               gormLog.SessionID,
+		Project:                 gormLog.Project,
+		FallbackChain:           gormLog.FallbackChain,
+		FallbackLevel:           gormLog.FallbackLevel,
+		Credential:              gormLog.Credential,
+		InputTokens:             gormLog.InputTokens,
+		OutputTokens:            gormLog.OutputTokens,
+		CacheCreationInputTokens: gormLog.CacheCreationInputTokens,
+		CacheReadInputTokens:    gormLog.CacheReadInputTokens,
 		OriginalModel:           gormLog.OriginalModel,
 		RewrittenModel:          gormLog.RewrittenModel,
 		ModelRewriteApplied:     gormLog.ModelRewriteApplied,
 		ThinkingEnabled:         gormLog.ThinkingEnabled,
 		ThinkingBudgetTokens:    gormLog.ThinkingBudgetTokens,
 		OriginalRequestURL:      gormLog.OriginalRequestURL,
-		OriginalRequestBody:     gormLog.OriginalRequestBody,
-		OriginalResponseBody:    gormLog.OriginalResponseBody,
+		OriginalRequestBody:     gormLog.resolveBody(gormLog.OriginalRequestBody, gormLog.OriginalRequestBodyRef),
+		OriginalResponseBody:    gormLog.resolveBody(gormLog.OriginalResponseBody, gormLog.OriginalResponseBodyRef),
 		FinalRequestURL:         gormLog.FinalRequestURL,
-		FinalRequestBody:        gormLog.FinalRequestBody,
-		FinalResponseBody:       gormLog.FinalResponseBody,
+		FinalRequestBody:        gormLog.resolveBody(gormLog.FinalRequestBody, gormLog.FinalRequestBodyRef),
+		FinalResponseBody:       gormLog.resolveBody(gormLog.FinalResponseBody, gormLog.FinalResponseBodyRef),
 		BlacklistCausingRequestIDs: unmarshalTagsFromJSON(gormLog.BlacklistCausingRequestIDs),
 		EndpointBlacklistedAt:   gormLog.EndpointBlacklistedAt,
 		EndpointBlacklistReason: gormLog.EndpointBlacklistReason,
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerStorageBackend(dialectPostgres, func(config LogConfig, log *logrus.Logger) (StorageInterface, error) {
+		return NewPostgresStorage(config.Storage.DSN, log)
+	})
+}
+
+// NewPostgresStorage 创建基于 PostgreSQL 的日志存储，多个实例可以共享同一个数据库
+func NewPostgresStorage(dsn string, log *logrus.Logger) (*GORMStorage, error) {
+	config := DefaultGORMConfig("")
+	config.MaxOpenConns = 10
+	config.MaxIdleConns = 5
+
+	db, err := gorm.Open(postgres.Open(dsn), newGormConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %v", err)
+	}
+	if err := configureConnectionPool(db, config); err != nil {
+		return nil, err
+	}
+
+	return initGORMStorage(db, config, dialectPostgres, log)
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	appconfig "claude-code-companion/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 需要本地 PostgreSQL，例如：
+//
+//	CCC_TEST_POSTGRES_DSN="host=localhost user=ccc password=ccc dbname=ccc_test sslmode=disable" go test ./internal/logger/
+//
+// 测试会清空该数据库中的日志表
+func newPostgresTestStorage(t *testing.T) *GORMStorage {
+	t.Helper()
+	dsn := os.Getenv("CCC_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CCC_TEST_POSTGRES_DSN not set, skipping PostgreSQL log storage test")
+	}
+
+	storage, err := NewPostgresStorage(dsn, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create PostgreSQL storage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	storage.maintenance.Wait()
+
+	for _, table := range []string{"request_logs", "log_bodies"} {
+		if err := storage.db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("Failed to clear %s: %v", table, err)
+		}
+	}
+	return storage
+}
+
+func TestPostgresStorage(t *testing.T) {
+	storage := newPostgresTestStorage(t)
+
+	now := time.Now()
+	largeBody := strings.Repeat("postgres body ", 100)
+	entries := []*RequestLog{
+		{Timestamp: now.Add(-20 * 24 * time.Hour), RequestID: "old", SessionID: "s1", Project: "alpha", StatusCode: 200, RequestBody: largeBody, InputTokens: 10},
+		{Timestamp: now.Add(-time.Minute), RequestID: "r1", SessionID: "s1", Project: "alpha", StatusCode: 200, RequestBody: largeBody, InputTokens: 100, OutputTokens: 20},
+		{Timestamp: now, RequestID: "r2", SessionID: "s2", Project: "beta", StatusCode: 502, Error: "upstream failed", InputTokens: 5},
+	}
+	for _, entry := range entries {
+		storage.SaveLog(entry)
+	}
+
+	logs, total, err := storage.QueryLogs(LogQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("QueryLogs failed: %v", err)
+	}
+	if total != 3 || logs[0].RequestID != "r2" {
+		t.Fatalf("Expected 3 logs newest first, got %d", total)
+	}
+	if logs[1].RequestBody != largeBody {
+		t.Errorf("Expected externalized body to be restored, got %d bytes", len(logs[1].RequestBody))
+	}
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, FailedOnly: true}); total != 1 {
+		t.Errorf("Expected 1 failed log, got %d", total)
+	}
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, Text: "postgres body"}); total != 2 {
+		t.Errorf("Expected text search to match 2 logs, got %d", total)
+	}
+
+	sessions, total, err := storage.GetSessions(10, 0, "alpha")
+	if err != nil {
+		t.Fatalf("GetSessions failed: %v", err)
+	}
+	if total != 1 || len(sessions) != 1 || sessions[0].SessionID != "s1" {
+		t.Errorf("Expected only session s1 for project alpha, got %d", total)
+	}
+
+	stats, err := storage.GetTokenStats(LogQuery{})
+	if err != nil {
+		t.Fatalf("GetTokenStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Project != "alpha" || stats[0].RequestCount != 2 || stats[0].InputTokens != 110 {
+		t.Errorf("Unexpected token stats: %+v", stats[0])
+	}
+
+	result, err := storage.ApplyRetention(appconfig.LogRetentionConfig{MaxAgeDays: 10})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.DeletedByAge != 1 {
+		t.Errorf("Expected the old log to be deleted, got %+v", result)
+	}
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10}); total != 2 {
+		t.Errorf("Expected 2 logs after retention, got %d", total)
+	}
+}
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// retentionSizePasses 按体积清理时的最大轮数，避免页碎片导致体积下降缓慢时无限循环
+const retentionSizePasses = 10
+
+// failedLogCondition 失败请求的判定条件，与 FailedOnly 查询保持一致
+const failedLogCondition = "(status_code >= 400 OR error != '')"
+
+// RetentionResult 一次保留策略执行的结果
+type RetentionResult struct {
+	DeletedByAge    int64 `json:"deleted_by_age"`
+	DeletedByCount  int64 `json:"deleted_by_count"`
+	DeletedBySize   int64 `json:"deleted_by_size"`
+	TruncatedBodies int64 `json:"truncated_bodies"`
+	DeletedBlobs    int64 `json:"deleted_blobs"`
+}
+
+// Deleted 返回删除的日志总数
+func (r *RetentionResult) Deleted() int64 {
+	return r.DeletedByAge + r.DeletedByCount + r.DeletedBySize
+}
+
+// Changed 判断是否有数据被删除或截断
+func (r *RetentionResult) Changed() bool {
+	return r.Deleted() > 0 || r.TruncatedBodies > 0
+}
+
+// defaultRetentionPolicy 未配置保留策略时的默认行为：保留30天
+func defaultRetentionPolicy() appconfig.LogRetentionConfig {
+	return appconfig.LogRetentionConfig{
+		MaxAgeDays:      appconfig.Default.Logging.RetentionDays,
+		CleanupInterval: appconfig.Default.Logging.CleanupInterval,
+	}
+}
+
+// retentionInterval 解析清理间隔，无效时使用默认值
+func retentionInterval(policy appconfig.LogRetentionConfig) time.Duration {
+	if interval, err := time.ParseDuration(policy.CleanupInterval); err == nil && interval > 0 {
+		return interval
+	}
+	interval, _ := time.ParseDuration(appconfig.Default.Logging.CleanupInterval)
+	return interval
+}
+
+// SetRetentionPolicy 更新保留策略，后台清理程序在下一次执行时生效
+func (g *GORMStorage) SetRetentionPolicy(policy appconfig.LogRetentionConfig) {
+	g.retentionMu.Lock()
+	g.retention = policy
+	g.retentionMu.Unlock()
+
+	if g.cleanupTicker != nil {
+		g.cleanupTicker.Reset(retentionInterval(policy))
+	}
+}
+
+// retentionPolicy 返回当前保留策略
+func (g *GORMStorage) retentionPolicy() appconfig.LogRetentionConfig {
+	g.retentionMu.RLock()
+	defer g.retentionMu.RUnlock()
+	return g.retention
+}
+
+// ApplyRetention 按保留策略清理日志：
+// 1. 按时间删除（失败请求可以保留更久）
+// 2. 超过正文保留期的日志清空请求/响应体，只保留元数据
+// 3. 超过条数上限时删除最旧的日志
+// 4. 超过体积上限时继续删除最旧的日志
+// 最后清理无引用的外置正文并回收空间
+func (g *GORMStorage) ApplyRetention(policy appconfig.LogRetentionConfig) (*RetentionResult, error) {
+	result := &RetentionResult{}
+	now := time.Now()
+
+	successDays := policy.MaxAgeDays
+	failedDays := policy.FailedMaxAgeDays
+	if failedDays == 0 {
+		failedDays = successDays
+	}
+	if successDays > 0 {
+		res := g.db.Where("timestamp < ? AND NOT "+failedLogCondition, now.AddDate(0, 0, -successDays)).Delete(&GormRequestLog{})
+		if res.Error != nil {
+			return result, fmt.Errorf("failed to delete expired logs: %v", res.Error)
+		}
+		result.DeletedByAge += res.RowsAffected
+	}
+	if failedDays > 0 {
+		res := g.db.Where("timestamp < ? AND "+failedLogCondition, now.AddDate(0, 0, -failedDays)).Delete(&GormRequestLog{})
+		if res.Error != nil {
+			return result, fmt.Errorf("failed to delete expired failed logs: %v", res.Error)
+		}
+		result.DeletedByAge += res.RowsAffected
+	}
+
+	if policy.BodyMaxAgeDays > 0 {
+		truncated, err := g.truncateBodiesBefore(now.AddDate(0, 0, -policy.BodyMaxAgeDays))
+		if err != nil {
+			return result, err
+		}
+		result.TruncatedBodies = truncated
+	}
+
+	if policy.MaxRows > 0 {
+		var total int64
+		if err := g.db.Model(&GormRequestLog{}).Count(&total).Error; err != nil {
+			return result, fmt.Errorf("failed to count logs: %v", err)
+		}
+		if total > policy.MaxRows {
+			deleted, err := g.deleteOldestLogs(total - policy.MaxRows)
+			if err != nil {
+				return result, err
+			}
+			result.DeletedByCount = deleted
+		}
+	}
+
+	if result.Changed() {
+		deleted, err := deleteOrphanBodies(g.db)
+		if err != nil {
+			return result, fmt.Errorf("failed to cleanup log bodies: %v", err)
+		}
+		result.DeletedBlobs += deleted
+	}
+
+	if policy.MaxDatabaseSizeMB > 0 {
+		if err := g.enforceDatabaseSize(int64(policy.MaxDatabaseSizeMB)*1024*1024, result); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Changed() {
+		g.reclaimSpace()
+	}
+
+	return result, nil
+}
+
+// truncateBodiesBefore 清空指定时间之前日志的请求/响应体，同时移除其全文索引
+func (g *GORMStorage) truncateBodiesBefore(cutoff time.Time) (int64, error) {
+	columns := bodyColumns()
+	conditions := make([]string, len(columns))
+	updates := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = column + " != ''"
+		updates[column] = ""
+	}
+	where := "timestamp < ? AND (" + strings.Join(conditions, " OR ") + ")"
+
+	var truncated int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if g.ftsIndexing {
+			if err := tx.Exec("DELETE FROM request_logs_fts WHERE rowid IN (SELECT id FROM request_logs WHERE "+where+")", cutoff).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Model(&GormRequestLog{}).Where(where, cutoff).Updates(updates)
+		truncated = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to truncate log bodies: %v", err)
+	}
+	return truncated, nil
+}
+
+// deleteOldestLogs 删除最旧的 count 条日志
+func (g *GORMStorage) deleteOldestLogs(count int64) (int64, error) {
+	res := g.db.Exec("DELETE FROM request_logs WHERE id IN (SELECT id FROM request_logs ORDER BY id ASC LIMIT ?)", count)
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete oldest logs: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// enforceDatabaseSize 删除最旧的日志直到数据库实际占用（不含空闲页）低于上限
+func (g *GORMStorage) enforceDatabaseSize(limit int64, result *RetentionResult) error {
+	for pass := 0; pass < retentionSizePasses; pass++ {
+		used, err := g.usedDatabaseSize()
+		if err != nil {
+			return err
+		}
+		if used <= limit {
+			return nil
+		}
+
+		var total int64
+		if err := g.db.Model(&GormRequestLog{}).Count(&total).Error; err != nil {
+			return fmt.Errorf("failed to count logs: %v", err)
+		}
+		if total == 0 {
+			return nil
+		}
+
+		// 按超出比例估算需要删除的条数
+		count := int64(float64(total)*float64(used-limit)/float64(used)) + 1
+		deleted, err := g.deleteOldestLogs(count)
+		if err != nil {
+			return err
+		}
+		result.DeletedBySize += deleted
+
+		blobs, err := deleteOrphanBodies(g.db)
+		if err != nil {
+			return fmt.Errorf("failed to cleanup log bodies: %v", err)
+		}
+		result.DeletedBlobs += blobs
+	}
+	return nil
+}
+
+// bodyColumns 返回所有正文列及其外置引用列
+func bodyColumns() []string {
+	var columns []string
+	for _, field := range (&GormRequestLog{}).bodyFields() {
+		columns = append(columns, field.column, field.column+"_ref")
+	}
+	return columns
+}
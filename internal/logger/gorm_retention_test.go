@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appconfig "claude-code-companion/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestApplyRetentionByAge(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	now := time.Now()
+	largeBody := strings.Repeat("retained body ", 100)
+	entries := []*RequestLog{
+		{Timestamp: now.Add(-20 * 24 * time.Hour), RequestID: "old-success", StatusCode: 200, RequestBody: largeBody},
+		{Timestamp: now.Add(-20 * 24 * time.Hour), RequestID: "old-failed", StatusCode: 502, RequestBody: largeBody},
+		{Timestamp: now.Add(-5 * 24 * time.Hour), RequestID: "recent-success", StatusCode: 200, RequestBody: largeBody, ResponseBody: "ok"},
+		{Timestamp: now, RequestID: "new-success", StatusCode: 200, RequestBody: largeBody},
+	}
+	for _, entry := range entries {
+		storage.SaveLog(entry)
+	}
+
+	result, err := storage.ApplyRetention(appconfig.LogRetentionConfig{
+		MaxAgeDays:       10,
+		FailedMaxAgeDays: 30,
+		BodyMaxAgeDays:   3,
+	})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.DeletedByAge != 1 {
+		t.Errorf("Expected only the old successful log to be deleted, got %+v", result)
+	}
+	// 过期的失败请求和近期请求都超过了正文保留期
+	if result.TruncatedBodies != 2 {
+		t.Errorf("Expected 2 logs to have their bodies truncated, got %+v", result)
+	}
+
+	logs, _, err := storage.QueryLogs(LogQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("QueryLogs failed: %v", err)
+	}
+	bodies := make(map[string]string)
+	for _, log := range logs {
+		bodies[log.RequestID] = log.RequestBody + log.ResponseBody
+	}
+	if _, ok := bodies["old-success"]; ok || len(bodies) != 3 {
+		t.Errorf("Unexpected remaining logs: %v", bodies)
+	}
+	if bodies["old-failed"] != "" || bodies["recent-success"] != "" || bodies["new-success"] != largeBody {
+		t.Errorf("Expected bodies older than 3 days to be truncated, got %v", bodies)
+	}
+
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 10, Text: "retained body"}); total != 1 {
+		t.Errorf("Expected truncated bodies to be removed from the full-text index, got %d matches", total)
+	}
+	var blobs int64
+	storage.db.Model(&GormLogBody{}).Count(&blobs)
+	if blobs != 1 {
+		t.Errorf("Expected only the blob of the untouched log to remain, got %d", blobs)
+	}
+}
+
+func TestApplyRetentionByCountAndSize(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	// 新数据库在建表前设置模式，不需要后台 VACUUM
+	var mode int
+	storage.db.Raw("PRAGMA auto_vacuum").Scan(&mode)
+	if mode != 2 {
+		t.Errorf("Expected incremental auto_vacuum to be enabled, got %d", mode)
+	}
+	if switched, err := enableIncrementalVacuum(storage.db); err != nil || switched {
+		t.Errorf("Expected no VACUUM when the mode is already incremental, got %v, %v", switched, err)
+	}
+
+	// 随机内容无法被压缩或去重，保证数据库体积随条数增长
+	payload := make([]byte, 32*1024)
+	for i := 0; i < 50; i++ {
+		rand.Read(payload)
+		storage.SaveLog(&RequestLog{
+			Timestamp:   time.Now(),
+			RequestID:   fmt.Sprintf("req-%02d", i),
+			StatusCode:  200,
+			RequestBody: hex.EncodeToString(payload),
+		})
+	}
+
+	result, err := storage.ApplyRetention(appconfig.LogRetentionConfig{MaxAgeDays: -1, MaxRows: 40})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.DeletedByCount != 10 {
+		t.Errorf("Expected 10 logs to be deleted by count, got %+v", result)
+	}
+	logs, total, _ := storage.QueryLogs(LogQuery{Limit: 1})
+	if total != 40 || logs[0].RequestID != "req-49" {
+		t.Errorf("Expected newest 40 logs to remain, got total %d", total)
+	}
+
+	used, _ := storage.usedDatabaseSize()
+	limitMB := int(used/(1024*1024)) / 2
+	if limitMB == 0 {
+		t.Fatalf("Expected test database to exceed 2MB, got %d bytes", used)
+	}
+	if _, err := storage.ApplyRetention(appconfig.LogRetentionConfig{MaxAgeDays: -1, MaxDatabaseSizeMB: limitMB}); err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if used, _ := storage.usedDatabaseSize(); used > int64(limitMB)*1024*1024 {
+		t.Errorf("Expected database to shrink below %d MB, still uses %d bytes", limitMB, used)
+	}
+}
+
+func TestEnableIncrementalVacuumSwitchesExistingDatabase(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewGORMStorage(dir, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	// 模拟旧版本创建的数据库：auto_vacuum 为 NONE
+	storage.db.Exec("PRAGMA auto_vacuum = NONE")
+	storage.db.Exec("VACUUM")
+	storage.Close()
+
+	storage, err = NewGORMStorage(dir, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer storage.Close()
+	storage.maintenance.Wait()
+
+	var mode int
+	storage.db.Raw("PRAGMA auto_vacuum").Scan(&mode)
+	if mode != 2 {
+		t.Errorf("Expected the background maintenance to switch to incremental auto_vacuum, got %d", mode)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -16,14 +19,22 @@ import (
 
 // GORMStorage 基于GORM的日志存储实现
 type GORMStorage struct {
-	db             *gorm.DB
-	config         *GORMConfig
-	cleanupTicker  *time.Ticker
-	stopCleanup    chan struct{}
+	db              *gorm.DB
+	config          *GORMConfig
+	cleanupTicker   *time.Ticker
+	stopCleanup     chan struct{}
+	dialect         string      // 数据库类型：sqlite 或 postgres
+	ftsIndexing     bool        // FTS5 索引表已创建，写入日志时同步索引
+	ftsEnabled      atomic.Bool // 全文索引可用于查询，后台重建完成前全文搜索回退到 LIKE
+	retention       appconfig.LogRetentionConfig
+	retentionMu     sync.RWMutex
+	stopMaintenance chan struct{}  // 关闭时中止后台迁移
+	maintenance     sync.WaitGroup // 等待后台迁移退出后再关闭数据库
+	logger          *logrus.Logger // 报告写入失败和后台维护结果
 }
 
 // NewGORMStorage 创建一个新的基于GORM的日志存储
-func NewGORMStorage(logDir string) (*GORMStorage, error) {
+func NewGORMStorage(logDir string, log *logrus.Logger) (*GORMStorage, error) {
 	// 创建日志目录
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %v", err)
@@ -36,29 +47,16 @@ func NewGORMStorage(logDir string) (*GORMStorage, error) {
 	db, err := gorm.Open(sqlite.Dialector{
 		DriverName: "sqlite",
 		DSN:        dbPath + "?_journal_mode=WAL&_timeout=5000&_busy_timeout=5000",
-	}, &gorm.Config{
-		Logger: logger.Default.LogMode(config.LogLevel),
-		// 禁用外键约束检查（保持与现有数据库一致）
-		DisableForeignKeyConstraintWhenMigrating: true,
-		// 设置时间函数
-		NowFunc: func() time.Time {
-			return time.Now().UTC()
-		},
-	})
+	}, newGormConfig(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %v", err)
 	}
 	
 	// 配置连接池（modernc.org/sqlite 特定设置）
-	sqlDB, err := db.DB()
-	if err != nil {
+	if err := configureConnectionPool(db, config); err != nil {
 		return nil, err
 	}
 	
-	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
-	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
-	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
-	
 	// 设置SQLite优化参数以减少锁定
 	optimizationPragmas := []string{
 		"PRAGMA synchronous = NORMAL",     // 平衡性能与安全
@@ -70,14 +68,55 @@ func NewGORMStorage(logDir string) (*GORMStorage, error) {
 	
 	for _, pragma := range optimizationPragmas {
 		if err := db.Exec(pragma).Error; err != nil {
-			fmt.Printf("Warning: Failed to set pragma %s: %v\n", pragma, err)
+			log.WithError(err).Warnf("Failed to set pragma %s", pragma)
 		}
 	}
 	
+	// 启用增量 VACUUM，保留策略清理后无需重写整个数据库即可回收空间
+	// 新建的数据库在建表前设置即可生效；已有数据库需要一次完整 VACUUM，由后台迁移执行
+	if err := db.Exec("PRAGMA auto_vacuum = INCREMENTAL").Error; err != nil {
+		log.WithError(err).Warn("Failed to enable incremental vacuum")
+	}
+	
+	return initGORMStorage(db, config, dialectSQLite, log)
+}
+
+// newGormConfig 返回各数据库共用的 GORM 配置
+func newGormConfig(config *GORMConfig) *gorm.Config {
+	return &gorm.Config{
+		Logger: logger.Default.LogMode(config.LogLevel),
+		// 禁用外键约束检查（保持与现有数据库一致）
+		DisableForeignKeyConstraintWhenMigrating: true,
+		// 设置时间函数
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+// configureConnectionPool 按配置设置连接池
+func configureConnectionPool(db *gorm.DB, config *GORMConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	return nil
+}
+
+// initGORMStorage 完成表结构迁移并启动后台清理，SQLite 和 PostgreSQL 共用
+func initGORMStorage(db *gorm.DB, config *GORMConfig, dialect string, log *logrus.Logger) (*GORMStorage, error) {
 	storage := &GORMStorage{
-		db:          db,
-		config:      config,
-		stopCleanup: make(chan struct{}),
+		db:              db,
+		config:          config,
+		dialect:         dialect,
+		stopCleanup:     make(chan struct{}),
+		retention:       defaultRetentionPolicy(),
+		stopMaintenance: make(chan struct{}),
+		logger:          log,
 	}
 	
 	// 验证表结构兼容性
@@ -93,6 +132,27 @@ func NewGORMStorage(logDir string) (*GORMStorage, error) {
 		return nil, fmt.Errorf("failed to create optimized indexes: %v", err)
 	}
 	
+	// 创建外置正文表和存储元数据表
+	if err := db.AutoMigrate(&GormLogBody{}, &GormStorageMeta{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate log body tables: %v", err)
+	}
+	
+	// 创建全文索引（FTS5 仅 SQLite 可用，失败不影响日志存储）
+	rebuildFTS := false
+	if dialect == dialectSQLite {
+		rebuild, err := setupFullTextSearch(db)
+		if err != nil {
+			log.WithError(err).Warn("Full-text search unavailable, falling back to LIKE")
+		} else {
+			storage.ftsIndexing = true
+			storage.ftsEnabled.Store(!rebuild)
+			rebuildFTS = rebuild
+		}
+	}
+	
+	// 旧日志的正文迁移和全文索引重建可能需要处理整个数据库，放到后台执行，不阻塞启动
+	storage.startMaintenance(rebuildFTS)
+	
 	// 启动后台清理程序
 	storage.startBackgroundCleanup()
 	
@@ -102,43 +162,125 @@ func NewGORMStorage(logDir string) (*GORMStorage, error) {
 // SaveLog 保存日志条目到数据库
 // 保持与现有实现相同的错误处理策略：静默失败，不阻塞主流程
 func (g *GORMStorage) SaveLog(log *RequestLog) {
-	gormLog := ConvertToGormRequestLog(log)
+	if err := g.SaveLogs([]*RequestLog{log}); err != nil {
+		g.logger.WithError(err).Error("Failed to save log to database")
+	}
+}
+
+// startMaintenance 在后台依次执行正文迁移、auto_vacuum 模式切换和全文索引重建，都完成前全文搜索回退到 LIKE
+// 迁移失败时不重建索引，避免未迁移的大正文按行进入索引，下次启动重试
+func (g *GORMStorage) startMaintenance(rebuildFTS bool) {
+	g.maintenance.Add(1)
+	go func() {
+		defer g.maintenance.Done()
+
+		migrated, err := migrateInlineBodies(g.db, g.stopMaintenance)
+		if err != nil {
+			if err != errMaintenanceStopped {
+				g.logger.WithError(err).Error("Failed to migrate log bodies")
+			}
+			return
+		}
+		if migrated > 0 {
+			g.logger.Infof("Migrated bodies of %d log entries to compressed storage", migrated)
+			g.reclaimSpace()
+		}
+
+		// 完整 VACUUM 期间日志写入会等待，只在模式确实需要切换时执行一次，切换后重启不再执行
+		if g.dialect == dialectSQLite && !maintenanceStopped(g.stopMaintenance) {
+			switched, err := enableIncrementalVacuum(g.db)
+			if err != nil {
+				g.logger.WithError(err).Warn("Failed to enable incremental vacuum")
+			} else if switched {
+				g.logger.Info("Switched log database to incremental auto_vacuum")
+			}
+		}
+
+		if rebuildFTS {
+			if err := rebuildFullTextIndex(g.db, g.stopMaintenance); err != nil {
+				if err != errMaintenanceStopped {
+					g.logger.WithError(err).Error("Failed to build full-text index")
+				}
+				return
+			}
+			g.ftsEnabled.Store(true)
+		}
+	}()
+}
+
+// pendingLog 待写入的日志行及其外置正文
+type pendingLog struct {
+	row   *GormRequestLog
+	blobs []*GormLogBody
+}
+
+// SaveLogs 在一个事务中批量保存日志
+func (g *GORMStorage) SaveLogs(logs []*RequestLog) error {
+	entries := make([]pendingLog, 0, len(logs))
+	for _, log := range logs {
+		gormLog := ConvertToGormRequestLog(log)
+		
+		entry := pendingLog{row: gormLog}
+		blobs, err := externalizeBodies(gormLog)
+		if err != nil {
+			return err
+		}
+		entry.blobs = blobs
+		entries = append(entries, entry)
+	}
 	
 	// 添加重试机制处理SQLite BUSY错误
 	maxRetries := appconfig.Default.Database.MaxRetries
+	var err error
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		err := g.db.Create(gormLog).Error
-		if err == nil {
-			return // 成功保存
-		}
-		
-		// 检查是否是SQLite忙碌错误
-		if strings.Contains(err.Error(), "database is locked") || 
-		   strings.Contains(err.Error(), "SQLITE_BUSY") {
-			if attempt < maxRetries-1 {
-				// 等待一小段时间后重试
-				time.Sleep(time.Duration(attempt+1) * 10 * time.Millisecond)
-				continue
+		err = g.db.Transaction(func(tx *gorm.DB) error {
+			for _, entry := range entries {
+				entry.row.ID = 0
+				if err := saveBodies(tx, entry.blobs); err != nil {
+					return err
+				}
+				if err := tx.Create(entry.row).Error; err != nil {
+					return err
+				}
+				// 行内正文按日志行索引，外置正文按内容哈希只索引一次
+				if g.ftsIndexing {
+					if err := indexFullText(tx, entry.row.ID, entry.row.RequestBody, entry.row.ResponseBody); err != nil {
+						return err
+					}
+					if err := indexBodies(tx, entry.blobs); err != nil {
+						return err
+					}
+				}
 			}
+			return nil
+		})
+		if err == nil || !isBusyError(err) {
+			return err
 		}
 		
-		// 与现有实现保持一致：只打印错误，不返回
-		fmt.Printf("Failed to save log to database: %v\n", err)
-		return
+		// 等待一小段时间后重试
+		time.Sleep(time.Duration(attempt+1) * 10 * time.Millisecond)
 	}
+	return err
+}
+
+// isBusyError 判断是否是SQLite忙碌错误
+func isBusyError(err error) bool {
+	return strings.Contains(err.Error(), "database is locked") ||
+		strings.Contains(err.Error(), "SQLITE_BUSY")
 }
 
 // GetLogs 获取日志列表，支持分页和过滤
 func (g *GORMStorage) GetLogs(limit, offset int, failedOnly bool) ([]*RequestLog, int, error) {
+	return g.QueryLogs(LogQuery{Limit: limit, Offset: offset, FailedOnly: failedOnly})
+}
+
+// QueryLogs 按结构化条件查询日志，返回当前页日志和匹配总数
+func (g *GORMStorage) QueryLogs(q LogQuery) ([]*RequestLog, int, error) {
 	var gormLogs []GormRequestLog
 	var total int64
 	
-	query := g.db.Model(&GormRequestLog{})
-	
-	// 应用过滤条件（与现有逻辑保持一致）
-	if failedOnly {
-		query = query.Where("status_code >= ? OR error != ?", 400, "")
-	}
+	query := g.applyLogQuery(g.db.Model(&GormRequestLog{}), q)
 	
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -147,13 +289,16 @@ func (g *GORMStorage) GetLogs(limit, offset int, failedOnly bool) ([]*RequestLog
 	
 	// 获取分页数据
 	err := query.Order("timestamp DESC").
-		Limit(limit).
-		Offset(offset).
+		Limit(q.Limit).
+		Offset(q.Offset).
 		Find(&gormLogs).Error
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query logs: %v", err)
 	}
+	if err := loadBodies(g.db, gormLogs); err != nil {
+		return nil, 0, err
+	}
 	
 	// 转换为现有的RequestLog格式
 	logs := make([]*RequestLog, len(gormLogs))
@@ -175,6 +320,9 @@ func (g *GORMStorage) GetAllLogsByRequestID(requestID string) ([]*RequestLog, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to query logs by request ID: %v", err)
 	}
+	if err := loadBodies(g.db, gormLogs); err != nil {
+		return nil, err
+	}
 	
 	// 转换为现有的RequestLog格式
 	logs := make([]*RequestLog, len(gormLogs))
@@ -201,10 +349,15 @@ func (g *GORMStorage) CleanupLogsByDays(days int) (int64, error) {
 		return 0, fmt.Errorf("failed to cleanup logs: %v", result.Error)
 	}
 	
-	// VACUUM 操作（保持与现有实现一致）
 	if result.RowsAffected > 0 {
+		// 删除不再被引用的外置正文
+		if _, err := deleteOrphanBodies(g.db); err != nil {
+			g.logger.WithError(err).Error("Failed to cleanup log bodies")
+		}
+		
+		// VACUUM 操作（保持与现有实现一致）
 		if err := g.db.Exec("VACUUM").Error; err != nil {
-			fmt.Printf("Failed to vacuum database: %v\n", err)
+			g.logger.WithError(err).Error("Failed to vacuum database")
 		}
 	}
 	
@@ -218,10 +371,11 @@ func (g *GORMStorage) Close() error {
 		g.cleanupTicker.Stop()
 	}
 	
-	select {
-	case g.stopCleanup <- struct{}{}:
-	default:
-	}
+	close(g.stopCleanup)
+	
+	// 中止后台迁移并等待其退出
+	close(g.stopMaintenance)
+	g.maintenance.Wait()
 	
 	// 关闭数据库连接
 	sqlDB, err := g.db.DB()
@@ -231,26 +385,11 @@ func (g *GORMStorage) Close() error {
 	return sqlDB.Close()
 }
 
-// startBackgroundCleanup 启动后台清理程序（保持与现有实现一致）
+// startBackgroundCleanup 启动后台清理程序，按当前保留策略定期清理
 func (g *GORMStorage) startBackgroundCleanup() {
-	g.cleanupTicker = time.NewTicker(24 * time.Hour)
+	g.cleanupTicker = time.NewTicker(retentionInterval(g.retentionPolicy()))
 	
-	go func() {
-		for {
-			select {
-			case <-g.cleanupTicker.C:
-				// 清理30天前的日志
-				deleted, err := g.CleanupLogsByDays(30)
-				if err != nil {
-					fmt.Printf("Background cleanup error: %v\n", err)
-				} else if deleted > 0 {
-					fmt.Printf("Background cleanup: deleted %d old log entries\n", deleted)
-				}
-			case <-g.stopCleanup:
-				return
-			}
-		}
-	}()
+	go runRetentionLoop(g.cleanupTicker, g.stopCleanup, g.retentionPolicy, g.ApplyRetention, g.logger)
 }
 
 // GetStats 获取统计信息
@@ -273,11 +412,15 @@ func (g *GORMStorage) GetStats() (map[string]interface{}, error) {
 		stats["oldest_log"] = oldestLog.Timestamp
 	}
 	
+	// 外置正文数量
+	var bodyCount int64
+	g.db.Model(&GormLogBody{}).Count(&bodyCount)
+	stats["log_bodies"] = bodyCount
+	
 	// 数据库大小
-	var pageCount, pageSize int
-	g.db.Raw("PRAGMA page_count").Scan(&pageCount)
-	g.db.Raw("PRAGMA page_size").Scan(&pageSize)
-	stats["db_size_bytes"] = pageCount * pageSize
+	size, _ := g.databaseSize()
+	stats["db_size_bytes"] = size
+	stats["backend"] = g.dialect
 	
 	return stats, nil
 }
\ No newline at end of file
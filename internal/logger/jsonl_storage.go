@@ -0,0 +1,537 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	appconfig "claude-code-companion/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const jsonlDateLayout = "2006-01-02"
+
+var jsonlFilePattern = regexp.MustCompile(`^requests-(\d{4}-\d{2}-\d{2})(?:\.(\d+))?\.jsonl$`)
+
+// JSONLStorage 以 JSONL 文件追加写入日志，按天和文件大小轮转，便于日志采集程序消费
+// 文件命名为 requests-YYYY-MM-DD.jsonl，当天文件超过大小上限后依次写入 requests-YYYY-MM-DD.1.jsonl、.2.jsonl ...
+// 查询需要扫描全部文件，结果按写入顺序排列，适合日志量不大或主要由外部系统消费的场景
+type JSONLStorage struct {
+	dir         string
+	maxFileSize int64
+
+	mu       sync.Mutex // 保护当前写入文件和文件删除
+	file     *os.File
+	current  jsonlFile
+	fileSize int64
+
+	retention     appconfig.LogRetentionConfig
+	retentionMu   sync.RWMutex
+	cleanupTicker *time.Ticker
+	stopCleanup   chan struct{}
+	logger        *logrus.Logger
+}
+
+// jsonlFile 日志文件的日期和当天的轮转序号
+type jsonlFile struct {
+	date  string
+	index int
+}
+
+func (f jsonlFile) name() string {
+	if f.index == 0 {
+		return fmt.Sprintf("requests-%s.jsonl", f.date)
+	}
+	return fmt.Sprintf("requests-%s.%d.jsonl", f.date, f.index)
+}
+
+// NewJSONLStorage 创建 JSONL 文件日志存储
+func NewJSONLStorage(logDir string, maxFileSize int64, log *logrus.Logger) (*JSONLStorage, error) {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	storage := &JSONLStorage{
+		dir:         logDir,
+		maxFileSize: maxFileSize,
+		retention:   defaultRetentionPolicy(),
+		stopCleanup: make(chan struct{}),
+		logger:      log,
+	}
+	storage.cleanupTicker = time.NewTicker(retentionInterval(storage.retention))
+	go runRetentionLoop(storage.cleanupTicker, storage.stopCleanup, storage.retentionPolicy, storage.ApplyRetention, log)
+
+	return storage, nil
+}
+
+// SaveLog 追加一条日志，失败时只记录错误，不阻塞主流程
+func (s *JSONLStorage) SaveLog(log *RequestLog) {
+	data, err := json.Marshal(log)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to save log to JSONL file")
+		return
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.openFor(time.Now(), len(data)); err != nil {
+		s.logger.WithError(err).Error("Failed to save log to JSONL file")
+		return
+	}
+	n, err := s.file.Write(data)
+	s.fileSize += int64(n)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to save log to JSONL file")
+	}
+}
+
+// openFor 确保当前文件可以写入 size 字节，日期变化或超过大小上限时轮转到新文件
+// 调用方需持有 s.mu
+func (s *JSONLStorage) openFor(now time.Time, size int) error {
+	date := now.Format(jsonlDateLayout)
+	if s.file != nil && s.current.date == date && (s.fileSize == 0 || s.fileSize+int64(size) <= s.maxFileSize) {
+		return nil
+	}
+
+	next := jsonlFile{date: date}
+	if s.file != nil {
+		if s.current.date == date {
+			next.index = s.current.index + 1
+		}
+		s.file.Close()
+		s.file = nil
+	} else if files, err := s.listFiles(); err == nil {
+		// 重启后续写当天最新的文件
+		for _, f := range files {
+			if f.date == date {
+				next = f
+			}
+		}
+	}
+
+	for {
+		file, err := os.OpenFile(filepath.Join(s.dir, next.name()), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if info.Size() > 0 && info.Size()+int64(size) > s.maxFileSize {
+			file.Close()
+			next.index++
+			continue
+		}
+		s.file, s.current, s.fileSize = file, next, info.Size()
+		return nil
+	}
+}
+
+// listFiles 按时间顺序列出日志文件
+func (s *JSONLStorage) listFiles() ([]jsonlFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []jsonlFile
+	for _, entry := range entries {
+		match := jsonlFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		f := jsonlFile{date: match[1]}
+		if match[2] != "" {
+			f.index, _ = strconv.Atoi(match[2])
+		}
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].date != files[j].date {
+			return files[i].date < files[j].date
+		}
+		return files[i].index < files[j].index
+	})
+	return files, nil
+}
+
+// scan 按写入顺序读取全部日志，无法解析的行（如正在写入的行）会被跳过
+func (s *JSONLStorage) scan(fn func(log *RequestLog)) error {
+	files, err := s.listFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		file, err := os.Open(filepath.Join(s.dir, f.name()))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // 已被清理
+			}
+			return err
+		}
+
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				var log RequestLog
+				if json.Unmarshal(line, &log) == nil {
+					fn(&log)
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return err
+			}
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// GetLogs 获取日志列表，支持分页和过滤
+func (s *JSONLStorage) GetLogs(limit, offset int, failedOnly bool) ([]*RequestLog, int, error) {
+	return s.QueryLogs(LogQuery{Limit: limit, Offset: offset, FailedOnly: failedOnly})
+}
+
+// QueryLogs 按条件查询日志，按写入顺序倒序分页
+func (s *JSONLStorage) QueryLogs(q LogQuery) ([]*RequestLog, int, error) {
+	// 只保留最近匹配的 offset+limit 条，避免把所有日志读入内存
+	window := q.Offset + q.Limit
+	var recent []*RequestLog
+	total := 0
+
+	err := s.scan(func(log *RequestLog) {
+		if !q.Matches(log) {
+			return
+		}
+		total++
+		if window <= 0 {
+			return
+		}
+		if len(recent) == window {
+			recent = recent[1:]
+		}
+		recent = append(recent, log)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read JSONL logs: %v", err)
+	}
+
+	logs := []*RequestLog{}
+	for i := len(recent) - 1 - q.Offset; i >= 0 && len(logs) < q.Limit; i-- {
+		logs = append(logs, recent[i])
+	}
+	return logs, total, nil
+}
+
+// GetAllLogsByRequestID 获取指定request_id的所有日志条目
+func (s *JSONLStorage) GetAllLogsByRequestID(requestID string) ([]*RequestLog, error) {
+	return s.collectSorted(func(log *RequestLog) bool {
+		return log.RequestID == requestID
+	})
+}
+
+// GetLogsBySessionID 按时间顺序获取指定会话的所有日志条目
+func (s *JSONLStorage) GetLogsBySessionID(sessionID string) ([]*RequestLog, error) {
+	return s.collectSorted(func(log *RequestLog) bool {
+		return log.SessionID == sessionID
+	})
+}
+
+// collectSorted 读取所有匹配的日志并按时间排序
+func (s *JSONLStorage) collectSorted(match func(log *RequestLog) bool) ([]*RequestLog, error) {
+	logs := []*RequestLog{}
+	err := s.scan(func(log *RequestLog) {
+		if match(log) {
+			logs = append(logs, log)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSONL logs: %v", err)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+	return logs, nil
+}
+
+// GetSessions 按最近活动倒序返回会话汇总列表，project 非空时只返回该项目的会话
+func (s *JSONLStorage) GetSessions(limit, offset int, project string) ([]*SessionSummary, int, error) {
+	sessionLogs := make(map[string][]*RequestLog)
+	lastSeen := make(map[string]int)
+	seq := 0
+
+	err := s.scan(func(log *RequestLog) {
+		if log.SessionID == "" {
+			return
+		}
+		// 汇总只需要元数据，丢弃正文以节省内存
+		log.RequestBody, log.ResponseBody = "", ""
+		log.OriginalRequestBody, log.OriginalResponseBody = "", ""
+		log.FinalRequestBody, log.FinalResponseBody = "", ""
+
+		sessionLogs[log.SessionID] = append(sessionLogs[log.SessionID], log)
+		seq++
+		lastSeen[log.SessionID] = seq
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read JSONL logs: %v", err)
+	}
+
+	summaries := make(map[string]*SessionSummary, len(sessionLogs))
+	ids := make([]string, 0, len(sessionLogs))
+	for id, logs := range sessionLogs {
+		sort.SliceStable(logs, func(a, b int) bool {
+			return logs[a].Timestamp.Before(logs[b].Timestamp)
+		})
+		summary := SummarizeSession(id, logs)
+		if project != "" && summary.Project != project {
+			continue
+		}
+		summaries[id] = summary
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return lastSeen[ids[i]] > lastSeen[ids[j]]
+	})
+
+	sessions := []*SessionSummary{}
+	for i := offset; i < len(ids) && len(sessions) < limit; i++ {
+		sessions = append(sessions, summaries[ids[i]])
+	}
+	return sessions, len(ids), nil
+}
+
+// CleanupLogsByDays 删除指定天数之前的日志文件，days <= 0 时删除全部
+// 按文件删除，只删除整天都早于截止时间的文件
+func (s *JSONLStorage) CleanupLogsByDays(days int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.listFiles()
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup logs: %v", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days).Format(jsonlDateLayout)
+	var deleted int64
+	for _, f := range files {
+		if days > 0 && f.date >= cutoff {
+			continue
+		}
+		count, err := s.removeFile(f)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to cleanup logs: %v", err)
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// ApplyRetention 按保留策略删除最旧的日志文件
+// 文件中成功和失败的请求混合存放，按两者中较长的保留期整文件删除；
+// 追加写入的文件不会被改写，因此 body_max_age_days 对 JSONL 不生效
+func (s *JSONLStorage) ApplyRetention(policy appconfig.LogRetentionConfig) (*RetentionResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &RetentionResult{}
+	files, err := s.listFiles()
+	if err != nil {
+		return result, err
+	}
+
+	keepDays := policy.MaxAgeDays
+	if keepDays > 0 && policy.FailedMaxAgeDays > keepDays {
+		keepDays = policy.FailedMaxAgeDays
+	}
+	if keepDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -keepDays).Format(jsonlDateLayout)
+		for len(files) > 0 && files[0].date < cutoff {
+			deleted, err := s.removeFile(files[0])
+			if err != nil {
+				return result, err
+			}
+			result.DeletedByAge += deleted
+			files = files[1:]
+		}
+	}
+
+	// 条数和体积限制按文件粒度生效，始终保留最新的文件
+	if policy.MaxRows > 0 {
+		counts := make([]int64, len(files))
+		var total int64
+		for i, f := range files {
+			if counts[i], err = s.countLines(f); err != nil {
+				return result, err
+			}
+			total += counts[i]
+		}
+		for len(files) > 1 && total > policy.MaxRows {
+			deleted, err := s.removeFile(files[0])
+			if err != nil {
+				return result, err
+			}
+			result.DeletedByCount += deleted
+			total -= counts[0]
+			files, counts = files[1:], counts[1:]
+		}
+	}
+
+	if policy.MaxDatabaseSizeMB > 0 {
+		limit := int64(policy.MaxDatabaseSizeMB) * 1024 * 1024
+		sizes := make([]int64, len(files))
+		var total int64
+		for i, f := range files {
+			if info, err := os.Stat(filepath.Join(s.dir, f.name())); err == nil {
+				sizes[i] = info.Size()
+			}
+			total += sizes[i]
+		}
+		for len(files) > 1 && total > limit {
+			deleted, err := s.removeFile(files[0])
+			if err != nil {
+				return result, err
+			}
+			result.DeletedBySize += deleted
+			total -= sizes[0]
+			files, sizes = files[1:], sizes[1:]
+		}
+	}
+
+	return result, nil
+}
+
+// removeFile 删除日志文件并返回其中的日志条数，调用方需持有 s.mu
+func (s *JSONLStorage) removeFile(f jsonlFile) (int64, error) {
+	count, err := s.countLines(f)
+	if err != nil {
+		return 0, err
+	}
+	if s.file != nil && s.current == f {
+		s.file.Close()
+		s.file = nil
+	}
+	if err := os.Remove(filepath.Join(s.dir, f.name())); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	return count, nil
+}
+
+// countLines 统计日志文件中的条数
+func (s *JSONLStorage) countLines(f jsonlFile) (int64, error) {
+	file, err := os.Open(filepath.Join(s.dir, f.name()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	var count int64
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+// SetRetentionPolicy 更新保留策略，后台清理程序在下一次执行时生效
+func (s *JSONLStorage) SetRetentionPolicy(policy appconfig.LogRetentionConfig) {
+	s.retentionMu.Lock()
+	s.retention = policy
+	s.retentionMu.Unlock()
+
+	s.cleanupTicker.Reset(retentionInterval(policy))
+}
+
+// retentionPolicy 返回当前保留策略
+func (s *JSONLStorage) retentionPolicy() appconfig.LogRetentionConfig {
+	s.retentionMu.RLock()
+	defer s.retentionMu.RUnlock()
+	return s.retention
+}
+
+// GetStats 获取统计信息
+func (s *JSONLStorage) GetStats() (map[string]interface{}, error) {
+	stats := make(map[string]interface{})
+
+	var totalLogs, failedLogs int64
+	var oldest time.Time
+	err := s.scan(func(log *RequestLog) {
+		totalLogs++
+		if log.StatusCode >= 400 || log.Error != "" {
+			failedLogs++
+		}
+		if oldest.IsZero() || log.Timestamp.Before(oldest) {
+			oldest = log.Timestamp
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats["total_logs"] = totalLogs
+	stats["failed_logs"] = failedLogs
+	if !oldest.IsZero() {
+		stats["oldest_log"] = oldest
+	}
+
+	files, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, f := range files {
+		if info, err := os.Stat(filepath.Join(s.dir, f.name())); err == nil {
+			size += info.Size()
+		}
+	}
+	stats["files"] = len(files)
+	stats["db_size_bytes"] = size
+	stats["backend"] = "jsonl"
+
+	return stats, nil
+}
+
+// Close 停止后台清理并关闭当前文件
+func (s *JSONLStorage) Close() error {
+	s.cleanupTicker.Stop()
+	close(s.stopCleanup)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		err := s.file.Close()
+		s.file = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appconfig "claude-code-companion/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJSONLStorageQueries(t *testing.T) {
+	storage, err := NewJSONLStorage(t.TempDir(), 1024*1024, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	now := time.Now()
+	entries := []*RequestLog{
+		{Timestamp: now.Add(-3 * time.Minute), RequestID: "req-1", SessionID: "s1", Project: "api", Endpoint: "ep-a", Model: "claude-opus-4", StatusCode: 502, AttemptNumber: 1, Tags: []string{"long"}, RequestBody: `{"prompt":"deploy the Kubernetes cluster"}`},
+		{Timestamp: now.Add(-2 * time.Minute), RequestID: "req-1", SessionID: "s1", Project: "api", Endpoint: "ep-b", Model: "claude-opus-4", StatusCode: 200, AttemptNumber: 2, InputTokens: 8, Tags: []string{"long", "vip"}},
+		{Timestamp: now.Add(-1 * time.Minute), RequestID: "req-2", SessionID: "s2", Endpoint: "ep-a", Model: "claude-sonnet-4", StatusCode: 200, AttemptNumber: 1},
+	}
+	for _, entry := range entries {
+		storage.SaveLog(entry)
+	}
+
+	tests := []struct {
+		name     string
+		query    LogQuery
+		expected []string
+	}{
+		{"all newest first", LogQuery{}, []string{"ep-a", "ep-b", "ep-a"}},
+		{"endpoint and 5xx", LogQuery{Endpoint: "ep-a", StatusMin: 500, StatusMax: 599}, []string{"ep-a"}},
+		{"model substring", LogQuery{Model: "OPUS"}, []string{"ep-b", "ep-a"}},
+		{"all tags", LogQuery{Tags: []string{"long", "vip"}}, []string{"ep-b"}},
+		{"full text", LogQuery{Text: "kubernetes"}, []string{"ep-a"}},
+		{"offset", LogQuery{Offset: 1, Limit: 1}, []string{"ep-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.query.Limit == 0 {
+				tt.query.Limit = 10
+			}
+			logs, total, err := storage.QueryLogs(tt.query)
+			if err != nil {
+				t.Fatalf("QueryLogs failed: %v", err)
+			}
+			if tt.query.Offset == 0 && total != len(tt.expected) {
+				t.Errorf("Expected total %d, got %d", len(tt.expected), total)
+			}
+			if len(logs) != len(tt.expected) {
+				t.Fatalf("Expected %d logs, got %d", len(tt.expected), len(logs))
+			}
+			for i, endpoint := range tt.expected {
+				if logs[i].Endpoint != endpoint {
+					t.Errorf("Expected log %d from %s, got %s", i, endpoint, logs[i].Endpoint)
+				}
+			}
+		})
+	}
+
+	attempts, err := storage.GetAllLogsByRequestID("req-1")
+	if err != nil || len(attempts) != 2 || attempts[0].AttemptNumber != 1 {
+		t.Errorf("Expected both attempts of req-1 in order, got %d (err %v)", len(attempts), err)
+	}
+
+	sessions, total, err := storage.GetSessions(10, 0, "")
+	if err != nil {
+		t.Fatalf("GetSessions failed: %v", err)
+	}
+	if total != 2 || sessions[0].SessionID != "s2" || sessions[1].FailoverCount != 1 || sessions[1].AttemptCount != 2 {
+		t.Errorf("Unexpected sessions: %+v", sessions)
+	}
+	if sessions, total, _ := storage.GetSessions(10, 0, "api"); total != 1 || sessions[0].SessionID != "s1" {
+		t.Errorf("Expected only the api session, got %+v", sessions)
+	}
+
+	stats, err := storage.GetTokenStats(LogQuery{})
+	if err != nil || len(stats) != 2 || stats[0].Project != "api" || stats[0].RequestCount != 1 || stats[0].InputTokens != 8 {
+		t.Errorf("Unexpected token stats: %+v (err %v)", stats, err)
+	}
+}
+
+func TestJSONLStorageRotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewJSONLStorage(dir, 2048, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	body := strings.Repeat("x", 500)
+	for i := 0; i < 20; i++ {
+		storage.SaveLog(&RequestLog{Timestamp: time.Now(), RequestID: "req", StatusCode: 200, RequestBody: body})
+	}
+	files, _ := storage.listFiles()
+	if len(files) < 5 {
+		t.Fatalf("Expected logs to rotate into multiple files, got %d", len(files))
+	}
+	storage.Close()
+
+	// 重启后续写最新的文件
+	storage, err = NewJSONLStorage(dir, 2048, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer storage.Close()
+	storage.SaveLog(&RequestLog{Timestamp: time.Now(), RequestID: "req", StatusCode: 200})
+	if _, total, _ := storage.QueryLogs(LogQuery{Limit: 1}); total != 21 {
+		t.Errorf("Expected 21 logs after reopening, got %d", total)
+	}
+
+	// 过期文件按日期整体删除
+	old := filepath.Join(dir, "requests-2020-01-01.jsonl")
+	if err := os.WriteFile(old, []byte(`{"request_id":"old"}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write old log file: %v", err)
+	}
+
+	result, err := storage.ApplyRetention(appconfig.LogRetentionConfig{MaxAgeDays: 30, MaxRows: 5})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.DeletedByAge != 1 || result.DeletedByCount == 0 {
+		t.Errorf("Unexpected retention result: %+v", result)
+	}
+	_, total, _ := storage.QueryLogs(LogQuery{Limit: 1})
+	if total == 0 || total > 5 {
+		t.Errorf("Expected at most 5 logs to remain, got %d", total)
+	}
+
+	deleted, err := storage.CleanupLogsByDays(0)
+	if err != nil || deleted != int64(total) {
+		t.Errorf("Expected cleanup to delete %d logs, got %d (err %v)", total, deleted, err)
+	}
+	storage.SaveLog(&RequestLog{Timestamp: time.Now(), RequestID: "after-cleanup"})
+	if logs, _, _ := storage.QueryLogs(LogQuery{Limit: 10}); len(logs) != 1 || logs[0].RequestID != "after-cleanup" {
+		t.Errorf("Expected storage to keep working after cleanup, got %d logs", len(logs))
+	}
+}
+
+func TestNewStorageBackends(t *testing.T) {
+	storage, err := newStorage(LogConfig{LogDirectory: t.TempDir(), Storage: appconfig.LogStorageConfig{Backend: "jsonl"}}, logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create jsonl storage: %v", err)
+	}
+	defer storage.Close()
+	if _, ok := storage.(*JSONLStorage); !ok {
+		t.Errorf("Expected JSONLStorage, got %T", storage)
+	}
+
+	if _, err := newStorage(LogConfig{LogDirectory: t.TempDir(), Storage: appconfig.LogStorageConfig{Backend: "mongodb"}}, logrus.New()); err == nil {
+		t.Error("Expected unknown backend to be rejected")
+	}
+}
@@ -0,0 +1,366 @@
+package logger
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// ftsMinQueryLength trigram 分词器要求查询至少包含 3 个字符，更短的查询回退到 LIKE
+const ftsMinQueryLength = 3
+
+// LogQuery 日志结构化查询条件，零值字段表示不过滤
+type LogQuery struct {
+	Limit  int
+	Offset int
+
+	FailedOnly      bool       // 状态码 >= 400 或存在错误
+	Endpoint        string     // 端点名称（精确匹配）
+	StatusMin       int        // 状态码下限（含）
+	StatusMax       int        // 状态码上限（含）
+	Model           string     // 模型名（子串匹配，同时匹配重写后的模型）
+	OriginalModel   string     // 客户端请求的原始模型名（子串匹配）
+	Tags            []string   // 必须同时包含的 tag
+	SessionID       string     // 会话ID（精确匹配）
+	Project         string     // 项目名（精确匹配）
+	Since           *time.Time // 开始时间（含）
+	Until           *time.Time // 结束时间（不含）
+	DurationMin     int64      // 最小耗时（毫秒，含）
+	DurationMax     int64      // 最大耗时（毫秒，含）
+	AttemptNumber   int        // 尝试次数（精确匹配）
+	ErrorContains   string     // 错误信息子串
+	ThinkingEnabled *bool      // 是否启用 thinking
+	Text            string     // 请求/响应体全文搜索
+}
+
+// IsFiltered 判断查询是否包含除分页以外的过滤条件
+func (q LogQuery) IsFiltered() bool {
+	return q.FailedOnly || q.Endpoint != "" || q.StatusMin > 0 || q.StatusMax > 0 ||
+		q.Model != "" || q.OriginalModel != "" || len(q.Tags) > 0 || q.SessionID != "" ||
+		q.Project != "" || q.Since != nil || q.Until != nil || q.DurationMin > 0 ||
+		q.DurationMax > 0 || q.AttemptNumber > 0 || q.ErrorContains != "" ||
+		q.ThinkingEnabled != nil || q.Text != ""
+}
+
+// applyLogQuery 将查询条件应用到 request_logs 查询上
+func (g *GORMStorage) applyLogQuery(query *gorm.DB, q LogQuery) *gorm.DB {
+	if q.FailedOnly {
+		query = query.Where("status_code >= ? OR error != ?", 400, "")
+	}
+	if q.Endpoint != "" {
+		query = query.Where("endpoint = ?", q.Endpoint)
+	}
+	if q.StatusMin > 0 {
+		query = query.Where("status_code >= ?", q.StatusMin)
+	}
+	if q.StatusMax > 0 {
+		query = query.Where("status_code <= ?", q.StatusMax)
+	}
+	if q.Model != "" {
+		pattern := likePattern(q.Model)
+		query = query.Where("(model LIKE ? ESCAPE '\\' OR rewritten_model LIKE ? ESCAPE '\\')", pattern, pattern)
+	}
+	if q.OriginalModel != "" {
+		query = query.Where("original_model LIKE ? ESCAPE '\\'", likePattern(q.OriginalModel))
+	}
+	for _, tag := range q.Tags {
+		query = query.Where(g.tagCondition(), tag)
+	}
+	if q.SessionID != "" {
+		query = query.Where("session_id = ?", q.SessionID)
+	}
+	if q.Project != "" {
+		query = query.Where("project = ?", q.Project)
+	}
+	// 时间戳以本地时区写入，比较时保持同一时区
+	if q.Since != nil {
+		query = query.Where("timestamp >= ?", q.Since.Local())
+	}
+	if q.Until != nil {
+		query = query.Where("timestamp < ?", q.Until.Local())
+	}
+	if q.DurationMin > 0 {
+		query = query.Where("duration_ms >= ?", q.DurationMin)
+	}
+	if q.DurationMax > 0 {
+		query = query.Where("duration_ms <= ?", q.DurationMax)
+	}
+	if q.AttemptNumber > 0 {
+		query = query.Where("attempt_number = ?", q.AttemptNumber)
+	}
+	if q.ErrorContains != "" {
+		query = query.Where("error LIKE ? ESCAPE '\\'", likePattern(q.ErrorContains))
+	}
+	if q.ThinkingEnabled != nil {
+		query = query.Where("thinking_enabled = ?", *q.ThinkingEnabled)
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		if g.ftsEnabled.Load() && len([]rune(text)) >= ftsMinQueryLength {
+			phrase := ftsPhrase(text)
+			matchedBodies := "SELECT hash FROM log_body_fts_ids WHERE id IN (SELECT rowid FROM log_bodies_fts WHERE log_bodies_fts MATCH ?)"
+			query = query.Where("(id IN (SELECT rowid FROM request_logs_fts WHERE request_logs_fts MATCH ?) OR request_body_ref IN ("+matchedBodies+") OR response_body_ref IN ("+matchedBodies+"))",
+				phrase, phrase, phrase)
+		} else {
+			// 外置压缩的正文无法用 LIKE 匹配，这里只能搜索行内存储的较短正文
+			pattern := likePattern(text)
+			query = query.Where("(request_body LIKE ? ESCAPE '\\' OR response_body LIKE ? ESCAPE '\\')", pattern, pattern)
+		}
+	}
+	return query
+}
+
+// Matches 在内存中判断日志是否满足查询条件，语义与 applyLogQuery 一致（子串匹配不区分大小写）
+// 供不支持 SQL 查询的存储后端使用
+func (q LogQuery) Matches(log *RequestLog) bool {
+	if q.FailedOnly && log.StatusCode < 400 && log.Error == "" {
+		return false
+	}
+	if q.Endpoint != "" && log.Endpoint != q.Endpoint {
+		return false
+	}
+	if q.StatusMin > 0 && log.StatusCode < q.StatusMin {
+		return false
+	}
+	if q.StatusMax > 0 && log.StatusCode > q.StatusMax {
+		return false
+	}
+	if q.Model != "" && !containsFold(log.Model, q.Model) && !containsFold(log.RewrittenModel, q.Model) {
+		return false
+	}
+	if q.OriginalModel != "" && !containsFold(log.OriginalModel, q.OriginalModel) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !containsString(log.Tags, tag) {
+			return false
+		}
+	}
+	if q.SessionID != "" && log.SessionID != q.SessionID {
+		return false
+	}
+	if q.Project != "" && log.Project != q.Project {
+		return false
+	}
+	if q.Since != nil && log.Timestamp.Before(*q.Since) {
+		return false
+	}
+	if q.Until != nil && !log.Timestamp.Before(*q.Until) {
+		return false
+	}
+	if q.DurationMin > 0 && log.DurationMs < q.DurationMin {
+		return false
+	}
+	if q.DurationMax > 0 && log.DurationMs > q.DurationMax {
+		return false
+	}
+	if q.AttemptNumber > 0 && log.AttemptNumber != q.AttemptNumber {
+		return false
+	}
+	if q.ErrorContains != "" && !containsFold(log.Error, q.ErrorContains) {
+		return false
+	}
+	if q.ThinkingEnabled != nil && log.ThinkingEnabled != *q.ThinkingEnabled {
+		return false
+	}
+	if text := strings.TrimSpace(q.Text); text != "" && !containsFold(log.RequestBody, text) && !containsFold(log.ResponseBody, text) {
+		return false
+	}
+	return true
+}
+
+func containsFold(value, substr string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(substr))
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// likePattern 构造子串匹配的 LIKE 模式，转义通配符
+func likePattern(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+	return "%" + replacer.Replace(value) + "%"
+}
+
+// ftsPhrase 将用户输入转换为 FTS5 短语查询，避免其中的运算符被解析
+func ftsPhrase(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// ftsVersionKey 全文索引版本，2 表示外置正文按内容哈希只索引一次
+const (
+	ftsVersionKey = "fts_version"
+	ftsVersion    = "2"
+)
+
+// setupFullTextSearch 创建请求/响应体的 FTS5 索引，返回是否需要在后台重建索引
+// 行内存储的短正文按日志行索引（request_logs_fts）；外置正文按内容哈希只索引一次（log_bodies_fts），
+// Claude Code 的重试和故障转移会重复发送相同的正文，按行索引会让同一份内容重复占用索引空间
+// log_bodies 的隐式 rowid 会在 VACUUM 后改变，因此通过 log_body_fts_ids 为哈希分配稳定的索引ID
+// 索引表都是 contentless 表，不保存明文；trigram 分词器支持任意子串（包括中文）搜索
+func setupFullTextSearch(db *gorm.DB) (bool, error) {
+	var existingSQL string
+	if err := db.Raw("SELECT COALESCE(MAX(sql), '') FROM sqlite_master WHERE type = 'table' AND name = 'request_logs_fts'").Scan(&existingSQL).Error; err != nil {
+		return false, err
+	}
+
+	// 早期版本的索引直接引用 request_logs 的正文列，正文外置后无法继续使用，需要重建
+	if existingSQL != "" && strings.Contains(existingSQL, "content='request_logs'") {
+		legacy := []string{
+			"DROP TRIGGER IF EXISTS request_logs_fts_insert",
+			"DROP TRIGGER IF EXISTS request_logs_fts_update",
+			"DROP TRIGGER IF EXISTS request_logs_fts_delete",
+			"DROP TABLE IF EXISTS request_logs_fts",
+		}
+		for _, sql := range legacy {
+			if err := db.Exec(sql).Error; err != nil {
+				return false, err
+			}
+		}
+	}
+
+	statements := []string{
+		"CREATE VIRTUAL TABLE IF NOT EXISTS request_logs_fts USING fts5(request_body, response_body, content='', contentless_delete=1, tokenize='trigram')",
+		`CREATE TRIGGER IF NOT EXISTS request_logs_fts_delete AFTER DELETE ON request_logs BEGIN
+			DELETE FROM request_logs_fts WHERE rowid = old.id;
+		END`,
+		"CREATE TABLE IF NOT EXISTS log_body_fts_ids (id INTEGER PRIMARY KEY AUTOINCREMENT, hash VARCHAR(64) NOT NULL UNIQUE)",
+		"CREATE VIRTUAL TABLE IF NOT EXISTS log_bodies_fts USING fts5(body, content='', contentless_delete=1, tokenize='trigram')",
+		`CREATE TRIGGER IF NOT EXISTS log_bodies_fts_delete AFTER DELETE ON log_bodies BEGIN
+			DELETE FROM log_bodies_fts WHERE rowid = (SELECT id FROM log_body_fts_ids WHERE hash = old.hash);
+			DELETE FROM log_body_fts_ids WHERE hash = old.hash;
+		END`,
+	}
+	for _, sql := range statements {
+		if err := db.Exec(sql).Error; err != nil {
+			return false, err
+		}
+	}
+
+	var meta GormStorageMeta
+	if err := db.Where("key = ?", ftsVersionKey).Limit(1).Find(&meta).Error; err != nil {
+		return false, err
+	}
+	if meta.Value == ftsVersion {
+		return false, nil
+	}
+
+	// 新数据库没有需要索引的内容，直接标记为最新版本
+	var count int64
+	if err := db.Model(&GormRequestLog{}).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, db.Save(&GormStorageMeta{Key: ftsVersionKey, Value: ftsVersion}).Error
+	}
+	return true, nil
+}
+
+// rebuildFullTextIndex 清空并分批重建全文索引，stop 关闭时中止，下次启动重新开始
+// 重建期间新写入的日志由 SaveLogs 索引：日志行只重建开始时已存在的部分，外置正文通过 log_body_fts_ids 判断是否已索引
+func rebuildFullTextIndex(db *gorm.DB, stop <-chan struct{}) error {
+	var maxID uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"INSERT INTO request_logs_fts(request_logs_fts) VALUES('delete-all')",
+			"INSERT INTO log_bodies_fts(log_bodies_fts) VALUES('delete-all')",
+			"DELETE FROM log_body_fts_ids",
+		}
+		for _, sql := range statements {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&GormRequestLog{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	batch := appconfig.Default.Database.BodyMigrationBatch
+	var lastID uint
+	for {
+		if maintenanceStopped(stop) {
+			return errMaintenanceStopped
+		}
+		var rows []GormRequestLog
+		err := db.Select("id", "request_body", "response_body").
+			Where("id > ? AND id <= ? AND (request_body != '' OR response_body != '')", lastID, maxID).
+			Order("id ASC").Limit(batch).Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for i := range rows {
+				if err := indexFullText(tx, rows[i].ID, rows[i].RequestBody, rows[i].ResponseBody); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	for {
+		if maintenanceStopped(stop) {
+			return errMaintenanceStopped
+		}
+		var blobs []*GormLogBody
+		if err := db.Where("hash NOT IN (SELECT hash FROM log_body_fts_ids)").Limit(batch).Find(&blobs).Error; err != nil {
+			return err
+		}
+		if len(blobs) == 0 {
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return indexBodies(tx, blobs) }); err != nil {
+			return err
+		}
+	}
+
+	return db.Save(&GormStorageMeta{Key: ftsVersionKey, Value: ftsVersion}).Error
+}
+
+// indexFullText 将一条日志行内存储的请求/响应体写入全文索引，外置的正文由 indexBodies 索引
+func indexFullText(tx *gorm.DB, id uint, requestBody, responseBody string) error {
+	if requestBody == "" && responseBody == "" {
+		return nil
+	}
+	return tx.Exec("INSERT INTO request_logs_fts(rowid, request_body, response_body) VALUES (?, ?, ?)", id, requestBody, responseBody).Error
+}
+
+// indexBodies 为尚未索引的外置正文建立全文索引，已索引的内容直接跳过
+// 无法解压的正文同样登记索引ID，避免重建时反复处理
+func indexBodies(tx *gorm.DB, blobs []*GormLogBody) error {
+	for _, blob := range blobs {
+		res := tx.Exec("INSERT INTO log_body_fts_ids(hash) VALUES (?) ON CONFLICT(hash) DO NOTHING", blob.Hash)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		text, err := blob.text()
+		if err != nil || text == "" {
+			continue
+		}
+		err = tx.Exec("INSERT INTO log_bodies_fts(rowid, body) SELECT id, ? FROM log_body_fts_ids WHERE hash = ?", text, blob.Hash).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
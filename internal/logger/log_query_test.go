@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestQueryLogsFilters(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	now := time.Now()
+	entries := []*RequestLog{
+		{Timestamp: now.Add(-26 * time.Hour), RequestID: "req-1", Endpoint: "ep-a", Model: "claude-opus-4", StatusCode: 502, DurationMs: 1200, AttemptNumber: 1, Tags: []string{"long"}, RequestBody: `{"prompt":"deploy the kubernetes cluster"}`},
+		{Timestamp: now.Add(-25 * time.Hour), RequestID: "req-2", Endpoint: "ep-a", Model: "claude-sonnet-4", StatusCode: 200, DurationMs: 300, AttemptNumber: 2, ThinkingEnabled: true, ResponseBody: `{"text":"部署完成"}`},
+		{Timestamp: now.Add(-1 * time.Hour), RequestID: "req-3", Endpoint: "ep-b", Model: "claude-opus-4", StatusCode: 503, DurationMs: 5000, AttemptNumber: 1, Tags: []string{"long", "vip"}, Error: "upstream timeout", SessionID: "sess-1"},
+	}
+	for _, entry := range entries {
+		storage.SaveLog(entry)
+	}
+
+	since := now.Add(-48 * time.Hour)
+	until := now.Add(-24 * time.Hour)
+	thinking := true
+
+	tests := []struct {
+		name     string
+		query    LogQuery
+		expected []string
+	}{
+		{"endpoint and 5xx", LogQuery{Endpoint: "ep-a", StatusMin: 500, StatusMax: 599}, []string{"req-1"}},
+		{"model substring", LogQuery{Model: "opus"}, []string{"req-3", "req-1"}},
+		{"time range", LogQuery{Since: &since, Until: &until}, []string{"req-2", "req-1"}},
+		{"all tags", LogQuery{Tags: []string{"long", "vip"}}, []string{"req-3"}},
+		{"duration range", LogQuery{DurationMin: 1000, DurationMax: 2000}, []string{"req-1"}},
+		{"attempt", LogQuery{AttemptNumber: 2}, []string{"req-2"}},
+		{"thinking", LogQuery{ThinkingEnabled: &thinking}, []string{"req-2"}},
+		{"error substring", LogQuery{ErrorContains: "timeout"}, []string{"req-3"}},
+		{"session", LogQuery{SessionID: "sess-1"}, []string{"req-3"}},
+		{"full text", LogQuery{Text: "kubernetes"}, []string{"req-1"}},
+		{"full text cjk", LogQuery{Text: "部署完"}, []string{"req-2"}},
+		{"short text falls back to like", LogQuery{Text: "部署"}, []string{"req-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Limit = 10
+			logs, total, err := storage.QueryLogs(tt.query)
+			if err != nil {
+				t.Fatalf("QueryLogs failed: %v", err)
+			}
+			if total != len(tt.expected) || len(logs) != len(tt.expected) {
+				t.Fatalf("Expected %d logs, got %d (total %d)", len(tt.expected), len(logs), total)
+			}
+			for i, id := range tt.expected {
+				if logs[i].RequestID != id {
+					t.Errorf("Expected log %d to be %s, got %s", i, id, logs[i].RequestID)
+				}
+			}
+		})
+	}
+
+	if !storage.ftsEnabled.Load() {
+		t.Error("Expected FTS5 full-text index to be available")
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	storage, err := NewGORMStorage(t.TempDir(), logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	now := time.Now()
+	entries := []*RequestLog{
+		{Timestamp: now.Add(-5 * time.Minute), RequestID: "r3", SessionID: "s2", Project: "web", Endpoint: "ep-a", StatusCode: 200, AttemptNumber: 1, InputTokens: 4},
+		{Timestamp: now.Add(-3 * time.Minute), RequestID: "r1", SessionID: "s1", Project: "api", Endpoint: "ep-a", StatusCode: 200, AttemptNumber: 1, InputTokens: 10, OutputTokens: 5},
+		{Timestamp: now.Add(-2 * time.Minute), RequestID: "r2", SessionID: "s1", Project: "api", Endpoint: "ep-a", StatusCode: 502, AttemptNumber: 1},
+		{Timestamp: now.Add(-1 * time.Minute), RequestID: "r2", SessionID: "s1", Project: "api", Endpoint: "ep-b", StatusCode: 200, AttemptNumber: 2, InputTokens: 20, OutputTokens: 7},
+		{Timestamp: now, RequestID: "r4", Endpoint: "ep-a", StatusCode: 200, AttemptNumber: 1},
+	}
+	for _, entry := range entries {
+		storage.SaveLog(entry)
+	}
+
+	sessions, total, err := storage.GetSessions(10, 0, "")
+	if err != nil {
+		t.Fatalf("GetSessions failed: %v", err)
+	}
+	if total != 2 || len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d (total %d)", len(sessions), total)
+	}
+
+	s1 := sessions[0]
+	if s1.SessionID != "s1" || s1.RequestCount != 2 || s1.AttemptCount != 3 || s1.FailoverCount != 1 || s1.FailedCount != 1 {
+		t.Errorf("Unexpected session summary: %+v", s1)
+	}
+	if s1.InputTokens != 30 || s1.OutputTokens != 12 || len(s1.Endpoints) != 2 {
+		t.Errorf("Unexpected session totals: %+v", s1)
+	}
+	if !s1.StartTime.Before(s1.EndTime) {
+		t.Errorf("Expected start time before end time, got %v - %v", s1.StartTime, s1.EndTime)
+	}
+
+	sessions, total, err = storage.GetSessions(10, 0, "web")
+	if err != nil || total != 1 || len(sessions) != 1 || sessions[0].SessionID != "s2" {
+		t.Errorf("Expected only the web session, got %d (total %d, err %v)", len(sessions), total, err)
+	}
+
+	stats, err := storage.GetTokenStats(LogQuery{})
+	if err != nil {
+		t.Fatalf("GetTokenStats failed: %v", err)
+	}
+	if len(stats) != 3 || stats[0].Project != "api" || stats[0].RequestCount != 2 || stats[0].InputTokens != 30 || stats[0].OutputTokens != 12 {
+		t.Errorf("Unexpected token stats: %+v", stats)
+	}
+	if stats, _ := storage.GetTokenStats(LogQuery{Project: "web"}); len(stats) != 1 || stats[0].InputTokens != 4 {
+		t.Errorf("Expected token stats filtered to the web project, got %+v", stats)
+	}
+
+	logs, err := storage.GetLogsBySessionID("s1")
+	if err != nil {
+		t.Fatalf("GetLogsBySessionID failed: %v", err)
+	}
+	summary := SummarizeSession("s1", logs)
+	if summary.RequestCount != s1.RequestCount || summary.FailoverCount != s1.FailoverCount || summary.InputTokens != s1.InputTokens {
+		t.Errorf("SummarizeSession %+v does not match GetSessions %+v", summary, s1)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	appconfig "claude-code-companion/internal/config"
+	"claude-code-companion/internal/events"
 	"claude-code-companion/internal/utils"
 
 	"github.com/sirupsen/logrus"
@@ -35,6 +38,15 @@ type RequestLog struct {
 	Tags                 []string          `json:"tags,omitempty"`
 	ContentTypeOverride  string            `json:"content_type_override,omitempty"`
 	SessionID            string            `json:"session_id,omitempty"`
+	Project              string            `json:"project,omitempty"`              // 从 Claude Code 工作目录识别的项目名
+	FallbackChain        string            `json:"fallback_chain,omitempty"`       // 命中的tag回退链名称
+	FallbackLevel        int               `json:"fallback_level"`                 // 回退链级别，0表示未使用回退链
+	Credential           string            `json:"credential,omitempty"`           // 本次尝试使用的凭据名称（凭据池）
+	// Token 用量（从响应中提取）
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 	// Thinking mode fields
 	ThinkingEnabled      bool              `json:"thinking_enabled"`               // 是否启用了 thinking 模式
 	ThinkingBudgetTokens int               `json:"thinking_budget_tokens"`         // thinking 模式的 budget tokens
@@ -65,15 +77,27 @@ type RequestLog struct {
 type StorageInterface interface {
 	SaveLog(log *RequestLog)
 	GetLogs(limit, offset int, failedOnly bool) ([]*RequestLog, int, error)
+	QueryLogs(query LogQuery) ([]*RequestLog, int, error)
 	GetAllLogsByRequestID(requestID string) ([]*RequestLog, error)
+	GetSessions(limit, offset int, project string) ([]*SessionSummary, int, error)
+	GetTokenStats(query LogQuery) ([]*ProjectTokenStats, error)
+	GetLogsBySessionID(sessionID string) ([]*RequestLog, error)
 	CleanupLogsByDays(days int) (int64, error)
+	ApplyRetention(policy appconfig.LogRetentionConfig) (*RetentionResult, error)
+	SetRetentionPolicy(policy appconfig.LogRetentionConfig)
+	GetStats() (map[string]interface{}, error)
 	Close() error
 }
 
 type Logger struct {
-	logger  *logrus.Logger
-	storage StorageInterface
-	config  LogConfig
+	logger   *logrus.Logger
+	storage  StorageInterface
+	config   LogConfig
+	redactor *atomic.Pointer[Redactor] // 指针共享，Logger 按值复制后仍使用同一套规则
+	// skipBodies 判断端点是否禁止记录请求/响应体，由代理服务在启动时设置
+	skipBodies func(endpoint string) bool
+	// events 日志提交后发布 log_written 事件，由代理服务在启动时设置
+	events *events.Hub
 }
 
 type LogConfig struct {
@@ -82,6 +106,10 @@ type LogConfig struct {
 	LogRequestBody  string
 	LogResponseBody string
 	LogDirectory    string
+	Retention       appconfig.LogRetentionConfig
+	Storage         appconfig.LogStorageConfig
+	Writer          appconfig.LogWriterConfig
+	Redaction       appconfig.LogRedactionConfig
 }
 
 func NewLogger(config LogConfig) (*Logger, error) {
@@ -97,22 +125,62 @@ func NewLogger(config LogConfig) (*Logger, error) {
 		TimestampFormat: time.RFC3339,
 	})
 
-	// Use GORM storage instead of SQLite storage
-	storage, err := NewGORMStorage(config.LogDirectory)
+	redactor, err := NewRedactor(config.Redaction)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize GORM log storage: %v", err)
+		return nil, err
+	}
+
+	storage, err := newStorage(config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize log storage: %v", err)
+	}
+	// 经过配置校验的保留策略 MaxAgeDays 不为0，直接构造的 LogConfig 则沿用默认策略
+	if config.Retention.MaxAgeDays != 0 {
+		storage.SetRetentionPolicy(config.Retention)
+	}
+	redactorPtr := &atomic.Pointer[Redactor]{}
+	redactorPtr.Store(redactor)
+	// 默认通过异步队列批量写入，请求路径不等待数据库
+	if !config.Writer.Sync {
+		storage = newAsyncStorage(storage, config.Writer, logger, redactorPtr)
 	}
 
 	return &Logger{
-		logger:  logger,
-		storage: storage,
-		config:  config,
+		logger:   logger,
+		storage:  storage,
+		config:   config,
+		redactor: redactorPtr,
 	}, nil
 }
 
 func (l *Logger) LogRequest(log *RequestLog) {
+	// 持久化前移除正文（按端点配置）和敏感信息
+	if l.skipBodies != nil && l.skipBodies(log.Endpoint) {
+		dropLogBodies(log)
+	}
+	// 异步写入时正文由写入协程脱敏；错误信息还会发布为事件并输出到控制台，在此脱敏
+	if _, async := l.storage.(*asyncStorage); async {
+		log.Error = l.Redactor().RedactString(log.Error)
+	} else {
+		l.Redactor().RedactLog(log)
+	}
+
 	// 总是记录到存储，方便Web界面查看
 	l.storage.SaveLog(log)
+	l.events.Publish(events.Event{
+		Type:         events.LogWritten,
+		RequestID:    log.RequestID,
+		Endpoint:     log.Endpoint,
+		Attempt:      log.AttemptNumber,
+		Model:        log.Model,
+		StatusCode:   log.StatusCode,
+		Error:        log.Error,
+		Success:      log.StatusCode >= 200 && log.StatusCode < 300 && log.Error == "",
+		Streaming:    log.IsStreaming,
+		InputTokens:  log.InputTokens,
+		OutputTokens: log.OutputTokens,
+		DurationMs:   log.DurationMs,
+	})
 
 	// 根据配置决定是否输出到控制台
 	shouldLog := l.shouldLogRequest(log.StatusCode)
@@ -203,6 +271,14 @@ func (l *Logger) GetLogs(limit, offset int, failedOnly bool) ([]*RequestLog, int
 	return l.storage.GetLogs(limit, offset, failedOnly)
 }
 
+// QueryLogs 按结构化条件查询日志
+func (l *Logger) QueryLogs(query LogQuery) ([]*RequestLog, int, error) {
+	if l.storage == nil {
+		return []*RequestLog{}, 0, nil
+	}
+	return l.storage.QueryLogs(query)
+}
+
 func (l *Logger) GetAllLogsByRequestID(requestID string) ([]*RequestLog, error) {
 	if l.storage == nil {
 		return []*RequestLog{}, nil
@@ -210,6 +286,30 @@ func (l *Logger) GetAllLogsByRequestID(requestID string) ([]*RequestLog, error)
 	return l.storage.GetAllLogsByRequestID(requestID)
 }
 
+// GetSessions 获取会话汇总列表，project 非空时只返回该项目的会话
+func (l *Logger) GetSessions(limit, offset int, project string) ([]*SessionSummary, int, error) {
+	if l.storage == nil {
+		return []*SessionSummary{}, 0, nil
+	}
+	return l.storage.GetSessions(limit, offset, project)
+}
+
+// GetTokenStats 按项目汇总满足查询条件的 token 用量
+func (l *Logger) GetTokenStats(query LogQuery) ([]*ProjectTokenStats, error) {
+	if l.storage == nil {
+		return []*ProjectTokenStats{}, nil
+	}
+	return l.storage.GetTokenStats(query)
+}
+
+// GetLogsBySessionID 获取指定会话的所有日志条目
+func (l *Logger) GetLogsBySessionID(sessionID string) ([]*RequestLog, error) {
+	if l.storage == nil {
+		return []*RequestLog{}, nil
+	}
+	return l.storage.GetLogsBySessionID(sessionID)
+}
+
 func (l *Logger) CleanupLogsByDays(days int) (int64, error) {
 	if l.storage == nil {
 		return 0, fmt.Errorf("storage not available")
@@ -217,6 +317,78 @@ func (l *Logger) CleanupLogsByDays(days int) (int64, error) {
 	return l.storage.CleanupLogsByDays(days)
 }
 
+// GetStats 获取日志存储的统计信息
+func (l *Logger) GetStats() (map[string]interface{}, error) {
+	if l.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	return l.storage.GetStats()
+}
+
+// ApplyRetention 立即按保留策略清理日志
+func (l *Logger) ApplyRetention(policy appconfig.LogRetentionConfig) (*RetentionResult, error) {
+	if l.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	return l.storage.ApplyRetention(policy)
+}
+
+// Flush 等待异步写入队列中的日志全部落盘，同步写入时直接返回
+func (l *Logger) Flush() {
+	if writer, ok := l.storage.(*asyncStorage); ok {
+		writer.Flush()
+	}
+}
+
+// WriterStats 返回异步写入队列的运行指标，同步写入时返回 false
+func (l *Logger) WriterStats() (WriterStats, bool) {
+	if writer, ok := l.storage.(*asyncStorage); ok {
+		return writer.Stats(), true
+	}
+	return WriterStats{}, false
+}
+
+// Redactor 返回当前使用的脱敏器，调试导出等场景复用同一套规则
+func (l *Logger) Redactor() *Redactor {
+	if l.redactor == nil {
+		return nil
+	}
+	return l.redactor.Load()
+}
+
+// SetRedactionPolicy 更新脱敏规则，只影响之后写入的日志
+func (l *Logger) SetRedactionPolicy(config appconfig.LogRedactionConfig) error {
+	redactor, err := NewRedactor(config)
+	if err != nil {
+		return err
+	}
+	if l.redactor == nil {
+		l.redactor = &atomic.Pointer[Redactor]{}
+	}
+	l.config.Redaction = config
+	l.redactor.Store(redactor)
+	return nil
+}
+
+// SetBodyLoggingFilter 设置按端点禁止记录正文的判断函数，需在开始处理请求前调用
+func (l *Logger) SetBodyLoggingFilter(skipBodies func(endpoint string) bool) {
+	l.skipBodies = skipBodies
+}
+
+// SetEventHub 设置日志写入事件的发布目标，需在开始处理请求前调用
+func (l *Logger) SetEventHub(hub *events.Hub) {
+	l.events = hub
+}
+
+// SetRetentionPolicy 更新后台清理使用的保留策略
+func (l *Logger) SetRetentionPolicy(policy appconfig.LogRetentionConfig) {
+	if l.storage == nil {
+		return
+	}
+	l.config.Retention = policy
+	l.storage.SetRetentionPolicy(policy)
+}
+
 
 func (l *Logger) CreateRequestLog(requestID, endpoint, method, path string) *RequestLog {
 	return &RequestLog{
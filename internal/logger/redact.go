@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	appconfig "claude-code-companion/internal/config"
+)
+
+// RedactedValue 替换敏感内容的占位符
+const RedactedValue = "[REDACTED]"
+
+// sensitiveHeaders 内置的整体脱敏请求/响应头（规范化后的名称）
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// redactionRule 正文脱敏规则，replacement 中可以引用捕获组保留上下文
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// builtinRedactionRules 内置的密钥格式，覆盖常见的 API Key、OAuth 令牌和私钥
+var builtinRedactionRules = []redactionRule{
+	// PEM 私钥
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), RedactedValue},
+	// JSON 中的令牌字段，保留字段名
+	{regexp.MustCompile(`("(?:access_token|refresh_token|id_token|api_key|apiKey|client_secret|password|secret)"\s*:\s*")[^"]+(")`), "${1}" + RedactedValue + "${2}"},
+	// Bearer 令牌，保留认证方案
+	{regexp.MustCompile(`((?i:bearer)\s+)[A-Za-z0-9._~+/=-]{16,}`), "${1}" + RedactedValue},
+	// URL 查询参数中的密钥
+	{regexp.MustCompile(`([?&](?:key|api_key|access_token|token)=)[^&\s"]+`), "${1}" + RedactedValue},
+	// Anthropic / OpenAI 风格的密钥（sk-ant-api03-...、sk-proj-...、sk-...）
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`), RedactedValue},
+	// Google API Key
+	{regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`), RedactedValue},
+	// AWS Access Key ID
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), RedactedValue},
+	// GitHub 令牌
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})`), RedactedValue},
+	// Slack 令牌
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), RedactedValue},
+	// JWT
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), RedactedValue},
+}
+
+// Redactor 在日志持久化和导出前移除认证信息和密钥
+type Redactor struct {
+	disabled bool
+	headers  map[string]bool
+	rules    []redactionRule
+}
+
+// NewRedactor 根据配置创建脱敏器，用户正则追加在内置规则之后
+func NewRedactor(config appconfig.LogRedactionConfig) (*Redactor, error) {
+	r := &Redactor{
+		disabled: config.Disabled,
+		headers:  make(map[string]bool, len(sensitiveHeaders)+len(config.Headers)),
+		rules:    append([]redactionRule(nil), builtinRedactionRules...),
+	}
+	for _, header := range sensitiveHeaders {
+		r.headers[header] = true
+	}
+	for _, header := range config.Headers {
+		r.headers[http.CanonicalHeaderKey(strings.TrimSpace(header))] = true
+	}
+	for _, pattern := range config.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern '%s': %v", pattern, err)
+		}
+		r.rules = append(r.rules, redactionRule{pattern: re, replacement: RedactedValue})
+	}
+	return r, nil
+}
+
+// RedactString 按正文规则替换文本中的密钥
+func (r *Redactor) RedactString(s string) string {
+	if r == nil || r.disabled || s == "" {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// RedactHeaders 返回脱敏后的请求头副本：敏感头整体替换，其余头按正文规则处理
+func (r *Redactor) RedactHeaders(headers map[string]string) map[string]string {
+	if r == nil || r.disabled || len(headers) == 0 {
+		return headers
+	}
+	redacted := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if r.headers[http.CanonicalHeaderKey(key)] {
+			redacted[key] = redactHeaderValue(value)
+		} else {
+			redacted[key] = r.RedactString(value)
+		}
+	}
+	return redacted
+}
+
+// redactHeaderValue 整体替换头的值，保留 Bearer/Basic 等认证方案便于排查
+func redactHeaderValue(value string) string {
+	if value == "" || value == RedactedValue {
+		return value
+	}
+	if scheme, _, ok := strings.Cut(value, " "); ok && len(scheme) <= 10 {
+		return scheme + " " + RedactedValue
+	}
+	return RedactedValue
+}
+
+// RedactLog 原地脱敏日志中的请求头、正文、URL 和错误信息
+func (r *Redactor) RedactLog(log *RequestLog) {
+	if r == nil || r.disabled {
+		return
+	}
+	log.RequestHeaders = r.RedactHeaders(log.RequestHeaders)
+	log.ResponseHeaders = r.RedactHeaders(log.ResponseHeaders)
+	log.OriginalRequestHeaders = r.RedactHeaders(log.OriginalRequestHeaders)
+	log.OriginalResponseHeaders = r.RedactHeaders(log.OriginalResponseHeaders)
+	log.FinalRequestHeaders = r.RedactHeaders(log.FinalRequestHeaders)
+	log.FinalResponseHeaders = r.RedactHeaders(log.FinalResponseHeaders)
+
+	log.RequestBody = r.RedactString(log.RequestBody)
+	log.ResponseBody = r.RedactString(log.ResponseBody)
+	log.OriginalRequestBody = r.RedactString(log.OriginalRequestBody)
+	log.OriginalResponseBody = r.RedactString(log.OriginalResponseBody)
+	log.FinalRequestBody = r.RedactString(log.FinalRequestBody)
+	log.FinalResponseBody = r.RedactString(log.FinalResponseBody)
+
+	log.OriginalRequestURL = r.RedactString(log.OriginalRequestURL)
+	log.FinalRequestURL = r.RedactString(log.FinalRequestURL)
+	log.Error = r.RedactString(log.Error)
+}
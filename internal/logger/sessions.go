@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionSummary Claude Code 会话的请求汇总
+type SessionSummary struct {
+	SessionID                string    `json:"session_id"`
+	Project                  string    `json:"project,omitempty"`
+	StartTime                time.Time `json:"start_time"`
+	EndTime                  time.Time `json:"end_time"`
+	RequestCount             int       `json:"request_count"`  // 不同 request_id 的数量
+	AttemptCount             int       `json:"attempt_count"`  // 包括重试在内的全部尝试次数
+	FailoverCount            int       `json:"failover_count"` // 需要多次尝试才完成的请求数
+	FailedCount              int       `json:"failed_count"`   // 失败的尝试次数
+	Endpoints                []string  `json:"endpoints"`
+	Models                   []string  `json:"models"`
+	InputTokens              int       `json:"input_tokens"`
+	OutputTokens             int       `json:"output_tokens"`
+	CacheCreationInputTokens int       `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int       `json:"cache_read_input_tokens"`
+}
+
+// sessionAggregateRow 会话聚合查询的结果行
+type sessionAggregateRow struct {
+	SessionID                string
+	Project                  string
+	FirstID                  uint
+	LastID                   uint
+	RequestCount             int
+	AttemptCount             int
+	FailoverCount            int
+	FailedCount              int
+	Endpoints                string
+	Models                   string
+	InputTokens              int
+	OutputTokens             int
+	CacheCreationInputTokens int
+	CacheReadInputTokens     int
+}
+
+// GetSessions 按最近活动时间倒序返回会话汇总列表，project 非空时只返回该项目的会话
+func (g *GORMStorage) GetSessions(limit, offset int, project string) ([]*SessionSummary, int, error) {
+	grouped := func() *gorm.DB {
+		query := g.db.Model(&GormRequestLog{}).Where("session_id != ?", "").Group("session_id")
+		if project != "" {
+			// 与列表中展示的项目保持一致，按会话聚合后的项目过滤
+			query = query.Having("MAX(project) = ?", project)
+		}
+		return query
+	}
+
+	var total int64
+	if err := g.db.Table("(?) AS sessions", grouped().Select("session_id")).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %v", err)
+	}
+
+	var rows []sessionAggregateRow
+	err := grouped().
+		Select(`session_id,
+			MAX(project) AS project,
+			MIN(id) AS first_id,
+			MAX(id) AS last_id,
+			COUNT(DISTINCT request_id) AS request_count,
+			COUNT(*) AS attempt_count,
+			COUNT(DISTINCT CASE WHEN attempt_number > 1 THEN request_id END) AS failover_count,
+			SUM(CASE WHEN status_code >= 400 OR error != '' THEN 1 ELSE 0 END) AS failed_count,
+			`+g.groupConcatDistinct("endpoint")+` AS endpoints,
+			`+g.groupConcatDistinct("model")+` AS models,
+			SUM(input_tokens) AS input_tokens,
+			SUM(output_tokens) AS output_tokens,
+			SUM(cache_creation_input_tokens) AS cache_creation_input_tokens,
+			SUM(cache_read_input_tokens) AS cache_read_input_tokens`).
+		Order("last_id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sessions: %v", err)
+	}
+
+	// 聚合结果中的时间戳丢失了列类型，通过首尾记录的ID单独读取
+	ids := make([]uint, 0, len(rows)*2)
+	for _, row := range rows {
+		ids = append(ids, row.FirstID, row.LastID)
+	}
+	timestamps := make(map[uint]time.Time, len(ids))
+	if len(ids) > 0 {
+		var boundaries []GormRequestLog
+		if err := g.db.Select("id", "timestamp").Where("id IN ?", ids).Find(&boundaries).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to query session time range: %v", err)
+		}
+		for _, b := range boundaries {
+			timestamps[b.ID] = b.Timestamp
+		}
+	}
+
+	sessions := make([]*SessionSummary, len(rows))
+	for i, row := range rows {
+		sessions[i] = &SessionSummary{
+			SessionID:                row.SessionID,
+			Project:                  row.Project,
+			StartTime:                timestamps[row.FirstID],
+			EndTime:                  timestamps[row.LastID],
+			RequestCount:             row.RequestCount,
+			AttemptCount:             row.AttemptCount,
+			FailoverCount:            row.FailoverCount,
+			FailedCount:              row.FailedCount,
+			Endpoints:                splitConcat(row.Endpoints),
+			Models:                   splitConcat(row.Models),
+			InputTokens:              row.InputTokens,
+			OutputTokens:             row.OutputTokens,
+			CacheCreationInputTokens: row.CacheCreationInputTokens,
+			CacheReadInputTokens:     row.CacheReadInputTokens,
+		}
+	}
+
+	return sessions, int(total), nil
+}
+
+// GetLogsBySessionID 按时间顺序获取指定会话的所有日志条目
+func (g *GORMStorage) GetLogsBySessionID(sessionID string) ([]*RequestLog, error) {
+	var gormLogs []GormRequestLog
+
+	err := g.db.Where("session_id = ?", sessionID).
+		Order("timestamp ASC, id ASC").
+		Find(&gormLogs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs by session ID: %v", err)
+	}
+	if err := loadBodies(g.db, gormLogs); err != nil {
+		return nil, err
+	}
+
+	logs := make([]*RequestLog, len(gormLogs))
+	for i, gormLog := range gormLogs {
+		logs[i] = ConvertFromGormRequestLog(&gormLog)
+	}
+	return logs, nil
+}
+
+// splitConcat 拆分逗号拼接的聚合结果，忽略空值
+func splitConcat(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// SummarizeSession 根据会话的日志条目计算汇总信息，logs 需按时间顺序排列
+func SummarizeSession(sessionID string, logs []*RequestLog) *SessionSummary {
+	summary := &SessionSummary{
+		SessionID: sessionID,
+		Endpoints: []string{},
+		Models:    []string{},
+	}
+
+	requests := make(map[string]bool)
+	failovers := make(map[string]bool)
+	endpoints := make(map[string]bool)
+	models := make(map[string]bool)
+
+	for i, log := range logs {
+		if i == 0 {
+			summary.StartTime = log.Timestamp
+		}
+		summary.EndTime = log.Timestamp
+		if log.Project != "" {
+			summary.Project = log.Project
+		}
+
+		summary.AttemptCount++
+		requests[log.RequestID] = true
+		if log.AttemptNumber > 1 {
+			failovers[log.RequestID] = true
+		}
+		if log.StatusCode >= 400 || log.Error != "" {
+			summary.FailedCount++
+		}
+		if log.Endpoint != "" && !endpoints[log.Endpoint] {
+			endpoints[log.Endpoint] = true
+			summary.Endpoints = append(summary.Endpoints, log.Endpoint)
+		}
+		if log.Model != "" && !models[log.Model] {
+			models[log.Model] = true
+			summary.Models = append(summary.Models, log.Model)
+		}
+
+		summary.InputTokens += log.InputTokens
+		summary.OutputTokens += log.OutputTokens
+		summary.CacheCreationInputTokens += log.CacheCreationInputTokens
+		summary.CacheReadInputTokens += log.CacheReadInputTokens
+	}
+
+	summary.RequestCount = len(requests)
+	summary.FailoverCount = len(failovers)
+	return summary
+}
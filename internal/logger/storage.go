@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	appconfig "claude-code-companion/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// storageFactory 根据日志配置创建存储后端
+type storageFactory func(config LogConfig, log *logrus.Logger) (StorageInterface, error)
+
+// storageBackends 已注册的日志存储后端，需要额外驱动的后端（如 postgres）在各自文件的 init() 中注册
+var storageBackends = map[string]storageFactory{
+	"sqlite": func(config LogConfig, log *logrus.Logger) (StorageInterface, error) {
+		return NewGORMStorage(config.LogDirectory, log)
+	},
+	"jsonl": func(config LogConfig, log *logrus.Logger) (StorageInterface, error) {
+		maxFileSizeMB := config.Storage.MaxFileSizeMB
+		if maxFileSizeMB <= 0 {
+			maxFileSizeMB = appconfig.Default.Logging.JSONLMaxFileMB
+		}
+		return NewJSONLStorage(config.LogDirectory, int64(maxFileSizeMB)*1024*1024, log)
+	},
+}
+
+// registerStorageBackend 注册日志存储后端
+func registerStorageBackend(name string, factory storageFactory) {
+	storageBackends[name] = factory
+}
+
+// newStorage 按配置创建日志存储后端，未配置时使用 SQLite
+func newStorage(config LogConfig, log *logrus.Logger) (StorageInterface, error) {
+	backend := config.Storage.Backend
+	if backend == "" {
+		backend = appconfig.Default.Logging.StorageBackend
+	}
+
+	factory, ok := storageBackends[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown log storage backend: %s", backend)
+	}
+	return factory(config, log)
+}
+
+// runRetentionLoop 按间隔执行保留策略，直到收到停止信号，各存储后端的后台清理程序共用
+func runRetentionLoop(ticker *time.Ticker, stop <-chan struct{}, policy func() appconfig.LogRetentionConfig,
+	apply func(appconfig.LogRetentionConfig) (*RetentionResult, error), log *logrus.Logger) {
+	for {
+		select {
+		case <-ticker.C:
+			result, err := apply(policy())
+			if err != nil {
+				log.WithError(err).Error("Background cleanup error")
+			} else if result.Changed() {
+				log.Infof("Background cleanup: deleted %d log entries (age %d, count %d, size %d), truncated %d bodies",
+					result.Deleted(), result.DeletedByAge, result.DeletedByCount, result.DeletedBySize, result.TruncatedBodies)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 	"os"
 	"fmt"
+
+	"github.com/sirupsen/logrus"
 )
 
 // 生成测试日志
@@ -37,7 +39,7 @@ func generateTestLog(index int) *RequestLog {
 
 func setupGORMStorage() (*GORMStorage, func()) {
 	tempDir := "./benchmark_gorm_storage"
-	storage, err := NewGORMStorage(tempDir)
+	storage, err := NewGORMStorage(tempDir, logrus.New())
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create GORM storage: %v", err))
 	}
@@ -52,7 +54,7 @@ func setupGORMStorage() (*GORMStorage, func()) {
 
 func setupSQLiteStorage() (*GORMStorage, func()) {
 	tempDir := "./benchmark_sqlite_storage"
-	storage, err := NewGORMStorage(tempDir)
+	storage, err := NewGORMStorage(tempDir, logrus.New())
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create SQLite storage: %v", err))
 	}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProjectTokenStats 一个项目的 token 用量汇总，未识别出项目的请求汇总在 Project 为空的一行
+type ProjectTokenStats struct {
+	Project                  string `json:"project"`
+	RequestCount             int    `json:"request_count"` // 不同 request_id 的数量
+	InputTokens              int    `json:"input_tokens"`
+	OutputTokens             int    `json:"output_tokens"`
+	CacheCreationInputTokens int    `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int    `json:"cache_read_input_tokens"`
+}
+
+// GetTokenStats 按项目汇总满足查询条件的 token 用量，按总 token 数倒序，忽略分页参数
+func (g *GORMStorage) GetTokenStats(q LogQuery) ([]*ProjectTokenStats, error) {
+	var stats []*ProjectTokenStats
+	err := g.applyLogQuery(g.db.Model(&GormRequestLog{}), q).
+		Select(`project,
+			COUNT(DISTINCT request_id) AS request_count,
+			SUM(input_tokens) AS input_tokens,
+			SUM(output_tokens) AS output_tokens,
+			SUM(cache_creation_input_tokens) AS cache_creation_input_tokens,
+			SUM(cache_read_input_tokens) AS cache_read_input_tokens`).
+		Group("project").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token stats: %v", err)
+	}
+	sortTokenStats(stats)
+	return stats, nil
+}
+
+// GetTokenStats 按项目汇总满足查询条件的 token 用量，按总 token 数倒序，忽略分页参数
+func (s *JSONLStorage) GetTokenStats(q LogQuery) ([]*ProjectTokenStats, error) {
+	byProject := make(map[string]*ProjectTokenStats)
+	requests := make(map[string]map[string]bool)
+
+	err := s.scan(func(log *RequestLog) {
+		if !q.Matches(log) {
+			return
+		}
+		stats, ok := byProject[log.Project]
+		if !ok {
+			stats = &ProjectTokenStats{Project: log.Project}
+			byProject[log.Project] = stats
+			requests[log.Project] = make(map[string]bool)
+		}
+		if !requests[log.Project][log.RequestID] {
+			requests[log.Project][log.RequestID] = true
+			stats.RequestCount++
+		}
+		stats.InputTokens += log.InputTokens
+		stats.OutputTokens += log.OutputTokens
+		stats.CacheCreationInputTokens += log.CacheCreationInputTokens
+		stats.CacheReadInputTokens += log.CacheReadInputTokens
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSONL logs: %v", err)
+	}
+
+	stats := make([]*ProjectTokenStats, 0, len(byProject))
+	for _, item := range byProject {
+		stats = append(stats, item)
+	}
+	sortTokenStats(stats)
+	return stats, nil
+}
+
+func (p *ProjectTokenStats) totalTokens() int {
+	return p.InputTokens + p.OutputTokens + p.CacheCreationInputTokens + p.CacheReadInputTokens
+}
+
+// sortTokenStats 按总 token 数倒序排列，相同时按项目名排序保证结果稳定
+func sortTokenStats(stats []*ProjectTokenStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].totalTokens() != stats[j].totalTokens() {
+			return stats[i].totalTokens() > stats[j].totalTokens()
+		}
+		return stats[i].Project < stats[j].Project
+	})
+}
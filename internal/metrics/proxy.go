@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default 进程内共享的指标注册表，通过 /admin/metrics 输出
+var Default = NewRegistry()
+
+// startTime 进程启动时间，用于计算运行时长
+var startTime = time.Now()
+
+var (
+	// RequestDurationBuckets 上游请求耗时的桶，覆盖长时间的流式响应
+	RequestDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+	// TTFBBuckets 上游首字节时间的桶
+	TTFBBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60}
+	// TaggerDurationBuckets tagger 执行耗时的桶
+	TaggerDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+)
+
+// 代理请求相关指标，每次向上游端点的尝试记录一次
+var (
+	RequestDuration = Default.NewHistogramVec("claude_proxy_request_duration_seconds",
+		"Duration of upstream request attempts, including reading the full response",
+		RequestDurationBuckets, "endpoint", "model", "status_class", "tag")
+
+	TimeToFirstByte = Default.NewHistogramVec("claude_proxy_request_ttfb_seconds",
+		"Time from sending an upstream request attempt until response headers are received",
+		TTFBBuckets, "endpoint", "model", "status_class", "tag")
+
+	Retries = Default.NewCounterVec("claude_proxy_retries_total",
+		"Total number of in-place retries on the same endpoint by error category", "category")
+
+	Failovers = Default.NewCounterVec("claude_proxy_failovers_total",
+		"Total number of switches to another endpoint by error category", "category")
+
+	Tokens = Default.NewCounterVec("claude_proxy_tokens_total",
+		"Total number of tokens reported by upstream responses", "endpoint", "model", "type")
+
+	InFlight = Default.NewGaugeVec("claude_proxy_requests_in_flight",
+		"Number of client requests currently being proxied")
+
+	TaggerDuration = Default.NewHistogramVec("claude_proxy_tagger_duration_seconds",
+		"Duration of tagger executions by tagger and result", TaggerDurationBuckets, "tagger", "result")
+)
+
+func init() {
+	Default.NewGaugeFunc("claude_proxy_uptime_seconds", "Proxy uptime in seconds", func() float64 {
+		return time.Since(startTime).Seconds()
+	})
+	Default.NewGaugeFunc("claude_proxy_start_time_seconds", "Start time of the proxy since unix epoch in seconds", func() float64 {
+		return float64(startTime.UnixNano()) / 1e9
+	})
+}
+
+// StatusClass 将状态码归类为 2xx/4xx/5xx 等，没有响应（网络错误等）时为 error
+func StatusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// TagLabel 将请求标签排序后以逗号拼接，没有标签时为 none
+func TagLabel(tags []string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// modelLabelSet 允许作为 model 标签的取值，由配置中出现的模型名和模型重写的源模式组成
+type modelLabelSet struct {
+	models   map[string]bool
+	patterns []string
+}
+
+// modelLabels 当前生效的 model 标签集合，未设置时所有模型都归为 other
+var modelLabels atomic.Pointer[modelLabelSet]
+
+// SetModelLabels 设置允许作为 model 标签的模型名和通配符模式
+// 客户端可以发送任意模型名，只保留配置中出现的模型，避免标签基数无限增长
+func SetModelLabels(models, patterns []string) {
+	set := &modelLabelSet{models: make(map[string]bool, len(models)), patterns: patterns}
+	for _, model := range models {
+		set.models[model] = true
+	}
+	modelLabels.Store(set)
+}
+
+// ModelLabel 将模型名映射为有限的标签值：配置中出现的模型保持原样，匹配重写源模式的模型使用该模式，
+// 其他模型归为 other，没有识别出模型时使用 unknown
+func ModelLabel(model string) string {
+	if model == "" {
+		return "unknown"
+	}
+	set := modelLabels.Load()
+	if set == nil {
+		return "other"
+	}
+	if set.models[model] {
+		return model
+	}
+	for _, pattern := range set.patterns {
+		if matched, err := filepath.Match(pattern, model); err == nil && matched {
+			return pattern
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Kind 回调指标的类型，对应 Prometheus 文本格式中的 TYPE
+type Kind string
+
+const (
+	KindCounter Kind = "counter"
+	KindGauge   Kind = "gauge"
+)
+
+func (k Kind) valueType() prometheus.ValueType {
+	if k == KindCounter {
+		return prometheus.CounterValue
+	}
+	return prometheus.GaugeValue
+}
+
+// Registry 基于 prometheus/client_golang 的指标注册表
+// 同名指标重复注册时替换旧的采集器（例如管理服务重新创建时），不会像 client_golang 那样返回错误
+type Registry struct {
+	mu         sync.Mutex
+	registry   *prometheus.Registry
+	collectors map[string]prometheus.Collector
+}
+
+// NewRegistry 创建空的指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		registry:   prometheus.NewRegistry(),
+		collectors: make(map[string]prometheus.Collector),
+	}
+}
+
+// register 注册采集器，同名采集器会被替换
+func (r *Registry) register(name string, c prometheus.Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.collectors[name]; ok {
+		r.registry.Unregister(existing)
+	}
+	if err := r.registry.Register(c); err != nil {
+		log.Printf("Failed to register metric %s: %v", name, err)
+		return
+	}
+	r.collectors[name] = c
+}
+
+// Handler 返回输出所有指标的 HTTP 处理器，按 Accept 头协商输出格式
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// WriteText 以 Prometheus 文本格式输出所有指标，个别指标采集失败时仍输出其余指标并返回错误
+func (r *Registry) WriteText(w io.Writer) error {
+	families, gatherErr := r.registry.Gather()
+	encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	return gatherErr
+}
+
+// reportLabelError 标签数量与注册时不一致属于调用方的编程错误，记录后丢弃该次观测，不影响请求处理
+func reportLabelError(name string, err error) {
+	log.Printf("Failed to record metric %s: %v", name, err)
+}
+
+// CounterVec 只增不减的计数器
+type CounterVec struct {
+	name string
+	vec  *prometheus.CounterVec
+}
+
+// NewCounterVec 注册计数器
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{name: name, vec: prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)}
+	r.register(name, c.vec)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 delta，负数会被忽略
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta <= 0 {
+		return
+	}
+	counter, err := c.vec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		reportLabelError(c.name, err)
+		return
+	}
+	counter.Add(delta)
+}
+
+// GaugeVec 可增可减的仪表盘
+type GaugeVec struct {
+	name string
+	vec  *prometheus.GaugeVec
+}
+
+// NewGaugeVec 注册仪表盘
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{name: name, vec: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)}
+	r.register(name, g.vec)
+	return g
+}
+
+func (g *GaugeVec) gauge(labelValues []string) (prometheus.Gauge, bool) {
+	gauge, err := g.vec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		reportLabelError(g.name, err)
+		return nil, false
+	}
+	return gauge, true
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	if gauge, ok := g.gauge(labelValues); ok {
+		gauge.Set(value)
+	}
+}
+
+// Inc 加一
+func (g *GaugeVec) Inc(labelValues ...string) {
+	if gauge, ok := g.gauge(labelValues); ok {
+		gauge.Inc()
+	}
+}
+
+// Dec 减一
+func (g *GaugeVec) Dec(labelValues ...string) {
+	if gauge, ok := g.gauge(labelValues); ok {
+		gauge.Dec()
+	}
+}
+
+// HistogramVec 按桶统计观测值分布的直方图
+type HistogramVec struct {
+	name string
+	vec  *prometheus.HistogramVec
+}
+
+// NewHistogramVec 注册直方图，buckets 为升序的桶上界（不含 +Inf）
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		name: name,
+		vec:  prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: sorted}, labelNames),
+	}
+	r.register(name, h.vec)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	observer, err := h.vec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		reportLabelError(h.name, err)
+		return
+	}
+	observer.Observe(value)
+}
+
+// funcCollector 在采集时通过回调生成数值，用于端点状态等已有统计
+type funcCollector struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	collect   func(emit func(value float64, labelValues ...string))
+}
+
+// NewCollectorFunc 注册在采集时计算的指标，collect 对每组标签调用一次 emit
+func (r *Registry) NewCollectorFunc(name, help string, kind Kind, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	r.register(name, &funcCollector{
+		desc:      prometheus.NewDesc(name, help, labelNames, nil),
+		valueType: kind.valueType(),
+		collect:   collect,
+	})
+}
+
+// NewGaugeFunc 注册无标签、在采集时计算的仪表盘
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.NewCollectorFunc(name, help, KindGauge, nil, func(emit func(float64, ...string)) {
+		emit(fn())
+	})
+}
+
+func (f *funcCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.desc
+}
+
+func (f *funcCollector) Collect(ch chan<- prometheus.Metric) {
+	f.collect(func(value float64, labelValues ...string) {
+		metric, err := prometheus.NewConstMetric(f.desc, f.valueType, value, labelValues...)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(f.desc, err)
+			return
+		}
+		ch <- metric
+	})
+}
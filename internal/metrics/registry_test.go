@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("test_requests_total", "Total requests", "endpoint")
+	counter.Inc("b")
+	counter.Add(2, "a")
+	counter.Add(-1, "a")
+	counter.Inc(`quote"d`)
+
+	histogram := registry.NewHistogramVec("test_duration_seconds", "Duration", []float64{1, 0.5}, "endpoint")
+	histogram.Observe(0.3, "a")
+	histogram.Observe(0.7, "a")
+	histogram.Observe(5, "a")
+
+	registry.NewGaugeFunc("test_up", "Up", func() float64 { return 1 })
+
+	var b strings.Builder
+	if err := registry.WriteText(&b); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	output := b.String()
+
+	expected := []string{
+		"# HELP test_requests_total Total requests\n# TYPE test_requests_total counter\n",
+		"test_requests_total{endpoint=\"a\"} 2\ntest_requests_total{endpoint=\"b\"} 1\n",
+		`test_requests_total{endpoint="quote\"d"} 1`,
+		"# TYPE test_duration_seconds histogram\n",
+		`test_duration_seconds_bucket{endpoint="a",le="0.5"} 1`,
+		`test_duration_seconds_bucket{endpoint="a",le="1"} 2`,
+		`test_duration_seconds_bucket{endpoint="a",le="+Inf"} 3`,
+		`test_duration_seconds_sum{endpoint="a"} 6`,
+		`test_duration_seconds_count{endpoint="a"} 3`,
+		"test_up 1\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRegistryReplacesCollectorWithSameName(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewGaugeFunc("test_value", "Value", func() float64 { return 1 })
+	registry.NewGaugeFunc("test_value", "Value", func() float64 { return 2 })
+
+	var b strings.Builder
+	registry.WriteText(&b)
+	if strings.Count(b.String(), "# TYPE test_value") != 1 || !strings.Contains(b.String(), "test_value 2\n") {
+		t.Errorf("Expected the second registration to replace the first, got:\n%s", b.String())
+	}
+}
+
+func TestRegistryDropsObservationsWithWrongLabelCount(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("test_requests_total", "Total requests", "endpoint")
+	counter.Inc("a", "extra")
+	histogram := registry.NewHistogramVec("test_duration_seconds", "Duration", []float64{1}, "endpoint")
+	histogram.Observe(0.5)
+	registry.NewCollectorFunc("test_endpoint_up", "Up", KindGauge, []string{"endpoint"}, func(emit func(float64, ...string)) {
+		emit(1, "a")
+		emit(1)
+	})
+	registry.NewGaugeFunc("test_up", "Up", func() float64 { return 1 })
+
+	var b strings.Builder
+	if err := registry.WriteText(&b); err == nil {
+		t.Errorf("Expected the collector with a wrong label count to be reported")
+	}
+	output := b.String()
+	if strings.Contains(output, "test_requests_total{") || strings.Contains(output, "test_duration_seconds_count") {
+		t.Errorf("Expected observations with a wrong label count to be dropped, got:\n%s", output)
+	}
+	if !strings.Contains(output, "test_up 1\n") {
+		t.Errorf("Expected the remaining metrics to be written, got:\n%s", output)
+	}
+}
+
+func TestModelLabelIsBoundedByConfiguredModels(t *testing.T) {
+	defer modelLabels.Store(nil)
+	SetModelLabels([]string{"claude-sonnet-4"}, []string{"claude-*-haiku*"})
+
+	cases := map[string]string{
+		"":                          "unknown",
+		"claude-sonnet-4":           "claude-sonnet-4",
+		"claude-3-5-haiku-20241022": "claude-*-haiku*",
+		"made-up-model-123":         "other",
+	}
+	for model, want := range cases {
+		if got := ModelLabel(model); got != want {
+			t.Errorf("ModelLabel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestLabelHelpers(t *testing.T) {
+	if StatusClass(503) != "5xx" || StatusClass(0) != "error" {
+		t.Errorf("Unexpected status classes: %s, %s", StatusClass(503), StatusClass(0))
+	}
+	if TagLabel([]string{"b", "a"}) != "a,b" || TagLabel(nil) != "none" {
+		t.Errorf("Unexpected tag labels: %s, %s", TagLabel([]string{"b", "a"}), TagLabel(nil))
+	}
+}
@@ -13,12 +13,13 @@ func TestSSEResponseRewrite(t *testing.T) {
 		LogRequestTypes: "all",
 		LogRequestBody:  "none",
 		LogResponseBody: "none",
-		LogDirectory:    "./test_logs",
+		LogDirectory:    t.TempDir(),
 	}
 	mockLogger, err := logger.NewLogger(logConfig)
 	if err != nil {
 		t.Fatalf("Failed to create logger: %v", err)
 	}
+	defer mockLogger.Close()
 	rewriter := NewRewriter(*mockLogger)
 
 	// 模拟SSE响应
@@ -65,12 +66,13 @@ func TestJSONResponseRewrite(t *testing.T) {
 		LogRequestTypes: "all",
 		LogRequestBody:  "none",
 		LogResponseBody: "none",
-		LogDirectory:    "./test_logs",
+		LogDirectory:    t.TempDir(),
 	}
 	mockLogger, err := logger.NewLogger(logConfig)
 	if err != nil {
 		t.Fatalf("Failed to create logger: %v", err)
 	}
+	defer mockLogger.Close()
 	rewriter := NewRewriter(*mockLogger)
 
 	// 模拟JSON响应
@@ -98,12 +100,13 @@ func TestNoRewriteNeeded(t *testing.T) {
 		LogRequestTypes: "all",
 		LogRequestBody:  "none",
 		LogResponseBody: "none",
-		LogDirectory:    "./test_logs",
+		LogDirectory:    t.TempDir(),
 	}
 	mockLogger, err := logger.NewLogger(logConfig)
 	if err != nil {
 		t.Fatalf("Failed to create logger: %v", err)
 	}
+	defer mockLogger.Close()
 	rewriter := NewRewriter(*mockLogger)
 
 	// 没有模型字段的响应
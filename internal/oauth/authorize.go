@@ -0,0 +1,216 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"claude-code-companion/internal/config"
+)
+
+// 设备码授权轮询时的状态，调用方应继续轮询（slow_down 时加大间隔）
+var (
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("slow down")
+)
+
+// PKCE 授权码流程使用的 code_verifier 和 code_challenge（S256）
+type PKCE struct {
+	Verifier  string
+	Challenge string
+}
+
+// NewPKCE 生成随机的 code_verifier 和对应的 S256 code_challenge
+func NewPKCE() (*PKCE, error) {
+	verifier, err := RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return &PKCE{Verifier: verifier, Challenge: base64.RawURLEncoding.EncodeToString(sum[:])}, nil
+}
+
+// RandomString 生成 n 字节随机数的 base64url 编码，用于 state 和 code_verifier
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthorizationURL 构造授权码流程的授权地址
+func AuthorizationURL(oauthConfig *config.OAuthConfig, redirectURI, state string, pkce *PKCE) (string, error) {
+	if oauthConfig.AuthorizeURL == "" {
+		return "", fmt.Errorf("authorize_url is not configured")
+	}
+	u, err := url.Parse(oauthConfig.AuthorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorize_url: %v", err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", oauthConfig.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	if len(oauthConfig.Scopes) > 0 {
+		query.Set("scope", strings.Join(oauthConfig.Scopes, " "))
+	}
+	query.Set("state", state)
+	query.Set("code_challenge", pkce.Challenge)
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ExchangeCode 用授权码换取令牌，返回的配置以 oauthConfig 为基础更新令牌和过期时间
+func ExchangeCode(oauthConfig *config.OAuthConfig, code, state, redirectURI string, pkce *PKCE, httpClient *http.Client) (*config.OAuthConfig, error) {
+	params := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  redirectURI,
+		"client_id":     oauthConfig.ClientID,
+		"code_verifier": pkce.Verifier,
+	}
+	if state != "" {
+		params["state"] = state
+	}
+	// 与刷新令牌一致，先尝试 JSON 格式（Anthropic），失败后使用标准的 form 格式
+	respBody, status, err := postTokenRequest(oauthConfig.TokenURL, params, true, httpClient)
+	if err != nil || status != http.StatusOK {
+		respBody, status, err = postTokenRequest(oauthConfig.TokenURL, params, false, httpClient)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("code exchange failed with status %d: %s", status, string(respBody))
+	}
+	return parseTokenResponse(respBody, oauthConfig)
+}
+
+// DeviceAuthorization 设备码授权响应
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+// StartDeviceAuthorization 发起设备码授权，返回用户需要在浏览器中输入的代码
+func StartDeviceAuthorization(oauthConfig *config.OAuthConfig, httpClient *http.Client) (*DeviceAuthorization, error) {
+	if oauthConfig.DeviceAuthorizationURL == "" {
+		return nil, fmt.Errorf("device_authorization_url is not configured")
+	}
+	form := url.Values{}
+	form.Set("client_id", oauthConfig.ClientID)
+	if len(oauthConfig.Scopes) > 0 {
+		form.Set("scope", strings.Join(oauthConfig.Scopes, " "))
+	}
+	req, err := http.NewRequest("POST", oauthConfig.DeviceAuthorizationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device authorization request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send device authorization request: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device authorization response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device authorization failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var auth DeviceAuthorization
+	if err := json.Unmarshal(respBody, &auth); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal device authorization response: %v", err)
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" || auth.VerificationURI == "" {
+		return nil, fmt.Errorf("device authorization response missing device_code, user_code or verification_uri")
+	}
+	if auth.Interval <= 0 {
+		auth.Interval = 5
+	}
+	return &auth, nil
+}
+
+// PollDeviceToken 轮询设备码令牌，用户尚未完成授权时返回 ErrAuthorizationPending 或 ErrSlowDown
+func PollDeviceToken(oauthConfig *config.OAuthConfig, deviceCode string, httpClient *http.Client) (*config.OAuthConfig, error) {
+	respBody, status, err := postTokenRequest(oauthConfig.TokenURL, map[string]string{
+		"grant_type":  "urn:ietf:params:oauth:grant-type:device_code",
+		"device_code": deviceCode,
+		"client_id":   oauthConfig.ClientID,
+	}, false, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		var errResp struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.Unmarshal(respBody, &errResp)
+		switch errResp.Error {
+		case "authorization_pending":
+			return nil, ErrAuthorizationPending
+		case "slow_down":
+			return nil, ErrSlowDown
+		case "":
+			return nil, fmt.Errorf("device token request failed with status %d: %s", status, string(respBody))
+		default:
+			return nil, fmt.Errorf("device authorization failed: %s %s", errResp.Error, errResp.ErrorDescription)
+		}
+	}
+	return parseTokenResponse(respBody, oauthConfig)
+}
+
+// postTokenRequest 向 token 端点发送 JSON 或表单格式的请求，返回响应体和状态码
+func postTokenRequest(tokenURL string, params map[string]string, asJSON bool, httpClient *http.Client) ([]byte, int, error) {
+	form := url.Values{}
+	fields := make(map[string]string, len(params))
+	for key, value := range params {
+		if value != "" {
+			form.Set(key, value)
+			fields[key] = value
+		}
+	}
+	body, contentType := form.Encode(), "application/x-www-form-urlencoded"
+	if asJSON {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal token request: %v", err)
+		}
+		body, contentType = string(data), "application/json"
+	}
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create token request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to send token request: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read token response: %v", err)
+	}
+	return respBody, resp.StatusCode, nil
+}
@@ -0,0 +1,167 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"claude-code-companion/internal/config"
+)
+
+// mockAuthServer 模拟授权服务器：记录授权请求的 code_challenge，换取令牌时校验 code_verifier
+type mockAuthServer struct {
+	mutex      sync.Mutex
+	challenges map[string]string // code -> code_challenge
+	pending    int               // 设备码流程返回 authorization_pending 的次数
+}
+
+func newMockAuthServer(t *testing.T) (*mockAuthServer, *httptest.Server) {
+	mock := &mockAuthServer{challenges: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "unsupported challenge method", http.StatusBadRequest)
+			return
+		}
+		mock.mutex.Lock()
+		mock.challenges["auth-code"] = query.Get("code_challenge")
+		mock.mutex.Unlock()
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"auth-code"}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/device/code", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code": "device-123", "user_code": "ABCD-EFGH",
+			"verification_uri": "http://" + r.Host + "/device", "expires_in": 600,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		// 只接受标准的表单格式，验证 JSON 请求失败后会回退
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") == "" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			mock.mutex.Lock()
+			challenge := mock.challenges[r.PostForm.Get("code")]
+			delete(mock.challenges, r.PostForm.Get("code"))
+			mock.mutex.Unlock()
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if challenge == "" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"pkce-access","refresh_token":"pkce-refresh","expires_in":3600}`))
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			mock.mutex.Lock()
+			defer mock.mutex.Unlock()
+			if mock.pending > 0 {
+				mock.pending--
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"device-access","refresh_token":"device-refresh","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return mock, server
+}
+
+func TestPKCEAuthorizationCodeFlow(t *testing.T) {
+	_, server := newMockAuthServer(t)
+	oauthConfig := &config.OAuthConfig{
+		TokenURL:     server.URL + "/token",
+		AuthorizeURL: server.URL + "/authorize",
+		ClientID:     "client",
+		Scopes:       []string{"user:inference", "user:profile"},
+	}
+
+	pkce, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("Failed to create PKCE: %v", err)
+	}
+	redirectURI := "http://127.0.0.1/admin/oauth/callback"
+	authorizeURL, err := AuthorizationURL(oauthConfig, redirectURI, "state-1", pkce)
+	if err != nil {
+		t.Fatalf("Failed to build authorization URL: %v", err)
+	}
+	parsed, _ := url.Parse(authorizeURL)
+	if parsed.Query().Get("scope") != "user:inference user:profile" || parsed.Query().Get("state") != "state-1" {
+		t.Errorf("Unexpected authorization URL: %s", authorizeURL)
+	}
+
+	// 不跟随重定向，从 Location 中取出授权码
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authorizeURL)
+	if err != nil {
+		t.Fatalf("Authorization request failed: %v", err)
+	}
+	resp.Body.Close()
+	location, _ := url.Parse(resp.Header.Get("Location"))
+	if location.Query().Get("state") != "state-1" {
+		t.Fatalf("Expected state to be returned, got %s", location)
+	}
+
+	wrong, _ := NewPKCE()
+	if _, err := ExchangeCode(oauthConfig, location.Query().Get("code"), "state-1", redirectURI, wrong, http.DefaultClient); err == nil {
+		t.Error("Expected exchange with a different verifier to fail")
+	}
+
+	// 校验失败后授权码已作废，重新授权
+	resp, _ = client.Get(authorizeURL)
+	resp.Body.Close()
+	tokens, err := ExchangeCode(oauthConfig, "auth-code", "state-1", redirectURI, pkce, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if tokens.AccessToken != "pkce-access" || tokens.RefreshToken != "pkce-refresh" || tokens.ExpiresAt == 0 {
+		t.Errorf("Unexpected tokens: %+v", tokens)
+	}
+	if tokens.TokenURL != oauthConfig.TokenURL {
+		t.Error("Expected the rest of the config to be kept")
+	}
+}
+
+func TestDeviceAuthorizationFlow(t *testing.T) {
+	mock, server := newMockAuthServer(t)
+	mock.pending = 1
+	oauthConfig := &config.OAuthConfig{
+		TokenURL:               server.URL + "/token",
+		DeviceAuthorizationURL: server.URL + "/device/code",
+		ClientID:               "client",
+	}
+
+	auth, err := StartDeviceAuthorization(oauthConfig, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to start device authorization: %v", err)
+	}
+	if auth.UserCode != "ABCD-EFGH" || auth.Interval != 5 {
+		t.Errorf("Unexpected device authorization: %+v", auth)
+	}
+
+	if _, err := PollDeviceToken(oauthConfig, auth.DeviceCode, http.DefaultClient); err != ErrAuthorizationPending {
+		t.Errorf("Expected authorization pending, got %v", err)
+	}
+	tokens, err := PollDeviceToken(oauthConfig, auth.DeviceCode, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to poll device token: %v", err)
+	}
+	if tokens.AccessToken != "device-access" || tokens.RefreshToken != "device-refresh" {
+		t.Errorf("Unexpected tokens: %+v", tokens)
+	}
+}
@@ -3,6 +3,7 @@ package oauth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -30,6 +31,52 @@ type TokenRefreshRequest struct {
 	ClientID     string `json:"client_id"`
 }
 
+// TokenError 令牌端点返回的错误响应
+type TokenError struct {
+	StatusCode  int
+	Code        string // OAuth 错误码，如 invalid_grant
+	Description string
+	Body        string
+}
+
+func (e *TokenError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("token endpoint returned status %d: %s %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("token endpoint returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// newTokenError 解析令牌端点的错误响应，兼容标准的 {"error": "...", "error_description": "..."} 和 {"error": {"type": "...", "message": "..."}}
+func newTokenError(statusCode int, respBody []byte) *TokenError {
+	tokenErr := &TokenError{StatusCode: statusCode, Body: string(respBody)}
+	var errResp struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if json.Unmarshal(respBody, &errResp) != nil || len(errResp.Error) == 0 {
+		return tokenErr
+	}
+	var nested struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(errResp.Error, &tokenErr.Code) != nil && json.Unmarshal(errResp.Error, &nested) == nil {
+		tokenErr.Code = nested.Type
+		errResp.ErrorDescription = nested.Message
+	}
+	tokenErr.Description = errResp.ErrorDescription
+	return tokenErr
+}
+
+// IsRefreshTokenRejected 授权服务器拒绝了刷新令牌（invalid_grant 或 401/403），重试没有意义，需要重新授权
+func IsRefreshTokenRejected(err error) bool {
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) {
+		return false
+	}
+	return tokenErr.Code == "invalid_grant" || tokenErr.StatusCode == http.StatusUnauthorized || tokenErr.StatusCode == http.StatusForbidden
+}
+
 // RefreshToken 刷新 OAuth token
 func RefreshToken(oauthConfig *config.OAuthConfig, httpClient *http.Client) (*config.OAuthConfig, error) {
 	if oauthConfig == nil {
@@ -44,6 +91,11 @@ func RefreshToken(oauthConfig *config.OAuthConfig, httpClient *http.Client) (*co
 
 	// 尝试 JSON 格式请求
 	newConfig, err := refreshTokenWithJSON(oauthConfig, httpClient)
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) && tokenErr.Code == "invalid_grant" {
+		// 刷新令牌已失效，换用表单格式也会被拒绝
+		return nil, err
+	}
 	if err != nil {
 		log.Printf("[OAuth] JSON format refresh failed: %v, trying form format", err)
 		// 如果 JSON 格式失败，尝试 form 格式
@@ -97,7 +149,7 @@ func refreshTokenWithJSON(oauthConfig *config.OAuthConfig, httpClient *http.Clie
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, newTokenError(resp.StatusCode, respBody)
 	}
 
 	return parseTokenResponse(respBody, oauthConfig)
@@ -143,7 +195,7 @@ func refreshTokenWithForm(oauthConfig *config.OAuthConfig, httpClient *http.Clie
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("form token refresh failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, newTokenError(resp.StatusCode, respBody)
 	}
 
 	return parseTokenResponse(respBody, oauthConfig)
@@ -219,8 +271,22 @@ func IsTokenExpired(oauthConfig *config.OAuthConfig) bool {
 	return time.Now().Add(bufferTime).After(expirationTime)
 }
 
+// HasTokenExpired 检查 token 是否已经过了过期时间（不含提前量），没有过期的 token 仍可用于请求
+func HasTokenExpired(oauthConfig *config.OAuthConfig) bool {
+	if oauthConfig == nil || oauthConfig.ExpiresAt <= 0 {
+		return true
+	}
+	return !time.Now().Before(time.UnixMilli(oauthConfig.ExpiresAt))
+}
+
 // ShouldRefreshToken 检查是否应该刷新token（更宽松的检查）
 func ShouldRefreshToken(oauthConfig *config.OAuthConfig) bool {
+	// 提前5分钟刷新
+	return ShouldRefreshTokenBefore(oauthConfig, 5*time.Minute)
+}
+
+// ShouldRefreshTokenBefore 检查 token 是否将在 bufferTime 内过期，后台刷新使用更长的提前量
+func ShouldRefreshTokenBefore(oauthConfig *config.OAuthConfig, bufferTime time.Duration) bool {
 	if oauthConfig == nil {
 		return true
 	}
@@ -230,8 +296,6 @@ func ShouldRefreshToken(oauthConfig *config.OAuthConfig) bool {
 		return false // 让第一次请求尝试使用现有token
 	}
 	
-	// 提前5分钟刷新
-	bufferTime := 5 * time.Minute
 	expirationTime := time.UnixMilli(oauthConfig.ExpiresAt)
 	
 	return time.Now().Add(bufferTime).After(expirationTime)
@@ -7,10 +7,16 @@ import (
 	"time"
 
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/metrics"
 	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RetryBehavior 定义重试行为
@@ -49,6 +55,7 @@ func (s *Server) tryProxyRequestWithRetry(c *gin.Context, ep *endpoint.Endpoint,
 		
 		// 立即尝试下一个端点
 		s.logger.Debug(fmt.Sprintf("Endpoint %s is blacklisted, skipping to next endpoint", ep.Name))
+		metrics.Failovers.Inc("endpoint_blacklisted")
 		return false, true
 	}
 
@@ -56,7 +63,7 @@ func (s *Server) tryProxyRequestWithRetry(c *gin.Context, ep *endpoint.Endpoint,
 		currentGlobalAttempt := globalAttemptNumber + endpointAttempt - 1
 		s.logger.Debug(fmt.Sprintf("Trying endpoint %s (endpoint attempt %d/%d, global attempt %d)", ep.Name, endpointAttempt, MaxEndpointRetries, currentGlobalAttempt))
 		
-		success, shouldRetryAnywhere := s.proxyToEndpoint(c, ep, path, requestBody, requestID, startTime, taggedRequest, currentGlobalAttempt)
+		success, shouldRetryAnywhere := s.instrumentedProxyToEndpoint(c, ep, path, requestBody, requestID, startTime, taggedRequest, currentGlobalAttempt)
 		if success {
 			// 检查是否应该跳过健康统计记录
 			skipHealthRecord, _ := c.Get("skip_health_record")
@@ -106,6 +113,7 @@ func (s *Server) tryProxyRequestWithRetry(c *gin.Context, ep *endpoint.Endpoint,
 		
 		// 根据错误类型确定重试行为
 		retryBehavior := s.determineRetryBehaviorFromError(lastError, lastStatusCode, endpointAttempt)
+		errorCategory := s.categorizeError(lastError, lastStatusCode).String()
 		
 		switch retryBehavior {
 		case RetryBehaviorReturnError:
@@ -115,16 +123,19 @@ func (s *Server) tryProxyRequestWithRetry(c *gin.Context, ep *endpoint.Endpoint,
 		case RetryBehaviorRetryEndpoint:
 			if endpointAttempt < MaxEndpointRetries {
 				s.logger.Debug(fmt.Sprintf("Endpoint %s: RetryBehaviorRetryEndpoint - retrying same endpoint (attempt %d/%d)", ep.Name, endpointAttempt+1, MaxEndpointRetries))
+				metrics.Retries.Inc(errorCategory)
 				// 重新构建请求体，继续循环
 				s.rebuildRequestBody(c, requestBody)
 				continue
 			} else {
 				s.logger.Debug(fmt.Sprintf("Endpoint %s: Max retries reached, switching to next endpoint", ep.Name))
+				metrics.Failovers.Inc(errorCategory)
 				return false, true
 			}
 			
 		case RetryBehaviorSwitchEndpoint:
 			s.logger.Debug(fmt.Sprintf("Endpoint %s: RetryBehaviorSwitchEndpoint - switching to next endpoint", ep.Name))
+			metrics.Failovers.Inc(errorCategory)
 			return false, true
 		}
 	}
@@ -147,6 +158,122 @@ const (
 	ErrorCategoryResponseTimeoutError ErrorCategory = 6 // 响应超时错误，切换端点
 )
 
+// String 返回错误类别名称，用作指标标签
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorCategoryClientError:
+		return "client_error"
+	case ErrorCategoryServerError:
+		return "server_error"
+	case ErrorCategoryNetworkError:
+		return "network_error"
+	case ErrorCategoryUsageValidationError:
+		return "usage_validation_error"
+	case ErrorCategorySSEValidationError:
+		return "sse_validation_error"
+	case ErrorCategoryOtherValidationError:
+		return "other_validation_error"
+	case ErrorCategoryResponseTimeoutError:
+		return "response_timeout_error"
+	default:
+		return "unknown"
+	}
+}
+
+// instrumentedProxyToEndpoint 向端点发送一次请求，记录耗时和首字节时间指标，并为这次尝试创建追踪 span
+func (s *Server) instrumentedProxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path string, requestBody []byte, requestID string, startTime time.Time, taggedRequest *tagging.TaggedRequest, attemptNumber int) (bool, bool) {
+	c.Set("upstream_ttfb", nil)
+	c.Set("upstream_output_tokens", 0)
+	attemptStart := time.Now()
+
+	// 尝试期间的转换、重写、日志等 span 都挂在这次尝试下，结束后恢复为请求级的 context
+	parentCtx := c.Request.Context()
+	spanCtx, span := tracing.Start(parentCtx, "proxyToEndpoint",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("endpoint.name", ep.Name),
+			attribute.String("endpoint.type", ep.EndpointType),
+			attribute.String("server.address", ep.URL),
+			attribute.Int("proxy.attempt", attemptNumber),
+		))
+	c.Request = c.Request.WithContext(spanCtx)
+	s.events.Publish(events.Event{Type: events.AttemptStarted, RequestID: requestID, Endpoint: ep.Name, Attempt: attemptNumber})
+
+	success, shouldRetry := s.proxyToEndpoint(c, ep, path, requestBody, requestID, startTime, taggedRequest, attemptNumber)
+	c.Request = c.Request.WithContext(parentCtx)
+	s.endAttemptSpan(c, span, success)
+	if !success {
+		s.publishAttemptFailed(c, ep, requestID, attemptNumber)
+	}
+	if c.GetBool("count_tokens_openai_skip") && ep.EndpointType == "openai" {
+		// 跳过的 count_tokens 请求没有发往上游，不计入耗时
+		return success, shouldRetry
+	}
+
+	var tags []string
+	if taggedRequest != nil {
+		tags = taggedRequest.Tags
+	}
+	labels := []string{ep.Name, metrics.ModelLabel(c.GetString("original_model")), metrics.StatusClass(c.GetInt("last_status_code")), metrics.TagLabel(tags)}
+	attemptDuration := time.Since(attemptStart)
+	metrics.RequestDuration.Observe(attemptDuration.Seconds(), labels...)
+	if ttfb, ok := c.Get("upstream_ttfb"); ok {
+		if d, ok := ttfb.(time.Duration); ok {
+			metrics.TimeToFirstByte.Observe(d.Seconds(), labels...)
+			// 只用成功的生成请求评估端点延迟，count_tokens 响应很快会拉低首字节时间
+			if success && !strings.Contains(path, "/count_tokens") {
+				ep.RecordPerformance(c.GetString("original_model"), d, attemptDuration, c.GetInt("upstream_output_tokens"))
+			}
+		}
+	}
+	return success, shouldRetry
+}
+
+// endAttemptSpan 记录一次尝试的状态码、首字节时间和错误后结束 span
+func (s *Server) endAttemptSpan(c *gin.Context, span trace.Span, success bool) {
+	if !span.IsRecording() {
+		span.End()
+		return
+	}
+	if statusCode := c.GetInt("last_status_code"); statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+	if ttfb, ok := c.Get("upstream_ttfb"); ok {
+		if d, ok := ttfb.(time.Duration); ok {
+			span.SetAttributes(attribute.Float64("proxy.ttfb_ms", float64(d.Microseconds())/1000))
+		}
+	}
+	if !success {
+		if lastErr, ok := c.Get("last_error"); ok {
+			if err, ok := lastErr.(error); ok {
+				span.RecordError(err)
+			}
+		}
+		span.SetStatus(codes.Error, "upstream attempt failed")
+	}
+	span.End()
+}
+
+// publishAttemptFailed 发布尝试失败事件，错误信息按日志脱敏规则处理
+func (s *Server) publishAttemptFailed(c *gin.Context, ep *endpoint.Endpoint, requestID string, attemptNumber int) {
+	event := events.Event{
+		Type:       events.AttemptFailed,
+		RequestID:  requestID,
+		Endpoint:   ep.Name,
+		Attempt:    attemptNumber,
+		StatusCode: c.GetInt("last_status_code"),
+	}
+	if lastErr, ok := c.Get("last_error"); ok {
+		if err, ok := lastErr.(error); ok && err != nil {
+			event.Error = s.logger.Redactor().RedactString(err.Error())
+		}
+	}
+	if event.Error == "" && event.StatusCode != 0 {
+		event.Error = fmt.Sprintf("HTTP %d", event.StatusCode)
+	}
+	s.events.Publish(event)
+}
+
 // determineRetryBehaviorFromError 根据错误信息确定重试行为
 func (s *Server) determineRetryBehaviorFromError(err error, statusCode int, currentAttempt int) RetryBehavior {
 	if err == nil && statusCode >= 200 && statusCode < 300 {
@@ -319,8 +446,9 @@ func (s *Server) tryEndpointList(c *gin.Context, endpoints []utils.EndpointSorte
 }
 
 // filterAndSortEndpoints 过滤并排序端点（包括被拉黑端点，用于在实际轮到时记录虚拟日志）
-func (s *Server) filterAndSortEndpoints(allEndpoints []*endpoint.Endpoint, failedEndpoint *endpoint.Endpoint, filterFunc func(*endpoint.Endpoint) bool) []utils.EndpointSorter {
-	var filtered []*endpoint.Endpoint
+// 上下文长度规则与 SelectEndpointForContext 一致：max_context_tokens 不足的端点不排除，只排在能容纳请求的端点之后作为最后的选择
+func (s *Server) filterAndSortEndpoints(allEndpoints []*endpoint.Endpoint, failedEndpoint *endpoint.Endpoint, estimatedTokens int, model string, filterFunc func(*endpoint.Endpoint) bool) []utils.EndpointSorter {
+	var fitting, undersized []utils.EndpointSorter
 	
 	for _, ep := range allEndpoints {
 		// 跳过已失败的endpoint
@@ -331,20 +459,26 @@ func (s *Server) filterAndSortEndpoints(allEndpoints []*endpoint.Endpoint, faile
 		if !ep.Enabled {
 			continue
 		}
+		if !filterFunc(ep) {
+			continue
+		}
 		
-		if filterFunc(ep) {
-			filtered = append(filtered, ep)
+		if ep.CanHandleContext(estimatedTokens) {
+			fitting = append(fitting, ep)
+		} else {
+			s.logger.Debug(fmt.Sprintf("Endpoint %s moved to the end: estimated %d tokens exceeds max_context_tokens %d", ep.Name, estimatedTokens, ep.MaxContextTokens))
+			undersized = append(undersized, ep)
 		}
 	}
 	
-	// 转换为接口类型并排序
-	sorter := make([]utils.EndpointSorter, len(filtered))
-	for i, ep := range filtered {
-		sorter[i] = ep
-	}
-	utils.SortEndpointsByPriority(sorter)
-	
-	return sorter
+	return append(s.sortCandidates(fitting, model), s.sortCandidates(undersized, model)...)
+}
+
+// sortCandidates 按优先级排序，处理 model 时明显慢于其他候选端点的端点放到最后尝试
+func (s *Server) sortCandidates(candidates []utils.EndpointSorter, model string) []utils.EndpointSorter {
+	utils.SortEndpointsByPriority(candidates)
+	utils.DemoteDegradedEndpoints(candidates, model, s.endpointManager.DegradationPolicy())
+	return candidates
 }
 
 // endpointContainsAllTags 检查endpoint的标签是否包含请求的所有标签
@@ -385,17 +519,24 @@ func (s *Server) fallbackToOtherEndpoints(c *gin.Context, path string, requestBo
 	}
 	
 	totalAttempted := MaxEndpointRetries // 包括最初失败的endpoint的所有重试
+	estimatedTokens := c.GetInt("estimated_tokens")
+	originalModel := c.GetString("original_model")
 	
 	if len(requestTags) > 0 {
 		// 有标签请求：分两阶段尝试
 		s.logger.Debug(fmt.Sprintf("Tagged request failed on %s, trying fallback with tags: %v", failedEndpoint.Name, requestTags))
 		
+		tried := make(map[string]bool)
+		// 首个端点可能是通过回退链选中的，Phase 1 重新按原始tag尝试
+		s.setFallbackLevel(c, "", 0, "")
+		
 		// Phase 1：尝试有标签且匹配的端点
-		taggedEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, func(ep *endpoint.Endpoint) bool {
+		taggedEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, estimatedTokens, originalModel, func(ep *endpoint.Endpoint) bool {
 			return len(ep.Tags) > 0 && s.endpointContainsAllTags(ep.Tags, requestTags)
 		})
 		
 		if len(taggedEndpoints) > 0 {
+			markEndpointsTried(tried, taggedEndpoints)
 			s.logger.Debug(fmt.Sprintf("Phase 1: Trying %d tagged endpoints", len(taggedEndpoints)))
 			success, attemptedCount := s.tryEndpointList(c, taggedEndpoints, path, requestBody, requestID, startTime, taggedRequest, "Phase 1", totalAttempted+1)
 			if success {
@@ -404,9 +545,19 @@ func (s *Server) fallbackToOtherEndpoints(c *gin.Context, path string, requestBo
 			totalAttempted += attemptedCount
 		}
 		
+		// 回退链：按配置逐级替换或放宽tag
+		if chain := s.findFallbackChain(requestTags); chain != nil {
+			s.logger.Debug(fmt.Sprintf("Trying fallback chain %s with %d levels", chain.Name, len(chain.Steps)))
+			success, attemptedCount := s.tryFallbackChain(c, chain, allEndpoints, failedEndpoint, tried, path, requestBody, requestID, startTime, taggedRequest, totalAttempted+1)
+			if success {
+				return
+			}
+			totalAttempted += attemptedCount
+		}
+		
 		// Phase 2：尝试万用端点
-		universalEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, func(ep *endpoint.Endpoint) bool {
-			return len(ep.Tags) == 0
+		universalEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, estimatedTokens, originalModel, func(ep *endpoint.Endpoint) bool {
+			return len(ep.Tags) == 0 && !tried[ep.ID]
 		})
 		
 		if len(universalEndpoints) > 0 {
@@ -437,7 +588,7 @@ func (s *Server) fallbackToOtherEndpoints(c *gin.Context, path string, requestBo
 		// 无标签请求：只尝试万用端点
 		s.logger.Debug("Untagged request failed, trying universal endpoints only")
 		
-		universalEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, func(ep *endpoint.Endpoint) bool {
+		universalEndpoints := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, estimatedTokens, originalModel, func(ep *endpoint.Endpoint) bool {
 			return len(ep.Tags) == 0
 		})
 		
@@ -470,4 +621,15 @@ func (s *Server) fallbackToOtherEndpoints(c *gin.Context, path string, requestBo
 		errorMsg := s.generateDetailedEndpointUnavailableMessage(requestID, requestTags)
 		s.sendProxyError(c, http.StatusBadGateway, "all_universal_endpoints_failed", errorMsg, requestID)
 	}
-}
\ No newline at end of file
+}
+
+// getTriedCredentials 返回本次请求中端点已经使用过的凭据名称，同一请求重试同一端点时跳过这些凭据
+func (s *Server) getTriedCredentials(c *gin.Context, ep *endpoint.Endpoint) map[string]bool {
+	key := "credentials_tried_" + ep.ID
+	if tried, ok := c.Get(key); ok {
+		return tried.(map[string]bool)
+	}
+	tried := make(map[string]bool)
+	c.Set(key, tried)
+	return tried
+}
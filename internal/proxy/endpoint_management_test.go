@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"reflect"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/logger"
+	"claude-code-companion/internal/utils"
+)
+
+// newTestServer 创建只包含端点管理和日志的代理服务，数据写入测试临时目录
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	cfg.Logging.LogDirectory = t.TempDir()
+
+	log, err := logger.NewLogger(logger.LogConfig{Level: "error", LogDirectory: cfg.Logging.LogDirectory})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	endpointManager, err := endpoint.NewManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create endpoint manager: %v", err)
+	}
+	return &Server{config: cfg, endpointManager: endpointManager, logger: log}
+}
+
+func testEndpointConfig(name string, priority int, maxContextTokens int, tags ...string) config.EndpointConfig {
+	return config.EndpointConfig{
+		Name:             name,
+		URL:              "https://" + name + ".example.com",
+		EndpointType:     "anthropic",
+		AuthType:         "api_key",
+		AuthValue:        "key",
+		Enabled:          true,
+		Priority:         priority,
+		Tags:             tags,
+		MaxContextTokens: maxContextTokens,
+	}
+}
+
+func endpointNames(endpoints []utils.EndpointSorter) []string {
+	names := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		names[i] = ep.(*endpoint.Endpoint).Name
+	}
+	return names
+}
+
+func TestFilterAndSortEndpointsKeepsUndersizedEndpointsLast(t *testing.T) {
+	s := newTestServer(t, &config.Config{Endpoints: []config.EndpointConfig{
+		testEndpointConfig("failed", 1, 0),
+		testEndpointConfig("small", 2, 128000),
+		testEndpointConfig("large", 3, 1000000),
+		testEndpointConfig("unlimited", 4, 0),
+	}})
+	all := s.endpointManager.GetAllEndpoints()
+	acceptAll := func(*endpoint.Endpoint) bool { return true }
+
+	// 与初次选择规则一致：容量不足的端点不排除，只在能容纳请求的端点之后尝试
+	got := endpointNames(s.filterAndSortEndpoints(all, all[0], 180000, "", acceptAll))
+	want := []string{"large", "unlimited", "small"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	if got := endpointNames(s.filterAndSortEndpoints(all, all[0], 1000, "", acceptAll)); got[0] != "small" {
+		t.Errorf("Expected priority order for short requests, got %v", got)
+	}
+}
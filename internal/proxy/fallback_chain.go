@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"fmt"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// findFallbackChain 查找请求tags匹配的第一个回退链
+func (s *Server) findFallbackChain(requestTags []string) *config.FallbackChainConfig {
+	if len(requestTags) == 0 {
+		return nil
+	}
+
+	for i := range s.config.Tagging.FallbackChains {
+		chain := &s.config.Tagging.FallbackChains[i]
+		if s.endpointContainsAllTags(requestTags, chain.Tags) {
+			return chain
+		}
+	}
+	return nil
+}
+
+// fallbackStepTags 计算回退级别要求的tag：去掉回退链的触发tag，再加上该级别的tag
+func fallbackStepTags(requestTags, chainTags, stepTags []string) []string {
+	removed := make(map[string]bool, len(chainTags))
+	for _, tag := range chainTags {
+		removed[tag] = true
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range requestTags {
+		if !removed[tag] && !seen[tag] {
+			result = append(result, tag)
+			seen[tag] = true
+		}
+	}
+	for _, tag := range stepTags {
+		if !seen[tag] {
+			result = append(result, tag)
+			seen[tag] = true
+		}
+	}
+	return result
+}
+
+// tryFallbackChain 按顺序尝试回退链的每一级，返回(成功, 尝试次数)
+// tried 记录已经尝试过的端点，同一端点不会在不同级别重复尝试
+func (s *Server) tryFallbackChain(c *gin.Context, chain *config.FallbackChainConfig, allEndpoints []*endpoint.Endpoint, failedEndpoint *endpoint.Endpoint, tried map[string]bool, path string, requestBody []byte, requestID string, startTime time.Time, taggedRequest *tagging.TaggedRequest, startingAttemptNumber int) (bool, int) {
+	estimatedTokens := c.GetInt("estimated_tokens")
+	totalAttempts := 0
+
+	for i, step := range chain.Steps {
+		level := i + 1
+		stepTags := fallbackStepTags(taggedRequest.Tags, chain.Tags, step.Tags)
+
+		candidates := s.filterAndSortEndpoints(allEndpoints, failedEndpoint, estimatedTokens, c.GetString("original_model"), func(ep *endpoint.Endpoint) bool {
+			if tried[ep.ID] {
+				return false
+			}
+			if len(stepTags) == 0 {
+				return len(ep.Tags) == 0
+			}
+			return len(ep.Tags) > 0 && s.endpointContainsAllTags(ep.Tags, stepTags)
+		})
+		if len(candidates) == 0 {
+			s.logger.Debug(fmt.Sprintf("Fallback chain %s level %d: no endpoints for tags %v", chain.Name, level, stepTags))
+			continue
+		}
+		markEndpointsTried(tried, candidates)
+
+		s.setFallbackLevel(c, chain.Name, level, step.Model)
+		phase := fmt.Sprintf("Fallback %s#%d", chain.Name, level)
+		s.logger.Debug(fmt.Sprintf("%s: Trying %d endpoints with tags %v", phase, len(candidates), stepTags))
+
+		success, attemptedCount := s.tryEndpointList(c, candidates, path, requestBody, requestID, startTime, taggedRequest, phase, startingAttemptNumber+totalAttempts)
+		totalAttempts += attemptedCount
+		if success {
+			return true, totalAttempts
+		}
+	}
+
+	s.setFallbackLevel(c, "", 0, "")
+	return false, totalAttempts
+}
+
+// selectFallbackChainEndpoint 在没有可用端点完全匹配请求tags时，按回退链逐级选择端点
+// 返回nil表示回退链中没有可用端点，调用方继续使用默认选择结果（万用端点）
+func (s *Server) selectFallbackChainEndpoint(c *gin.Context, requestTags []string, estimatedTokens int) *endpoint.Endpoint {
+	chain := s.findFallbackChain(requestTags)
+	if chain == nil {
+		return nil
+	}
+
+	for i, step := range chain.Steps {
+		stepTags := fallbackStepTags(requestTags, chain.Tags, step.Tags)
+		selected, err := s.endpointManager.GetEndpointForContext(stepTags, estimatedTokens, c.GetString("original_model"))
+		if err != nil {
+			continue
+		}
+		// 只接受完全匹配该级别tag的端点；该级别不要求tag时接受万用端点
+		if len(stepTags) > 0 && (len(selected.Tags) == 0 || !s.endpointContainsAllTags(selected.Tags, stepTags)) {
+			continue
+		}
+		if len(stepTags) == 0 && len(selected.Tags) > 0 {
+			continue
+		}
+
+		s.setFallbackLevel(c, chain.Name, i+1, step.Model)
+		s.logger.Debug(fmt.Sprintf("No available endpoint matches tags %v, selected %s via fallback chain %s level %d", requestTags, selected.Name, chain.Name, i+1))
+		return selected
+	}
+	return nil
+}
+
+// setFallbackLevel 记录当前尝试所处的回退级别，level为0表示未使用回退链
+func (s *Server) setFallbackLevel(c *gin.Context, chainName string, level int, model string) {
+	c.Set("fallback_chain", chainName)
+	c.Set("fallback_level", level)
+	c.Set("fallback_model", model)
+}
+
+// fallbackModelRewriteConfig 返回当前尝试使用的模型重写配置
+// 回退级别指定了模型时，先替换为该模型，再应用端点自身的重写规则
+func (s *Server) fallbackModelRewriteConfig(c *gin.Context, ep *endpoint.Endpoint) *config.ModelRewriteConfig {
+	fallbackModel := c.GetString("fallback_model")
+	if fallbackModel == "" {
+		return ep.ModelRewrite
+	}
+
+	targetModel := fallbackModel
+	if ep.ModelRewrite != nil && ep.ModelRewrite.Enabled {
+		targetModel, _, _ = s.modelRewriter.TestRewriteRule(fallbackModel, ep.ModelRewrite.Rules)
+	}
+
+	return &config.ModelRewriteConfig{
+		Enabled: true,
+		Rules: []config.ModelRewriteRule{
+			{SourcePattern: "*", TargetModel: targetModel},
+		},
+	}
+}
+
+// markEndpointsTried 将端点列表标记为已尝试
+func markEndpointsTried(tried map[string]bool, endpoints []utils.EndpointSorter) {
+	for _, epInterface := range endpoints {
+		tried[epInterface.(*endpoint.Endpoint).ID] = true
+	}
+}
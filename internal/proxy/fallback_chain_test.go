@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/conversion"
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/health"
+	"claude-code-companion/internal/modelrewrite"
+	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/utils"
+	"claude-code-companion/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFallbackStepTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestTags []string
+		chainTags   []string
+		stepTags    []string
+		expected    []string
+	}{
+		{"replace", []string{"opus"}, []string{"opus"}, []string{"sonnet-premium"}, []string{"sonnet-premium"}},
+		{"keep other tags", []string{"opus", "thinking"}, []string{"opus"}, []string{"sonnet-any"}, []string{"thinking", "sonnet-any"}},
+		{"relax", []string{"opus"}, []string{"opus"}, nil, nil},
+		{"no duplicates", []string{"opus", "thinking"}, []string{"opus"}, []string{"thinking"}, []string{"thinking"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fallbackStepTags(tt.requestTags, tt.chainTags, tt.stepTags)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// fallbackUpstream 模拟上游端点，按端点名返回配置的状态码并记录收到请求的顺序和模型
+type fallbackUpstream struct {
+	mu     sync.Mutex
+	status map[string]int
+	hits   []string
+	models map[string]string
+}
+
+func (u *fallbackUpstream) handler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		u.mu.Lock()
+		status := u.status[name]
+		if len(u.hits) == 0 || u.hits[len(u.hits)-1] != name {
+			u.hits = append(u.hits, name)
+		}
+		u.models[name] = utils.ExtractModelFromRequestBody(string(body))
+		u.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte(`{"type":"error","error":{"type":"api_error","message":"upstream failure"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}
+}
+
+// newFallbackChainServer 创建带 opus 回退链的代理：opus -> sonnet-premium（换模型）-> 没有端点的级别 -> 放宽为万用端点
+func newFallbackChainServer(t *testing.T, status map[string]int, disabled ...string) (*Server, *fallbackUpstream) {
+	t.Helper()
+	upstream := &fallbackUpstream{status: status, models: make(map[string]string)}
+	urls := make(map[string]string)
+	for _, name := range []string{"opus", "premium", "universal"} {
+		server := httptest.NewServer(upstream.handler(name))
+		t.Cleanup(server.Close)
+		urls[name] = server.URL
+	}
+	enabled := func(name string) bool {
+		for _, d := range disabled {
+			if d == name {
+				return false
+			}
+		}
+		return true
+	}
+
+	dir := t.TempDir()
+	configYAML := fmt.Sprintf(`server:
+  host: 127.0.0.1
+  port: 8080
+endpoints:
+  - {name: opus, url: %q, endpoint_type: anthropic, auth_type: api_key, auth_value: k, enabled: %t, priority: 1, tags: [opus]}
+  - {name: premium, url: %q, endpoint_type: anthropic, auth_type: api_key, auth_value: k, enabled: %t, priority: 2, tags: [sonnet-premium]}
+  - {name: universal, url: %q, endpoint_type: anthropic, auth_type: api_key, auth_value: k, enabled: %t, priority: 3}
+logging:
+  level: error
+  log_directory: %q
+tagging:
+  taggers:
+    - {name: tier, type: builtin, builtin_type: header, tag: opus, enabled: true, priority: 1, config: {header_name: X-Tier, expected_value: opus}}
+  fallback_chains:
+    - name: opus-chain
+      tags: [opus]
+      steps:
+        - {tags: [sonnet-premium], model: claude-sonnet-4}
+        - {tags: [missing]}
+        - {tags: []}
+`, urls["opus"], enabled("opus"), urls["premium"], enabled("premium"), urls["universal"], enabled("universal"), dir)
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 管理界面需要嵌入的模板，这里只组装代理路径用到的组件
+	server := newTestServer(t, cfg)
+	server.configFilePath = configPath
+	server.validator = validator.NewResponseValidator()
+	server.modelRewriter = modelrewrite.NewRewriter(*server.logger)
+	server.converter = conversion.NewConverter(server.logger)
+	server.healthChecker = health.NewChecker(cfg.Timeouts.ToHealthCheckTimeoutConfig(), server.modelRewriter, server.converter)
+	server.events = events.NewHub(16)
+	server.taggingManager = tagging.NewManager()
+	if err := server.taggingManager.Initialize(&cfg.Tagging); err != nil {
+		t.Fatalf("Failed to initialize taggers: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	server.router = gin.New()
+	server.router.Group("/v1", server.loggingMiddleware()).Any("/*path", server.handleProxy)
+	return server, upstream
+}
+
+func sendTaggedRequest(server *Server) *httptest.ResponseRecorder {
+	body := `{"model":"claude-opus-4","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tier", "opus")
+	recorder := httptest.NewRecorder()
+	server.GetRouter().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestTryFallbackChainFollowsChainOrder(t *testing.T) {
+	server, upstream := newFallbackChainServer(t, map[string]int{"opus": 500, "premium": 500, "universal": 200})
+
+	recorder := sendTaggedRequest(server)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected request to succeed on the relaxed level, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	// 第二级没有匹配的端点，直接跳到放宽 tag 的第三级
+	if want := []string{"opus", "premium", "universal"}; !reflect.DeepEqual(upstream.hits, want) {
+		t.Errorf("Expected endpoints to be tried in chain order %v, got %v", want, upstream.hits)
+	}
+	if upstream.models["premium"] != "claude-sonnet-4" {
+		t.Errorf("Expected level 1 to rewrite the model, got %q", upstream.models["premium"])
+	}
+	if upstream.models["universal"] != "claude-opus-4" {
+		t.Errorf("Expected the relaxed level to keep the original model, got %q", upstream.models["universal"])
+	}
+}
+
+func TestTryFallbackChainExhausted(t *testing.T) {
+	server, upstream := newFallbackChainServer(t, map[string]int{"opus": 500, "premium": 500, "universal": 500})
+
+	recorder := sendTaggedRequest(server)
+	if recorder.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 after every level failed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	// 回退链已经尝试过的万用端点不会在 Phase 2 再次尝试
+	if want := []string{"opus", "premium", "universal"}; !reflect.DeepEqual(upstream.hits, want) {
+		t.Errorf("Expected each endpoint to be tried once in chain order %v, got %v", want, upstream.hits)
+	}
+}
+
+func TestSelectFallbackChainEndpoint(t *testing.T) {
+	// 没有可用的 opus 端点时，初次选择使用回退链第一级而不是万用端点
+	server, upstream := newFallbackChainServer(t, map[string]int{"premium": 200, "universal": 200}, "opus")
+
+	recorder := sendTaggedRequest(server)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected request to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if want := []string{"premium"}; !reflect.DeepEqual(upstream.hits, want) {
+		t.Errorf("Expected the level 1 endpoint to be selected, got %v", upstream.hits)
+	}
+	if upstream.models["premium"] != "claude-sonnet-4" {
+		t.Errorf("Expected level 1 to rewrite the model, got %q", upstream.models["premium"])
+	}
+
+	// 第一级也不可用时跳过没有端点的第二级，落到放宽的第三级
+	server, upstream = newFallbackChainServer(t, map[string]int{"universal": 200}, "opus", "premium")
+	if recorder := sendTaggedRequest(server); recorder.Code != http.StatusOK {
+		t.Fatalf("Expected request to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if want := []string{"universal"}; !reflect.DeepEqual(upstream.hits, want) {
+		t.Errorf("Expected the relaxed level endpoint to be selected, got %v", upstream.hits)
+	}
+}
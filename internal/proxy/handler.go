@@ -1,15 +1,21 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (s *Server) handleProxy(c *gin.Context) {
@@ -17,6 +23,32 @@ func (s *Server) handleProxy(c *gin.Context) {
 	startTime := c.MustGet("start_time").(time.Time)
 	path := c.Param("path")
 
+	// 客户端带有 traceparent 时延续其追踪，后续 span 都挂在这个 span 下
+	ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+	ctx, span := tracing.Start(ctx, "handleProxy",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("url.path", c.Request.URL.Path),
+			attribute.String("request_id", requestID),
+		))
+	c.Request = c.Request.WithContext(ctx)
+	defer func() {
+		statusCode := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		if statusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+		span.End()
+		s.events.Publish(events.Event{
+			Type:       events.RequestCompleted,
+			RequestID:  requestID,
+			StatusCode: statusCode,
+			Success:    statusCode >= 200 && statusCode < 300,
+			DurationMs: time.Since(startTime).Milliseconds(),
+		})
+	}()
+
 	// 读取请求体
 	requestBody, err := s.readRequestBody(c)
 	if err != nil {
@@ -28,6 +60,15 @@ func (s *Server) handleProxy(c *gin.Context) {
 	originalModel := s.extractModelFromRequest(requestBody)
 	// 存储到context中，供后续使用
 	c.Set("original_model", originalModel)
+	span.SetAttributes(attribute.String("claude.model", originalModel))
+	s.events.Publish(events.Event{
+		Type:      events.RequestReceived,
+		Time:      startTime,
+		RequestID: requestID,
+		Method:    c.Request.Method,
+		Path:      path,
+		Model:     originalModel,
+	})
 
 	// 提取 thinking 信息
 	thinkingInfo, err := utils.ExtractThinkingInfo(string(requestBody))
@@ -37,14 +78,24 @@ func (s *Server) handleProxy(c *gin.Context) {
 	// 存储到context中，供后续使用
 	c.Set("thinking_info", thinkingInfo)
 
+	// 估算 prompt token 数，用于按端点的 max_context_tokens 选择端点
+	estimatedTokens := utils.EstimateRequestTokens(requestBody)
+	c.Set("estimated_tokens", estimatedTokens)
+	// 同时放入请求上下文，供 context-length tagger 复用，避免重复解析请求体
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), "estimated_tokens", estimatedTokens))
+
 	// 处理请求标签
 	taggedRequest := s.processRequestTags(c.Request)
+	if taggedRequest != nil {
+		span.SetAttributes(attribute.String("claude.tags", strings.Join(taggedRequest.Tags, ",")))
+		s.events.Publish(events.Event{Type: events.RequestTagged, RequestID: requestID, Tags: taggedRequest.Tags})
+	}
 
 	// count_tokens 请求将通过统一的端点尝试和回退逻辑处理
 	// OpenAI 端点不支持 count_tokens，但会自动回退到支持的端点
 
 	// 选择端点并处理请求
-	selectedEndpoint, err := s.selectEndpointForRequest(taggedRequest)
+	selectedEndpoint, err := s.selectEndpointForRequest(c, taggedRequest, estimatedTokens)
 	if err != nil {
 		s.logger.Error("Failed to select endpoint", err)
 		// 获取tags用于日志记录
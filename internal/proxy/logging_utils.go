@@ -8,10 +8,14 @@ import (
 	"time"
 
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/logger"
 	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // sendFailureResponse 发送失败响应
@@ -35,8 +39,9 @@ func (s *Server) sendFailureResponse(c *gin.Context, requestID string, startTime
 		requestLog.Model = utils.ExtractModelFromRequestBody(string(requestBody))
 		requestLog.RequestBodySize = len(requestBody)
 		
-		// 提取 Session ID
+		// 提取 Session ID 和项目
 		requestLog.SessionID = utils.ExtractSessionIDFromRequestBody(string(requestBody))
+		requestLog.Project = utils.ExtractProjectFromRequestBody(string(requestBody))
 		
 		// 根据配置记录请求体内容
 		if s.config.Logging.LogRequestBody != "none" {
@@ -75,7 +80,7 @@ func (s *Server) sendFailureResponse(c *gin.Context, requestID string, startTime
 	
 	requestLog.Tags = requestTags
 	requestLog.Error = errorMsg
-	s.logger.LogRequest(requestLog)
+	s.persistRequestLog(c, requestLog)
 	s.sendProxyError(c, http.StatusBadGateway, errorType, requestLog.Error, requestID)
 }
 
@@ -95,6 +100,11 @@ func (s *Server) logSimpleRequest(requestID, endpoint, method, path string, orig
 				requestLog.ThinkingBudgetTokens = info.BudgetTokens
 			}
 		}
+		
+		// 设置回退链信息
+		requestLog.FallbackChain = c.GetString("fallback_chain")
+		requestLog.FallbackLevel = c.GetInt("fallback_level")
+		requestLog.Credential = c.GetString("attempt_credential")
 	}
 	
 	// 记录原始客户端请求数据
@@ -185,14 +195,31 @@ func (s *Server) logSimpleRequest(requestID, endpoint, method, path string, orig
 			requestLog.ModelRewriteApplied = rewrittenModel != requestLog.OriginalModel
 		}
 		
-		// 提取 Session ID
+		// 提取 Session ID 和项目
 		requestLog.SessionID = utils.ExtractSessionIDFromRequestBody(string(originalRequestBody))
+		requestLog.Project = utils.ExtractProjectFromRequestBody(string(originalRequestBody))
 	}
 	
 	// 更新并记录日志
 	s.logger.UpdateRequestLog(requestLog, req, resp, responseBody, duration, err)
 	requestLog.IsStreaming = isStreaming
-	s.logger.LogRequest(requestLog)
+	s.persistRequestLog(c, requestLog)
+}
+
+// logRejectedAttempt 记录在同一端点上重试之前被拒绝的那次请求（凭据被拒绝或 OAuth 令牌失效），并设置状态码供重试逻辑使用
+func (s *Server) logRejectedAttempt(c *gin.Context, ep *endpoint.Endpoint, req *http.Request, resp *http.Response, requestID, path string, requestBody, finalRequestBody []byte, endpointStartTime time.Time, tags []string, originalModel, rewrittenModel string, attemptNumber int) {
+	duration := time.Since(endpointStartTime)
+	body, _ := io.ReadAll(resp.Body)
+	
+	// 解压响应体用于日志记录
+	decompressedBody, err := s.validator.GetDecompressedBody(body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		decompressedBody = body // 如果解压失败，使用原始数据
+	}
+	
+	s.logSimpleRequest(requestID, ep.URL, c.Request.Method, path, requestBody, finalRequestBody, c, req, resp, decompressedBody, duration, nil, s.isRequestExpectingStream(req), tags, "", originalModel, rewrittenModel, attemptNumber)
+	c.Set("last_error", nil)
+	c.Set("last_status_code", resp.StatusCode)
 }
 
 // logBlacklistedEndpointRequest 记录对被拉黑端点的请求日志
@@ -219,6 +246,10 @@ func (s *Server) logBlacklistedEndpointRequest(requestID string, ep *endpoint.En
 		requestLog.Tags = taggedRequest.Tags
 	}
 	
+	// 设置回退链信息
+	requestLog.FallbackChain = c.GetString("fallback_chain")
+	requestLog.FallbackLevel = c.GetInt("fallback_level")
+	
 	// 记录原始请求数据
 	if c.Request != nil {
 		requestLog.OriginalRequestHeaders = utils.HeadersToMap(c.Request.Header)
@@ -230,6 +261,7 @@ func (s *Server) logBlacklistedEndpointRequest(requestID string, ep *endpoint.En
 	if len(requestBody) > 0 {
 		requestLog.Model = utils.ExtractModelFromRequestBody(string(requestBody))
 		requestLog.SessionID = utils.ExtractSessionIDFromRequestBody(string(requestBody))
+		requestLog.Project = utils.ExtractProjectFromRequestBody(string(requestBody))
 		
 		if s.config.Logging.LogRequestBody != "none" {
 			if s.config.Logging.LogRequestBody == "truncated" {
@@ -241,5 +273,16 @@ func (s *Server) logBlacklistedEndpointRequest(requestID string, ep *endpoint.En
 		}
 	}
 	
+	s.persistRequestLog(c, requestLog)
+}
+
+// persistRequestLog 写入请求日志（脱敏并进入写入队列，同步写入时包含数据库写入），并记录追踪 span
+func (s *Server) persistRequestLog(c *gin.Context, requestLog *logger.RequestLog) {
+	var span trace.Span
+	if c != nil {
+		_, span = tracing.Start(c.Request.Context(), "Logger.LogRequest",
+			trace.WithAttributes(attribute.Int("log.attempt", requestLog.AttemptNumber)))
+	}
 	s.logger.LogRequest(requestLog)
-}
\ No newline at end of file
+	tracing.End(span, nil)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"claude-code-companion/internal/metrics"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,6 +17,9 @@ func (s *Server) loggingMiddleware() gin.HandlerFunc {
 		c.Set("request_id", requestID)
 		c.Set("start_time", start)
 
+		metrics.InFlight.Inc()
+		defer metrics.InFlight.Dec()
+
 		c.Next()
 	}
 }
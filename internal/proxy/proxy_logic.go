@@ -14,13 +14,31 @@ import (
 
 	"claude-code-companion/internal/conversion"
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/metrics"
 	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path string, requestBody []byte, requestID string, startTime time.Time, taggedRequest *tagging.TaggedRequest, attemptNumber int) (bool, bool) {
+	// 凭据被拒绝或 OAuth 令牌刷新后在同一端点上重试，被拒绝的那次请求已在 proxyToEndpointOnce 中单独记录日志
+	for {
+		success, shouldRetry, retrySameEndpoint := s.proxyToEndpointOnce(c, ep, path, requestBody, requestID, startTime, taggedRequest, attemptNumber)
+		if !retrySameEndpoint {
+			return success, shouldRetry
+		}
+	}
+}
+
+// proxyToEndpointOnce 使用一个凭据向端点发送一次请求，retrySameEndpoint 表示应换凭据或用刷新后的令牌再试一次同一端点
+func (s *Server) proxyToEndpointOnce(c *gin.Context, ep *endpoint.Endpoint, path string, requestBody []byte, requestID string, startTime time.Time, taggedRequest *tagging.TaggedRequest, attemptNumber int) (success, shouldRetry, retrySameEndpoint bool) {
+	c.Set("attempt_credential", "")
+
 	// 检查是否为 count_tokens 请求到 OpenAI 端点
 	isCountTokensRequest := strings.Contains(path, "/count_tokens")
 	isOpenAIEndpoint := ep.EndpointType == "openai"
@@ -34,7 +52,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		c.Set("count_tokens_openai_skip", true)
 		c.Set("last_error", fmt.Errorf("count_tokens not supported on OpenAI endpoint"))
 		c.Set("last_status_code", http.StatusNotFound)
-		return false, true // 立即尝试下一个端点
+		return false, true, false // 立即尝试下一个端点
 	}
 	// 为这个端点记录独立的开始时间
 	endpointStartTime := time.Now()
@@ -57,11 +75,14 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", fmt.Errorf(createRequestError))
 		c.Set("last_status_code", 0)
-		return false, false
+		return false, false, false
 	}
 
-	// 应用模型重写（如果配置了）
-	originalModel, rewrittenModel, err := s.modelRewriter.RewriteRequestWithTags(tempReq, ep.ModelRewrite, ep.Tags)
+	// 应用模型重写（如果配置了，回退链级别指定的模型优先）
+	_, rewriteSpan := tracing.Start(c.Request.Context(), "ModelRewrite.RewriteRequest")
+	originalModel, rewrittenModel, err := s.modelRewriter.RewriteRequestWithTags(tempReq, s.fallbackModelRewriteConfig(c, ep), ep.Tags)
+	rewriteSpan.SetAttributes(attribute.String("model.original", originalModel), attribute.String("model.rewritten", rewrittenModel))
+	tracing.End(rewriteSpan, err)
 	if err != nil {
 		s.logger.Error("Model rewrite failed", err)
 		// 记录模型重写失败的日志
@@ -70,7 +91,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", err)
 		c.Set("last_status_code", 0)
-		return false, false
+		return false, false, false
 	}
 
 	// 如果进行了模型重写，获取重写后的请求体
@@ -84,7 +105,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			// 设置错误信息到context中
 			c.Set("last_error", err)
 			c.Set("last_status_code", 0)
-			return false, false
+			return false, false, false
 		}
 	} else {
 		finalRequestBody = requestBody // 使用原始请求体
@@ -102,7 +123,10 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			MaxTokensFieldName: ep.MaxTokensFieldName,
 		}
 		
+		_, convertSpan := tracing.Start(c.Request.Context(), "ConvertRequest",
+			trace.WithAttributes(attribute.String("endpoint.type", ep.EndpointType), attribute.Int("body.size", len(finalRequestBody))))
 		convertedBody, ctx, err := s.converter.ConvertRequest(finalRequestBody, endpointInfo)
+		tracing.End(convertSpan, err)
 		if err != nil {
 			s.logger.Error("Request format conversion failed", err)
 			duration := time.Since(endpointStartTime)
@@ -112,7 +136,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			// 设置错误信息到context中
 			c.Set("last_error", err)
 			c.Set("last_status_code", http.StatusBadRequest)
-			return false, false // 不重试，直接返回
+			return false, false, false // 不重试，直接返回
 		}
 		finalRequestBody = convertedBody
 		conversionContext = ctx
@@ -177,7 +201,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", fmt.Errorf(createRequestError))
 		c.Set("last_status_code", 0)
-		return false, false
+		return false, false, false
 	}
 
 	for key, values := range c.Request.Header {
@@ -189,24 +213,55 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		}
 	}
 
+	// 配置了凭据池时轮换使用凭据，本次请求中已经被拒绝的凭据不再使用
+	triedCredentials := s.getTriedCredentials(c, ep)
+	credential, err := ep.AcquireCredential(triedCredentials)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to acquire credential: %v", err), err)
+		c.Set("last_error", err)
+		c.Set("last_status_code", 0)
+		return false, true, false
+	}
+	authValue := ep.ResolvedAuthValue()
+	if credential != nil {
+		authValue = credential.Value
+		triedCredentials[credential.Name] = true
+		c.Set("attempt_credential", credential.Name)
+		s.logger.Debug(fmt.Sprintf("Using credential %s for endpoint %s", credential.Name, ep.Name))
+	}
+
 	// 根据认证类型设置不同的认证头部
-	if ep.AuthType == "api_key" {
-		req.Header.Set("x-api-key", ep.AuthValue)
+	if credential != nil {
+		if ep.AuthType == "api_key" {
+			req.Header.Set("x-api-key", authValue)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+authValue)
+		}
+	} else if ep.AuthType == "api_key" {
+		req.Header.Set("x-api-key", authValue)
 	} else {
+		// 令牌已过期时获取认证头会先同步刷新令牌
+		var refreshSpan trace.Span
+		if ep.OAuthTokenExpired() {
+			_, refreshSpan = tracing.Start(c.Request.Context(), "OAuth.RefreshToken",
+				trace.WithAttributes(attribute.String("endpoint.name", ep.Name), attribute.String("oauth.trigger", "expiring")))
+		}
 		authHeader, err := ep.GetAuthHeaderWithRefreshCallback(s.config.Timeouts.ToProxyTimeoutConfig(), s.createOAuthTokenRefreshCallback())
+		tracing.End(refreshSpan, err)
 		if err != nil {
 			s.logger.Error(fmt.Sprintf("Failed to get auth header: %v", err), err)
+			s.oauthRefresher.HandleRefreshError(ep, err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
 			// 设置错误信息到context中
 			c.Set("last_error", err)
 			c.Set("last_status_code", http.StatusUnauthorized)
-			return false, false
+			return false, false, false
 		}
 		req.Header.Set("Authorization", authHeader)
 	}
 
 	// Special OAuth header hack for api.anthropic.com with OAuth tokens
-	if strings.Contains(ep.URL, "api.anthropic.com") && ep.AuthType == "auth_token" && strings.HasPrefix(ep.AuthValue, "sk-ant-oat01") {
+	if strings.Contains(ep.URL, "api.anthropic.com") && ep.AuthType == "auth_token" && strings.HasPrefix(authValue, "sk-ant-oat01") {
 		if existingBeta := req.Header.Get("Anthropic-Beta"); existingBeta != "" {
 			// Prepend oauth-2025-04-20 to existing Anthropic-Beta header
 			req.Header.Set("Anthropic-Beta", "oauth-2025-04-20,"+existingBeta)
@@ -216,6 +271,9 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		}
 	}
 
+	// 将本次尝试的 span 作为上游请求的父节点（header 覆盖规则仍可改写或删除）
+	tracing.Inject(c.Request.Context(), req.Header)
+
 	// 应用HTTP Header覆盖规则（在所有其他header处理之后）
 	if headerOverrides := ep.GetHeaderOverrides(); headerOverrides != nil && len(headerOverrides) > 0 {
 		for headerName, headerValue := range headerOverrides {
@@ -244,17 +302,20 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", err)
 		c.Set("last_status_code", 0)
-		return false, true
+		return false, true, false
 	}
 
 	resp, err := client.Do(req)
+	if err == nil {
+		c.Set("upstream_ttfb", time.Since(endpointStartTime))
+	}
 	if err != nil {
 		duration := time.Since(endpointStartTime)
 		s.logSimpleRequest(requestID, ep.URL, c.Request.Method, path, requestBody, finalRequestBody, c, req, nil, nil, duration, err, s.isRequestExpectingStream(req), tags, "", originalModel, rewrittenModel, attemptNumber)
 		// 设置错误信息到context中，供重试逻辑使用
 		c.Set("last_error", err)
 		c.Set("last_status_code", 0) // 网络错误，没有状态码
-		return false, true
+		return false, true, false
 	}
 	defer resp.Body.Close()
 
@@ -273,8 +334,13 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			c.Set(refreshKey, true)
 			
 			// 尝试刷新token
-			if refreshErr := ep.RefreshOAuthTokenWithCallback(s.config.Timeouts.ToProxyTimeoutConfig(), s.createOAuthTokenRefreshCallback()); refreshErr != nil {
+			_, refreshSpan := tracing.Start(c.Request.Context(), "OAuth.RefreshToken",
+				trace.WithAttributes(attribute.String("endpoint.name", ep.Name), attribute.String("oauth.trigger", "auth_failed")))
+			refreshErr := ep.RefreshOAuthTokenWithCallback(s.config.Timeouts.ToProxyTimeoutConfig(), s.createOAuthTokenRefreshCallback())
+			tracing.End(refreshSpan, refreshErr)
+			if refreshErr != nil {
 				s.logger.Error(fmt.Sprintf("Failed to refresh OAuth token for endpoint %s: %v", ep.Name, refreshErr), refreshErr)
+				s.oauthRefresher.HandleRefreshError(ep, refreshErr)
 				
 				// 刷新失败，读取响应体用于日志记录
 				duration := time.Since(endpointStartTime)
@@ -289,21 +355,39 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 				// 设置错误信息到context中
 				c.Set("last_error", fmt.Errorf("OAuth token refresh failed: %v", refreshErr))
 				c.Set("last_status_code", resp.StatusCode)
-				return false, true
+				return false, true, false
 			} else {
 				s.logger.Info(fmt.Sprintf("OAuth token refreshed successfully for endpoint %s, retrying request", ep.Name))
 				
-				// 关闭原始响应体
-				resp.Body.Close()
-				
-				// Token刷新成功，递归重试相同的endpoint（重新走完整的请求流程）
-				return s.proxyToEndpoint(c, ep, path, requestBody, requestID, startTime, taggedRequest, attemptNumber)
+				// 记录被拒绝的这次请求，然后用新令牌重试相同的endpoint（重新走完整的请求流程）
+				s.logRejectedAttempt(c, ep, req, resp, requestID, path, requestBody, finalRequestBody, endpointStartTime, tags, originalModel, rewrittenModel, attemptNumber)
+				return false, true, true
 			}
 		} else {
 			s.logger.Debug(fmt.Sprintf("OAuth token refresh already attempted for endpoint %s in this request, not retrying", ep.Name))
 		}
 	}
 	
+	// 凭据引用的值在存储中轮换后（如更新了 Docker/K8s secret 文件），上游拒绝旧值时重新读取并用新值重试同一端点
+	if (resp.StatusCode == 401 || resp.StatusCode == 403) && ep.AuthType != "oauth" && ep.ReloadSecret(credential) {
+		if credential != nil {
+			delete(triedCredentials, credential.Name)
+		}
+		s.logger.Info(fmt.Sprintf("Authentication failed (HTTP %d) for endpoint %s, secret was rotated in the secrets backend, retrying with the new value", resp.StatusCode, ep.Name))
+		s.logRejectedAttempt(c, ep, req, resp, requestID, path, requestBody, finalRequestBody, endpointStartTime, tags, originalModel, rewrittenModel, attemptNumber)
+		return false, true, true
+	}
+	
+	// 凭据被拒绝（401/403）或限流（429）时只拉黑或冷却该凭据，端点还有其他可用凭据时换一个凭据重试
+	if credential != nil && ep.ReportCredentialResult(credential, resp.StatusCode, resp.Header.Get("Retry-After")) {
+		if ep.HasUsableCredential(triedCredentials) {
+			s.logger.Info(fmt.Sprintf("Credential %s of endpoint %s rejected with HTTP %d, retrying with another credential", credential.Name, ep.Name, resp.StatusCode))
+			s.logRejectedAttempt(c, ep, req, resp, requestID, path, requestBody, finalRequestBody, endpointStartTime, tags, originalModel, rewrittenModel, attemptNumber)
+			return false, true, true
+		}
+		s.logger.Info(fmt.Sprintf("Credential %s of endpoint %s rejected with HTTP %d, no other usable credentials", credential.Name, ep.Name, resp.StatusCode))
+	}
+	
 	// 只有2xx状态码才认为是成功，其他所有状态码都尝试下一个端点
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		duration := time.Since(endpointStartTime)
@@ -321,10 +405,13 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置状态码到context中，供重试逻辑使用
 		c.Set("last_error", nil)
 		c.Set("last_status_code", resp.StatusCode)
-		return false, true
+		return false, true, false
 	}
 
-	responseBody, err := io.ReadAll(resp.Body)
+	// 读取过程中向管理界面推送接收进度（流式响应包含实时 token 数）
+	progressBase := events.Event{RequestID: requestID, Endpoint: ep.Name, Attempt: attemptNumber}
+	upstreamStreaming := strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream")
+	responseBody, err := io.ReadAll(newProgressReader(resp.Body, s.events, progressBase, upstreamStreaming))
 	if err != nil {
 		s.logger.Error("Failed to read response body", err)
 		// 记录读取响应体失败的日志
@@ -334,7 +421,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", fmt.Errorf(readError))
 		c.Set("last_status_code", resp.StatusCode)
-		return false, false
+		return false, false, false
 	}
 
 	// 解压响应体仅用于日志记录和验证
@@ -349,7 +436,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", fmt.Errorf(decompressError))
 		c.Set("last_status_code", resp.StatusCode)
-		return false, false
+		return false, false, false
 	}
 
 	// 智能检测内容类型并自动覆盖
@@ -399,7 +486,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			// 设置错误信息到context中
 			c.Set("last_error", fmt.Errorf(errorLog))
 			c.Set("last_status_code", resp.StatusCode)
-			return false, true // 验证失败，尝试下一个endpoint
+			return false, true, false // 验证失败，尝试下一个endpoint
 		}
 		
 		// 如果是SSE流不完整的验证失败，尝试下一个endpoint
@@ -411,7 +498,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			// 设置错误信息到context中
 			c.Set("last_error", fmt.Errorf(errorLog))
 			c.Set("last_status_code", resp.StatusCode)
-			return false, true // SSE流不完整，尝试下一个endpoint
+			return false, true, false // SSE流不完整，尝试下一个endpoint
 		}
 		
 		// 验证失败，尝试下一个端点
@@ -422,7 +509,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		// 设置错误信息到context中
 		c.Set("last_error", fmt.Errorf(validationError))
 		c.Set("last_status_code", resp.StatusCode)
-		return false, true // 验证失败，尝试下一个endpoint
+		return false, true, false // 验证失败，尝试下一个endpoint
 	}
 
 	c.Status(resp.StatusCode)
@@ -431,7 +518,10 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 	convertedResponseBody := decompressedBody
 	if conversionContext != nil {
 		s.logger.Info(fmt.Sprintf("Starting response conversion. Streaming: %v, OriginalSize: %d", isStreaming, len(decompressedBody)))
+		_, convertSpan := tracing.Start(c.Request.Context(), "ConvertResponse",
+			trace.WithAttributes(attribute.Bool("response.streaming", isStreaming), attribute.Int("body.size", len(decompressedBody))))
 		convertedResp, err := s.converter.ConvertResponse(decompressedBody, conversionContext, isStreaming)
+		tracing.End(convertSpan, err)
 		if err != nil {
 			s.logger.Error("Response format conversion failed", err)
 			// Response转换失败，记录错误并尝试下一个端点
@@ -441,7 +531,7 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			// 设置错误信息到context中
 			c.Set("last_error", fmt.Errorf(conversionError))
 			c.Set("last_status_code", resp.StatusCode)
-			return false, true // Response转换失败，尝试下一个端点
+			return false, true, false // Response转换失败，尝试下一个端点
 		} else {
 			convertedResponseBody = convertedResp
 			s.logger.Info(fmt.Sprintf("Response conversion successful! Original: %d bytes -> Converted: %d bytes", len(decompressedBody), len(convertedResp)))
@@ -456,7 +546,9 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 	// 应用响应模型重写（如果进行了请求模型重写）
 	finalResponseBody := convertedResponseBody
 	if originalModel != "" && rewrittenModel != "" {
+		_, rewriteSpan := tracing.Start(c.Request.Context(), "ModelRewrite.RewriteResponse")
 		rewrittenResponseBody, err := s.modelRewriter.RewriteResponse(convertedResponseBody, originalModel, rewrittenModel)
+		tracing.End(rewriteSpan, err)
 		if err != nil {
 			s.logger.Error("Failed to rewrite response model", err)
 			// 如果响应重写失败，使用转换后的响应体，不中断请求
@@ -517,6 +609,11 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		}
 	}
 	
+	// 设置回退链信息
+	requestLog.FallbackChain = c.GetString("fallback_chain")
+	requestLog.FallbackLevel = c.GetInt("fallback_level")
+	requestLog.Credential = c.GetString("attempt_credential")
+	
 	// 记录原始客户端请求数据
 	requestLog.OriginalRequestURL = c.Request.URL.String()
 	requestLog.OriginalRequestHeaders = utils.HeadersToMap(c.Request.Header)
@@ -574,6 +671,14 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 		}
 	}
 	
+	// 提取 token 用量（使用发送给客户端的完整 Anthropic 格式响应）
+	usage := utils.ExtractTokenUsage(finalResponseBody)
+	c.Set("upstream_output_tokens", usage.OutputTokens)
+	requestLog.InputTokens = usage.InputTokens
+	requestLog.OutputTokens = usage.OutputTokens
+	requestLog.CacheCreationInputTokens = usage.CacheCreationInputTokens
+	requestLog.CacheReadInputTokens = usage.CacheReadInputTokens
+	
 	// 设置兼容性字段
 	requestLog.RequestHeaders = requestLog.FinalRequestHeaders
 	requestLog.RequestBody = requestLog.OriginalRequestBody
@@ -596,16 +701,24 @@ func (s *Server) proxyToEndpoint(c *gin.Context, ep *endpoint.Endpoint, path str
 			requestLog.ModelRewriteApplied = rewrittenModel != requestLog.OriginalModel
 		}
 		
-		// 提取 Session ID
+		// 提取 Session ID 和项目
 		requestLog.SessionID = utils.ExtractSessionIDFromRequestBody(string(requestBody))
+		requestLog.Project = utils.ExtractProjectFromRequestBody(string(requestBody))
 	}
 	
 	// 更新基本字段
 	s.logger.UpdateRequestLog(requestLog, req, resp, decompressedBody, duration, nil)
 	requestLog.IsStreaming = isStreaming
-	s.logger.LogRequest(requestLog)
+	s.persistRequestLog(c, requestLog)
+
+	// 记录 token 用量指标
+	modelLabel := metrics.ModelLabel(requestLog.Model)
+	metrics.Tokens.Add(float64(usage.InputTokens), ep.Name, modelLabel, "input")
+	metrics.Tokens.Add(float64(usage.OutputTokens), ep.Name, modelLabel, "output")
+	metrics.Tokens.Add(float64(usage.CacheCreationInputTokens), ep.Name, modelLabel, "cache_creation_input")
+	metrics.Tokens.Add(float64(usage.CacheReadInputTokens), ep.Name, modelLabel, "cache_read_input")
 
-	return true, false
+	return true, false, false
 }
 
 // applyParameterOverrides 应用请求参数覆盖规则
@@ -846,7 +959,7 @@ func (s *Server) processRateLimitHeaders(ep *endpoint.Endpoint, headers http.Hea
 		})
 		
 		// 持久化到配置文件
-		if err := s.persistRateLimitState(ep.ID, resetValue, statusValue); err != nil {
+		if err := s.persistRateLimitState(ep.Name, resetValue, statusValue); err != nil {
 			s.logger.Error("Failed to persist rate limit state", err)
 			return err
 		}
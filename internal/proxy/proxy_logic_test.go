@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/conversion"
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/health"
+	"claude-code-companion/internal/modelrewrite"
+	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCredentialRejectionRetriesAndLogsEachAttempt(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("x-api-key")
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if key != "key-b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	configYAML := fmt.Sprintf(`server:
+  host: 127.0.0.1
+  port: 8080
+endpoints:
+  - name: pool
+    url: %q
+    endpoint_type: anthropic
+    auth_type: api_key
+    enabled: true
+    priority: 1
+    credentials:
+      - {name: a, value: key-a}
+      - {name: b, value: key-b}
+logging:
+  level: error
+  log_directory: %q
+`, upstream.URL, dir)
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	server := newTestServer(t, cfg)
+	server.configFilePath = configPath
+	server.validator = validator.NewResponseValidator()
+	server.modelRewriter = modelrewrite.NewRewriter(*server.logger)
+	server.converter = conversion.NewConverter(server.logger)
+	server.healthChecker = health.NewChecker(cfg.Timeouts.ToHealthCheckTimeoutConfig(), server.modelRewriter, server.converter)
+	server.events = events.NewHub(16)
+	server.taggingManager = tagging.NewManager()
+	if err := server.taggingManager.Initialize(&cfg.Tagging); err != nil {
+		t.Fatalf("Failed to initialize taggers: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	server.router = gin.New()
+	server.router.Group("/v1", server.loggingMiddleware()).Any("/*path", server.handleProxy)
+
+	body := `{"model":"claude-sonnet-4","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	server.GetRouter().ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the request to succeed with the second credential, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if want := []string{"key-a", "key-b"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Expected credentials to be tried in order %v, got %v", want, keys)
+	}
+
+	server.logger.Flush()
+	logs, _, err := server.logger.GetLogs(10, 0, false)
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	var attempts []string
+	for _, log := range logs {
+		attempts = append(attempts, fmt.Sprintf("%s:%d", log.Credential, log.StatusCode))
+	}
+	sort.Strings(attempts)
+	if want := []string{"a:401", "b:200"}; !reflect.DeepEqual(attempts, want) {
+		t.Errorf("Expected the rejected attempt to be logged with its credential, got %v", attempts)
+	}
+}
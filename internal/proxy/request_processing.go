@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strings"
 
+	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/metrics"
 	"claude-code-companion/internal/tagging"
 	"claude-code-companion/internal/utils"
 
@@ -43,6 +45,7 @@ func (s *Server) processRequestTags(req *http.Request) *tagging.TaggedRequest {
 		// 记录详细的tagging结果
 		s.logger.Debug(fmt.Sprintf("Tagging completed: found %d tags: %v", len(taggedRequest.Tags), taggedRequest.Tags))
 		for _, result := range taggedRequest.TaggerResults {
+			metrics.TaggerDuration.Observe(result.Duration.Seconds(), result.TaggerName, taggerResultLabel(result))
 			if result.Error != nil {
 				s.logger.Debug(fmt.Sprintf("Tagger %s failed: %v", result.TaggerName, result.Error))
 			} else {
@@ -55,21 +58,68 @@ func (s *Server) processRequestTags(req *http.Request) *tagging.TaggedRequest {
 	return taggedRequest
 }
 
-// selectEndpointForRequest selects the appropriate endpoint based on tags
-func (s *Server) selectEndpointForRequest(taggedRequest *tagging.TaggedRequest) (*endpoint.Endpoint, error) {
-	if taggedRequest != nil && len(taggedRequest.Tags) > 0 {
-		// 使用tag匹配选择endpoint
-		selectedEndpoint, err := s.endpointManager.GetEndpointWithTags(taggedRequest.Tags)
-		s.logger.Debug(fmt.Sprintf("Request tagged with: %v, selected endpoint: %s", 
-			taggedRequest.Tags, 
+// taggerResultLabel 返回 tagger 执行结果的指标标签
+func taggerResultLabel(result tagging.TaggerResult) string {
+	if result.Error != nil {
+		return "error"
+	}
+	if result.Matched {
+		return "matched"
+	}
+	return "not_matched"
+}
+
+// updateMetricModelLabels 用配置中出现的模型更新指标的 model 标签集合：
+// 模型重写的目标模型和不含通配符的源模式原样保留，含通配符的源模式作为一组模型的标签
+func updateMetricModelLabels(cfg *config.Config) {
+	var models, patterns []string
+	for _, ep := range cfg.Endpoints {
+		if ep.ModelRewrite != nil {
+			for _, rule := range ep.ModelRewrite.Rules {
+				models = append(models, rule.TargetModel)
+				if strings.ContainsAny(rule.SourcePattern, "*?[") {
+					patterns = append(patterns, rule.SourcePattern)
+				} else {
+					models = append(models, rule.SourcePattern)
+				}
+			}
+		}
+		if ep.HealthCheck != nil && ep.HealthCheck.Model != "" {
+			models = append(models, ep.HealthCheck.Model)
+		}
+	}
+	for _, chain := range cfg.Tagging.FallbackChains {
+		for _, step := range chain.Steps {
+			if step.Model != "" {
+				models = append(models, step.Model)
+			}
+		}
+	}
+	metrics.SetModelLabels(models, patterns)
+}
+
+// selectEndpointForRequest selects the appropriate endpoint based on tags and estimated context length
+func (s *Server) selectEndpointForRequest(c *gin.Context, taggedRequest *tagging.TaggedRequest, estimatedTokens int) (*endpoint.Endpoint, error) {
+	var tags []string
+	if taggedRequest != nil {
+		tags = taggedRequest.Tags
+	}
+
+	selectedEndpoint, err := s.endpointManager.GetEndpointForContext(tags, estimatedTokens, c.GetString("original_model"))
+	// 没有可用端点完全匹配请求tags时，优先使用回退链而不是直接落到万用端点
+	if len(tags) > 0 && (err != nil || len(selectedEndpoint.Tags) == 0) {
+		if chainEndpoint := s.selectFallbackChainEndpoint(c, tags, estimatedTokens); chainEndpoint != nil {
+			selectedEndpoint, err = chainEndpoint, nil
+		}
+	}
+	if len(tags) > 0 {
+		s.logger.Debug(fmt.Sprintf("Request tagged with: %v, estimated tokens: %d, selected endpoint: %s", 
+			tags, estimatedTokens,
 			func() string { if selectedEndpoint != nil { return selectedEndpoint.Name } else { return "none" } }()))
-		return selectedEndpoint, err
 	} else {
-		// 使用原有逻辑选择endpoint
-		selectedEndpoint, err := s.endpointManager.GetEndpoint()
-		s.logger.Debug("Request has no tags, using default endpoint selection")
-		return selectedEndpoint, err
+		s.logger.Debug(fmt.Sprintf("Request has no tags, estimated tokens: %d, using default endpoint selection", estimatedTokens))
 	}
+	return selectedEndpoint, err
 }
 
 // extractModelFromRequest extracts the model name from the request body
@@ -1,18 +1,23 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/conversion"
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/events"
 	"claude-code-companion/internal/health"
 	"claude-code-companion/internal/i18n"
 	"claude-code-companion/internal/logger"
 	"claude-code-companion/internal/modelrewrite"
-	"claude-code-companion/internal/statistics"
+	"claude-code-companion/internal/secrets"
 	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/validator"
 	"claude-code-companion/internal/web"
 
@@ -30,9 +35,11 @@ type Server struct {
 	modelRewriter   *modelrewrite.Rewriter // 新增：模型重写器
 	converter       conversion.Converter   // 新增：格式转换器
 	i18nManager     *i18n.Manager          // 新增：国际化管理器
+	events          *events.Hub            // 请求生命周期事件，推送给管理界面
+	oauthRefresher  *endpoint.OAuthRefresher // 后台提前刷新 OAuth 令牌
 	router          *gin.Engine
 	configFilePath  string
-	configMutex     sync.Mutex             // 新增：保护配置文件操作的互斥锁
+	configMutex     sync.Mutex             // 保护 config 的替换；端点配置的修改和保存由管理服务器在它的配置锁内完成
 }
 
 func NewServer(cfg *config.Config, configFilePath string, version string) (*Server, error) {
@@ -42,6 +49,10 @@ func NewServer(cfg *config.Config, configFilePath string, version string) (*Serv
 		LogRequestBody:  cfg.Logging.LogRequestBody,
 		LogResponseBody: cfg.Logging.LogResponseBody,
 		LogDirectory:    cfg.Logging.LogDirectory,
+		Retention:       cfg.Logging.Retention,
+		Storage:         cfg.Logging.Storage,
+		Writer:          cfg.Logging.Writer,
+		Redaction:       cfg.Logging.Redaction,
 	}
 
 	log, err := logger.NewLogger(logConfig)
@@ -53,6 +64,18 @@ func NewServer(cfg *config.Config, configFilePath string, version string) (*Serv
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize endpoint manager: %v", err)
 	}
+	// 日志中的 Endpoint 字段记录的是端点 URL；多个端点共用 URL 时，任一端点关闭正文记录即对该 URL 生效
+	log.SetBodyLoggingFilter(func(endpointURL string) bool {
+		for _, ep := range endpointManager.GetAllEndpoints() {
+			if ep.DisableBodyLogging && (ep.URL == endpointURL || ep.Name == endpointURL) {
+				return true
+			}
+		}
+		return false
+	})
+	// 代理和日志向同一个事件中心发布请求生命周期事件，管理界面订阅后实时展示
+	eventHub := events.NewHub(256)
+	log.SetEventHub(eventHub)
 	responseValidator := validator.NewResponseValidator()
 
 	// 初始化tagging系统
@@ -61,6 +84,11 @@ func NewServer(cfg *config.Config, configFilePath string, version string) (*Serv
 		return nil, fmt.Errorf("failed to initialize tagging system: %v", err)
 	}
 
+	// 初始化链路追踪
+	if err := configureTracing(cfg.Tracing, log); err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %v", err)
+	}
+
 	// 初始化模型重写器
 	modelRewriter := modelrewrite.NewRewriter(*log)
 
@@ -100,15 +128,25 @@ func NewServer(cfg *config.Config, configFilePath string, version string) (*Serv
 		modelRewriter:   modelRewriter,  // 新增：设置模型重写器
 		converter:       converter,      // 新增：设置格式转换器
 		i18nManager:     i18nManager,    // 新增：设置国际化管理器
+		events:          eventHub,
 		configFilePath:  configFilePath,
 	}
 
 	// 设置热更新处理器
 	adminServer.SetHotUpdateHandler(server)
+	adminServer.SetOAuthTokenCallback(server.createOAuthTokenRefreshCallback())
+	adminServer.SetEventHub(eventHub)
 
 	// 让端点管理器使用同一个健康检查器
 	endpointManager.SetHealthChecker(healthChecker)
 
+	// 在令牌过期前后台刷新，避免请求路径上的同步刷新
+	server.oauthRefresher = endpoint.NewOAuthRefresher(endpointManager, server.createOAuthTokenRefreshCallback(), server.disableEndpointWithReason)
+	server.oauthRefresher.Configure(cfg.OAuthRefresh, cfg.Timeouts.ToProxyTimeoutConfig())
+	server.oauthRefresher.Start()
+
+	updateMetricModelLabels(cfg)
+
 	server.setupRoutes()
 	return server, nil
 }
@@ -163,6 +201,22 @@ func (s *Server) HotUpdateConfig(newConfig *config.Config) error {
 
 	s.logger.Info("Starting configuration hot update")
 
+	// 更新凭据存储，新配置中的凭据引用无法解析时拒绝更新
+	secretsChanged := !reflect.DeepEqual(newConfig.Secrets, s.config.Secrets)
+	if secretsChanged {
+		if err := secrets.Configure(newConfig.Secrets); err != nil {
+			return fmt.Errorf("failed to configure secrets backend: %v", err)
+		}
+	}
+	if err := secrets.CheckReferences(newConfig); err != nil {
+		if secretsChanged {
+			if rollbackErr := secrets.Configure(s.config.Secrets); rollbackErr != nil {
+				s.logger.Error("Failed to restore previous secrets backend", rollbackErr)
+			}
+		}
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+
 	// 更新端点配置
 	if err := s.updateEndpoints(newConfig.Endpoints); err != nil {
 		return fmt.Errorf("failed to update endpoints: %v", err)
@@ -176,6 +230,26 @@ func (s *Server) HotUpdateConfig(newConfig *config.Config) error {
 	// 更新验证器配置
 	s.updateValidatorConfig(newConfig.Validation)
 
+	// 更新上游连接池限制
+	s.endpointManager.SetConnectionLimits(newConfig.HTTPClient)
+
+	// 更新端点降级判定策略
+	s.endpointManager.SetDegradationPolicy(newConfig.LatencyScoring)
+
+	// 更新 OAuth 后台刷新参数
+	s.oauthRefresher.Configure(newConfig.OAuthRefresh, newConfig.Timeouts.ToProxyTimeoutConfig())
+
+	// 更新链路追踪配置
+	if !reflect.DeepEqual(newConfig.Tracing, s.config.Tracing) {
+		if err := configureTracing(newConfig.Tracing, s.logger); err != nil {
+			s.logger.Error("Failed to update tracing config, keeping previous tracing settings", err)
+			newConfig.Tracing = s.config.Tracing
+		}
+	}
+
+	// 更新指标的 model 标签集合
+	updateMetricModelLabels(newConfig)
+
 	// 更新内存中的配置（需要锁保护，因为可能与其他配置更新并发）
 	s.configMutex.Lock()
 	s.config = newConfig
@@ -215,77 +289,88 @@ func (s *Server) updateLoggingConfig(newLogging config.LoggingConfig) error {
 	if newLogging.LogDirectory != s.config.Logging.LogDirectory {
 		return fmt.Errorf("log directory cannot be changed via hot update")
 	}
+	if newLogging.Storage != s.config.Logging.Storage {
+		return fmt.Errorf("log storage backend cannot be changed via hot update")
+	}
+	if newLogging.Writer != s.config.Logging.Writer {
+		return fmt.Errorf("log writer queue cannot be changed via hot update")
+	}
+	if err := s.logger.SetRedactionPolicy(newLogging.Redaction); err != nil {
+		return fmt.Errorf("invalid log redaction config: %v", err)
+	}
 
 	// 可以安全更新的日志配置
 	s.config.Logging.Level = newLogging.Level
 	s.config.Logging.LogRequestTypes = newLogging.LogRequestTypes
 	s.config.Logging.LogRequestBody = newLogging.LogRequestBody
 	s.config.Logging.LogResponseBody = newLogging.LogResponseBody
+	s.config.Logging.Retention = newLogging.Retention
+	s.logger.SetRetentionPolicy(newLogging.Retention)
+	s.config.Logging.Redaction = newLogging.Redaction
 
 	return nil
 }
 
+// configureTracing 按配置替换全局追踪 Provider，旧 Provider 在后台导出剩余 span 后关闭
+func configureTracing(cfg config.TracingConfig, log *logger.Logger) error {
+	provider, err := tracing.NewProviderFromConfig(cfg, func(err error) {
+		log.Error("Failed to export trace spans", err)
+	})
+	if err != nil {
+		return err
+	}
+	if provider != nil {
+		log.Info(fmt.Sprintf("Tracing enabled, exporting spans to %s", cfg.Endpoint))
+	}
+	if previous := tracing.SetProvider(provider); previous != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			previous.Shutdown(ctx)
+		}()
+	}
+	return nil
+}
+
 // updateValidatorConfig updates response validator configuration
 func (s *Server) updateValidatorConfig(newValidation config.ValidationConfig) {
 	s.validator = validator.NewResponseValidator()
 	s.config.Validation = newValidation
 }
 
-// saveConfigToFile 将当前配置保存到文件（线程安全）
-func (s *Server) saveConfigToFile() error {
-	// 注意：这个方法假设调用者已经持有 configMutex
-	return config.SaveConfig(s.config, s.configFilePath)
-}
-
 // updateEndpointConfig 安全地更新指定端点的配置并持久化
+// 配置由管理服务器持有，通过它的配置锁修改，避免与管理界面的热更新互相覆盖
 func (s *Server) updateEndpointConfig(endpointName string, updateFunc func(*config.EndpointConfig) error) error {
-	s.configMutex.Lock()
-	defer s.configMutex.Unlock()
-	
-	// 查找对应的端点配置
-	for i, cfgEndpoint := range s.config.Endpoints {
-		if cfgEndpoint.Name == endpointName {
-			// 应用更新函数
-			if err := updateFunc(&s.config.Endpoints[i]); err != nil {
-				return err
-			}
-			
-			// 保存到配置文件
-			return s.saveConfigToFile()
-		}
-	}
-	
-	return fmt.Errorf("endpoint not found: %s", endpointName)
+	return s.adminServer.UpdateEndpointConfig(endpointName, updateFunc)
 }
 
 // createOAuthTokenRefreshCallback 创建 OAuth token 刷新后的回调函数
-func (s *Server) createOAuthTokenRefreshCallback() func(*endpoint.Endpoint) error {
-	return func(ep *endpoint.Endpoint) error {
+func (s *Server) createOAuthTokenRefreshCallback() func(*endpoint.Endpoint, *config.OAuthConfig) error {
+	return func(ep *endpoint.Endpoint, oauthConfig *config.OAuthConfig) error {
 		// 使用统一的配置更新机制
 		return s.updateEndpointConfig(ep.Name, func(cfg *config.EndpointConfig) error {
-			cfg.OAuthConfig = ep.OAuthConfig
+			cfg.OAuthConfig = oauthConfig
 			return nil
 		})
 	}
 }
 
-// persistRateLimitState 持久化endpoint的rate limit状态到配置文件
-func (s *Server) persistRateLimitState(endpointID string, reset *int64, status *string) error {
-	// 首先根据endpoint ID找到对应的endpoint名称
-	var endpointName string
-	s.configMutex.Lock()
-	for _, cfgEndpoint := range s.config.Endpoints {
-		if statistics.GenerateEndpointID(cfgEndpoint.Name) == endpointID {
-			endpointName = cfgEndpoint.Name
-			break
-		}
-	}
-	s.configMutex.Unlock()
-	
-	if endpointName == "" {
-		return fmt.Errorf("endpoint with ID %s not found", endpointID)
+// disableEndpointWithReason 禁用端点并把禁用状态和原因保存到配置文件，重新启用或重新授权后清除
+func (s *Server) disableEndpointWithReason(ep *endpoint.Endpoint, reason string) {
+	ep.DisableWithReason(reason)
+	s.logger.Error(fmt.Sprintf("Endpoint %s disabled: %s", ep.Name, reason), nil)
+	err := s.updateEndpointConfig(ep.Name, func(cfg *config.EndpointConfig) error {
+		cfg.Enabled = false
+		cfg.DisabledReason = reason
+		return nil
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to persist disabled state for endpoint %s", ep.Name), err)
 	}
-	
+}
+
+// persistRateLimitState 持久化endpoint的rate limit状态到配置文件
+func (s *Server) persistRateLimitState(endpointName string, reset *int64, status *string) error {
 	// 使用统一的配置更新机制
 	return s.updateEndpointConfig(endpointName, func(cfg *config.EndpointConfig) error {
 		cfg.RateLimitReset = reset
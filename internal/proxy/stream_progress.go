@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"claude-code-companion/internal/events"
+	"claude-code-companion/internal/utils"
+)
+
+// streamProgressInterval 两次 stream_progress 事件之间的最短间隔
+const streamProgressInterval = 250 * time.Millisecond
+
+// maxPendingLine 未结束的 SSE 行超过该长度时不再解析，只统计字节数
+const maxPendingLine = 1 << 20
+
+// progressReader 包装上游响应体，读取过程中定期发布 stream_progress 事件
+// 流式响应会解析 SSE 数据行：上游给出 usage 时使用准确的 token 数，否则按已收到的文本估算输出 token 数
+type progressReader struct {
+	reader    io.Reader
+	hub       *events.Hub
+	base      events.Event
+	streaming bool
+
+	bytes        int64
+	pending      []byte
+	skipLine     bool
+	inputTokens  int
+	outputTokens int // 上游报告的输出 token 数，为 0 时使用估算值
+	estimator    utils.TextTokenEstimator
+	lastPublish  time.Time
+	finished     bool
+}
+
+// newProgressReader 创建进度读取器，hub 为 nil 时直接返回原始 reader
+func newProgressReader(reader io.Reader, hub *events.Hub, base events.Event, streaming bool) io.Reader {
+	if hub == nil {
+		return reader
+	}
+	base.Type = events.StreamProgress
+	base.Streaming = streaming
+	return &progressReader{reader: reader, hub: hub, base: base, streaming: streaming}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.bytes += int64(n)
+		if r.streaming {
+			r.scan(p[:n])
+		}
+	}
+	if err != nil {
+		// 读取结束（包括出错）时发布最终进度
+		if !r.finished {
+			r.finished = true
+			r.publish()
+		}
+	} else if n > 0 && time.Since(r.lastPublish) >= streamProgressInterval {
+		r.publish()
+	}
+	return n, err
+}
+
+func (r *progressReader) publish() {
+	event := r.base
+	event.Bytes = r.bytes
+	event.InputTokens = r.inputTokens
+	event.OutputTokens = r.outputTokens
+	if event.OutputTokens == 0 {
+		event.OutputTokens = r.estimator.Tokens()
+	}
+	r.lastPublish = time.Now()
+	r.hub.Publish(event)
+}
+
+// scan 按行切分 SSE 数据，跨读取边界的行先缓存
+func (r *progressReader) scan(data []byte) {
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			if !r.skipLine {
+				r.pending = append(r.pending, data...)
+				if len(r.pending) > maxPendingLine {
+					r.pending = r.pending[:0]
+					r.skipLine = true
+				}
+			}
+			return
+		}
+		if !r.skipLine {
+			if len(r.pending) > 0 {
+				r.pending = append(r.pending, data[:idx]...)
+				r.parseLine(r.pending)
+				r.pending = r.pending[:0]
+			} else {
+				r.parseLine(data[:idx])
+			}
+		}
+		r.skipLine = false
+		data = data[idx+1:]
+	}
+}
+
+// streamChunk 同时覆盖 Anthropic 和 OpenAI 流式事件中与进度相关的字段
+type streamChunk struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Usage *streamUsage `json:"usage"`
+	} `json:"message"`
+	Delta *struct {
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage   *streamUsage `json:"usage"`
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"`
+			ToolCalls        []struct {
+				Function struct {
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+type streamUsage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (r *progressReader) parseLine(line []byte) {
+	data, found := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !found {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return
+	}
+	var chunk streamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return
+	}
+
+	switch chunk.Type {
+	case "message_start":
+		// message_start 中的 output_tokens 只是占位值，只取输入 token 数
+		if chunk.Message != nil && chunk.Message.Usage != nil && chunk.Message.Usage.InputTokens > 0 {
+			r.inputTokens = chunk.Message.Usage.InputTokens
+		}
+		return
+	case "content_block_delta":
+		if chunk.Delta != nil {
+			r.estimator.Add(chunk.Delta.Text)
+			r.estimator.Add(chunk.Delta.Thinking)
+			r.estimator.Add(chunk.Delta.PartialJSON)
+		}
+		return
+	}
+
+	for _, choice := range chunk.Choices {
+		r.estimator.Add(choice.Delta.Content)
+		r.estimator.Add(choice.Delta.ReasoningContent)
+		for _, call := range choice.Delta.ToolCalls {
+			r.estimator.Add(call.Function.Arguments)
+		}
+	}
+	if usage := chunk.Usage; usage != nil {
+		if input := max(usage.InputTokens, usage.PromptTokens); input > 0 {
+			r.inputTokens = input
+		}
+		if output := max(usage.OutputTokens, usage.CompletionTokens); output > 0 {
+			r.outputTokens = output
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"claude-code-companion/internal/events"
+)
+
+func TestProgressReaderReportsStreamTokens(t *testing.T) {
+	hub := events.NewHub(64)
+	hub.Publish(events.Event{Type: events.RequestReceived, RequestID: "req-1"})
+
+	stream := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":42,"output_tokens":1}}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"` + strings.Repeat("a", 400) + `"}}`,
+		``,
+	}, "\n") + "\n"
+
+	// 逐字节读取，覆盖跨读取边界的行
+	reader := newProgressReader(iotest.OneByteReader(strings.NewReader(stream)), hub, events.Event{RequestID: "req-1", Endpoint: "primary", Attempt: 1}, true)
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+	state := hub.InFlight()[0]
+	if state.InputTokens != 42 || state.OutputTokens != 100 || state.Bytes != int64(len(stream)) || !state.Streaming {
+		t.Errorf("Expected estimated output tokens before usage arrives, got %+v", state)
+	}
+
+	// message_delta 中的 usage 为准确值
+	final := stream + "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":87}}\n\n"
+	reader = newProgressReader(strings.NewReader(final), hub, events.Event{RequestID: "req-1"}, true)
+	io.ReadAll(reader)
+	if state := hub.InFlight()[0]; state.OutputTokens != 87 {
+		t.Errorf("Expected reported output tokens to replace the estimate, got %d", state.OutputTokens)
+	}
+}
+
+func TestProgressReaderParsesOpenAIChunks(t *testing.T) {
+	hub := events.NewHub(64)
+	hub.Publish(events.Event{Type: events.RequestReceived, RequestID: "req-1"})
+
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"" + strings.Repeat("b", 40) + "\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":11}}\n\n" +
+		"data: [DONE]\n\n"
+	io.ReadAll(newProgressReader(strings.NewReader(stream), hub, events.Event{RequestID: "req-1"}, true))
+	if state := hub.InFlight()[0]; state.InputTokens != 7 || state.OutputTokens != 11 {
+		t.Errorf("Expected OpenAI usage to be reported, got %+v", state)
+	}
+}
+
+func TestProgressReaderWithoutHub(t *testing.T) {
+	original := strings.NewReader("body")
+	if reader := newProgressReader(original, nil, events.Event{}, false); reader != io.Reader(original) {
+		t.Error("Expected the original reader when no hub is configured")
+	}
+}
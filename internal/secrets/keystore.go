@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const keystoreVersion = 1
+
+// keystoreFile 密钥库文件格式：凭据以 JSON 对象整体加密（AES-256-GCM），密钥由主密钥经 scrypt 派生
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keystore 加密的本地密钥库文件，主密钥从环境变量读取
+type Keystore struct {
+	mutex     sync.Mutex
+	path      string
+	masterKey []byte
+}
+
+// NewKeystore 创建密钥库，masterKeyEnv 为保存主密钥的环境变量名；文件不存在时在第一次写入时创建
+func NewKeystore(path, masterKeyEnv string) (*Keystore, error) {
+	masterKey := os.Getenv(masterKeyEnv)
+	if masterKey == "" {
+		return nil, fmt.Errorf("master key environment variable %s is not set", masterKeyEnv)
+	}
+	return &Keystore{path: path, masterKey: []byte(masterKey)}, nil
+}
+
+// Get 读取凭据
+func (k *Keystore) Get(name string) (string, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	values, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[name]
+	if !ok {
+		return "", fmt.Errorf("secret not found in keystore %s", k.path)
+	}
+	return value, nil
+}
+
+// Set 写入凭据
+func (k *Keystore) Set(name, value string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	values, err := k.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return k.save(values)
+}
+
+// Delete 删除凭据
+func (k *Keystore) Delete(name string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	values, err := k.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return fmt.Errorf("secret '%s' not found", name)
+	}
+	delete(values, name)
+	return k.save(values)
+}
+
+// List 返回所有凭据名称
+func (k *Keystore) List() ([]string, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	values, err := k.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (k *Keystore) deriveKey(salt []byte) ([]byte, error) {
+	return scrypt.Key(k.masterKey, salt, 1<<15, 8, 1, 32)
+}
+
+func (k *Keystore) load() (map[string]string, error) {
+	data, err := os.ReadFile(k.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore %s: %v", k.path, err)
+	}
+	if file.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", file.Version)
+	}
+	key, err := k.deriveKey(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %s: wrong master key or corrupted file", k.path)
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted keystore: %v", err)
+	}
+	return values, nil
+}
+
+// save 每次写入使用新的盐和随机数，先写临时文件再替换，避免写入中断损坏密钥库
+func (k *Keystore) save(values map[string]string) error {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	file := keystoreFile{Version: keystoreVersion, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	key, err := k.deriveKey(file.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirProvider 每个凭据一个文件的目录，文件名即凭据名，适用于 Docker/Kubernetes secrets
+type DirProvider struct {
+	dir string
+}
+
+// NewDirProvider 创建目录凭据存储
+func NewDirProvider(dir string) *DirProvider {
+	return &DirProvider{dir: dir}
+}
+
+// Get 读取凭据文件，去掉末尾的换行
+func (d *DirProvider) Get(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// ExecProvider 通过外部命令获取凭据（如 vault、pass、1Password CLI），凭据值从标准输出读取
+type ExecProvider struct {
+	command []string
+	timeout time.Duration
+}
+
+// NewExecProvider 创建命令凭据存储，参数中的 {name} 替换为凭据名，没有 {name} 时凭据名作为最后一个参数
+func NewExecProvider(command []string, timeout time.Duration) *ExecProvider {
+	return &ExecProvider{command: command, timeout: timeout}
+}
+
+// Get 执行命令读取凭据，命令不经过 shell
+func (e *ExecProvider) Get(name string) (string, error) {
+	args := make([]string, 0, len(e.command)+1)
+	substituted := false
+	for _, arg := range e.command[1:] {
+		if strings.Contains(arg, "{name}") {
+			substituted = true
+			arg = strings.ReplaceAll(arg, "{name}", name)
+		}
+		args = append(args, arg)
+	}
+	if !substituted {
+		args = append(args, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command[0], args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("command timed out after %v", e.timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return "", fmt.Errorf("command failed: %v %s", err, msg)
+	}
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("command returned an empty value")
+	}
+	return value, nil
+}
@@ -0,0 +1,148 @@
+// Package secrets 解析配置中的 secret://名称 凭据引用
+//
+// 配置文件只保存引用，真实值保存在凭据存储中：加密密钥库文件、每个凭据一个文件的目录（Docker/K8s secrets）
+// 或外部命令。端点创建时解析引用，OAuth 令牌刷新后通过 Persist 写回可写的存储，配置文件中仍然保留引用。
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+// ErrReadOnly 凭据存储不支持写入
+var ErrReadOnly = errors.New("secrets backend is read-only")
+
+// Provider 凭据存储
+type Provider interface {
+	Get(name string) (string, error)
+}
+
+// Writer 可写的凭据存储
+type Writer interface {
+	Set(name, value string) error
+	Delete(name string) error
+	List() ([]string, error)
+}
+
+var (
+	mutex    sync.RWMutex
+	provider Provider
+	cache    = make(map[string]string)
+)
+
+// NewProvider 根据配置创建凭据存储，未配置后端时返回 nil
+func NewProvider(cfg config.SecretsConfig) (Provider, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "keystore":
+		return NewKeystore(cfg.KeystorePath, cfg.MasterKeyEnv)
+	case "dir":
+		return NewDirProvider(cfg.Directory), nil
+	case "exec":
+		timeout := config.GetTimeoutDuration(cfg.Timeout, 10*time.Second)
+		return NewExecProvider(cfg.Command, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend '%s'", cfg.Backend)
+	}
+}
+
+// Configure 设置全局凭据存储并清空缓存，启动和配置热更新时调用
+func Configure(cfg config.SecretsConfig) error {
+	p, err := NewProvider(cfg)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	provider = p
+	cache = make(map[string]string)
+	return nil
+}
+
+// Resolve 解析凭据引用，不是引用的值原样返回
+func Resolve(value string) (string, error) {
+	name, ok := config.SecretReferenceName(value)
+	if !ok {
+		return value, nil
+	}
+
+	mutex.RLock()
+	p := provider
+	cached, hit := cache[name]
+	mutex.RUnlock()
+	if hit {
+		return cached, nil
+	}
+	if p == nil {
+		return "", fmt.Errorf("secret '%s' referenced but no secrets backend is configured", name)
+	}
+
+	resolved, err := p.Get(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret '%s': %v", name, err)
+	}
+	mutex.Lock()
+	if provider == p {
+		cache[name] = resolved
+	}
+	mutex.Unlock()
+	return resolved, nil
+}
+
+// Invalidate 清除凭据引用的缓存，下一次 Resolve 重新从存储读取；上游拒绝认证时调用，以便读到存储中已经轮换的值
+func Invalidate(reference string) {
+	name, ok := config.SecretReferenceName(reference)
+	if !ok {
+		return
+	}
+	mutex.Lock()
+	delete(cache, name)
+	mutex.Unlock()
+}
+
+// Persist 返回应写入配置文件的值：reference 是凭据引用时把新值写入凭据存储并返回引用，否则返回新值
+// 存储只读时仍返回引用和 ErrReadOnly，新值只在内存中生效
+func Persist(reference, value string) (string, error) {
+	name, ok := config.SecretReferenceName(reference)
+	if !ok {
+		return value, nil
+	}
+	if current, err := Resolve(reference); err == nil && current == value {
+		return reference, nil
+	}
+
+	mutex.RLock()
+	p := provider
+	mutex.RUnlock()
+	w, writable := p.(Writer)
+	if !writable {
+		return reference, ErrReadOnly
+	}
+	if err := w.Set(name, value); err != nil {
+		return reference, fmt.Errorf("failed to store secret '%s': %v", name, err)
+	}
+	mutex.Lock()
+	if provider == p {
+		cache[name] = value
+	}
+	mutex.Unlock()
+	return reference, nil
+}
+
+// CheckReferences 解析配置中的所有凭据引用，返回第一个无法解析的引用
+func CheckReferences(cfg *config.Config) error {
+	for _, field := range cfg.SecretFields() {
+		if _, ok := config.SecretReferenceName(*field.Value); !ok {
+			continue
+		}
+		if _, err := Resolve(*field.Value); err != nil {
+			return fmt.Errorf("%s: %v", field.Location, err)
+		}
+	}
+	return nil
+}
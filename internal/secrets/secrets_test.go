@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/config"
+)
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	t.Setenv("TEST_MASTER_KEY", "correct horse battery staple")
+
+	store, err := NewKeystore(path, "TEST_MASTER_KEY")
+	if err != nil {
+		t.Fatalf("Failed to create keystore: %v", err)
+	}
+	if err := store.Set("anthropic", "sk-ant-api03-secret"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := store.Set("proxy", "hunter2"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-ant-api03-secret") {
+		t.Error("Expected keystore file to be encrypted")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected keystore file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if value, err := store.Get("anthropic"); err != nil || value != "sk-ant-api03-secret" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+	if names, _ := store.List(); len(names) != 2 || names[0] != "anthropic" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	if err := store.Delete("proxy"); err != nil {
+		t.Errorf("Failed to delete secret: %v", err)
+	}
+	if _, err := store.Get("proxy"); err == nil {
+		t.Error("Expected deleted secret to be missing")
+	}
+
+	t.Setenv("TEST_MASTER_KEY", "wrong key")
+	wrong, _ := NewKeystore(path, "TEST_MASTER_KEY")
+	if _, err := wrong.Get("anthropic"); err == nil {
+		t.Error("Expected wrong master key to fail")
+	}
+
+	t.Setenv("TEST_MASTER_KEY", "")
+	if _, err := NewKeystore(path, "TEST_MASTER_KEY"); err == nil {
+		t.Error("Expected missing master key to fail")
+	}
+}
+
+func TestDirProvider(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "api-key"), []byte("sk-from-file\n"), 0600)
+
+	provider := NewDirProvider(dir)
+	if value, err := provider.Get("api-key"); err != nil || value != "sk-from-file" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+	if _, err := provider.Get("missing"); err == nil {
+		t.Error("Expected missing file to fail")
+	}
+}
+
+func TestExecProvider(t *testing.T) {
+	provider := NewExecProvider([]string{"echo", "value-of-{name}"}, 5*time.Second)
+	if value, err := provider.Get("token"); err != nil || value != "value-of-token" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+	// 没有 {name} 时凭据名作为最后一个参数
+	provider = NewExecProvider([]string{"echo", "-n"}, 5*time.Second)
+	if value, err := provider.Get("token"); err != nil || value != "token" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+	provider = NewExecProvider([]string{"false"}, 5*time.Second)
+	if _, err := provider.Get("token"); err == nil {
+		t.Error("Expected failing command to fail")
+	}
+}
+
+func TestResolveAndPersist(t *testing.T) {
+	defer Configure(config.SecretsConfig{})
+
+	if value, err := Resolve("plain-value"); err != nil || value != "plain-value" {
+		t.Errorf("Expected plain values to pass through, got %q, %v", value, err)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token"), []byte("from-dir"), 0600)
+	if err := Configure(config.SecretsConfig{Backend: "dir", Directory: dir}); err != nil {
+		t.Fatalf("Failed to configure: %v", err)
+	}
+	if value, err := Resolve("secret://token"); err != nil || value != "from-dir" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+	// 只读存储无法写回，但仍然返回引用，避免明文写入配置文件
+	if ref, err := Persist("secret://token", "refreshed"); err != ErrReadOnly || ref != "secret://token" {
+		t.Errorf("Expected read-only error and reference, got %q, %v", ref, err)
+	}
+	if value, err := Persist("plain", "refreshed"); err != nil || value != "refreshed" {
+		t.Errorf("Expected plain values to be replaced, got %q, %v", value, err)
+	}
+
+	t.Setenv("TEST_MASTER_KEY", "master")
+	cfg := config.SecretsConfig{Backend: "keystore", KeystorePath: filepath.Join(t.TempDir(), "secrets.enc"), MasterKeyEnv: "TEST_MASTER_KEY"}
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Failed to configure: %v", err)
+	}
+	if ref, err := Persist("secret://oauth-access", "new-token"); err != nil || ref != "secret://oauth-access" {
+		t.Fatalf("Expected secret to be stored, got %q, %v", ref, err)
+	}
+	// 重新配置会清空缓存，值从密钥库文件读取
+	Configure(cfg)
+	if value, err := Resolve("secret://oauth-access"); err != nil || value != "new-token" {
+		t.Errorf("Unexpected value %q, err %v", value, err)
+	}
+
+	full := &config.Config{Endpoints: []config.EndpointConfig{{Name: "main", AuthValue: "secret://missing"}}}
+	if err := CheckReferences(full); err == nil || !strings.Contains(err.Error(), "endpoints[main].auth_value") {
+		t.Errorf("Expected unresolved reference to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package statistics
+
+import (
+	"sort"
+	"time"
+)
+
+// HealthEventRetention 健康事件的保留时间，略长于最大的可用率窗口
+const HealthEventRetention = 8 * 24 * time.Hour
+
+// AvailabilityWindows 计算可用率的时间窗口
+var AvailabilityWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// WindowAvailability 一个时间窗口内的可用率和恢复时间
+type WindowAvailability struct {
+	Window          string  `json:"window"`
+	Availability    float64 `json:"availability"`     // 可用时间占比（百分比）
+	DowntimeSeconds float64 `json:"downtime_seconds"` // 不可用的总时长
+	Incidents       int     `json:"incidents"`        // 窗口内变为不可用的次数
+	MTTRSeconds     float64 `json:"mttr_seconds"`     // 窗口内已恢复故障的平均恢复时间，没有时为0
+	Probes          int     `json:"probes"`           // 健康检查探测次数
+	ProbeFailures   int     `json:"probe_failures"`   // 失败的探测次数
+}
+
+// UptimeBucket 可用率时间条中的一格
+type UptimeBucket struct {
+	Start        time.Time `json:"start"`
+	Availability float64   `json:"availability"` // 百分比
+}
+
+// downtime 一段不可用时间，start 为零值表示早于所有事件
+type downtime struct {
+	start     time.Time
+	end       time.Time
+	recovered bool
+}
+
+// downtimes 根据状态切换事件还原不可用时间段，active 为端点当前是否可用
+func downtimes(events []*HealthEvent, active bool, now time.Time) []downtime {
+	var transitions []*HealthEvent
+	for _, event := range events {
+		if event.Type == HealthEventTransition {
+			transitions = append(transitions, event)
+		}
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].CreatedAt.Before(transitions[j].CreatedAt)
+	})
+
+	// 第一次切换之前的状态由其 FromStatus 决定，没有切换时一直是当前状态
+	inactive := !active
+	if len(transitions) > 0 {
+		inactive = transitions[0].FromStatus == "inactive"
+	}
+
+	var result []downtime
+	var start time.Time
+	for _, t := range transitions {
+		switch {
+		case !inactive && t.ToStatus == "inactive":
+			inactive = true
+			start = t.CreatedAt
+		case inactive && t.ToStatus == "active":
+			inactive = false
+			result = append(result, downtime{start: start, end: t.CreatedAt, recovered: true})
+		}
+	}
+	if inactive {
+		result = append(result, downtime{start: start, end: now})
+	}
+	return result
+}
+
+// overlap 返回不可用时间段与 [from, to) 重叠的时长
+func (d downtime) overlap(from, to time.Time) time.Duration {
+	start, end := d.start, d.end
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// ComputeAvailability 根据最近的健康事件计算各窗口的可用率和平均恢复时间（MTTR）
+func ComputeAvailability(events []*HealthEvent, active bool, now time.Time) []WindowAvailability {
+	periods := downtimes(events, active, now)
+	result := make([]WindowAvailability, 0, len(AvailabilityWindows))
+
+	for _, window := range AvailabilityWindows {
+		from := now.Add(-window.Duration)
+		stats := WindowAvailability{Window: window.Name}
+
+		var down, recovery time.Duration
+		recovered := 0
+		for _, period := range periods {
+			down += period.overlap(from, now)
+			if !period.start.IsZero() && !period.start.Before(from) {
+				stats.Incidents++
+				if period.recovered {
+					recovery += period.end.Sub(period.start)
+					recovered++
+				}
+			}
+		}
+		for _, event := range events {
+			if event.Type == HealthEventProbe && !event.CreatedAt.Before(from) {
+				stats.Probes++
+				if !event.Success {
+					stats.ProbeFailures++
+				}
+			}
+		}
+
+		stats.DowntimeSeconds = down.Seconds()
+		stats.Availability = 100 * (1 - down.Seconds()/window.Duration.Seconds())
+		if recovered > 0 {
+			stats.MTTRSeconds = recovery.Seconds() / float64(recovered)
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+// ComputeUptimeBuckets 将最近 span 时间等分为 count 格，计算每格的可用率
+func ComputeUptimeBuckets(events []*HealthEvent, active bool, now time.Time, span time.Duration, count int) []UptimeBucket {
+	periods := downtimes(events, active, now)
+	size := span / time.Duration(count)
+	buckets := make([]UptimeBucket, count)
+
+	for i := range buckets {
+		from := now.Add(-span + time.Duration(i)*size)
+		to := from.Add(size)
+		var down time.Duration
+		for _, period := range periods {
+			down += period.overlap(from, to)
+		}
+		buckets[i] = UptimeBucket{Start: from, Availability: 100 * (1 - down.Seconds()/size.Seconds())}
+	}
+	return buckets
+}
@@ -0,0 +1,63 @@
+package statistics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func transition(at time.Time, from, to string) *HealthEvent {
+	return &HealthEvent{Type: HealthEventTransition, FromStatus: from, ToStatus: to, CreatedAt: at}
+}
+
+func TestComputeAvailability(t *testing.T) {
+	now := time.Date(2026, 1, 8, 12, 0, 0, 0, time.UTC)
+	events := []*HealthEvent{
+		// 两天前故障 30 分钟
+		transition(now.Add(-48*time.Hour), "active", "inactive"),
+		transition(now.Add(-48*time.Hour+30*time.Minute), "inactive", "active"),
+		// 最近一小时内故障 6 分钟
+		transition(now.Add(-20*time.Minute), "active", "inactive"),
+		{Type: HealthEventProbe, Success: false, CreatedAt: now.Add(-17 * time.Minute)},
+		{Type: HealthEventProbe, Success: true, CreatedAt: now.Add(-14 * time.Minute)},
+		transition(now.Add(-14*time.Minute), "inactive", "active"),
+	}
+
+	windows := ComputeAvailability(events, true, now)
+	if len(windows) != 3 {
+		t.Fatalf("Expected 3 windows, got %d", len(windows))
+	}
+	hour, day, week := windows[0], windows[1], windows[2]
+	if math.Abs(hour.Availability-90) > 0.001 || hour.Incidents != 1 || hour.MTTRSeconds != 360 {
+		t.Errorf("Unexpected 1h availability: %+v", hour)
+	}
+	if hour.Probes != 2 || hour.ProbeFailures != 1 {
+		t.Errorf("Expected probes in the 1h window to be counted, got %+v", hour)
+	}
+	if day.Incidents != 1 || day.DowntimeSeconds != 360 {
+		t.Errorf("Expected only the recent incident in 24h, got %+v", day)
+	}
+	if week.Incidents != 2 || week.DowntimeSeconds != 36*60 || week.MTTRSeconds != 18*60 {
+		t.Errorf("Unexpected 7d availability: %+v", week)
+	}
+}
+
+func TestComputeAvailabilityOngoingDowntime(t *testing.T) {
+	now := time.Date(2026, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	// 没有切换记录时按当前状态计算
+	if windows := ComputeAvailability(nil, false, now); windows[0].Availability != 0 || windows[0].Incidents != 0 {
+		t.Errorf("Expected an inactive endpoint without history to be down, got %+v", windows[0])
+	}
+
+	events := []*HealthEvent{transition(now.Add(-30*time.Minute), "active", "inactive")}
+	hour := ComputeAvailability(events, false, now)[0]
+	if hour.Availability != 50 || hour.Incidents != 1 || hour.MTTRSeconds != 0 {
+		t.Errorf("Expected unrecovered downtime without MTTR, got %+v", hour)
+	}
+
+	buckets := ComputeUptimeBuckets(events, false, now, time.Hour, 4)
+	if buckets[0].Availability != 100 || buckets[1].Availability != 100 || buckets[2].Availability != 0 || buckets[3].Availability != 0 {
+		t.Errorf("Unexpected uptime buckets: %+v", buckets)
+	}
+}
@@ -1,5 +1,7 @@
 package statistics
 
+import "time"
+
 // StatisticsManager defines the interface for endpoint statistics management
 type StatisticsManager interface {
 	// LoadStatistics loads statistics for a specific endpoint ID
@@ -29,6 +31,13 @@ type StatisticsManager interface {
 	// GetStatisticsSummary returns a summary of all statistics
 	GetStatisticsSummary() (map[string]interface{}, error)
 	
+	// RecordHealthEvent persists a health probe result or status transition
+	RecordHealthEvent(event *HealthEvent) error
+	
+	// GetHealthEvents returns health events of an endpoint since the given time, newest first
+	// A limit of 0 returns all matching events
+	GetHealthEvents(endpointID string, since time.Time, limit int) ([]*HealthEvent, error)
+	
 	// Close closes any resources used by the statistics manager
 	Close() error
 	
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,6 +18,10 @@ import (
 type Manager struct {
 	db     *gorm.DB
 	dbPath string
+
+	// 上次清理过期健康事件的时间
+	pruneMutex sync.Mutex
+	lastPrune  time.Time
 }
 
 // NewManager creates a new statistics manager with independent statistics.db
@@ -75,7 +80,7 @@ func NewManager(dataDirectory string) (*Manager, error) {
 	}
 
 	// Auto-migrate the statistics table
-	if err := db.AutoMigrate(&EndpointStatistics{}); err != nil {
+	if err := db.AutoMigrate(&EndpointStatistics{}, &HealthEvent{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate statistics database: %v", err)
 	}
 
@@ -251,9 +256,48 @@ func (m *Manager) DeleteStatistics(endpointID string) error {
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete statistics for endpoint %s: %v", endpointID, result.Error)
 	}
+	if err := m.db.Where("endpoint_id = ?", endpointID).Delete(&HealthEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to delete health events for endpoint %s: %v", endpointID, err)
+	}
+	return nil
+}
+
+// RecordHealthEvent persists a health probe result or status transition
+// Events older than HealthEventRetention are pruned at most once an hour
+func (m *Manager) RecordHealthEvent(event *HealthEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	if err := m.db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record health event for endpoint %s: %v", event.EndpointID, err)
+	}
+
+	m.pruneMutex.Lock()
+	defer m.pruneMutex.Unlock()
+	if time.Since(m.lastPrune) < time.Hour {
+		return nil
+	}
+	m.lastPrune = time.Now()
+	cutoff := time.Now().UTC().Add(-HealthEventRetention)
+	if err := m.db.Where("created_at < ?", cutoff).Delete(&HealthEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to prune health events: %v", err)
+	}
 	return nil
 }
 
+// GetHealthEvents returns health events of an endpoint since the given time, newest first
+func (m *Manager) GetHealthEvents(endpointID string, since time.Time, limit int) ([]*HealthEvent, error) {
+	var events []*HealthEvent
+	query := m.db.Where("endpoint_id = ? AND created_at >= ?", endpointID, since.UTC()).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load health events for endpoint %s: %v", endpointID, err)
+	}
+	return events, nil
+}
+
 // GetAllStatistics returns all endpoint statistics
 func (m *Manager) GetAllStatistics() ([]*EndpointStatistics, error) {
 	var allStats []*EndpointStatistics
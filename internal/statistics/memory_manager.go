@@ -9,14 +9,16 @@ import (
 // MemoryManager is a fallback statistics manager that stores data in memory only
 // This is used when SQLite/CGO is not available
 type MemoryManager struct {
-	statistics map[string]*EndpointStatistics
-	mutex      sync.RWMutex
+	statistics   map[string]*EndpointStatistics
+	healthEvents map[string][]*HealthEvent // 按端点ID保存，按时间先后排列
+	mutex        sync.RWMutex
 }
 
 // NewMemoryManager creates a new memory-only statistics manager
 func NewMemoryManager() *MemoryManager {
 	return &MemoryManager{
-		statistics: make(map[string]*EndpointStatistics),
+		statistics:   make(map[string]*EndpointStatistics),
+		healthEvents: make(map[string][]*HealthEvent),
 	}
 }
 
@@ -142,9 +144,47 @@ func (m *MemoryManager) DeleteStatistics(endpointID string) error {
 	defer m.mutex.Unlock()
 	
 	delete(m.statistics, endpointID)
+	delete(m.healthEvents, endpointID)
 	return nil
 }
 
+// RecordHealthEvent stores a health event in memory, dropping events older than HealthEventRetention
+func (m *MemoryManager) RecordHealthEvent(event *HealthEvent) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	eventCopy := *event
+	events := append(m.healthEvents[event.EndpointID], &eventCopy)
+	
+	cutoff := time.Now().UTC().Add(-HealthEventRetention)
+	expired := 0
+	for expired < len(events) && events[expired].CreatedAt.Before(cutoff) {
+		expired++
+	}
+	m.healthEvents[event.EndpointID] = events[expired:]
+	return nil
+}
+
+// GetHealthEvents returns health events of an endpoint from memory, newest first
+func (m *MemoryManager) GetHealthEvents(endpointID string, since time.Time, limit int) ([]*HealthEvent, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	events := m.healthEvents[endpointID]
+	result := make([]*HealthEvent, 0)
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].CreatedAt.Before(since) || (limit > 0 && len(result) >= limit) {
+			break
+		}
+		eventCopy := *events[i]
+		result = append(result, &eventCopy)
+	}
+	return result, nil
+}
+
 // GetAllStatistics returns all endpoint statistics from memory
 func (m *MemoryManager) GetAllStatistics() ([]*EndpointStatistics, error) {
 	m.mutex.RLock()
@@ -69,4 +69,36 @@ func (e *EndpointStatistics) IsHealthy() bool {
 	// 1. Has no recent consecutive failures, OR
 	// 2. Has recent consecutive successes
 	return e.FailureCount == 0 || e.SuccessiveSuccesses > 0
-}
\ No newline at end of file
+}
+
+// Health event types
+const (
+	HealthEventProbe      = "probe"      // 一次健康检查探测
+	HealthEventTransition = "transition" // 一次端点状态切换
+)
+
+// HealthEvent represents a health probe result or an endpoint status transition
+// This corresponds to the endpoint_health_events table in statistics.db
+type HealthEvent struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	EndpointID string `gorm:"column:endpoint_id;size:64;not null;index:idx_health_events_endpoint_time,priority:1" json:"endpoint_id"`
+	Type       string `gorm:"column:type;size:20;not null" json:"type"`
+
+	// Probe result
+	Success   bool   `gorm:"column:success" json:"success"`
+	LatencyMs int64  `gorm:"column:latency_ms" json:"latency_ms,omitempty"`
+	Error     string `gorm:"column:error;size:1000" json:"error,omitempty"`
+
+	// Status transition, Reason and CausingRequestIDs come from the BlacklistReason
+	FromStatus        string `gorm:"column:from_status;size:20" json:"from_status,omitempty"`
+	ToStatus          string `gorm:"column:to_status;size:20" json:"to_status,omitempty"`
+	Reason            string `gorm:"column:reason;size:500" json:"reason,omitempty"`
+	CausingRequestIDs string `gorm:"column:causing_request_ids;size:1000" json:"causing_request_ids,omitempty"`
+
+	CreatedAt time.Time `gorm:"column:created_at;not null;index:idx_health_events_endpoint_time,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (HealthEvent) TableName() string {
+	return "endpoint_health_events"
+}
@@ -28,6 +28,8 @@ func NewBuiltinTaggerFactory() *BuiltinTaggerFactory {
 	factory.Register("user-message", NewUserMessageTagger)
 	factory.Register("model", NewModelTagger)
 	factory.Register("thinking", NewThinkingTagger)
+	factory.Register("project", NewProjectTagger)
+	factory.Register("context-length", NewContextLengthTagger)
 
 	return factory
 }
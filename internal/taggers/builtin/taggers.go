@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
+	appconfig "claude-code-companion/internal/config"
 	"claude-code-companion/internal/interfaces"
+	"claude-code-companion/internal/utils"
 )
 
 // wildcardMatch 统一的通配符匹配函数，支持更直观的通配符语义
@@ -495,4 +498,115 @@ func (tt *ThinkingTagger) ShouldTag(request *http.Request) (bool, error) {
 
 	// thinking已启用且满足budget_tokens要求
 	return true, nil
-}
\ No newline at end of file
+}
+
+// ProjectTagger 项目匹配tagger
+// 从 Claude Code 系统提示的 <env> 块中提取工作目录，用通配符匹配项目名或完整工作目录
+type ProjectTagger struct {
+	BaseTagger
+	expectedValue string
+}
+
+// NewProjectTagger 创建项目匹配tagger
+func NewProjectTagger(name, tag string, config map[string]interface{}) (interfaces.Tagger, error) {
+	expectedValue, ok := config["expected_value"].(string)
+	if !ok || expectedValue == "" {
+		return nil, fmt.Errorf("project tagger requires 'expected_value' in config")
+	}
+
+	return &ProjectTagger{
+		BaseTagger:    BaseTagger{name: name, tag: tag},
+		expectedValue: strings.ReplaceAll(expectedValue, "\\", "/"),
+	}, nil
+}
+
+func (pt *ProjectTagger) ShouldTag(request *http.Request) (bool, error) {
+	// 只处理JSON内容类型
+	contentType := request.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		return false, nil
+	}
+
+	// 从请求上下文中获取预处理的请求体数据
+	bodyContent, ok := request.Context().Value("cached_body").([]byte)
+	if !ok || len(bodyContent) == 0 {
+		return false, nil
+	}
+
+	project := utils.ExtractProjectInfo(bodyContent)
+	if project == nil {
+		return false, nil
+	}
+
+	// 先匹配项目名，再匹配仓库地址和完整工作目录（统一使用 / 作为分隔符）
+	matched, err := wildcardMatch(pt.expectedValue, project.Name)
+	if err != nil || matched {
+		return matched, err
+	}
+	if project.Repository != "" {
+		if matched, err := wildcardMatch(pt.expectedValue, project.Repository); err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return wildcardMatch(pt.expectedValue, strings.ReplaceAll(project.WorkingDirectory, "\\", "/"))
+}
+
+// ContextLengthTagger 长上下文匹配tagger
+// 本地估算请求的 prompt token 数，达到阈值时打标（通常配置为 long-context）
+type ContextLengthTagger struct {
+	BaseTagger
+	minTokens int
+}
+
+// NewContextLengthTagger 创建长上下文匹配tagger
+func NewContextLengthTagger(name, tag string, config map[string]interface{}) (interfaces.Tagger, error) {
+	minTokens := appconfig.Default.Tagging.LongContextTokens
+
+	if minInterface, ok := config["min_tokens"]; ok {
+		if minFloat, ok := minInterface.(float64); ok {
+			minTokens = int(minFloat)
+		} else if minInt, ok := minInterface.(int); ok {
+			minTokens = minInt
+		} else if minString, ok := minInterface.(string); ok {
+			// 通过Web界面保存的配置值为字符串
+			parsed, err := strconv.Atoi(strings.TrimSpace(minString))
+			if err != nil {
+				return nil, fmt.Errorf("context-length tagger 'min_tokens' must be a number")
+			}
+			minTokens = parsed
+		} else {
+			return nil, fmt.Errorf("context-length tagger 'min_tokens' must be a number")
+		}
+	}
+
+	if minTokens <= 0 {
+		return nil, fmt.Errorf("context-length tagger 'min_tokens' must be positive")
+	}
+
+	return &ContextLengthTagger{
+		BaseTagger: BaseTagger{name: name, tag: tag},
+		minTokens:  minTokens,
+	}, nil
+}
+
+func (ct *ContextLengthTagger) ShouldTag(request *http.Request) (bool, error) {
+	// 只处理JSON内容类型
+	contentType := request.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		return false, nil
+	}
+
+	// 从请求上下文中获取预处理的请求体数据
+	bodyContent, ok := request.Context().Value("cached_body").([]byte)
+	if !ok || len(bodyContent) == 0 {
+		return false, nil
+	}
+
+	// 优先使用代理已估算好的 token 数，未提供时才自行估算
+	estimatedTokens, ok := request.Context().Value("estimated_tokens").(int)
+	if !ok {
+		estimatedTokens = utils.EstimateRequestTokens(bodyContent)
+	}
+	return estimatedTokens >= ct.minTokens, nil
+}
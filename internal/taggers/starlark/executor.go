@@ -139,6 +139,16 @@ func (e *Executor) createRequestObject(req *http.Request) *starlarkstruct.Struct
 		queryDict.SetKey(starlark.String(key), starlark.String(value))
 	}
 	
+	// 创建tags列表：之前阶段的tagger已经产生的tag
+	var tagValues []starlark.Value
+	if tags, ok := req.Context().Value("request_tags").([]string); ok {
+		for _, tag := range tags {
+			tagValues = append(tagValues, starlark.String(tag))
+		}
+	}
+	tagsList := starlark.NewList(tagValues)
+	tagsList.Freeze()
+	
 	// 创建请求对象
 	requestData := starlark.StringDict{
 		"method":  starlark.String(req.Method),
@@ -148,6 +158,7 @@ func (e *Executor) createRequestObject(req *http.Request) *starlarkstruct.Struct
 		"params":  queryDict,
 		"host":    starlark.String(req.Host),
 		"scheme":  starlark.String(req.URL.Scheme),
+		"tags":    tagsList,
 	}
 	
 	return starlarkstruct.FromStringDict(starlarkstruct.Default, requestData)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"time"
 
 	"claude-code-companion/internal/config"
@@ -17,6 +18,7 @@ type Manager struct {
 	pipeline *TaggerPipeline
 	factory  *builtin.BuiltinTaggerFactory
 	enabled  bool
+	configs  []config.TaggerConfig // 已启用tagger的配置，用于计算执行阶段
 }
 
 // NewManager 创建tagging系统管理器
@@ -56,40 +58,9 @@ func (m *Manager) Initialize(config *config.TaggingConfig) error {
 			continue // 跳过禁用的tagger
 		}
 
-		var tagger Tagger
-		if taggerConfig.Type == "builtin" {
-			tagger, err = m.factory.CreateTagger(
-				taggerConfig.BuiltinType,
-				taggerConfig.Name,
-				taggerConfig.Tag,
-				taggerConfig.Config,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to create builtin tagger '%s': %v", taggerConfig.Name, err)
-			}
-		} else if taggerConfig.Type == "starlark" {
-			// 创建Starlark tagger
-			var script string
-			
-			// 支持两种方式：script_file 或 script
-			if scriptFile, ok := taggerConfig.Config["script_file"].(string); ok && scriptFile != "" {
-				// 从文件读取脚本
-				scriptBytes, readErr := os.ReadFile(scriptFile)
-				if readErr != nil {
-					return fmt.Errorf("starlark tagger '%s': failed to read script file '%s': %v", 
-						taggerConfig.Name, scriptFile, readErr)
-				}
-				script = string(scriptBytes)
-			} else if inlineScript, ok := taggerConfig.Config["script"].(string); ok && inlineScript != "" {
-				// 使用内联脚本
-				script = inlineScript
-			} else {
-				return fmt.Errorf("starlark tagger '%s': missing script or script_file config", taggerConfig.Name)
-			}
-			
-			tagger = starlark.NewTagger(taggerConfig.Name, taggerConfig.Tag, script, timeout)
-		} else {
-			return fmt.Errorf("unknown tagger type: %s", taggerConfig.Type)
+		tagger, err := m.createTagger(taggerConfig, timeout)
+		if err != nil {
+			return err
 		}
 
 		// 注册tagger到registry
@@ -100,12 +71,93 @@ func (m *Manager) Initialize(config *config.TaggingConfig) error {
 		taggers = append(taggers, tagger)
 	}
 
-	// 设置pipeline中的tagger
-	m.pipeline.SetTaggers(taggers)
+	// 按依赖关系计算执行阶段，设置pipeline中的tagger
+	enabledConfigs := enabledTaggerConfigs(config.Taggers)
+	stages, err := groupTaggersByStage(taggers, enabledConfigs)
+	if err != nil {
+		return err
+	}
+	m.pipeline.SetStages(stages)
+	m.configs = enabledConfigs
 
 	return nil
 }
 
+// enabledTaggerConfigs 返回已启用的tagger配置
+func enabledTaggerConfigs(taggerConfigs []config.TaggerConfig) []config.TaggerConfig {
+	var enabled []config.TaggerConfig
+	for _, taggerConfig := range taggerConfigs {
+		if taggerConfig.Enabled {
+			enabled = append(enabled, taggerConfig)
+		}
+	}
+	return enabled
+}
+
+// groupTaggersByStage 根据配置的阶段和依赖关系将tagger分组，按阶段从小到大排列
+func groupTaggersByStage(taggers []Tagger, configs []config.TaggerConfig) ([][]Tagger, error) {
+	stageOf, err := config.ResolveTaggerStages(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[int][]Tagger)
+	var stageNumbers []int
+	for _, tagger := range taggers {
+		stage := stageOf[tagger.Name()]
+		if _, exists := grouped[stage]; !exists {
+			stageNumbers = append(stageNumbers, stage)
+		}
+		grouped[stage] = append(grouped[stage], tagger)
+	}
+	sort.Ints(stageNumbers)
+
+	stages := make([][]Tagger, 0, len(stageNumbers))
+	for _, stage := range stageNumbers {
+		stages = append(stages, grouped[stage])
+	}
+	return stages, nil
+}
+
+// createTagger 根据配置创建tagger实例（不注册）
+func (m *Manager) createTagger(taggerConfig config.TaggerConfig, timeout time.Duration) (Tagger, error) {
+	if taggerConfig.Type == "builtin" {
+		tagger, err := m.factory.CreateTagger(
+			taggerConfig.BuiltinType,
+			taggerConfig.Name,
+			taggerConfig.Tag,
+			taggerConfig.Config,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create builtin tagger '%s': %v", taggerConfig.Name, err)
+		}
+		return tagger, nil
+	} else if taggerConfig.Type == "starlark" {
+		// 创建Starlark tagger
+		var script string
+		
+		// 支持两种方式：script_file 或 script
+		if scriptFile, ok := taggerConfig.Config["script_file"].(string); ok && scriptFile != "" {
+			// 从文件读取脚本
+			scriptBytes, readErr := os.ReadFile(scriptFile)
+			if readErr != nil {
+				return nil, fmt.Errorf("starlark tagger '%s': failed to read script file '%s': %v", 
+					taggerConfig.Name, scriptFile, readErr)
+			}
+			script = string(scriptBytes)
+		} else if inlineScript, ok := taggerConfig.Config["script"].(string); ok && inlineScript != "" {
+			// 使用内联脚本
+			script = inlineScript
+		} else {
+			return nil, fmt.Errorf("starlark tagger '%s': missing script or script_file config", taggerConfig.Name)
+		}
+		
+		return starlark.NewTagger(taggerConfig.Name, taggerConfig.Tag, script, timeout), nil
+	}
+
+	return nil, fmt.Errorf("unknown tagger type: %s", taggerConfig.Type)
+}
+
 // ProcessRequest 处理HTTP请求，进行tag标记
 func (m *Manager) ProcessRequest(req *http.Request) (*TaggedRequest, error) {
 	if !m.enabled {
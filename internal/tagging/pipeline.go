@@ -5,13 +5,20 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
+
+	"claude-code-companion/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TaggerPipeline 负责管理和执行所有tagger
+// tagger按阶段顺序执行，同一阶段内并发执行，后面的阶段可以看到前面阶段产生的tag
 type TaggerPipeline struct {
-	taggers []Tagger
+	stages  [][]Tagger
 	timeout time.Duration
 	mu      sync.RWMutex
 }
@@ -19,34 +26,64 @@ type TaggerPipeline struct {
 // NewTaggerPipeline 创建新的tagger管道
 func NewTaggerPipeline(timeout time.Duration) *TaggerPipeline {
 	return &TaggerPipeline{
-		taggers: make([]Tagger, 0),
+		stages:  make([][]Tagger, 0),
 		timeout: timeout,
 	}
 }
 
-// AddTagger 添加一个tagger到管道中
+// AddTagger 添加一个tagger到管道的第一个阶段中
 func (tp *TaggerPipeline) AddTagger(tagger Tagger) {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 	
-	tp.taggers = append(tp.taggers, tagger)
+	if len(tp.stages) == 0 {
+		tp.stages = append(tp.stages, nil)
+	}
+	tp.stages[0] = append(tp.stages[0], tagger)
 }
 
-// SetTaggers 设置管道中的所有tagger
+// SetTaggers 设置管道中的所有tagger（全部位于同一阶段）
 func (tp *TaggerPipeline) SetTaggers(taggers []Tagger) {
+	tp.SetStages([][]Tagger{taggers})
+}
+
+// SetStages 按阶段设置管道中的tagger，空阶段会被忽略
+func (tp *TaggerPipeline) SetStages(stages [][]Tagger) {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 	
-	tp.taggers = make([]Tagger, len(taggers))
-	copy(tp.taggers, taggers)
+	tp.stages = make([][]Tagger, 0, len(stages))
+	for _, stage := range stages {
+		if len(stage) == 0 {
+			continue
+		}
+		taggers := make([]Tagger, len(stage))
+		copy(taggers, stage)
+		tp.stages = append(tp.stages, taggers)
+	}
 }
 
-// ProcessRequest 处理HTTP请求，并发执行所有tagger进行标记
-func (tp *TaggerPipeline) ProcessRequest(req *http.Request) (*TaggedRequest, error) {
+// GetStages 获取按阶段分组的tagger
+func (tp *TaggerPipeline) GetStages() [][]Tagger {
 	tp.mu.RLock()
-	taggers := make([]Tagger, len(tp.taggers))
-	copy(taggers, tp.taggers)
-	tp.mu.RUnlock()
+	defer tp.mu.RUnlock()
+	
+	stages := make([][]Tagger, len(tp.stages))
+	for i, stage := range tp.stages {
+		stages[i] = make([]Tagger, len(stage))
+		copy(stages[i], stage)
+	}
+	return stages
+}
+
+// ProcessRequest 处理HTTP请求，按阶段执行所有tagger进行标记
+func (tp *TaggerPipeline) ProcessRequest(req *http.Request) (*TaggedRequest, error) {
+	stages := tp.GetStages()
+
+	spanCtx, span := tracing.Start(req.Context(), "TaggerPipeline.ProcessRequest",
+		trace.WithAttributes(attribute.Int("tagging.stages", len(stages))))
+	defer span.End()
+	req = req.WithContext(spanCtx)
 
 	// 预处理请求体 - 读取并缓存，然后重新设置给request
 	var cachedBody []byte
@@ -63,50 +100,106 @@ func (tp *TaggerPipeline) ProcessRequest(req *http.Request) (*TaggedRequest, err
 		}
 	}
 
-	// 创建上下文，设置超时
+	// 创建上下文，设置超时（所有阶段共享）
 	ctx, cancel := context.WithTimeout(context.Background(), tp.timeout)
 	defer cancel()
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	state := &pipelineState{tagSet: make(map[string]bool)}
+	originalRequest := req
+
+	for _, stage := range stages {
+		// 将之前阶段产生的tag设置到context中，供后续阶段的tagger使用
+		stageReq := req.WithContext(context.WithValue(req.Context(), "request_tags", state.snapshotTags()))
+		if !tp.runStage(ctx, stageReq, stage, state) {
+			// 超时，跳过剩余阶段，但不影响已完成的结果
+			span.AddEvent("tagging.timeout")
+			break
+		}
+	}
+
+	tags, results := state.snapshot()
+	span.SetAttributes(attribute.String("tagging.tags", strings.Join(tags, ",")))
+	return &TaggedRequest{
+		OriginalRequest: originalRequest,
+		Tags:           tags,
+		TaggingTime:    time.Now(),
+		TaggerResults:  results,
+	}, nil
+}
+
+// pipelineState 记录一次请求处理过程中累积的tag和执行结果
+type pipelineState struct {
+	mu      sync.Mutex
+	tags    []string
+	tagSet  map[string]bool // 用于快速检查标签重复
+	results []TaggerResult
+}
+
+func (ps *pipelineState) record(t Tagger, matched bool, err error, duration time.Duration) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	// 创建结果记录
+	ps.results = append(ps.results, TaggerResult{
+		TaggerName: t.Name(),
+		Tag:        t.Tag(),
+		Matched:    matched,
+		Error:      err,
+		Duration:   duration,
+	})
+
+	// 如果匹配成功且没有错误，添加tag（去重）
+	if matched && err == nil {
+		tag := t.Tag()
+		if !ps.tagSet[tag] {
+			ps.tagSet[tag] = true
+			ps.tags = append(ps.tags, tag)
+		}
+	}
+}
+
+func (ps *pipelineState) snapshotTags() []string {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	tags := make([]string, len(ps.tags))
+	copy(tags, ps.tags)
+	return tags
+}
+
+func (ps *pipelineState) snapshot() ([]string, []TaggerResult) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	var tags []string
-	var tagSet map[string]bool // 用于快速检查标签重复
+	if ps.tags != nil {
+		tags = make([]string, len(ps.tags))
+		copy(tags, ps.tags)
+	}
 	var results []TaggerResult
+	if ps.results != nil {
+		results = make([]TaggerResult, len(ps.results))
+		copy(results, ps.results)
+	}
+	return tags, results
+}
 
-	tagSet = make(map[string]bool)
+// runStage 并发执行一个阶段的所有tagger，超时返回false
+func (tp *TaggerPipeline) runStage(ctx context.Context, req *http.Request, taggers []Tagger, state *pipelineState) bool {
+	var wg sync.WaitGroup
 
-	// 并发执行所有tagger
 	for _, tagger := range taggers {
 		wg.Add(1)
 		go func(t Tagger) {
 			defer wg.Done()
 			
+			_, span := tracing.Start(req.Context(), "Tagger "+t.Name(),
+				trace.WithAttributes(attribute.String("tagger.name", t.Name()), attribute.String("tagger.tag", t.Tag())))
 			start := time.Now()
 			matched, err := t.ShouldTag(req)
-			duration := time.Since(start)
-			
-			// 创建结果记录
-			result := TaggerResult{
-				TaggerName: t.Name(),
-				Tag:        t.Tag(),
-				Matched:    matched,
-				Error:      err,
-				Duration:   duration,
-			}
-			
-			mu.Lock()
-			results = append(results, result)
-			
-			// 如果匹配成功且没有错误，添加tag（去重）
-			if matched && err == nil {
-				// 使用map快速检查tag是否已存在
-				tag := t.Tag()
-				if !tagSet[tag] {
-					tagSet[tag] = true
-					tags = append(tags, tag)
-				}
-			}
-			mu.Unlock()
+			state.record(t, matched, err, time.Since(start))
+			span.SetAttributes(attribute.Bool("tagger.matched", matched))
+			tracing.End(span, err)
 		}(tagger)
 	}
 
@@ -119,27 +212,22 @@ func (tp *TaggerPipeline) ProcessRequest(req *http.Request) (*TaggedRequest, err
 
 	select {
 	case <-done:
-		// 所有tagger执行完成
+		// 本阶段所有tagger执行完成
+		return true
 	case <-ctx.Done():
-		// 超时，但不影响已完成的结果
-		break
+		return false
 	}
-
-	return &TaggedRequest{
-		OriginalRequest: req,
-		Tags:           tags,
-		TaggingTime:    time.Now(),
-		TaggerResults:  results,
-	}, nil
 }
 
-// GetTaggers 获取当前管道中的所有tagger
+// GetTaggers 获取当前管道中的所有tagger（按阶段顺序）
 func (tp *TaggerPipeline) GetTaggers() []Tagger {
 	tp.mu.RLock()
 	defer tp.mu.RUnlock()
 	
-	taggers := make([]Tagger, len(tp.taggers))
-	copy(taggers, tp.taggers)
+	var taggers []Tagger
+	for _, stage := range tp.stages {
+		taggers = append(taggers, stage...)
+	}
 	return taggers
 }
 
@@ -0,0 +1,89 @@
+package tagging
+
+import (
+	"fmt"
+	"net/http"
+
+	"claude-code-companion/internal/config"
+)
+
+// SimulationResult 记录候选tagger在单个请求上的试运行结果
+type SimulationResult struct {
+	Matched       bool           // 候选tagger是否匹配
+	Error         error          // 候选tagger执行错误（如脚本错误、超时）
+	Tags          []string       // 包含候选tagger在内的完整tag集合
+	TaggerResults []TaggerResult // 所有tagger的执行结果
+}
+
+// Simulator 在不修改tagging系统状态的前提下试运行候选tagger
+type Simulator struct {
+	candidate string
+	pipeline  *TaggerPipeline
+}
+
+// NewSimulator 创建候选tagger的试运行器
+// 当前已启用的tagger同样参与执行，以得到完整的tag集合；与候选tagger同名的已有tagger会被候选替换
+// 候选tagger的依赖在已启用tagger中不存在时会被忽略
+func (m *Manager) NewSimulator(candidate config.TaggerConfig) (*Simulator, error) {
+	timeout := m.pipeline.GetTimeout()
+
+	tagger, err := m.createTagger(candidate, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var taggers []Tagger
+	for _, existing := range m.pipeline.GetTaggers() {
+		if existing.Name() == candidate.Name {
+			continue
+		}
+		taggers = append(taggers, existing)
+	}
+	taggers = append(taggers, tagger)
+
+	var configs []config.TaggerConfig
+	for _, existing := range m.configs {
+		if existing.Name != candidate.Name {
+			configs = append(configs, existing)
+		}
+	}
+	configs = append(configs, candidate)
+
+	// 候选tagger同样按阶段和依赖关系执行
+	stages, err := groupTaggersByStage(taggers, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := NewTaggerPipeline(timeout)
+	pipeline.SetStages(stages)
+
+	return &Simulator{
+		candidate: candidate.Name,
+		pipeline:  pipeline,
+	}, nil
+}
+
+// Run 对单个请求执行试运行
+func (s *Simulator) Run(req *http.Request) (*SimulationResult, error) {
+	taggedRequest, err := s.pipeline.ProcessRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{
+		Tags:          taggedRequest.Tags,
+		TaggerResults: taggedRequest.TaggerResults,
+		Error:         fmt.Errorf("tagger '%s' did not finish within pipeline timeout", s.candidate),
+	}
+
+	for _, taggerResult := range taggedRequest.TaggerResults {
+		if taggerResult.TaggerName == s.candidate {
+			result.Matched = taggerResult.Matched && taggerResult.Error == nil
+			result.Error = taggerResult.Error
+			break
+		}
+	}
+
+	return result, nil
+}
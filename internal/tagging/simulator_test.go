@@ -0,0 +1,146 @@
+package tagging
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"claude-code-companion/internal/config"
+)
+
+func newSimulationRequest(t *testing.T, body string) *http.Request {
+	req, err := http.NewRequest("POST", "http://localhost/v1/messages", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestSimulatorCombinesExistingTaggers(t *testing.T) {
+	manager := NewManager()
+	err := manager.Initialize(&config.TaggingConfig{
+		PipelineTimeout: "5s",
+		Taggers: []config.TaggerConfig{
+			{
+				Name:        "thinking",
+				Type:        "builtin",
+				BuiltinType: "thinking",
+				Tag:         "thinking",
+				Enabled:     true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize manager: %v", err)
+	}
+
+	simulator, err := manager.NewSimulator(config.TaggerConfig{
+		Name:        "haiku",
+		Type:        "builtin",
+		BuiltinType: "model",
+		Tag:         "haiku",
+		Config:      map[string]interface{}{"expected_value": "claude-3-5-haiku*"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+
+	result, err := simulator.Run(newSimulationRequest(t, `{"model":"claude-3-5-haiku-20241022","thinking":{"enabled":true}}`))
+	if err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+	if !result.Matched || result.Error != nil {
+		t.Fatalf("Expected candidate to match without error, got matched=%v err=%v", result.Matched, result.Error)
+	}
+	if len(result.Tags) != 2 {
+		t.Errorf("Expected 2 tags, got %v", result.Tags)
+	}
+
+	result, err = simulator.Run(newSimulationRequest(t, `{"model":"claude-sonnet-4-20250514"}`))
+	if err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+	if result.Matched {
+		t.Errorf("Expected candidate not to match sonnet request")
+	}
+
+	// 试运行不应修改已注册的tagger
+	if _, exists := manager.GetRegistry().GetTagger("haiku"); exists {
+		t.Errorf("Simulation should not register the candidate tagger")
+	}
+}
+
+func TestSimulatorReportsScriptErrors(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Initialize(&config.TaggingConfig{PipelineTimeout: "5s"}); err != nil {
+		t.Fatalf("Failed to initialize manager: %v", err)
+	}
+
+	simulator, err := manager.NewSimulator(config.TaggerConfig{
+		Name:   "broken",
+		Type:   "starlark",
+		Tag:    "broken",
+		Config: map[string]interface{}{"script": "def should_tag():\n    return undefined_name\n"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+
+	result, err := simulator.Run(newSimulationRequest(t, `{}`))
+	if err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+	if result.Matched {
+		t.Errorf("Broken script should not match")
+	}
+	if result.Error == nil {
+		t.Errorf("Expected script error to be reported")
+	}
+}
+
+func TestPipelineStagesSeeEarlierTags(t *testing.T) {
+	manager := NewManager()
+	err := manager.Initialize(&config.TaggingConfig{
+		PipelineTimeout: "5s",
+		Taggers: []config.TaggerConfig{
+			{
+				Name:      "cheap",
+				Type:      "starlark",
+				Tag:       "cheap",
+				Enabled:   true,
+				DependsOn: []string{"haiku"},
+				Config:    map[string]interface{}{"script": "def should_tag():\n    return \"haiku\" in request.tags\n"},
+			},
+			{
+				Name:        "haiku",
+				Type:        "builtin",
+				BuiltinType: "model",
+				Tag:         "haiku",
+				Enabled:     true,
+				Config:      map[string]interface{}{"expected_value": "claude-3-5-haiku*"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize manager: %v", err)
+	}
+
+	result, err := manager.ProcessRequest(newSimulationRequest(t, `{"model":"claude-3-5-haiku-20241022"}`))
+	if err != nil {
+		t.Fatalf("Failed to process request: %v", err)
+	}
+	if len(result.Tags) != 2 || result.Tags[0] != "haiku" || result.Tags[1] != "cheap" {
+		t.Errorf("Expected tags [haiku cheap], got %v", result.Tags)
+	}
+}
+
+func TestResolveTaggerStagesRejectsCycles(t *testing.T) {
+	_, err := config.ResolveTaggerStages([]config.TaggerConfig{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	})
+	if err == nil {
+		t.Errorf("Expected dependency cycle to be rejected")
+	}
+}
@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// propagator W3C trace context，traceparent 和 tracestate 一起传播
+var propagator = propagation.TraceContext{}
+
+// Inject 将 ctx 中的 span 标识写入 traceparent/tracestate 请求头，未启用追踪或没有 span 时不修改请求头
+func Inject(ctx context.Context, header http.Header) {
+	if !Enabled() {
+		return
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract 解析请求头中的 traceparent/tracestate，有效时作为后续 span 的父节点
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"claude-code-companion/internal/config"
+)
+
+// NewProviderFromConfig 根据配置创建以 OTLP/HTTP 导出的 TracerProvider，未启用时返回 nil
+// 导出失败通过 OpenTelemetry 的全局错误处理器报告给 onError
+func NewProviderFromConfig(cfg config.TracingConfig, onError func(error)) (*sdktrace.TracerProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	parsed, err := url.Parse(cfg.Endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint '%s'", cfg.Endpoint)
+	}
+
+	// 导出请求使用 exporter 自己的客户端，不会被追踪或受上游超时配置影响
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+		otlptracehttp.WithTimeout(config.GetTimeoutDuration(cfg.ExportTimeout, 10*time.Second)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+	if onError != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(onError))
+	}
+	return newProvider(cfg, exporter), nil
+}
+
+// newProvider 按配置的采样比例和批量参数创建 TracerProvider
+// 客户端请求带有 traceparent 时沿用其采样决定，否则按 sample_ratio 采样
+func newProvider(cfg config.TracingConfig, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = config.Default.Tracing.BatchSize
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = config.Default.Tracing.ServiceName
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxExportBatchSize(batchSize),
+			sdktrace.WithMaxQueueSize(batchSize*4),
+			sdktrace.WithBatchTimeout(config.GetTimeoutDuration(cfg.ExportInterval, 5*time.Second)),
+			sdktrace.WithExportTimeout(config.GetTimeoutDuration(cfg.ExportTimeout, 10*time.Second))),
+	)
+}
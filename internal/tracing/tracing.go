@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationScope 本服务创建的 span 所属的 instrumentation scope
+const instrumentationScope = "claude-code-companion/internal/tracing"
+
+// global 当前生效的 TracerProvider，未启用追踪时为 nil
+var global atomic.Pointer[sdktrace.TracerProvider]
+
+// SetProvider 替换全局 TracerProvider 并返回旧的 Provider，传入 nil 表示关闭追踪
+// 同时设置 OpenTelemetry 的全局 TracerProvider，其他使用 otel 的组件共享同一个导出管道
+func SetProvider(p *sdktrace.TracerProvider) *sdktrace.TracerProvider {
+	if p != nil {
+		otel.SetTracerProvider(p)
+	} else {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}
+	return global.Swap(p)
+}
+
+// Enabled 是否启用了追踪
+func Enabled() bool {
+	return global.Load() != nil
+}
+
+// Start 以 ctx 中的 span 为父节点创建新 span；未启用追踪时返回不记录的 span
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationScope).Start(ctx, name, opts...)
+}
+
+// End 结束 span，err 不为 nil 时记录 exception 事件并将状态设为错误；span 为 nil 时不做任何事
+func End(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Shutdown 关闭全局 Provider 并导出剩余的 span
+func Shutdown(ctx context.Context) error {
+	if p := SetProvider(nil); p != nil {
+		return p.Shutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"claude-code-companion/internal/config"
+)
+
+// useInMemoryProvider 安装导出到内存的全局 Provider，测试结束后关闭
+func useInMemoryProvider(t *testing.T, sampleRatio float64) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newProvider(config.TracingConfig{SampleRatio: sampleRatio, ExportInterval: "1h"}, exporter)
+	previous := SetProvider(provider)
+	t.Cleanup(func() {
+		SetProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// flushSpans 导出队列中的 span 并返回已导出的全部 span
+func flushSpans(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStubs {
+	if err := global.Load().ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+	return exporter.GetSpans()
+}
+
+func TestStartWithoutProviderIsNoop(t *testing.T) {
+	previous := SetProvider(nil)
+	defer SetProvider(previous)
+
+	ctx, span := Start(context.Background(), "noop")
+	if span.IsRecording() {
+		t.Fatal("Expected a non-recording span when tracing is disabled")
+	}
+	End(span, errors.New("boom"))
+	End(nil, nil)
+
+	header := http.Header{}
+	Inject(ctx, header)
+	if header.Get("traceparent") != "" {
+		t.Error("Expected no traceparent header when tracing is disabled")
+	}
+}
+
+func TestSpansFormTreeAndPropagate(t *testing.T) {
+	exporter := useInMemoryProvider(t, 1)
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	incoming.Set("tracestate", "vendor=opaque")
+	ctx := Extract(context.Background(), incoming)
+
+	rootCtx, root := Start(ctx, "handleProxy", trace.WithSpanKind(trace.SpanKindServer))
+	childCtx, child := Start(rootCtx, "proxyToEndpoint", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("proxy.attempt", 1)))
+
+	outgoing := http.Header{}
+	Inject(childCtx, outgoing)
+	End(child, errors.New("upstream failed"))
+	End(root, nil)
+
+	expectedTraceparent := "00-0af7651916cd43dd8448eb211c80319c-" + child.SpanContext().SpanID().String() + "-01"
+	if outgoing.Get("traceparent") != expectedTraceparent {
+		t.Errorf("Expected traceparent %s, got %s", expectedTraceparent, outgoing.Get("traceparent"))
+	}
+	if outgoing.Get("tracestate") != "vendor=opaque" {
+		t.Errorf("Expected tracestate to be propagated, got %q", outgoing.Get("tracestate"))
+	}
+
+	spans := flushSpans(t, exporter)
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	childData, rootData := spans[0], spans[1]
+	if rootData.SpanContext.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" || rootData.Parent.SpanID().String() != "b7ad6b7169203331" {
+		t.Errorf("Expected root span to continue the incoming trace, got trace %s parent %s",
+			rootData.SpanContext.TraceID(), rootData.Parent.SpanID())
+	}
+	if childData.Parent.SpanID() != rootData.SpanContext.SpanID() {
+		t.Error("Expected child span to be parented to the root span")
+	}
+	if childData.SpanKind != trace.SpanKindClient || len(childData.Attributes) != 1 || childData.Attributes[0] != attribute.Int("proxy.attempt", 1) {
+		t.Errorf("Unexpected child span data: kind %v attributes %v", childData.SpanKind, childData.Attributes)
+	}
+	if childData.Status.Code != codes.Error || len(childData.Events) != 1 || childData.Events[0].Name != "exception" {
+		t.Errorf("Expected recorded error on child span, got status %v events %v", childData.Status, childData.Events)
+	}
+	if got := rootData.Resource.Attributes(); !containsAttribute(got, attribute.String("service.name", config.Default.Tracing.ServiceName)) {
+		t.Errorf("Expected default service name in resource, got %v", got)
+	}
+}
+
+func TestUnsampledSpansPropagateButAreNotExported(t *testing.T) {
+	exporter := useInMemoryProvider(t, 1)
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	ctx, span := Start(Extract(context.Background(), incoming), "handleProxy")
+	if span.IsRecording() {
+		t.Error("Expected span to follow the unsampled parent")
+	}
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	span.End()
+
+	if !strings.HasSuffix(outgoing.Get("traceparent"), "-00") {
+		t.Errorf("Expected unsampled flag to be propagated, got %s", outgoing.Get("traceparent"))
+	}
+	if spans := flushSpans(t, exporter); len(spans) != 0 {
+		t.Errorf("Expected unsampled spans to be dropped, got %d", len(spans))
+	}
+}
+
+func TestNewProviderFromConfigExportsOTLP(t *testing.T) {
+	type export struct {
+		path, contentType, auth string
+		size                    int
+	}
+	received := make(chan export, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- export{r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization"), len(body)}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider, err := NewProviderFromConfig(config.TracingConfig{
+		Enabled:        true,
+		Endpoint:       server.URL + "/v1/traces",
+		Headers:        map[string]string{"Authorization": "Bearer collector"},
+		ServiceName:    "test-service",
+		SampleRatio:    1,
+		ExportInterval: "1h",
+		ExportTimeout:  "5s",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer(instrumentationScope).Start(context.Background(), "ConvertRequest")
+	span.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.path != "/v1/traces" || got.contentType != "application/x-protobuf" || got.auth != "Bearer collector" || got.size == 0 {
+			t.Errorf("Unexpected OTLP export request: %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected spans to be exported to the collector")
+	}
+
+	if _, err := NewProviderFromConfig(config.TracingConfig{Enabled: true, Endpoint: "localhost:4318"}, nil); err == nil {
+		t.Error("Expected an endpoint without scheme to be rejected")
+	}
+	if provider, err := NewProviderFromConfig(config.TracingConfig{}, nil); provider != nil || err != nil {
+		t.Error("Expected no provider when tracing is disabled")
+	}
+}
+
+func containsAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"sort"
+	"time"
 )
 
 // EndpointSorter interface for sorting endpoints
@@ -135,6 +136,11 @@ func SelectBestEndpoint(endpoints []EndpointSorter) EndpointSorter {
 
 // SelectBestEndpointWithTags selects the first available endpoint matching the tags
 func SelectBestEndpointWithTags(endpoints []EndpointSorter, requiredTags []string) EndpointSorter {
+	return SelectBestEndpointWithPolicy(endpoints, requiredTags, "", DegradationPolicy{})
+}
+
+// SelectBestEndpointWithPolicy 与 SelectBestEndpointWithTags 相同，但同一标签层级内处理 model 明显慢于同组端点的端点排在后面
+func SelectBestEndpointWithPolicy(endpoints []EndpointSorter, requiredTags []string, model string, policy DegradationPolicy) EndpointSorter {
 	// 首先过滤出启用的端点
 	enabled := FilterEnabledEndpoints(endpoints)
 	if len(enabled) == 0 {
@@ -147,8 +153,19 @@ func SelectBestEndpointWithTags(endpoints []EndpointSorter, requiredTags []strin
 		return nil
 	}
 	
-	// 按标签匹配和优先级排序
-	SortEndpointsByTagsAndPriority(filtered, requiredTags)
+	// 按 (标签层级, 是否降级, 优先级) 排序：降级只影响同一层级内的顺序
+	degraded := DegradedEndpoints(filtered, model, policy)
+	sort.SliceStable(filtered, func(i, j int) bool {
+		tierI := getEndpointTier(filtered[i].GetTags(), requiredTags)
+		tierJ := getEndpointTier(filtered[j].GetTags(), requiredTags)
+		if tierI != tierJ {
+			return tierI < tierJ
+		}
+		if degraded[filtered[i]] != degraded[filtered[j]] {
+			return !degraded[filtered[i]]
+		}
+		return filtered[i].GetPriority() < filtered[j].GetPriority()
+	})
 	
 	// 选择第一个可用的端点
 	for _, ep := range filtered {
@@ -158,4 +175,93 @@ func SelectBestEndpointWithTags(endpoints []EndpointSorter, requiredTags []strin
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// PerformanceReporter 可选接口：提供端点近期处理指定模型的真实请求的首字节时间和输出吞吐量（EWMA）
+// model 为空时返回端点所有请求的总体统计
+type PerformanceReporter interface {
+	RecentPerformance(model string) (ttfb time.Duration, tokensPerSecond float64, samples int)
+}
+
+// DegradationPolicy 判断端点是否明显慢于同组端点的阈值
+type DegradationPolicy struct {
+	Enabled    bool
+	MinSamples int           // 样本数少于该值的端点不参与比较
+	Factor     float64       // 首字节时间超过同组中位数的倍数，或吞吐量低于中位数除以该倍数时视为降级
+	MinTTFBGap time.Duration // 首字节时间至少比中位数慢这么多才视为降级，避免毫秒级差异触发
+}
+
+// DegradedEndpoints 返回近期处理 model 的表现明显差于其他可用端点的端点（降级但未失败）
+// 只比较同一模型的统计，不同模型的延迟差异不代表端点快慢；至少需要两个有足够样本的可用端点才会比较
+func DegradedEndpoints(endpoints []EndpointSorter, model string, policy DegradationPolicy) map[EndpointSorter]bool {
+	if !policy.Enabled || policy.Factor <= 1 {
+		return nil
+	}
+
+	type sample struct {
+		ep         EndpointSorter
+		ttfb       time.Duration
+		throughput float64
+	}
+	var samples []sample
+	for _, ep := range endpoints {
+		reporter, ok := ep.(PerformanceReporter)
+		if !ok || !ep.IsAvailable() {
+			continue
+		}
+		ttfb, throughput, count := reporter.RecentPerformance(model)
+		if count < policy.MinSamples || count == 0 {
+			continue
+		}
+		samples = append(samples, sample{ep: ep, ttfb: ttfb, throughput: throughput})
+	}
+	if len(samples) < 2 {
+		return nil
+	}
+
+	ttfbs := make([]float64, 0, len(samples))
+	var throughputs []float64
+	for _, s := range samples {
+		ttfbs = append(ttfbs, float64(s.ttfb))
+		if s.throughput > 0 {
+			throughputs = append(throughputs, s.throughput)
+		}
+	}
+	medianTTFB := time.Duration(median(ttfbs))
+	medianThroughput := median(throughputs)
+
+	degraded := make(map[EndpointSorter]bool)
+	for _, s := range samples {
+		slow := float64(s.ttfb) > float64(medianTTFB)*policy.Factor && s.ttfb-medianTTFB >= policy.MinTTFBGap
+		sluggish := len(throughputs) >= 2 && s.throughput > 0 && s.throughput < medianThroughput/policy.Factor
+		if slow || sluggish {
+			degraded[s.ep] = true
+		}
+	}
+	return degraded
+}
+
+// DemoteDegradedEndpoints 将处理 model 时降级的端点移到列表末尾，其余端点保持原有顺序
+func DemoteDegradedEndpoints(endpoints []EndpointSorter, model string, policy DegradationPolicy) {
+	degraded := DegradedEndpoints(endpoints, model, policy)
+	if len(degraded) == 0 {
+		return
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return !degraded[endpoints[i]] && degraded[endpoints[j]]
+	})
+}
+
+// median 返回中位数，没有值时为 0
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"sort"
+)
+
+// EWMA 指数加权移动平均，第一个样本直接作为初始值；调用方负责并发保护
+type EWMA struct {
+	alpha       float64
+	value       float64
+	initialized bool
+}
+
+// NewEWMA 创建指数加权移动平均，alpha 越大越偏向最近的样本
+func NewEWMA(alpha float64) *EWMA {
+	return &EWMA{alpha: alpha}
+}
+
+// Add 加入一个样本
+func (e *EWMA) Add(value float64) {
+	if !e.initialized {
+		e.value = value
+		e.initialized = true
+		return
+	}
+	e.value = e.alpha*value + (1-e.alpha)*e.value
+}
+
+// Value 返回当前平均值，没有样本时为 0
+func (e *EWMA) Value() float64 {
+	return e.value
+}
+
+// SampleWindow 保存最近 size 个样本，用于计算分位数；调用方负责并发保护
+type SampleWindow struct {
+	samples []float64
+	next    int
+	full    bool
+}
+
+// NewSampleWindow 创建保存最近 size 个样本的窗口
+func NewSampleWindow(size int) *SampleWindow {
+	return &SampleWindow{samples: make([]float64, size)}
+}
+
+// Add 加入一个样本，窗口满后覆盖最旧的样本
+func (w *SampleWindow) Add(value float64) {
+	w.samples[w.next] = value
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// Count 返回窗口中的样本数
+func (w *SampleWindow) Count() int {
+	if w.full {
+		return len(w.samples)
+	}
+	return w.next
+}
+
+// Quantile 返回窗口中样本的 q 分位数（0-1，最近秩法），没有样本时为 0
+func (w *SampleWindow) Quantile(q float64) float64 {
+	count := w.Count()
+	if count == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), w.samples[:count]...)
+	sort.Float64s(sorted)
+	index := int(q*float64(count)+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= count {
+		index = count - 1
+	}
+	return sorted[index]
+}
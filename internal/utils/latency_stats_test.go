@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestEWMA(t *testing.T) {
+	e := NewEWMA(0.5)
+	if e.Value() != 0 {
+		t.Errorf("Expected 0 before any sample, got %v", e.Value())
+	}
+	e.Add(100)
+	e.Add(200)
+	if e.Value() != 150 {
+		t.Errorf("Expected 150, got %v", e.Value())
+	}
+}
+
+func TestSampleWindowQuantile(t *testing.T) {
+	w := NewSampleWindow(10)
+	for i := 1; i <= 15; i++ {
+		w.Add(float64(i))
+	}
+	// 只保留最近 10 个样本：6..15
+	if w.Count() != 10 {
+		t.Fatalf("Expected 10 samples, got %d", w.Count())
+	}
+	if got := w.Quantile(0.5); got != 10 {
+		t.Errorf("Expected p50 10, got %v", got)
+	}
+	if got := w.Quantile(0.95); got != 15 {
+		t.Errorf("Expected p95 15, got %v", got)
+	}
+	if got := w.Quantile(0); got != 6 {
+		t.Errorf("Expected p0 6, got %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// workingDirectoryPattern 匹配 Claude Code 系统提示 <env> 块中的工作目录行
+var workingDirectoryPattern = regexp.MustCompile(`(?m)^\s*Working directory:\s*(.+?)\s*$`)
+
+// repositoryPatterns 匹配系统提示或 git 状态中的仓库信息：仓库/远程地址行，以及 git remote -v 的输出
+var repositoryPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?mi)^\s*(?:git\s+)?(?:repository|repo|remote(?:\s+url)?)\s*:\s*(\S+)\s*$`),
+	regexp.MustCompile(`(?m)^\s*origin\s+(\S+)\s+\(fetch\)\s*$`),
+}
+
+// ProjectInfo contains the project information detected from a Claude Code request
+type ProjectInfo struct {
+	WorkingDirectory string `json:"working_directory"`
+	Repository       string `json:"repository,omitempty"` // 仓库名或远程地址，提示中没有仓库信息时为空
+	Name             string `json:"name"`                 // 有仓库信息时为仓库名，否则为工作目录的最后一级
+}
+
+// ExtractProjectInfo extracts the working directory and git repository from the system prompt or <env> block
+// 优先查找 system 字段，找不到时再查找 messages 中的文本内容
+func ExtractProjectInfo(body []byte) *ProjectInfo {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var parsed struct {
+		System   interface{} `json:"system"`
+		Messages []struct {
+			Content interface{} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil
+	}
+
+	if info := extractProjectFromText(collectTextContent(parsed.System)); info != nil {
+		return info
+	}
+
+	for _, message := range parsed.Messages {
+		if info := extractProjectFromText(collectTextContent(message.Content)); info != nil {
+			return info
+		}
+	}
+
+	return nil
+}
+
+// ExtractProjectFromRequestBody extracts the project name from request body JSON
+func ExtractProjectFromRequestBody(body string) string {
+	info := ExtractProjectInfo([]byte(body))
+	if info == nil {
+		return ""
+	}
+	return info.Name
+}
+
+// ProjectNameFromPath returns the last path element, supporting both / and \ separators
+func ProjectNameFromPath(path string) string {
+	normalized := strings.TrimRight(strings.ReplaceAll(path, "\\", "/"), "/")
+	if normalized == "" {
+		return ""
+	}
+	if index := strings.LastIndex(normalized, "/"); index != -1 {
+		return normalized[index+1:]
+	}
+	return normalized
+}
+
+// RepositoryNameFromRemote returns the repository name from a remote URL or owner/name,
+// e.g. git@github.com:acme/api.git and https://github.com/acme/api both return "api"
+func RepositoryNameFromRemote(remote string) string {
+	normalized := strings.TrimSuffix(strings.TrimRight(remote, "/"), ".git")
+	if index := strings.LastIndexAny(normalized, "/:"); index != -1 {
+		normalized = normalized[index+1:]
+	}
+	return normalized
+}
+
+// collectTextContent 拼接字符串或 content block 数组中的所有文本
+func collectTextContent(content interface{}) string {
+	switch value := content.(type) {
+	case string:
+		return value
+	case []interface{}:
+		var builder strings.Builder
+		for _, item := range value {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := block["text"].(string); ok {
+				builder.WriteString(text)
+				builder.WriteString("\n")
+			}
+		}
+		return builder.String()
+	}
+	return ""
+}
+
+// extractProjectFromText 从文本中提取工作目录和仓库，项目名优先使用仓库名
+// 工作目录可能是仓库的子目录，这时目录名不能代表项目
+func extractProjectFromText(text string) *ProjectInfo {
+	if text == "" {
+		return nil
+	}
+
+	info := &ProjectInfo{}
+	if match := workingDirectoryPattern.FindStringSubmatch(text); match != nil {
+		info.WorkingDirectory = match[1]
+		info.Name = ProjectNameFromPath(info.WorkingDirectory)
+	}
+	for _, pattern := range repositoryPatterns {
+		if match := pattern.FindStringSubmatch(text); match != nil {
+			if name := RepositoryNameFromRemote(match[1]); name != "" {
+				info.Repository = match[1]
+				info.Name = name
+				break
+			}
+		}
+	}
+
+	if info.Name == "" {
+		return nil
+	}
+	return info
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractProjectInfoFromSystemBlocks(t *testing.T) {
+	body := `{"system":[{"type":"text","text":"You are Claude Code."},{"type":"text","text":"<env>\nWorking directory: /home/me/work/acme-api\nIs directory a git repo: Yes\n</env>"}],"messages":[]}`
+
+	info := ExtractProjectInfo([]byte(body))
+	if info == nil {
+		t.Fatal("Expected project info to be extracted")
+	}
+	if info.WorkingDirectory != "/home/me/work/acme-api" {
+		t.Errorf("Unexpected working directory: %q", info.WorkingDirectory)
+	}
+	if info.Name != "acme-api" {
+		t.Errorf("Unexpected project name: %q", info.Name)
+	}
+}
+
+func TestExtractProjectInfoFromMessages(t *testing.T) {
+	body := `{"messages":[{"role":"user","content":[{"type":"text","text":"<env>\nWorking directory: C:\\Users\\me\\repo\\\n</env>"}]}]}`
+
+	if name := ExtractProjectFromRequestBody(body); name != "repo" {
+		t.Errorf("Expected project name 'repo', got %q", name)
+	}
+}
+
+func TestExtractProjectInfoWithoutWorkingDirectory(t *testing.T) {
+	if info := ExtractProjectInfo([]byte(`{"system":"hello","messages":[]}`)); info != nil {
+		t.Errorf("Expected no project info, got %+v", info)
+	}
+	if info := ExtractProjectInfo([]byte(`not json`)); info != nil {
+		t.Errorf("Expected no project info for invalid JSON, got %+v", info)
+	}
+}
+
+func TestExtractProjectInfoPrefersRepositoryName(t *testing.T) {
+	body := `{"system":"<env>\nWorking directory: /home/me/work/acme-api/services/billing\nIs directory a git repo: Yes\n</env>\ngitStatus: Current branch: main\nRemote: git@github.com:acme/acme-api.git\n"}`
+
+	info := ExtractProjectInfo([]byte(body))
+	if info == nil || info.Name != "acme-api" || info.Repository != "git@github.com:acme/acme-api.git" {
+		t.Fatalf("Expected repository name to be used, got %+v", info)
+	}
+	if info.WorkingDirectory != "/home/me/work/acme-api/services/billing" {
+		t.Errorf("Unexpected working directory: %q", info.WorkingDirectory)
+	}
+
+	remotes := map[string]string{
+		"origin\thttps://github.com/acme/web/ (fetch)": "web",
+		"Repository: acme/tools":                       "tools",
+	}
+	for line, want := range remotes {
+		if name := ExtractProjectFromRequestBody(`{"system":"` + strings.ReplaceAll(line, "\t", "\\t") + `"}`); name != want {
+			t.Errorf("Expected %q from %q, got %q", want, line, name)
+		}
+	}
+}
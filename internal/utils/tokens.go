@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// imageTokenEstimate 单张图片按固定 token 数估算（与 Anthropic 文档中约 1.15 megapixel 图片的开销相当）
+const imageTokenEstimate = 1600
+
+// EstimateRequestTokens 本地近似估算 Anthropic 请求的 prompt token 数
+// 统计 system、messages 和 tools 中的文本：ASCII 字符约 4 个对应 1 个 token，非 ASCII 字符（如中日韩文字）每个按 1 个 token 计算
+// 结果只用于路由决策，与上游 count_tokens 的结果存在一定偏差
+func EstimateRequestTokens(body []byte) int {
+	if len(body) == 0 {
+		return 0
+	}
+
+	var parsed struct {
+		System   interface{}   `json:"system"`
+		Messages []interface{} `json:"messages"`
+		Tools    []interface{} `json:"tools"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return 0
+	}
+
+	counter := &tokenCounter{}
+	counter.walk(parsed.System)
+	for _, message := range parsed.Messages {
+		counter.walk(message)
+	}
+	for _, tool := range parsed.Tools {
+		counter.walk(tool)
+	}
+
+	return counter.total()
+}
+
+// TextTokenEstimator 按与 EstimateRequestTokens 相同的规则增量估算文本的 token 数，用于流式响应
+type TextTokenEstimator struct {
+	counter tokenCounter
+}
+
+// Add 累加一段文本
+func (e *TextTokenEstimator) Add(text string) {
+	e.counter.addText(text)
+}
+
+// Tokens 返回目前累加文本的估算 token 数
+func (e *TextTokenEstimator) Tokens() int {
+	return e.counter.total()
+}
+
+// tokenCounter 累计文本中的字符数
+type tokenCounter struct {
+	asciiChars    int
+	nonASCIIChars int
+	images        int
+}
+
+func (tc *tokenCounter) walk(value interface{}) {
+	switch v := value.(type) {
+	case string:
+		tc.addText(v)
+	case []interface{}:
+		for _, item := range v {
+			tc.walk(item)
+		}
+	case map[string]interface{}:
+		// 图片和文档的 base64 数据不按文本计算
+		if blockType, _ := v["type"].(string); blockType == "image" || blockType == "document" {
+			tc.images++
+			return
+		}
+		for key, item := range v {
+			tc.addText(key)
+			tc.walk(item)
+		}
+	}
+}
+
+func (tc *tokenCounter) addText(text string) {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		if r < utf8.RuneSelf {
+			tc.asciiChars++
+		} else {
+			tc.nonASCIIChars++
+		}
+		text = text[size:]
+	}
+}
+
+func (tc *tokenCounter) total() int {
+	return (tc.asciiChars+3)/4 + tc.nonASCIIChars + tc.images*imageTokenEstimate
+}
+
+// TokenUsage Anthropic 响应中的 token 用量
+type TokenUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// ExtractTokenUsage 从 Anthropic 格式的响应体中提取 token 用量
+// 同时支持普通 JSON 响应和 SSE 流式响应（message_start 和 message_delta 事件），后出现的非零值覆盖先前的值
+func ExtractTokenUsage(body []byte) TokenUsage {
+	var usage TokenUsage
+	if len(body) == 0 {
+		return usage
+	}
+
+	var response struct {
+		Usage *TokenUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil {
+		if response.Usage != nil {
+			usage = *response.Usage
+		}
+		return usage
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		data, found := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		if !found {
+			continue
+		}
+
+		var event struct {
+			Type    string      `json:"type"`
+			Usage   *TokenUsage `json:"usage"`
+			Message struct {
+				Usage *TokenUsage `json:"usage"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.merge(event.Message.Usage)
+		case "message_delta":
+			usage.merge(event.Usage)
+		}
+	}
+	return usage
+}
+
+func (u *TokenUsage) merge(other *TokenUsage) {
+	if other == nil {
+		return
+	}
+	if other.InputTokens > 0 {
+		u.InputTokens = other.InputTokens
+	}
+	if other.OutputTokens > 0 {
+		u.OutputTokens = other.OutputTokens
+	}
+	if other.CacheCreationInputTokens > 0 {
+		u.CacheCreationInputTokens = other.CacheCreationInputTokens
+	}
+	if other.CacheReadInputTokens > 0 {
+		u.CacheReadInputTokens = other.CacheReadInputTokens
+	}
+}
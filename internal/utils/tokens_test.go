@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateRequestTokens(t *testing.T) {
+	text := strings.Repeat("a", 4000)
+	body := `{"model":"claude-sonnet-4","system":"` + text + `","messages":[{"role":"user","content":[{"type":"text","text":"` + text + `"}]}]}`
+
+	tokens := EstimateRequestTokens([]byte(body))
+	// 两段各约1000 tokens，加上字段名等少量开销
+	if tokens < 2000 || tokens > 2100 {
+		t.Errorf("Expected about 2000 tokens, got %d", tokens)
+	}
+}
+
+func TestEstimateRequestTokensCountsImagesAndNonASCII(t *testing.T) {
+	body := `{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","data":"` + strings.Repeat("A", 100000) + `"}},{"type":"text","text":"你好世界"}]}]}`
+
+	tokens := EstimateRequestTokens([]byte(body))
+	if tokens < imageTokenEstimate+4 || tokens > imageTokenEstimate+50 {
+		t.Errorf("Expected image data to be counted as a fixed estimate, got %d tokens", tokens)
+	}
+}
+
+func TestEstimateRequestTokensInvalidBody(t *testing.T) {
+	if tokens := EstimateRequestTokens([]byte("not json")); tokens != 0 {
+		t.Errorf("Expected 0 tokens for invalid body, got %d", tokens)
+	}
+}
+
+func TestExtractTokenUsage(t *testing.T) {
+	jsonBody := `{"id":"msg_1","type":"message","usage":{"input_tokens":12,"output_tokens":34,"cache_read_input_tokens":5}}`
+	usage := ExtractTokenUsage([]byte(jsonBody))
+	if usage.InputTokens != 12 || usage.OutputTokens != 34 || usage.CacheReadInputTokens != 5 {
+		t.Errorf("Unexpected usage from JSON body: %+v", usage)
+	}
+
+	sseBody := "event: message_start\n" +
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":100,"cache_creation_input_tokens":20,"output_tokens":1}}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"}}` + "\n\n" +
+		"event: message_delta\n" +
+		`data: {"type":"message_delta","usage":{"output_tokens":42}}` + "\n\n"
+	usage = ExtractTokenUsage([]byte(sseBody))
+	if usage.InputTokens != 100 || usage.OutputTokens != 42 || usage.CacheCreationInputTokens != 20 {
+		t.Errorf("Unexpected usage from SSE body: %+v", usage)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/events"
 	"claude-code-companion/internal/i18n"
 	"claude-code-companion/internal/logger"
+	"claude-code-companion/internal/metrics"
 	"claude-code-companion/internal/security"
 	"claude-code-companion/internal/tagging"
 	"claude-code-companion/internal/webres"
@@ -23,19 +26,24 @@ type HotUpdateHandler interface {
 }
 
 type AdminServer struct {
-	config           *config.Config
-	endpointManager  *endpoint.Manager
-	taggingManager   *tagging.Manager
-	logger           *logger.Logger
-	configFilePath   string
-	hotUpdateHandler HotUpdateHandler
-	version          string
-	i18nManager      *i18n.Manager
-	csrfManager      *security.CSRFManager
+	config             *config.Config
+	endpointManager    *endpoint.Manager
+	taggingManager     *tagging.Manager
+	logger             *logger.Logger
+	configFilePath     string
+	hotUpdateHandler   HotUpdateHandler
+	version            string
+	i18nManager        *i18n.Manager
+	csrfManager        *security.CSRFManager
+	eventHub           *events.Hub
+	oauthLogins        *oauthLoginManager
+	oauthTokenCallback func(*endpoint.Endpoint, *config.OAuthConfig) error
+	configHistory      *config.ConfigHistory
+	configMutex        sync.Mutex // 保护 config 的读取和替换，后台 goroutine（如 OAuth 授权轮询、令牌刷新）与管理接口可能并发修改配置
 }
 
 func NewAdminServer(cfg *config.Config, endpointManager *endpoint.Manager, taggingManager *tagging.Manager, log *logger.Logger, configFilePath string, version string, i18nManager *i18n.Manager) *AdminServer {
-	return &AdminServer{
+	s := &AdminServer{
 		config:          cfg,
 		endpointManager: endpointManager,
 		taggingManager:  taggingManager,
@@ -44,7 +52,13 @@ func NewAdminServer(cfg *config.Config, endpointManager *endpoint.Manager, taggi
 		version:         version,
 		i18nManager:     i18nManager,
 		csrfManager:     security.NewCSRFManager(),
+		oauthLogins:     newOAuthLoginManager(),
+		configHistory:   newConfigHistory(cfg),
 	}
+	s.registerMetrics(metrics.Default)
+	// 启动时记录当前配置，手工修改过的配置文件也能回滚
+	s.recordConfigRevision(cfg, "startup", 0)
+	return s
 }
 
 // SetHotUpdateHandler sets the hot update handler
@@ -139,11 +153,42 @@ func calculateSuccessRate(successRequests, totalRequests int) string {
 	return fmt.Sprintf("%.1f%%", rate)
 }
 
+// currentConfig 在配置锁内读取当前配置，配置整体替换而不原地修改，调用方可以在锁外读取返回的配置
+func (s *AdminServer) currentConfig() *config.Config {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+	return s.config
+}
+
+// endpointConfigsSnapshot 在配置锁内复制当前端点配置，只用于读取；需要修改时使用 hotUpdateEndpointsWith
+func (s *AdminServer) endpointConfigsSnapshot() []config.EndpointConfig {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+	return append([]config.EndpointConfig(nil), s.config.Endpoints...)
+}
+
 // hotUpdateEndpoints performs hot update of endpoints configuration
-func (s *AdminServer) hotUpdateEndpoints(endpoints []config.EndpointConfig) error {
+// c 为 nil 表示不是由管理界面请求发起的修改
+func (s *AdminServer) hotUpdateEndpoints(c *gin.Context, endpoints []config.EndpointConfig) error {
+	return s.hotUpdateEndpointsWith(c, func([]config.EndpointConfig) ([]config.EndpointConfig, error) {
+		return endpoints, nil
+	})
+}
+
+// hotUpdateEndpointsWith 在同一个配置锁内读取当前端点配置、由 edit 生成新配置并应用，避免读取和应用之间的并发修改丢失
+// edit 收到的是副本，返回错误时不做任何修改并原样返回该错误；返回 nil 表示无需更新
+func (s *AdminServer) hotUpdateEndpointsWith(c *gin.Context, edit func(current []config.EndpointConfig) ([]config.EndpointConfig, error)) error {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	endpoints, err := edit(append([]config.EndpointConfig(nil), s.config.Endpoints...))
+	if err != nil || endpoints == nil {
+		return err
+	}
+
 	if s.hotUpdateHandler == nil {
 		// 回退到旧的更新方式
-		return s.saveEndpointsToConfig(endpoints)
+		return s.saveEndpointsToConfig(c, endpoints)
 	}
 
 	// 创建新配置，只更新端点部分
@@ -160,7 +205,7 @@ func (s *AdminServer) hotUpdateEndpoints(endpoints []config.EndpointConfig) erro
 	}
 
 	// 保存配置到文件
-	if err := config.SaveConfig(&newConfig, s.configFilePath); err != nil {
+	if err := s.saveConfig(c, &newConfig); err != nil {
 		s.logger.Error("Failed to save configuration file after endpoint update", err)
 		// 不返回错误，因为内存更新已成功
 	}
@@ -170,14 +215,37 @@ func (s *AdminServer) hotUpdateEndpoints(endpoints []config.EndpointConfig) erro
 	return nil
 }
 
+// UpdateEndpointConfig 在配置锁内修改单个端点的配置并保存到文件，供代理持久化令牌刷新、自动禁用等运行时状态
+// 与管理接口的修改共用同一把锁，且基于当前配置的副本修改，热更新不会再用修改前的副本覆盖这些状态
+func (s *AdminServer) UpdateEndpointConfig(endpointName string, update func(*config.EndpointConfig) error) error {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	endpoints := append([]config.EndpointConfig(nil), s.config.Endpoints...)
+	for i := range endpoints {
+		if endpoints[i].Name != endpointName {
+			continue
+		}
+		if err := update(&endpoints[i]); err != nil {
+			return err
+		}
+		newConfig := *s.config
+		newConfig.Endpoints = endpoints
+		// 运行时状态已经生效，保存失败时内存中的配置也要保持一致，避免下次热更新回退
+		s.config = &newConfig
+		return config.SaveConfig(&newConfig, s.configFilePath)
+	}
+	return fmt.Errorf("endpoint not found: %s", endpointName)
+}
+
 // updateConfigWithRollback 执行配置更新，失败时自动回滚
-func (s *AdminServer) updateConfigWithRollback(updateFunc func() error, rollbackFunc func() error) error {
+func (s *AdminServer) updateConfigWithRollback(c *gin.Context, updateFunc func() error, rollbackFunc func() error) error {
 	if err := updateFunc(); err != nil {
 		return err
 	}
 
 	// 保存配置到文件
-	if err := config.SaveConfig(s.config, s.configFilePath); err != nil {
+	if err := s.saveConfig(c, s.config); err != nil {
 		// 保存失败，尝试回滚
 		if rollbackErr := rollbackFunc(); rollbackErr != nil {
 			s.logger.Error("Failed to rollback after save error", rollbackErr)
@@ -211,11 +279,15 @@ func (s *AdminServer) RegisterRoutes(router *gin.Engine) {
 	router.GET("/admin/health", s.handleHealthCheck)
 	router.GET("/admin/metrics", s.handleMetrics)
 
+	// OAuth 授权码回调（由授权服务器重定向，不经过 CSRF 校验，由 state 保护）
+	router.GET("/admin/oauth/callback", s.handleOAuthCallback)
+
 	// 注册页面路由
 	router.GET("/admin/", s.handleDashboard)
 	router.GET("/admin/endpoints", s.handleEndpointsPage)
 	router.GET("/admin/taggers", s.handleTaggersPage)
 	router.GET("/admin/logs", s.handleLogsPage)
+	router.GET("/admin/sessions", s.handleSessionsPage)
 	router.GET("/admin/settings", s.handleSettingsPage)
 
 	// 注册 API 路由，添加UTF-8字符集中间件和CSRF防护
@@ -236,13 +308,24 @@ func (s *AdminServer) RegisterRoutes(router *gin.Engine) {
 		api.POST("/endpoints/:id/copy", s.handleCopyEndpoint)
 		api.POST("/endpoints/:id/toggle", s.handleToggleEndpoint)
 		api.POST("/endpoints/:id/reset-status", s.handleResetEndpointStatus)
+		api.POST("/endpoints/:id/credentials/:credential/reset", s.handleResetCredential)
 		api.POST("/endpoints/reorder", s.handleReorderEndpoints)
+		api.GET("/endpoints/availability", s.handleGetEndpointAvailability)
+		api.GET("/endpoints/performance", s.handleGetEndpointPerformance)
+		api.GET("/endpoints/:id/health-history", s.handleGetEndpointHealthHistory)
 
 		// 端点向导路由
 		s.registerEndpointWizardRoutes(api)
 
+		// OAuth 授权路由
+		s.registerOAuthLoginRoutes(api)
+
+		// 端点导入导出和批量操作路由
+		s.registerEndpointBundleRoutes(api)
+
 		api.GET("/taggers", s.handleGetTaggers)
 		api.POST("/taggers", s.handleCreateTagger)
+		api.POST("/taggers/simulate", s.handleSimulateTagger)
 		api.PUT("/taggers/:name", s.handleUpdateTagger)
 		api.DELETE("/taggers/:name", s.handleDeleteTagger)
 		api.GET("/tags", s.handleGetTags)
@@ -251,10 +334,17 @@ func (s *AdminServer) RegisterRoutes(router *gin.Engine) {
 		api.POST("/logs/cleanup", s.handleCleanupLogs)
 		api.GET("/logs/stats", s.handleGetLogStats)
 		api.GET("/logs/:request_id/export", s.handleExportDebugInfo)
+		api.GET("/sessions", s.handleGetSessions)
+		api.GET("/sessions/:session_id", s.handleGetSession)
+		api.GET("/tokens/stats", s.handleGetTokenStats)
+		api.GET("/events/stream", s.handleEventStream)
 		api.PUT("/config", s.handleHotUpdateConfig)
 		api.GET("/config", s.handleGetConfig)
 		api.PUT("/settings", s.handleUpdateSettings)
 
+		// 配置修订历史路由
+		s.registerConfigHistoryRoutes(api)
+
 		// 翻译API
 		api.GET("/translations", s.handleGetTranslations)
 	}
@@ -381,83 +471,5 @@ func (s *AdminServer) handleHealthCheck(c *gin.Context) {
 
 // handleMetrics 提供 Prometheus 格式的 metrics 端点
 func (s *AdminServer) handleMetrics(c *gin.Context) {
-	endpoints := s.endpointManager.GetAllEndpoints()
-
-	// 构建 Prometheus 格式的 metrics
-	metricsLines := []string{
-		"# HELP claude_proxy_endpoints_total Total number of configured endpoints",
-		"# TYPE claude_proxy_endpoints_total gauge",
-		fmt.Sprintf("claude_proxy_endpoints_total %d", len(endpoints)),
-		"",
-		"# HELP claude_proxy_endpoints_active Number of active and enabled endpoints",
-		"# TYPE claude_proxy_endpoints_active gauge",
-	}
-
-	activeEndpoints := 0
-	for _, ep := range endpoints {
-		if ep.Status == endpoint.StatusActive && ep.Enabled {
-			activeEndpoints++
-		}
-	}
-	metricsLines = append(metricsLines, fmt.Sprintf("claude_proxy_endpoints_active %d", activeEndpoints))
-	metricsLines = append(metricsLines, "")
-
-	// 端点统计数据
-	metricsLines = append(metricsLines, []string{
-		"# HELP claude_proxy_endpoint_requests_total Total number of requests processed by endpoint",
-		"# TYPE claude_proxy_endpoint_requests_total counter",
-	}...)
-
-	metricsLines = append(metricsLines, []string{
-		"# HELP claude_proxy_endpoint_requests_success Total number of successful requests by endpoint",
-		"# TYPE claude_proxy_endpoint_requests_success counter",
-	}...)
-
-	metricsLines = append(metricsLines, []string{
-		"# HELP claude_proxy_endpoint_status Endpoint status (1=active, 0=inactive)",
-		"# TYPE claude_proxy_endpoint_status gauge",
-	}...)
-
-	for _, ep := range endpoints {
-		// 端点标签
-		labels := fmt.Sprintf(`endpoint="%s",type="%s",url="%s"`,
-			ep.Name, ep.EndpointType, ep.URL)
-
-		// 总请求数
-		metricsLines = append(metricsLines,
-			fmt.Sprintf("claude_proxy_endpoint_requests_total{%s} %d",
-				labels, ep.TotalRequests))
-
-		// 成功请求数
-		metricsLines = append(metricsLines,
-			fmt.Sprintf("claude_proxy_endpoint_requests_success{%s} %d",
-				labels, ep.SuccessRequests))
-
-		// 端点状态
-		status := 0
-		if ep.Status == endpoint.StatusActive && ep.Enabled {
-			status = 1
-		}
-		metricsLines = append(metricsLines,
-			fmt.Sprintf("claude_proxy_endpoint_status{%s} %d",
-				labels, status))
-	}
-
-	metricsLines = append(metricsLines, "")
-
-	// 系统指标
-	metricsLines = append(metricsLines, []string{
-		"# HELP claude_proxy_info Proxy information",
-		"# TYPE claude_proxy_info gauge",
-		fmt.Sprintf(`claude_proxy_info{version="%s"} 1`, s.version),
-		"",
-		"# HELP claude_proxy_uptime_seconds Proxy uptime in seconds",
-		"# TYPE claude_proxy_uptime_seconds gauge",
-		// 注意：这里需要记录启动时间，现在简化为 0
-		"claude_proxy_uptime_seconds 0",
-	}...)
-
-	// 设置 Content-Type 为 Prometheus 格式
-	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-	c.String(http.StatusOK, strings.Join(metricsLines, "\n"))
+	metrics.Default.Handler().ServeHTTP(c.Writer, c.Request)
 }
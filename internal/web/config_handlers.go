@@ -48,7 +48,7 @@ func (s *AdminServer) handleHotUpdateConfig(c *gin.Context) {
 	}
 
 	// 保存配置到文件
-	if err := config.SaveConfig(&newConfig, s.configFilePath); err != nil {
+	if err := s.saveConfig(c, &newConfig); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to save configuration file: " + err.Error(),
 		})
@@ -150,7 +150,7 @@ func (s *AdminServer) handleUpdateEndpointModelRewrite(c *gin.Context) {
 		}
 
 		// 保存配置到文件
-		if err := config.SaveConfig(&newConfig, s.configFilePath); err != nil {
+		if err := s.saveConfig(c, &newConfig); err != nil {
 			s.logger.Error("Failed to save configuration file after model rewrite config update", err)
 			// 不返回错误，因为内存更新已成功
 		}
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"claude-code-companion/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerConfigHistoryRoutes 注册配置修订历史、对比和回滚路由
+func (s *AdminServer) registerConfigHistoryRoutes(api *gin.RouterGroup) {
+	api.GET("/config/history", s.handleGetConfigHistory)
+	api.GET("/config/history/diff", s.handleDiffConfigRevisions)
+	api.POST("/config/history/:id/rollback", s.handleRollbackConfig)
+}
+
+// newConfigHistory 修订历史保存在数据目录（与统计数据库相同的日志目录）下的 config_history
+func newConfigHistory(cfg *config.Config) *config.ConfigHistory {
+	dataDirectory := cfg.Logging.LogDirectory
+	if dataDirectory == "" {
+		dataDirectory = "."
+	}
+	return config.NewConfigHistory(filepath.Join(dataDirectory, "config_history"), cfg.ConfigHistory.MaxRevisions)
+}
+
+// configChangeAuthor 返回修订记录中的修改来源，管理界面没有账号体系，记录客户端地址
+func configChangeAuthor(c *gin.Context) string {
+	if c == nil {
+		return "system"
+	}
+	return c.ClientIP()
+}
+
+// configRollbackOf 返回请求回滚到的修订号，不是回滚请求时返回 0
+func configRollbackOf(c *gin.Context) int {
+	if c == nil {
+		return 0
+	}
+	return c.GetInt("config_rollback_of")
+}
+
+// saveConfig 保存配置文件并记录一次配置修订
+func (s *AdminServer) saveConfig(c *gin.Context, cfg *config.Config) error {
+	if err := config.SaveConfig(cfg, s.configFilePath); err != nil {
+		return err
+	}
+	s.recordConfigRevision(cfg, configChangeAuthor(c), configRollbackOf(c))
+	return nil
+}
+
+// recordConfigRevision 把配置文件的当前内容记录为修订，失败只记录日志，不影响配置保存
+func (s *AdminServer) recordConfigRevision(cfg *config.Config, author string, rollbackOf int) {
+	if cfg.ConfigHistory.Disabled {
+		return
+	}
+	data, err := os.ReadFile(s.configFilePath)
+	if err != nil {
+		s.logger.Error("Failed to read configuration file for config history", err)
+		return
+	}
+	s.configHistory.SetMaxRevisions(cfg.ConfigHistory.MaxRevisions)
+	revision, err := s.configHistory.Record(data, author, rollbackOf)
+	if err != nil {
+		s.logger.Error("Failed to record configuration revision", err)
+		return
+	}
+	if revision != nil {
+		s.logger.Info(fmt.Sprintf("Recorded configuration revision %d by %s (sections: %s)", revision.ID, revision.Author, strings.Join(revision.Sections, ", ")))
+	}
+}
+
+// handleGetConfigHistory 返回配置修订列表，最新的在前
+func (s *AdminServer) handleGetConfigHistory(c *gin.Context) {
+	revisions, err := s.configHistory.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if revisions == nil {
+		revisions = []config.ConfigRevision{}
+	}
+	historyConfig := s.currentConfig().ConfigHistory
+	c.JSON(http.StatusOK, gin.H{
+		"revisions":     revisions,
+		"max_revisions": historyConfig.MaxRevisions,
+		"disabled":      historyConfig.Disabled,
+	})
+}
+
+// handleDiffConfigRevisions 对比两个修订，from 为旧修订，to 为新修订
+func (s *AdminServer) handleDiffConfigRevisions(c *gin.Context) {
+	fromID, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from revision"})
+		return
+	}
+	toID, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to revision"})
+		return
+	}
+
+	from, fromData, err := s.configHistory.Load(fromID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	to, toData, err := s.configHistory.Load(toID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"sections": config.ChangedConfigSections(fromData, toData),
+		"lines":    config.DiffConfigs(fromData, toData),
+	})
+}
+
+// handleRollbackConfig 把配置恢复到指定修订，回滚本身也记录为一次新修订
+func (s *AdminServer) handleRollbackConfig(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return
+	}
+	_, data, err := s.configHistory.Load(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	newConfig, err := config.ParseConfigRevision(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Revision %d cannot be restored: %v", id, err)})
+		return
+	}
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	keepCurrentOAuthTokens(newConfig.Endpoints, s.config.Endpoints)
+	previous := s.config
+	c.Set("config_rollback_of", id)
+	err = s.updateConfigWithRollback(c,
+		// 更新函数
+		func() error {
+			if s.hotUpdateHandler != nil {
+				if err := s.hotUpdateHandler.HotUpdateConfig(newConfig); err != nil {
+					return fmt.Errorf("failed to apply revision: %v", err)
+				}
+			}
+			s.config = newConfig
+			return nil
+		},
+		// 回滚函数：配置文件保存失败时恢复正在运行的配置
+		func() error {
+			s.config = previous
+			if s.hotUpdateHandler != nil {
+				return s.hotUpdateHandler.HotUpdateConfig(previous)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to roll back configuration to revision %d", id), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 监听地址等服务器配置需要重启才能生效
+	restartRequired := !reflect.DeepEqual(newConfig.Server, previous.Server)
+	s.logger.SetRetentionPolicy(newConfig.Logging.Retention)
+	if err := s.taggingManager.Initialize(&newConfig.Tagging); err != nil {
+		s.logger.Error("Failed to reinitialize taggers after rollback", err)
+	}
+
+	s.logger.Info(fmt.Sprintf("Configuration rolled back to revision %d", id))
+	c.JSON(http.StatusOK, gin.H{
+		"message":          fmt.Sprintf("Configuration rolled back to revision %d", id),
+		"restart_required": restartRequired,
+	})
+}
+
+// keepCurrentOAuthTokens 刷新令牌每次刷新都会轮换，修订中保存的旧令牌可能已经失效，回滚时同名 OAuth 端点沿用当前令牌
+func keepCurrentOAuthTokens(endpoints []config.EndpointConfig, current []config.EndpointConfig) {
+	for i := range endpoints {
+		ep := &endpoints[i]
+		if ep.AuthType != "oauth" || ep.OAuthConfig == nil {
+			continue
+		}
+		for _, local := range current {
+			if local.Name != ep.Name || local.OAuthConfig == nil || local.OAuthConfig.RefreshToken == "" {
+				continue
+			}
+			oauthConfig := *ep.OAuthConfig
+			oauthConfig.AccessToken = local.OAuthConfig.AccessToken
+			oauthConfig.RefreshToken = local.OAuthConfig.RefreshToken
+			oauthConfig.ExpiresAt = local.OAuthConfig.ExpiresAt
+			ep.OAuthConfig = &oauthConfig
+			break
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRollbackConfigRestoresRunningConfigWhenSaveFails(t *testing.T) {
+	dir := t.TempDir()
+	log, err := logger.NewLogger(logger.LogConfig{Level: "error", LogDirectory: dir})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	history := config.NewConfigHistory(filepath.Join(dir, "config_history"), 10)
+	revision, err := history.Record([]byte("server:\n  host: 127.0.0.1\n  port: 8080\nendpoints:\n  - name: old\n    url: https://old.example.com\n    auth_type: api_key\n    auth_value: k\n"), "test", 0)
+	if err != nil {
+		t.Fatalf("Failed to record revision: %v", err)
+	}
+
+	current := &config.Config{
+		Server:    config.ServerConfig{Host: "127.0.0.1", Port: 8080},
+		Endpoints: []config.EndpointConfig{{Name: "current", URL: "https://current.example.com", AuthType: "api_key", AuthValue: "k"}},
+	}
+	updater := &recordingHotUpdater{}
+	// 配置文件所在目录不存在，保存失败
+	s := &AdminServer{config: current, logger: log, configHistory: history, hotUpdateHandler: updater,
+		configFilePath: filepath.Join(dir, "missing", "config.yaml")}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/admin/api/config/history/1/rollback", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	s.handleRollbackConfig(c)
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected rollback to fail when the config file cannot be saved, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if s.config != current {
+		t.Error("Expected the admin config to be restored")
+	}
+	if len(updater.configs) != 2 || updater.configs[0].Endpoints[0].Name != "old" || updater.configs[1] != current {
+		t.Errorf("Expected the revision to be applied and then the running config restored, got %d hot updates", len(updater.configs))
+	}
+	if revisions, _ := history.List(); len(revisions) != 1 || revisions[0].ID != revision.ID {
+		t.Errorf("Expected no revision to be recorded for the failed rollback, got %+v", revisions)
+	}
+}
@@ -1,6 +1,10 @@
 package web
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"claude-code-companion/internal/endpoint"
 
 	"github.com/gin-gonic/gin"
@@ -16,11 +20,21 @@ func (s *AdminServer) handleDashboard(c *gin.Context) {
 	type EndpointStats struct {
 		*endpoint.Endpoint
 		SuccessRate string
+		TTFB        string // 首字节时间 p50 / p95
+		Throughput  string // 输出速度 EWMA
+		ModelDetail string // 按模型的延迟明细，显示在提示中
+		Degraded    bool
+	}
+	
+	performance := make(map[string]endpoint.EndpointPerformance)
+	for _, perf := range s.endpointManager.PerformanceOverview() {
+		performance[perf.Name] = perf
 	}
 	
 	endpointStats := make([]EndpointStats, 0)
 	
 	for _, ep := range endpoints {
+		perf := performance[ep.Name]
 		totalRequests += ep.TotalRequests
 		successRequests += ep.SuccessRequests
 		if ep.Status == endpoint.StatusActive {
@@ -32,6 +46,10 @@ func (s *AdminServer) handleDashboard(c *gin.Context) {
 		endpointStats = append(endpointStats, EndpointStats{
 			Endpoint:    ep,
 			SuccessRate: successRate,
+			TTFB:        formatTTFB(perf.Overall),
+			Throughput:  formatThroughput(perf.Overall),
+			ModelDetail: formatModelPerformance(perf.Models),
+			Degraded:    perf.Degraded,
 		})
 	}
 	
@@ -73,4 +91,35 @@ func (s *AdminServer) handleEndpointsPage(c *gin.Context) {
 		"Endpoints": endpointStats,
 	})
 	s.renderHTML(c, "endpoints.html", data)
-}
\ No newline at end of file
+}
+
+// formatTTFB 格式化首字节时间的 p50 / p95，没有样本时返回 N/A
+func formatTTFB(stats endpoint.PerformanceStats) string {
+	if stats.Samples == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.0f / %.0f ms", stats.TTFBP50Ms, stats.TTFBP95Ms)
+}
+
+// formatThroughput 格式化输出速度，没有返回输出 token 数的请求时返回 N/A
+func formatThroughput(stats endpoint.PerformanceStats) string {
+	if stats.TokensPerSecond == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f tok/s", stats.TokensPerSecond)
+}
+
+// formatModelPerformance 按模型名排序输出每个模型的首字节时间和输出速度，每行一个模型
+func formatModelPerformance(models map[string]endpoint.PerformanceStats) string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		stats := models[name]
+		lines = append(lines, fmt.Sprintf("%s: TTFB %s, %s (n=%d)", name, formatTTFB(stats), formatThroughput(stats), stats.Samples))
+	}
+	return strings.Join(lines, "\n")
+}
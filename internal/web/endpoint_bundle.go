@@ -0,0 +1,533 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"claude-code-companion/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// endpointBundle 端点导出文件，YAML 和 JSON 使用相同的字段名，可以直接粘贴配置文件中的 endpoints 段导入
+type endpointBundle struct {
+	ExportedAt          string                  `yaml:"exported_at,omitempty"`
+	Version             string                  `yaml:"version,omitempty"`
+	CredentialsStripped bool                    `yaml:"credentials_stripped,omitempty"`
+	Endpoints           []config.EndpointConfig `yaml:"endpoints"`
+}
+
+// 导入时同名端点的处理方式
+const (
+	importConflictRename    = "rename"
+	importConflictOverwrite = "overwrite"
+	importConflictSkip      = "skip"
+)
+
+// endpointImportRequest 导入请求，dry_run 时只返回预览结果
+type endpointImportRequest struct {
+	Content     string            `json:"content"`
+	Conflict    string            `json:"conflict"`    // rename（默认）| overwrite | skip
+	Resolutions map[string]string `json:"resolutions"` // 按导入文件中的端点名覆盖 conflict
+	AuthValues  map[string]string `json:"auth_values"` // 按导入文件中的端点名补充被去除的 auth_value
+	DryRun      bool              `json:"dry_run"`
+}
+
+// endpointImportResult 单个端点的导入结果
+type endpointImportResult struct {
+	Name               string `json:"name"`          // 导入后的名称
+	OriginalName       string `json:"original_name"` // 导入文件中的名称
+	Action             string `json:"action"`        // created | renamed | overwritten | skipped
+	Conflict           bool   `json:"conflict"`
+	MissingCredentials bool   `json:"missing_credentials"`           // 凭据已被去除，需要在 auth_values 中补充
+	DroppedCredentials int    `json:"dropped_credentials,omitempty"` // 值为空而被丢弃的凭据池条目数
+}
+
+// endpointBulkRequest 批量操作请求
+type endpointBulkRequest struct {
+	Names  []string `json:"names"`
+	Action string   `json:"action"` // enable | disable | delete | add_tags | remove_tags
+	Tags   []string `json:"tags"`
+}
+
+// registerEndpointBundleRoutes 注册端点导入导出和批量操作路由
+func (s *AdminServer) registerEndpointBundleRoutes(api *gin.RouterGroup) {
+	api.GET("/endpoints/export", s.handleExportEndpoints)
+	api.POST("/endpoints/import", s.handleImportEndpoints)
+	api.POST("/endpoints/bulk", s.handleBulkEndpoints)
+}
+
+// handleExportEndpoints 导出端点，?name= 可重复指定端点，不指定时导出全部；format=yaml|json；strip_credentials=true 去除凭据
+func (s *AdminServer) handleExportEndpoints(c *gin.Context) {
+	format := c.DefaultQuery("format", "yaml")
+	if format != "yaml" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be 'yaml' or 'json'"})
+		return
+	}
+	strip := c.Query("strip_credentials") == "true"
+
+	selected, err := selectEndpoints(s.endpointConfigsSnapshot(), c.QueryArray("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	bundle, err := buildEndpointBundle(selected, strip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export endpoints: " + err.Error()})
+		return
+	}
+	bundle.ExportedAt = time.Now().Format(time.RFC3339)
+	bundle.Version = s.version
+
+	data, contentType, err := encodeEndpointBundle(bundle, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export endpoints: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("endpoints-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// handleImportEndpoints 导入端点，同名端点按 conflict / resolutions 重命名、覆盖或跳过
+func (s *AdminServer) handleImportEndpoints(c *gin.Context) {
+	var request endpointImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+
+	imported, err := parseEndpointBundle(request.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.DryRun {
+		_, results, err := planEndpointImport(s.endpointConfigsSnapshot(), imported, request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "results": results})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"results": results})
+		return
+	}
+
+	// 在配置锁内按最新的端点配置合并，避免合并期间其他修改被覆盖
+	var results []endpointImportResult
+	var planErr error
+	err = s.hotUpdateEndpointsWith(c, func(current []config.EndpointConfig) ([]config.EndpointConfig, error) {
+		var merged []config.EndpointConfig
+		merged, results, planErr = planEndpointImport(current, imported, request)
+		return merged, planErr
+	})
+	if planErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": planErr.Error(), "results": results})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to import endpoints: " + err.Error(), "results": results})
+		return
+	}
+
+	applied := 0
+	for _, result := range results {
+		if result.Action != "skipped" {
+			applied++
+		}
+	}
+	s.logger.Info(fmt.Sprintf("Imported %d of %d endpoints", applied, len(results)))
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Imported %d of %d endpoints", applied, len(results)),
+		"results": results,
+	})
+}
+
+// handleBulkEndpoints 对多个端点执行启用、禁用、删除或标签操作，一次热更新生效
+func (s *AdminServer) handleBulkEndpoints(c *gin.Context) {
+	var request endpointBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+
+	var actionErr error
+	err := s.hotUpdateEndpointsWith(c, func(current []config.EndpointConfig) ([]config.EndpointConfig, error) {
+		var updated []config.EndpointConfig
+		updated, actionErr = applyBulkEndpointAction(current, request)
+		return updated, actionErr
+	})
+	if actionErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": actionErr.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update endpoints: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Applied '%s' to %d endpoints", request.Action, len(request.Names)),
+	})
+}
+
+// selectEndpoints 按名称选出端点，names 为空时返回全部
+func selectEndpoints(endpoints []config.EndpointConfig, names []string) ([]config.EndpointConfig, error) {
+	if len(names) == 0 {
+		return endpoints, nil
+	}
+	byName := make(map[string]config.EndpointConfig, len(endpoints))
+	for _, ep := range endpoints {
+		byName[ep.Name] = ep
+	}
+	selected := make([]config.EndpointConfig, 0, len(names))
+	for _, name := range names {
+		ep, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("endpoint not found: %s", name)
+		}
+		selected = append(selected, ep)
+	}
+	return selected, nil
+}
+
+// buildEndpointBundle 深度复制端点并去除运行状态；strip 时清空凭据，secret:// 引用不含凭据本身，予以保留
+// header 和参数覆盖规则经常包含 API key，strip 时整体去除
+func buildEndpointBundle(endpoints []config.EndpointConfig, strip bool) (*endpointBundle, error) {
+	copied, err := copyEndpointConfigs(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	for i := range copied {
+		copied[i].RateLimitReset = nil
+		copied[i].RateLimitStatus = nil
+		copied[i].DisabledReason = ""
+	}
+	if strip {
+		holder := config.Config{Endpoints: copied}
+		for _, field := range holder.SecretFields() {
+			if _, ok := config.SecretReferenceName(*field.Value); !ok {
+				*field.Value = ""
+			}
+		}
+		for i := range copied {
+			copied[i].HeaderOverrides = nil
+			copied[i].ParameterOverrides = nil
+			if copied[i].OAuthConfig != nil {
+				copied[i].OAuthConfig.ExpiresAt = 0
+			}
+			// 代理用户名和密码必须同时提供，密码被清空时一并去除用户名
+			if proxy := copied[i].Proxy; proxy != nil && proxy.Password == "" {
+				proxy.Username = ""
+			}
+		}
+	}
+	return &endpointBundle{CredentialsStripped: strip, Endpoints: copied}, nil
+}
+
+// copyEndpointConfigs 通过 YAML 往返深度复制端点配置
+func copyEndpointConfigs(endpoints []config.EndpointConfig) ([]config.EndpointConfig, error) {
+	data, err := yaml.Marshal(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	var copied []config.EndpointConfig
+	if err := yaml.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// encodeEndpointBundle 序列化导出文件；JSON 由 YAML 结构转换而来，两种格式的字段名一致
+func encodeEndpointBundle(bundle *endpointBundle, format string) ([]byte, string, error) {
+	data, err := yaml.Marshal(bundle)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == "yaml" {
+		return data, "application/x-yaml; charset=utf-8", nil
+	}
+
+	var generic map[string]interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, "", err
+	}
+	data, err = json.MarshalIndent(generic, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	return data, "application/json; charset=utf-8", nil
+}
+
+// parseEndpointBundle 解析导出文件，支持 YAML 和 JSON（JSON 是合法的 YAML），也接受只有端点列表的内容
+func parseEndpointBundle(content string) ([]config.EndpointConfig, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("import content is empty")
+	}
+
+	var bundle endpointBundle
+	if err := yaml.Unmarshal([]byte(content), &bundle); err == nil && len(bundle.Endpoints) > 0 {
+		return bundle.Endpoints, nil
+	}
+	var list []config.EndpointConfig
+	if err := yaml.Unmarshal([]byte(content), &list); err == nil && len(list) > 0 {
+		return list, nil
+	}
+	return nil, fmt.Errorf("no endpoints found in import content, expected a bundle with an 'endpoints' list")
+}
+
+// planEndpointImport 把导入的端点合并到现有端点列表，返回合并后的列表和每个端点的处理结果，不修改 existing
+func planEndpointImport(existing, imported []config.EndpointConfig, request endpointImportRequest) ([]config.EndpointConfig, []endpointImportResult, error) {
+	defaultConflict := request.Conflict
+	if defaultConflict == "" {
+		defaultConflict = importConflictRename
+	}
+
+	merged := append([]config.EndpointConfig(nil), existing...)
+	index := make(map[string]int, len(merged))
+	taken := make(map[string]bool, len(merged)+len(imported))
+	maxPriority := 0
+	for i, ep := range merged {
+		index[ep.Name] = i
+		taken[ep.Name] = true
+		if ep.Priority > maxPriority {
+			maxPriority = ep.Priority
+		}
+	}
+
+	seen := make(map[string]bool, len(imported))
+	results := make([]endpointImportResult, 0, len(imported))
+	var missing []string
+	for _, ep := range imported {
+		if ep.Name == "" {
+			return nil, results, fmt.Errorf("imported endpoint name cannot be empty")
+		}
+		if seen[ep.Name] {
+			return nil, results, fmt.Errorf("duplicate endpoint name in import content: %s", ep.Name)
+		}
+		seen[ep.Name] = true
+
+		result := endpointImportResult{Name: ep.Name, OriginalName: ep.Name}
+		resolution := defaultConflict
+		if r, ok := request.Resolutions[ep.Name]; ok && r != "" {
+			resolution = r
+		}
+		if resolution != importConflictRename && resolution != importConflictOverwrite && resolution != importConflictSkip {
+			return nil, results, fmt.Errorf("invalid conflict resolution '%s' for endpoint %s, must be 'rename', 'overwrite' or 'skip'", resolution, ep.Name)
+		}
+
+		// 运行状态不随导入带入
+		ep.RateLimitReset = nil
+		ep.RateLimitStatus = nil
+		ep.DisabledReason = ""
+		result.DroppedCredentials = dropEmptyCredentials(&ep)
+		if value := request.AuthValues[ep.Name]; value != "" && ep.AuthType != "oauth" {
+			ep.AuthValue = value
+		}
+
+		existingIndex, conflict := index[ep.Name]
+		result.Conflict = conflict
+		if conflict && resolution == importConflictOverwrite {
+			// 覆盖时导入内容中被去除的凭据沿用本地配置
+			inheritCredentials(&ep, merged[existingIndex])
+		}
+		result.MissingCredentials = endpointMissingCredentials(ep)
+		switch {
+		case !conflict:
+			maxPriority++
+			ep.Priority = maxPriority
+			merged = append(merged, ep)
+			index[ep.Name] = len(merged) - 1
+			result.Action = "created"
+		case resolution == importConflictSkip:
+			result.Action = "skipped"
+			result.MissingCredentials = false
+		case resolution == importConflictOverwrite:
+			ep.Priority = merged[existingIndex].Priority
+			merged[existingIndex] = ep
+			result.Action = "overwritten"
+		default:
+			ep.Name = uniqueEndpointName(ep.Name, taken)
+			maxPriority++
+			ep.Priority = maxPriority
+			merged = append(merged, ep)
+			index[ep.Name] = len(merged) - 1
+			result.Name = ep.Name
+			result.Action = "renamed"
+		}
+		taken[ep.Name] = true
+
+		if result.MissingCredentials {
+			missing = append(missing, result.OriginalName)
+		}
+		results = append(results, result)
+	}
+
+	if len(missing) > 0 && !request.DryRun {
+		return nil, results, fmt.Errorf("endpoints without credentials: %s; provide auth_values or skip them", strings.Join(missing, ", "))
+	}
+	return merged, results, nil
+}
+
+// dropEmptyCredentials 去除导出时被清空的凭据池条目，返回去除的数量
+func dropEmptyCredentials(ep *config.EndpointConfig) int {
+	if len(ep.Credentials) == 0 {
+		return 0
+	}
+	kept := ep.Credentials[:0:0]
+	for _, cred := range ep.Credentials {
+		if cred.Value != "" {
+			kept = append(kept, cred)
+		}
+	}
+	dropped := len(ep.Credentials) - len(kept)
+	if len(kept) == 0 {
+		kept = nil
+	}
+	ep.Credentials = kept
+	return dropped
+}
+
+// inheritCredentials 认证方式相同时，把 local 中的凭据填入 ep 中为空的凭据字段，没有覆盖规则时沿用 local 的覆盖规则
+func inheritCredentials(ep *config.EndpointConfig, local config.EndpointConfig) {
+	if ep.AuthType != local.AuthType {
+		return
+	}
+	if len(ep.HeaderOverrides) == 0 && len(ep.ParameterOverrides) == 0 {
+		ep.HeaderOverrides = local.HeaderOverrides
+		ep.ParameterOverrides = local.ParameterOverrides
+	}
+	if ep.AuthValue == "" && len(ep.Credentials) == 0 {
+		ep.AuthValue = local.AuthValue
+		ep.Credentials = local.Credentials
+	}
+	if ep.OAuthConfig != nil && local.OAuthConfig != nil && ep.OAuthConfig.AccessToken == "" && ep.OAuthConfig.RefreshToken == "" {
+		oauthConfig := *ep.OAuthConfig
+		oauthConfig.AccessToken = local.OAuthConfig.AccessToken
+		oauthConfig.RefreshToken = local.OAuthConfig.RefreshToken
+		oauthConfig.ExpiresAt = local.OAuthConfig.ExpiresAt
+		ep.OAuthConfig = &oauthConfig
+	}
+	if ep.Proxy != nil && local.Proxy != nil && ep.Proxy.Username == "" && ep.Proxy.Password == "" && ep.Proxy.Address == local.Proxy.Address {
+		proxy := *ep.Proxy
+		proxy.Username = local.Proxy.Username
+		proxy.Password = local.Proxy.Password
+		ep.Proxy = &proxy
+	}
+}
+
+// endpointMissingCredentials 端点没有可用的凭据；配置了管理界面登录的 OAuth 端点导入后可以重新授权
+func endpointMissingCredentials(ep config.EndpointConfig) bool {
+	if ep.AuthType == "oauth" {
+		cfg := ep.OAuthConfig
+		if cfg == nil {
+			return false // 交给配置验证报告
+		}
+		canLogin := cfg.AuthorizeURL != "" || cfg.DeviceAuthorizationURL != ""
+		return !canLogin && (cfg.AccessToken == "" || cfg.RefreshToken == "")
+	}
+	return ep.AuthValue == "" && len(ep.Credentials) == 0
+}
+
+// uniqueEndpointName 在 taken 中为 base 生成不重复的名称，格式与复制端点相同
+func uniqueEndpointName(base string, taken map[string]bool) string {
+	if !taken[base] {
+		return base
+	}
+	for counter := 1; ; counter++ {
+		name := generateEndpointNameWithSuffix(base, counter)
+		if !taken[name] {
+			return name
+		}
+	}
+}
+
+// applyBulkEndpointAction 返回执行批量操作后的端点列表副本，不修改 endpoints
+func applyBulkEndpointAction(endpoints []config.EndpointConfig, request endpointBulkRequest) ([]config.EndpointConfig, error) {
+	if len(request.Names) == 0 {
+		return nil, fmt.Errorf("no endpoints selected")
+	}
+	exists := make(map[string]bool, len(endpoints))
+	for _, ep := range endpoints {
+		exists[ep.Name] = true
+	}
+	selected := make(map[string]bool, len(request.Names))
+	for _, name := range request.Names {
+		if !exists[name] {
+			return nil, fmt.Errorf("endpoint not found: %s", name)
+		}
+		selected[name] = true
+	}
+
+	tags := make([]string, 0, len(request.Tags))
+	for _, tag := range request.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	updated := make([]config.EndpointConfig, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if !selected[ep.Name] {
+			updated = append(updated, ep)
+			continue
+		}
+		switch request.Action {
+		case "enable":
+			ep.Enabled = true
+			ep.DisabledReason = ""
+		case "disable":
+			ep.Enabled = false
+		case "delete":
+			continue
+		case "add_tags", "remove_tags":
+			if len(tags) == 0 {
+				return nil, fmt.Errorf("tags are required for action '%s'", request.Action)
+			}
+			ep.Tags = updateEndpointTags(ep.Tags, tags, request.Action == "add_tags")
+		default:
+			return nil, fmt.Errorf("invalid action '%s', must be 'enable', 'disable', 'delete', 'add_tags' or 'remove_tags'", request.Action)
+		}
+		updated = append(updated, ep)
+	}
+
+	// 删除后重新计算优先级（按数组顺序），与删除单个端点一致
+	if request.Action == "delete" {
+		for i := range updated {
+			updated[i].Priority = i + 1
+		}
+	}
+	return updated, nil
+}
+
+// updateEndpointTags 返回添加或移除标签后的新切片，保持原有顺序
+func updateEndpointTags(current, tags []string, add bool) []string {
+	change := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		change[tag] = true
+	}
+	result := make([]string, 0, len(current)+len(tags))
+	for _, tag := range current {
+		if add {
+			delete(change, tag)
+			result = append(result, tag)
+		} else if !change[tag] {
+			result = append(result, tag)
+		}
+	}
+	if add {
+		for _, tag := range tags {
+			if change[tag] {
+				result = append(result, tag)
+				delete(change, tag)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"claude-code-companion/internal/config"
+)
+
+func bundleTestEndpoints() []config.EndpointConfig {
+	status := "allowed_warning"
+	return []config.EndpointConfig{
+		{Name: "a", URL: "https://a.example.com", AuthType: "api_key", AuthValue: "sk-a", Enabled: true, Priority: 1, Tags: []string{"fast"}, RateLimitStatus: &status,
+			HeaderOverrides: map[string]string{"X-Upstream-Key": "sk-header"}, ParameterOverrides: map[string]string{"api_key": "sk-param"}},
+		{Name: "b", URL: "https://b.example.com", AuthType: "auth_token", AuthValue: "secret://b-token", Enabled: true, Priority: 2,
+			Credentials: []config.EndpointCredentialConfig{{Name: "k1", Value: "sk-b1"}}},
+		{Name: "c", URL: "https://c.example.com", AuthType: "oauth", Enabled: false, Priority: 3, DisabledReason: "rejected",
+			OAuthConfig: &config.OAuthConfig{AccessToken: "at", RefreshToken: "rt", ExpiresAt: 123, TokenURL: "https://auth.example.com/token"},
+			Proxy:       &config.ProxyConfig{Type: "http", Address: "127.0.0.1:8080", Username: "u", Password: "p"}},
+	}
+}
+
+func TestEndpointBundleExportRoundTrip(t *testing.T) {
+	endpoints := bundleTestEndpoints()
+	bundle, err := buildEndpointBundle(endpoints, true)
+	if err != nil {
+		t.Fatalf("Failed to build bundle: %v", err)
+	}
+
+	a, b, c := bundle.Endpoints[0], bundle.Endpoints[1], bundle.Endpoints[2]
+	if a.AuthValue != "" || a.RateLimitStatus != nil || a.HeaderOverrides != nil || a.ParameterOverrides != nil {
+		t.Errorf("Expected credentials, overrides and runtime state to be stripped, got %+v", a)
+	}
+	if b.AuthValue != "secret://b-token" || b.Credentials[0].Value != "" {
+		t.Errorf("Expected secret references to be kept and pool values stripped, got %+v", b)
+	}
+	if c.OAuthConfig.AccessToken != "" || c.OAuthConfig.RefreshToken != "" || c.OAuthConfig.ExpiresAt != 0 || c.Proxy.Password != "" || c.Proxy.Username != "" || c.DisabledReason != "" {
+		t.Errorf("Expected oauth tokens and proxy password to be stripped, got %+v %+v", c.OAuthConfig, c.Proxy)
+	}
+	if endpoints[0].AuthValue != "sk-a" || endpoints[0].HeaderOverrides["X-Upstream-Key"] != "sk-header" || endpoints[2].OAuthConfig.AccessToken != "at" {
+		t.Error("Expected export not to modify the current config")
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		data, _, err := encodeEndpointBundle(bundle, format)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", format, err)
+		}
+		if !strings.Contains(string(data), "auth_type") {
+			t.Errorf("Expected %s export to use config field names, got %s", format, data)
+		}
+		parsed, err := parseEndpointBundle(string(data))
+		if err != nil {
+			t.Fatalf("Failed to parse %s export: %v", format, err)
+		}
+		if len(parsed) != 3 || parsed[2].OAuthConfig.TokenURL != "https://auth.example.com/token" || parsed[0].Tags[0] != "fast" {
+			t.Errorf("Unexpected %s round trip: %+v", format, parsed)
+		}
+	}
+
+	// 只有端点列表的内容也可以导入
+	parsed, err := parseEndpointBundle("- name: x\n  url: https://x.example.com\n  auth_type: api_key\n  auth_value: k\n")
+	if err != nil || len(parsed) != 1 || parsed[0].Name != "x" {
+		t.Errorf("Expected a bare endpoint list to be accepted, got %+v, %v", parsed, err)
+	}
+	if _, err := parseEndpointBundle("server:\n  port: 8080\n"); err == nil {
+		t.Error("Expected content without endpoints to be rejected")
+	}
+}
+
+func TestPlanEndpointImport(t *testing.T) {
+	existing := bundleTestEndpoints()
+	imported := []config.EndpointConfig{
+		{Name: "a", URL: "https://a2.example.com", AuthType: "api_key", Priority: 9},
+		{Name: "b", URL: "https://b2.example.com", AuthType: "api_key", AuthValue: "sk-new"},
+		{Name: "d", URL: "https://d.example.com", AuthType: "api_key", AuthValue: "sk-d", Priority: 1,
+			Credentials: []config.EndpointCredentialConfig{{Name: "k1"}, {Name: "k2", Value: "v2"}}},
+		{Name: "e", URL: "https://e.example.com", AuthType: "api_key"},
+		{Name: "c", URL: "https://c2.example.com", AuthType: "oauth",
+			OAuthConfig: &config.OAuthConfig{TokenURL: "https://auth.example.com/token"},
+			Proxy:       &config.ProxyConfig{Type: "http", Address: "127.0.0.1:8080"}},
+	}
+
+	// e 没有凭据，预览时报告，正式导入时拒绝
+	request := endpointImportRequest{Conflict: importConflictRename, Resolutions: map[string]string{"a": importConflictOverwrite, "c": importConflictOverwrite}, DryRun: true}
+	_, results, err := planEndpointImport(existing, imported, request)
+	if err != nil {
+		t.Fatalf("Unexpected dry run error: %v", err)
+	}
+	if !results[3].MissingCredentials {
+		t.Errorf("Expected missing credentials to be reported, got %+v", results[3])
+	}
+	request.DryRun = false
+	if _, _, err := planEndpointImport(existing, imported, request); err == nil || !strings.Contains(err.Error(), "e") {
+		t.Errorf("Expected import without credentials to be rejected, got %v", err)
+	}
+
+	request.AuthValues = map[string]string{"e": "sk-e"}
+	merged, results, err := planEndpointImport(existing, imported, request)
+	if err != nil {
+		t.Fatalf("Unexpected import error: %v", err)
+	}
+
+	want := []struct{ name, action string }{{"a", "overwritten"}, {"b (1)", "renamed"}, {"d", "created"}, {"e", "created"}}
+	for i, w := range want {
+		if results[i].Name != w.name || results[i].Action != w.action {
+			t.Errorf("Result %d: expected %s/%s, got %+v", i, w.name, w.action, results[i])
+		}
+	}
+	if len(merged) != 6 {
+		t.Fatalf("Expected 6 endpoints after import, got %d", len(merged))
+	}
+	// 覆盖保留原优先级并沿用本地凭据
+	if merged[0].URL != "https://a2.example.com" || merged[0].Priority != 1 || merged[0].AuthValue != "sk-a" || merged[0].Tags != nil || merged[0].ParameterOverrides["api_key"] != "sk-param" {
+		t.Errorf("Unexpected overwritten endpoint: %+v", merged[0])
+	}
+	if merged[3].Name != "b (1)" || merged[3].Priority != 4 || merged[4].Priority != 5 || merged[5].AuthValue != "sk-e" {
+		t.Errorf("Unexpected appended endpoints: %+v", merged[3:])
+	}
+	if c := merged[2]; results[4].Action != "overwritten" || c.OAuthConfig.AccessToken != "at" || c.OAuthConfig.RefreshToken != "rt" || c.Proxy.Username != "u" || c.Proxy.Password != "p" {
+		t.Errorf("Expected stripped oauth tokens and proxy auth to be inherited, got %+v %+v", c.OAuthConfig, c.Proxy)
+	}
+	if len(merged[4].Credentials) != 1 || results[2].DroppedCredentials != 1 {
+		t.Errorf("Expected empty pool entries to be dropped, got %+v", merged[4].Credentials)
+	}
+	if existing[0].URL != "https://a.example.com" || len(existing) != 3 {
+		t.Error("Expected import planning not to modify the current config")
+	}
+
+	request.Conflict = importConflictSkip
+	request.Resolutions = nil
+	merged, results, _ = planEndpointImport(existing, imported, request)
+	if results[0].Action != "skipped" || results[1].Action != "skipped" || results[4].Action != "skipped" || len(merged) != 5 {
+		t.Errorf("Expected conflicts to be skipped, got %+v", results)
+	}
+
+	request.Conflict = "merge"
+	if _, _, err := planEndpointImport(existing, imported, request); err == nil {
+		t.Error("Expected an invalid conflict resolution to be rejected")
+	}
+}
+
+func TestApplyBulkEndpointAction(t *testing.T) {
+	endpoints := bundleTestEndpoints()
+
+	updated, err := applyBulkEndpointAction(endpoints, endpointBulkRequest{Names: []string{"a", "c"}, Action: "add_tags", Tags: []string{"fast", "team"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(updated[0].Tags, ",") != "fast,team" || strings.Join(updated[2].Tags, ",") != "fast,team" || updated[1].Tags != nil {
+		t.Errorf("Unexpected tags after add: %v %v %v", updated[0].Tags, updated[1].Tags, updated[2].Tags)
+	}
+	if strings.Join(endpoints[0].Tags, ",") != "fast" {
+		t.Error("Expected bulk action not to modify the current config")
+	}
+
+	updated, _ = applyBulkEndpointAction(updated, endpointBulkRequest{Names: []string{"a"}, Action: "remove_tags", Tags: []string{"fast"}})
+	if strings.Join(updated[0].Tags, ",") != "team" {
+		t.Errorf("Unexpected tags after remove: %v", updated[0].Tags)
+	}
+
+	updated, _ = applyBulkEndpointAction(endpoints, endpointBulkRequest{Names: []string{"c"}, Action: "enable"})
+	if !updated[2].Enabled || updated[2].DisabledReason != "" {
+		t.Errorf("Expected endpoint to be enabled and reason cleared, got %+v", updated[2])
+	}
+
+	updated, _ = applyBulkEndpointAction(endpoints, endpointBulkRequest{Names: []string{"a", "b"}, Action: "delete"})
+	if len(updated) != 1 || updated[0].Name != "c" || updated[0].Priority != 1 {
+		t.Errorf("Unexpected endpoints after delete: %+v", updated)
+	}
+
+	if _, err := applyBulkEndpointAction(endpoints, endpointBulkRequest{Names: []string{"missing"}, Action: "disable"}); err == nil {
+		t.Error("Expected unknown endpoint to be rejected")
+	}
+	if _, err := applyBulkEndpointAction(endpoints, endpointBulkRequest{Names: []string{"a"}, Action: "add_tags"}); err == nil {
+		t.Error("Expected tag action without tags to be rejected")
+	}
+}
@@ -16,7 +16,9 @@ import (
 func (s *AdminServer) handleGetEndpoints(c *gin.Context) {
 	endpoints := s.endpointManager.GetAllEndpoints()
 	c.JSON(http.StatusOK, gin.H{
-		"endpoints": endpoints,
+		"endpoints":        endpoints,
+		"connection_stats": s.endpointManager.ConnectionStats(),
+		"credential_stats": s.endpointManager.CredentialStats(),
 	})
 }
 
@@ -36,7 +38,7 @@ func (s *AdminServer) handleUpdateEndpoints(c *gin.Context) {
 	newConfig.Endpoints = request.Endpoints
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(request.Endpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, request.Endpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update endpoints: " + err.Error(),
 		})
@@ -66,6 +68,11 @@ func (s *AdminServer) handleCreateEndpoint(c *gin.Context) {
 		OAuthConfig       *config.OAuthConfig  `json:"oauth_config,omitempty"` // 新增：OAuth配置
 		HeaderOverrides     map[string]string    `json:"header_overrides,omitempty"`   // 新增：HTTP Header覆盖配置
 		ParameterOverrides  map[string]string    `json:"parameter_overrides,omitempty"` // 新增：Request Parameter覆盖配置
+		MaxContextTokens    int                  `json:"max_context_tokens"`            // 最大上下文长度（token），0表示不限制
+		DisableBodyLogging  bool                 `json:"disable_body_logging"`          // 不记录请求/响应体
+		HealthCheck         *config.EndpointHealthCheckConfig `json:"health_check,omitempty"` // 健康检查探测配置
+		Credentials         []config.EndpointCredentialConfig `json:"credentials,omitempty"`  // 凭据池
+		CredentialStrategy  string               `json:"credential_strategy,omitempty"` // 凭据轮换策略
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -121,13 +128,29 @@ func (s *AdminServer) handleCreateEndpoint(c *gin.Context) {
 			return
 		}
 	} else {
-		// 非 OAuth 认证需要 auth_value
-		if request.AuthValue == "" {
+		// 非 OAuth 认证需要 auth_value 或凭据池
+		if request.AuthValue == "" && len(request.Credentials) == 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "auth_value is required for non-oauth authentication"})
 			return
 		}
 	}
 
+	if !validateCredentialsRequest(c, request.Credentials, request.CredentialStrategy, request.AuthType) {
+		return
+	}
+
+	if request.MaxContextTokens < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_context_tokens cannot be negative"})
+		return
+	}
+
+	if request.HealthCheck != nil {
+		if err := config.ValidateEndpointHealthCheck(request.HealthCheck, request.EndpointType); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid health check config: " + err.Error()})
+			return
+		}
+	}
+
 	// 验证代理配置（如果提供）
 	if request.Proxy != nil {
 		if err := config.ValidateProxyConfig(request.Proxy, fmt.Sprintf("endpoint '%s'", request.Name)); err != nil {
@@ -154,10 +177,15 @@ func (s *AdminServer) handleCreateEndpoint(c *gin.Context) {
 		request.Name, request.URL, request.EndpointType, request.PathPrefix,
 		request.AuthType, request.AuthValue, 
 		request.Enabled, maxPriority+1, request.Tags, request.Proxy, request.OAuthConfig, request.HeaderOverrides, request.ParameterOverrides)
+	newEndpoint.MaxContextTokens = request.MaxContextTokens
+	newEndpoint.DisableBodyLogging = request.DisableBodyLogging
+	newEndpoint.HealthCheck = request.HealthCheck
+	newEndpoint.Credentials = request.Credentials
+	newEndpoint.CredentialStrategy = request.CredentialStrategy
 	currentEndpoints = append(currentEndpoints, newEndpoint)
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(currentEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, currentEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create endpoint: " + err.Error(),
 		})
@@ -192,6 +220,11 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 		OAuthConfig       *config.OAuthConfig  `json:"oauth_config,omitempty"` // 新增：OAuth配置
 		HeaderOverrides     map[string]string    `json:"header_overrides,omitempty"`   // 新增：HTTP Header覆盖配置
 		ParameterOverrides  map[string]string    `json:"parameter_overrides,omitempty"` // 新增：Request Parameter覆盖配置
+		MaxContextTokens    int                  `json:"max_context_tokens"`            // 最大上下文长度（token），0表示不限制
+		DisableBodyLogging  bool                 `json:"disable_body_logging"`          // 不记录请求/响应体
+		HealthCheck         *config.EndpointHealthCheckConfig `json:"health_check,omitempty"` // 健康检查探测配置
+		Credentials         []config.EndpointCredentialConfig `json:"credentials,omitempty"`  // 凭据池
+		CredentialStrategy  string               `json:"credential_strategy,omitempty"` // 凭据轮换策略
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -235,6 +268,18 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 		}
 	}
 
+	if request.MaxContextTokens < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_context_tokens cannot be negative"})
+		return
+	}
+
+	if request.HealthCheck != nil {
+		if err := config.ValidateEndpointHealthCheck(request.HealthCheck, request.EndpointType); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid health check config: " + err.Error()})
+			return
+		}
+	}
+
 	// 验证代理配置（如果提供）
 	if request.Proxy != nil {
 		if err := config.ValidateProxyConfig(request.Proxy, fmt.Sprintf("endpoint '%s'", endpointName)); err != nil {
@@ -247,6 +292,17 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 	currentEndpoints := s.config.Endpoints
 	found := false
 
+	// 凭据池使用请求中的认证方式，请求没有指定时使用端点当前的认证方式
+	authType := request.AuthType
+	for _, ep := range currentEndpoints {
+		if ep.Name == endpointName && authType == "" {
+			authType = ep.AuthType
+		}
+	}
+	if !validateCredentialsRequest(c, request.Credentials, request.CredentialStrategy, authType) {
+		return
+	}
+
 	for i, ep := range currentEndpoints {
 		if ep.Name == endpointName {
 			// 更新端点，保持原有优先级
@@ -308,6 +364,9 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 				currentEndpoints[i].AuthType = request.AuthType
 			}
 			currentEndpoints[i].Enabled = request.Enabled
+			if request.Enabled {
+				currentEndpoints[i].DisabledReason = ""
+			}
 			
 			// 更新tags字段
 			currentEndpoints[i].Tags = request.Tags
@@ -322,6 +381,19 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 			// 更新Request Parameter覆盖配置
 			currentEndpoints[i].ParameterOverrides = request.ParameterOverrides
 			
+			// 更新最大上下文长度
+			currentEndpoints[i].MaxContextTokens = request.MaxContextTokens
+			
+			// 更新正文记录开关
+			currentEndpoints[i].DisableBodyLogging = request.DisableBodyLogging
+			
+			// 更新健康检查探测配置
+			currentEndpoints[i].HealthCheck = request.HealthCheck
+			
+			// 更新凭据池
+			currentEndpoints[i].Credentials = request.Credentials
+			currentEndpoints[i].CredentialStrategy = request.CredentialStrategy
+			
 			found = true
 			break
 		}
@@ -333,7 +405,7 @@ func (s *AdminServer) handleUpdateEndpoint(c *gin.Context) {
 	}
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(currentEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, currentEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update endpoint: " + err.Error(),
 		})
@@ -376,7 +448,7 @@ func (s *AdminServer) handleDeleteEndpoint(c *gin.Context) {
 	}
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(newEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, newEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete endpoint: " + err.Error(),
 		})
@@ -384,4 +456,19 @@ func (s *AdminServer) handleDeleteEndpoint(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Endpoint deleted successfully"})
-}
\ No newline at end of file
+}
+
+// validateCredentialsRequest 验证请求中的凭据池配置，验证失败时写入错误响应并返回 false
+func validateCredentialsRequest(c *gin.Context, credentials []config.EndpointCredentialConfig, strategy, authType string) bool {
+	for _, cred := range credentials {
+		if err := security.ValidateAuthToken(cred.Value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TCtx(c, "auth_token_validation_failed", "认证令牌验证失败: ") + cred.Name + ": " + err.Error()})
+			return false
+		}
+	}
+	if err := config.ValidateEndpointCredentials(credentials, strategy, authType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credentials: " + err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetEndpointAvailability 返回所有端点的 1h/24h/7d 可用率、MTTR 和最近24小时的可用率时间条
+func (s *AdminServer) handleGetEndpointAvailability(c *gin.Context) {
+	availability, err := s.endpointManager.Availability()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute availability: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"availability": availability})
+}
+
+// handleGetEndpointPerformance 返回各端点近期真实请求的首字节时间、耗时和输出吞吐量，以及是否被判定为降级
+func (s *AdminServer) handleGetEndpointPerformance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"performance": s.endpointManager.PerformanceOverview()})
+}
+
+// handleGetEndpointHealthHistory 返回端点最近的健康探测和状态切换记录
+func (s *AdminServer) handleGetEndpointHealthHistory(c *gin.Context) {
+	endpointName, err := url.PathUnescape(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endpoint name encoding"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 0 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 0 and 1000"})
+		return
+	}
+
+	events, err := s.endpointManager.HealthHistory(endpointName, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"endpoint": endpointName, "events": events})
+}
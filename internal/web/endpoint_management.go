@@ -36,6 +36,9 @@ func (s *AdminServer) handleToggleEndpoint(c *gin.Context) {
 		if ep.Name == endpointName {
 			// 更新enabled状态
 			currentEndpoints[i].Enabled = request.Enabled
+			if request.Enabled {
+				currentEndpoints[i].DisabledReason = ""
+			}
 			found = true
 			break
 		}
@@ -47,7 +50,7 @@ func (s *AdminServer) handleToggleEndpoint(c *gin.Context) {
 	}
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(currentEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, currentEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to toggle endpoint: " + err.Error(),
 		})
@@ -136,11 +139,24 @@ func (s *AdminServer) handleCopyEndpoint(c *gin.Context) {
 		}
 	}
 
+	// 深度复制健康检查探测配置
+	if sourceEndpoint.HealthCheck != nil {
+		healthCheck := *sourceEndpoint.HealthCheck
+		healthCheck.ExpectStatus = append([]int(nil), sourceEndpoint.HealthCheck.ExpectStatus...)
+		newEndpoint.HealthCheck = &healthCheck
+	}
+
+	// 深度复制凭据池配置
+	if len(sourceEndpoint.Credentials) > 0 {
+		newEndpoint.Credentials = append([]config.EndpointCredentialConfig(nil), sourceEndpoint.Credentials...)
+		newEndpoint.CredentialStrategy = sourceEndpoint.CredentialStrategy
+	}
+
 	// 添加到端点列表
 	currentEndpoints = append(currentEndpoints, newEndpoint)
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(currentEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, currentEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to copy endpoint: " + err.Error(),
 		})
@@ -189,6 +205,29 @@ func (s *AdminServer) handleResetEndpointStatus(c *gin.Context) {
 	})
 }
 
+// handleResetCredential 解除端点中单个凭据的拉黑和冷却
+func (s *AdminServer) handleResetCredential(c *gin.Context) {
+	endpointName, err := url.PathUnescape(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endpoint name encoding"})
+		return
+	}
+	credentialName, err := url.PathUnescape(c.Param("credential"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential name encoding"})
+		return
+	}
+
+	if err := s.endpointManager.ResetCredential(endpointName, credentialName); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Credential '%s' of endpoint '%s' has been reset", credentialName, endpointName),
+	})
+}
+
 // handleReorderEndpoints 重新排序端点
 func (s *AdminServer) handleReorderEndpoints(c *gin.Context) {
 	var request struct {
@@ -225,7 +264,7 @@ func (s *AdminServer) handleReorderEndpoints(c *gin.Context) {
 	}
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(newEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, newEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to reorder endpoints: " + err.Error(),
 		})
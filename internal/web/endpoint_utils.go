@@ -4,15 +4,17 @@ import (
 	"fmt"
 
 	"claude-code-companion/internal/config"
+
+	"github.com/gin-gonic/gin"
 )
 
 // saveEndpointsToConfig 将端点配置保存到配置文件
-func (s *AdminServer) saveEndpointsToConfig(endpointConfigs []config.EndpointConfig) error {
+func (s *AdminServer) saveEndpointsToConfig(c *gin.Context, endpointConfigs []config.EndpointConfig) error {
 	// 更新配置
 	s.config.Endpoints = endpointConfigs
 	
 	// 保存到文件
-	return config.SaveConfig(s.config, s.configFilePath)
+	return s.saveConfig(c, s.config)
 }
 
 // createEndpointConfigFromRequest 从请求创建端点配置，自动设置优先级
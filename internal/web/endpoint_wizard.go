@@ -123,7 +123,7 @@ func (s *AdminServer) handleCreateEndpointFromWizard(c *gin.Context) {
 	updatedEndpoints := append(currentEndpoints, newEndpoint)
 
 	// 使用热更新机制
-	if err := s.hotUpdateEndpoints(updatedEndpoints); err != nil {
+	if err := s.hotUpdateEndpoints(c, updatedEndpoints); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create endpoint: " + err.Error(),
 		})
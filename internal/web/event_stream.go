@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"claude-code-companion/internal/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventStreamHeartbeat SSE 连接的心跳间隔，避免空闲连接被中间代理断开
+const eventStreamHeartbeat = 15 * time.Second
+
+// SetEventHub 设置请求生命周期事件的来源
+func (s *AdminServer) SetEventHub(hub *events.Hub) {
+	s.eventHub = hub
+}
+
+// handleEventStream 通过 SSE 推送请求生命周期事件
+// 连接建立后先发送 snapshot 事件（正在处理的请求），之后每个事件以其类型作为 SSE 事件名发送
+// 订阅者处理过慢导致事件被丢弃时重新发送 snapshot，客户端据此重建状态
+func (s *AdminServer) handleEventStream(c *gin.Context) {
+	if s.eventHub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is not available"})
+		return
+	}
+
+	states, sub := s.eventHub.Subscribe()
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writeSnapshot := func(states []events.RequestState) error {
+		return writeSSEEvent(c.Writer, 0, "snapshot", gin.H{"in_flight": states})
+	}
+	if err := writeSnapshot(states); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	var dropped uint64
+
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			err = writeSSEEvent(c.Writer, event.ID, string(event.Type), event)
+			if current := sub.Dropped(); err == nil && current != dropped {
+				dropped = current
+				err = writeSnapshot(s.eventHub.InFlight())
+			}
+		case <-heartbeat.C:
+			_, err = io.WriteString(c.Writer, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeSSEEvent 按 SSE 格式写入一个事件，id 为 0 时不写 id 字段
+func writeSSEEvent(w io.Writer, id uint64, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -20,20 +21,32 @@ import (
 func (s *AdminServer) handleLogsPage(c *gin.Context) {
 	// 获取参数
 	pageStr := c.DefaultQuery("page", strconv.Itoa(config.Default.Pagination.DefaultPage))
-	failedOnlyStr := c.DefaultQuery("failed_only", "false")
 	
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < config.Default.Pagination.DefaultPage {
 		page = config.Default.Pagination.DefaultPage
 	}
 	
-	failedOnly, _ := strconv.ParseBool(failedOnlyStr)
-	
 	// 每页记录数使用统一默认值
 	limit := config.Default.Pagination.DefaultLimit
 	offset := (page - 1) * limit
 	
-	logs, total, _ := s.logger.GetLogs(limit, offset, failedOnly)
+	query := parseLogQuery(c)
+	query.Limit = limit
+	query.Offset = offset
+	failedOnly := query.FailedOnly
+	
+	logs, total, _ := s.logger.QueryLogs(query)
+	
+	// 仅失败请求有单独的提示，其余条件是否生效用于展开过滤面板
+	advancedQuery := query
+	advancedQuery.FailedOnly = false
+	
+	// 表单回填使用原始参数值
+	filter := make(map[string]string, len(logFilterParams))
+	for _, key := range logFilterParams {
+		filter[key] = c.Query(key)
+	}
 	
 	// 计算分页信息
 	totalPages := (total + limit - 1) / limit
@@ -73,6 +86,9 @@ func (s *AdminServer) handleLogsPage(c *gin.Context) {
 		"PrevPage":    page - 1,
 		"NextPage":    page + 1,
 		"Limit":       limit,
+		"Filter":      filter,
+		"Filtered":    advancedQuery.IsFiltered(),
+		"FilterQuery": template.URL(logFilterQueryString(c)),
 	})
 	s.renderHTML(c, "logs.html", data)
 }
@@ -80,12 +96,10 @@ func (s *AdminServer) handleLogsPage(c *gin.Context) {
 func (s *AdminServer) handleGetLogs(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "100")
 	offsetStr := c.DefaultQuery("offset", "0")
-	failedOnlyStr := c.DefaultQuery("failed_only", "false")
 	requestIDStr := c.DefaultQuery("request_id", "")
 
 	limit, _ := strconv.Atoi(limitStr)
 	offset, _ := strconv.Atoi(offsetStr)
-	failedOnly, _ := strconv.ParseBool(failedOnlyStr)
 
 	if requestIDStr != "" {
 		// 如果指定了request_id，返回该请求的所有尝试记录
@@ -97,7 +111,11 @@ func (s *AdminServer) handleGetLogs(c *gin.Context) {
 		return
 	}
 
-	logs, total, err := s.logger.GetLogs(limit, offset, failedOnly)
+	query := parseLogQuery(c)
+	query.Limit = limit
+	query.Offset = offset
+
+	logs, total, err := s.logger.QueryLogs(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
 		return
@@ -236,10 +254,13 @@ func (s *AdminServer) generateDebugInfoZip(requestID string, logs []*logger.Requ
 		return nil, err
 	}
 
-	// 为每次尝试创建目录和文件
+	// 为每次尝试创建目录和文件，导出前按当前规则再次脱敏（覆盖启用脱敏之前写入的日志）
+	redactor := s.logger.Redactor()
 	for i, log := range logs {
 		attemptDir := fmt.Sprintf("attempts/attempt_%d/", i+1)
-		if err := s.addLogFilesToZip(zipWriter, attemptDir, log); err != nil {
+		redacted := *log
+		redactor.RedactLog(&redacted)
+		if err := s.addLogFilesToZip(zipWriter, attemptDir, &redacted); err != nil {
 			return nil, err
 		}
 	}
@@ -304,6 +325,7 @@ func (s *AdminServer) generateReadmeContent(requestID string, logs []*logger.Req
 	readme.WriteString("- final_*: Data sent by the proxy to upstream (after transformations)\n\n")
 	
 	readme.WriteString("NOTE: Authentication values in endpoint configurations have been sanitized.\n")
+	readme.WriteString("NOTE: Credentials in headers, bodies and URLs have been replaced with " + logger.RedactedValue + ".\n")
 	
 	return readme.String()
 }
@@ -470,6 +492,13 @@ func (s *AdminServer) addEndpointConfigsToZip(zipWriter *zip.Writer, logs []*log
 			// 清空认证信息
 			sanitizedConfig := *config
 			sanitizedConfig.AuthValue = "[REDACTED]"
+			if len(sanitizedConfig.Credentials) > 0 {
+				// 复制切片后再清空，避免修改原配置
+				sanitizedConfig.Credentials = append(sanitizedConfig.Credentials[:0:0], sanitizedConfig.Credentials...)
+				for i := range sanitizedConfig.Credentials {
+					sanitizedConfig.Credentials[i].Value = "[REDACTED]"
+				}
+			}
 			
 			// 清空OAuth配置中的敏感信息
 			if sanitizedConfig.OAuthConfig != nil {
@@ -480,6 +509,17 @@ func (s *AdminServer) addEndpointConfigsToZip(zipWriter *zip.Writer, logs []*log
 				sanitizedConfig.OAuthConfig = &sanitizedOAuth
 			}
 
+			// Header/参数覆盖中可能包含密钥
+			redactor := s.logger.Redactor()
+			sanitizedConfig.HeaderOverrides = redactor.RedactHeaders(sanitizedConfig.HeaderOverrides)
+			if len(sanitizedConfig.ParameterOverrides) > 0 {
+				sanitizedParams := make(map[string]string, len(sanitizedConfig.ParameterOverrides))
+				for key, value := range sanitizedConfig.ParameterOverrides {
+					sanitizedParams[key] = redactor.RedactString(value)
+				}
+				sanitizedConfig.ParameterOverrides = sanitizedParams
+			}
+
 			// 清空代理配置中的敏感信息
 			if sanitizedConfig.Proxy != nil {
 				sanitizedProxy := *sanitizedConfig.Proxy
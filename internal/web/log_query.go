@@ -0,0 +1,131 @@
+package web
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-code-companion/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logFilterParams 日志页面和 /admin/api/logs 支持的过滤参数
+var logFilterParams = []string{
+	"failed_only", "endpoint", "status", "status_min", "status_max", "model", "original_model",
+	"tags", "session_id", "project", "since", "until", "duration_min", "duration_max",
+	"attempt", "error", "thinking", "q",
+}
+
+// filterTimeLayouts 时间参数支持的格式，无时区的格式按服务器本地时间解析
+var filterTimeLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseLogQuery 从请求参数解析日志过滤条件（不包含分页）
+func parseLogQuery(c *gin.Context) logger.LogQuery {
+	q := logger.LogQuery{
+		Endpoint:      strings.TrimSpace(c.Query("endpoint")),
+		Model:         strings.TrimSpace(c.Query("model")),
+		OriginalModel: strings.TrimSpace(c.Query("original_model")),
+		SessionID:     strings.TrimSpace(c.Query("session_id")),
+		Project:       strings.TrimSpace(c.Query("project")),
+		ErrorContains: strings.TrimSpace(c.Query("error")),
+		Text:          strings.TrimSpace(c.Query("q")),
+	}
+
+	q.FailedOnly, _ = strconv.ParseBool(c.DefaultQuery("failed_only", "false"))
+	q.StatusMin, q.StatusMax = parseStatusRange(c.Query("status"))
+	if v, err := strconv.Atoi(c.Query("status_min")); err == nil && v > 0 {
+		q.StatusMin = v
+	}
+	if v, err := strconv.Atoi(c.Query("status_max")); err == nil && v > 0 {
+		q.StatusMax = v
+	}
+
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			q.Tags = append(q.Tags, tag)
+		}
+	}
+
+	q.Since = parseFilterTime(c.Query("since"))
+	q.Until = parseFilterTime(c.Query("until"))
+
+	if v, err := strconv.ParseInt(c.Query("duration_min"), 10, 64); err == nil && v > 0 {
+		q.DurationMin = v
+	}
+	if v, err := strconv.ParseInt(c.Query("duration_max"), 10, 64); err == nil && v > 0 {
+		q.DurationMax = v
+	}
+	if v, err := strconv.Atoi(c.Query("attempt")); err == nil && v > 0 {
+		q.AttemptNumber = v
+	}
+	if v, err := strconv.ParseBool(c.Query("thinking")); err == nil {
+		q.ThinkingEnabled = &v
+	}
+
+	return q
+}
+
+// parseStatusRange 解析状态码过滤：支持 "502"、"5xx" 和 "400-499" 三种写法
+func parseStatusRange(value string) (int, int) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, 0
+	}
+
+	if len(value) == 3 && strings.HasSuffix(value, "xx") {
+		if class, err := strconv.Atoi(value[:1]); err == nil && class > 0 {
+			return class * 100, class*100 + 99
+		}
+		return 0, 0
+	}
+
+	if lower, upper, found := strings.Cut(value, "-"); found {
+		min, err1 := strconv.Atoi(strings.TrimSpace(lower))
+		max, err2 := strconv.Atoi(strings.TrimSpace(upper))
+		if err1 != nil || err2 != nil {
+			return 0, 0
+		}
+		return min, max
+	}
+
+	if code, err := strconv.Atoi(value); err == nil {
+		return code, code
+	}
+	return 0, 0
+}
+
+// parseFilterTime 解析时间过滤参数，支持 RFC3339 和 datetime-local 格式
+func parseFilterTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t
+	}
+	for _, layout := range filterTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// logFilterQueryString 生成保留当前过滤条件的查询字符串，用于分页链接
+func logFilterQueryString(c *gin.Context) string {
+	values := url.Values{}
+	for _, key := range logFilterParams {
+		if value := c.Query(key); value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,24 @@
+package web
+
+import "testing"
+
+func TestParseStatusRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"502", 502, 502},
+		{"5xx", 500, 599},
+		{"4XX", 400, 499},
+		{"400-499", 400, 499},
+		{"abc", 0, 0},
+	}
+
+	for _, tt := range tests {
+		min, max := parseStatusRange(tt.value)
+		if min != tt.min || max != tt.max {
+			t.Errorf("parseStatusRange(%q) = (%d, %d), expected (%d, %d)", tt.value, min, max, tt.min, tt.max)
+		}
+	}
+}
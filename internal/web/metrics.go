@@ -0,0 +1,82 @@
+package web
+
+import (
+	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/logger"
+	"claude-code-companion/internal/metrics"
+)
+
+// registerMetrics 注册在采集时从端点管理器和日志队列读取的指标
+// 指标名称与 monitoring/ 中的 Grafana 仪表板保持一致
+func (s *AdminServer) registerMetrics(registry *metrics.Registry) {
+	registry.NewGaugeFunc("claude_proxy_endpoints_total", "Total number of configured endpoints", func() float64 {
+		return float64(len(s.endpointManager.GetAllEndpoints()))
+	})
+
+	registry.NewGaugeFunc("claude_proxy_endpoints_active", "Number of active and enabled endpoints", func() float64 {
+		active := 0
+		for _, ep := range s.endpointManager.GetAllEndpoints() {
+			if ep.Status == endpoint.StatusActive && ep.Enabled {
+				active++
+			}
+		}
+		return float64(active)
+	})
+
+	endpointLabels := []string{"endpoint", "type", "url"}
+	forEachEndpoint := func(value func(ep *endpoint.Endpoint) float64) func(emit func(float64, ...string)) {
+		return func(emit func(float64, ...string)) {
+			for _, ep := range s.endpointManager.GetAllEndpoints() {
+				emit(value(ep), ep.Name, ep.EndpointType, ep.URL)
+			}
+		}
+	}
+
+	registry.NewCollectorFunc("claude_proxy_endpoint_requests_total", "Total number of requests processed by endpoint",
+		metrics.KindCounter, endpointLabels, forEachEndpoint(func(ep *endpoint.Endpoint) float64 {
+			return float64(ep.TotalRequests)
+		}))
+
+	registry.NewCollectorFunc("claude_proxy_endpoint_requests_success", "Total number of successful requests by endpoint",
+		metrics.KindCounter, endpointLabels, forEachEndpoint(func(ep *endpoint.Endpoint) float64 {
+			return float64(ep.SuccessRequests)
+		}))
+
+	registry.NewCollectorFunc("claude_proxy_endpoint_status", "Endpoint status (1=active, 0=inactive)",
+		metrics.KindGauge, endpointLabels, forEachEndpoint(func(ep *endpoint.Endpoint) float64 {
+			if ep.Status == endpoint.StatusActive && ep.Enabled {
+				return 1
+			}
+			return 0
+		}))
+
+	registry.NewCollectorFunc("claude_proxy_info", "Proxy information", metrics.KindGauge, []string{"version"},
+		func(emit func(float64, ...string)) {
+			emit(1, s.version)
+		})
+
+	// 日志写入队列指标，同步写入时不输出数值
+	writerStat := func(value func(stats logger.WriterStats) float64) func(emit func(float64, ...string)) {
+		return func(emit func(float64, ...string)) {
+			if stats, ok := s.logger.WriterStats(); ok {
+				emit(value(stats))
+			}
+		}
+	}
+	registry.NewCollectorFunc("claude_proxy_log_queue_depth", "Number of request logs waiting to be written",
+		metrics.KindGauge, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.QueueDepth) }))
+	registry.NewCollectorFunc("claude_proxy_log_queue_capacity", "Capacity of the request log queue",
+		metrics.KindGauge, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.QueueCapacity) }))
+	registry.NewCollectorFunc("claude_proxy_log_queue_bytes", "Bytes of request/response bodies waiting to be written",
+		metrics.KindGauge, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.QueuedBytes) }))
+	registry.NewCollectorFunc("claude_proxy_log_written_total", "Total number of request logs written to storage",
+		metrics.KindCounter, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.Written) }))
+	registry.NewCollectorFunc("claude_proxy_log_bodies_dropped_total", "Total number of request logs whose bodies were dropped because the queue was full",
+		metrics.KindCounter, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.BodiesDropped) }))
+	registry.NewCollectorFunc("claude_proxy_log_backpressure_total", "Total number of times a request waited for the full log queue",
+		metrics.KindCounter, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.Blocked) }))
+	registry.NewCollectorFunc("claude_proxy_log_write_errors_total", "Total number of request logs that failed to be written",
+		metrics.KindCounter, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.WriteErrors) }))
+	registry.NewCollectorFunc("claude_proxy_log_dropped_total", "Total number of request logs dropped because they were submitted after the log writer was closed",
+		metrics.KindCounter, nil, writerStat(func(stats logger.WriterStats) float64 { return float64(stats.Dropped) }))
+}
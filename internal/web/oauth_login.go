@@ -0,0 +1,450 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/endpoint"
+	"claude-code-companion/internal/oauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth 登录会话状态
+const (
+	oauthLoginPending   = "pending"
+	oauthLoginCompleted = "completed"
+	oauthLoginFailed    = "failed"
+	oauthLoginCancelled = "cancelled"
+)
+
+// oauthLoginTimeout 授权码流程等待用户完成授权的最长时间，设备码流程使用授权服务器返回的有效期
+const oauthLoginTimeout = 10 * time.Minute
+
+// oauthLoginSession 管理界面发起的一次 OAuth 授权，会话 ID 同时作为授权码流程的 state
+type oauthLoginSession struct {
+	ID                      string    `json:"id"`
+	Endpoint                string    `json:"endpoint"`
+	Flow                    string    `json:"flow"` // "pkce" | "device"
+	Status                  string    `json:"status"`
+	Error                   string    `json:"error,omitempty"`
+	AuthorizeURL            string    `json:"authorize_url,omitempty"`
+	RedirectURI             string    `json:"redirect_uri,omitempty"`
+	ManualCode              bool      `json:"manual_code,omitempty"` // 回调地址不是本服务，需要手动粘贴授权码
+	UserCode                string    `json:"user_code,omitempty"`
+	VerificationURI         string    `json:"verification_uri,omitempty"`
+	VerificationURIComplete string    `json:"verification_uri_complete,omitempty"`
+	ExpiresAt               time.Time `json:"expires_at"`
+	TokenExpiresAt          int64     `json:"token_expires_at,omitempty"`
+
+	oauthConfig *config.OAuthConfig
+	client      *http.Client
+	pkce        *oauth.PKCE
+	deviceCode  string
+	interval    time.Duration
+	done        chan struct{}
+}
+
+// oauthLoginManager 保存进行中的授权会话，只在内存中
+type oauthLoginManager struct {
+	mutex    sync.Mutex
+	sessions map[string]*oauthLoginSession
+}
+
+func newOAuthLoginManager() *oauthLoginManager {
+	return &oauthLoginManager{sessions: make(map[string]*oauthLoginSession)}
+}
+
+// add 保存新会话，同一端点之前未完成的会话会被取消
+func (m *oauthLoginManager) add(session *oauthLoginSession) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	for id, existing := range m.sessions {
+		if existing.Endpoint == session.Endpoint && existing.Status == oauthLoginPending {
+			existing.Status = oauthLoginCancelled
+			close(existing.done)
+		}
+		if now.After(existing.ExpiresAt.Add(oauthLoginTimeout)) {
+			delete(m.sessions, id)
+		}
+	}
+	m.sessions[session.ID] = session
+}
+
+// snapshot 返回会话的副本，供接口返回
+func (m *oauthLoginManager) snapshot(id string) (oauthLoginSession, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return oauthLoginSession{}, false
+	}
+	if session.Status == oauthLoginPending && time.Now().After(session.ExpiresAt) {
+		session.Status = oauthLoginFailed
+		session.Error = "authorization expired"
+		close(session.done)
+	}
+	return *session, true
+}
+
+// claim 取出等待授权码的会话，防止同一个授权码被重复使用
+func (m *oauthLoginManager) claim(id string) (*oauthLoginSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown or expired authorization session")
+	}
+	if session.Status != oauthLoginPending {
+		return nil, fmt.Errorf("authorization session is %s", session.Status)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		session.Status = oauthLoginFailed
+		session.Error = "authorization expired"
+		close(session.done)
+		return nil, fmt.Errorf("authorization expired")
+	}
+	if session.Flow != "pkce" || session.pkce == nil {
+		return nil, fmt.Errorf("authorization session does not accept an authorization code")
+	}
+	pkce := session.pkce
+	session.pkce = nil
+	return &oauthLoginSession{ID: session.ID, Endpoint: session.Endpoint, RedirectURI: session.RedirectURI,
+		oauthConfig: session.oauthConfig, client: session.client, pkce: pkce}, nil
+}
+
+// finish 结束会话，会话已经被取消时忽略
+func (m *oauthLoginManager) finish(id string, tokenExpiresAt int64, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok || session.Status != oauthLoginPending {
+		return
+	}
+	if err != nil {
+		session.Status = oauthLoginFailed
+		session.Error = err.Error()
+	} else {
+		session.Status = oauthLoginCompleted
+		session.TokenExpiresAt = tokenExpiresAt
+	}
+	close(session.done)
+}
+
+// cancel 取消进行中的会话
+func (m *oauthLoginManager) cancel(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return false
+	}
+	if session.Status == oauthLoginPending {
+		session.Status = oauthLoginCancelled
+		close(session.done)
+	}
+	return true
+}
+
+// SetOAuthTokenCallback 设置授权完成后持久化令牌的回调，与令牌刷新使用同一个回调
+func (s *AdminServer) SetOAuthTokenCallback(callback func(*endpoint.Endpoint, *config.OAuthConfig) error) {
+	s.oauthTokenCallback = callback
+}
+
+func (s *AdminServer) findEndpoint(name string) *endpoint.Endpoint {
+	for _, ep := range s.endpointManager.GetAllEndpoints() {
+		if ep.Name == name {
+			return ep
+		}
+	}
+	return nil
+}
+
+// oauthCallbackURL 根据请求推断管理界面的 OAuth 回调地址
+func oauthCallbackURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/admin/oauth/callback", scheme, c.Request.Host)
+}
+
+// handleStartOAuthLogin 为 OAuth 端点发起授权码（PKCE）或设备码授权
+func (s *AdminServer) handleStartOAuthLogin(c *gin.Context) {
+	endpointName, err := url.PathUnescape(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endpoint name encoding"})
+		return
+	}
+	var request struct {
+		Flow string `json:"flow"` // "pkce" | "device"，为空时优先使用授权码流程
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	ep := s.findEndpoint(endpointName)
+	if ep == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Endpoint not found"})
+		return
+	}
+	if ep.AuthType != "oauth" || ep.OAuthConfig == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Endpoint is not configured for OAuth"})
+		return
+	}
+	oauthConfig := *ep.OAuthConfig
+	oauthConfig.AccessToken, oauthConfig.RefreshToken = "", ""
+
+	flow := request.Flow
+	if flow == "" {
+		flow = "pkce"
+		if oauthConfig.AuthorizeURL == "" {
+			flow = "device"
+		}
+	}
+	if (flow == "pkce" && oauthConfig.AuthorizeURL == "") || (flow == "device" && oauthConfig.DeviceAuthorizationURL == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Endpoint has no %s login URL configured", flow)})
+		return
+	}
+	if flow != "pkce" && flow != "device" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "flow must be 'pkce' or 'device'"})
+		return
+	}
+
+	// 授权请求与代理请求一样经过端点配置的代理
+	client, err := ep.CreateProxyClient(s.config.Timeouts.ToProxyTimeoutConfig())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create HTTP client: " + err.Error()})
+		return
+	}
+	id, err := oauth.RandomString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate state: " + err.Error()})
+		return
+	}
+	session := &oauthLoginSession{
+		ID:          id,
+		Endpoint:    endpointName,
+		Flow:        flow,
+		Status:      oauthLoginPending,
+		oauthConfig: &oauthConfig,
+		client:      client,
+		done:        make(chan struct{}),
+	}
+
+	if flow == "pkce" {
+		session.pkce, err = oauth.NewPKCE()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PKCE verifier: " + err.Error()})
+			return
+		}
+		callbackURL := oauthCallbackURL(c)
+		session.RedirectURI = config.GetStringWithDefault(oauthConfig.RedirectURI, callbackURL)
+		session.ManualCode = session.RedirectURI != callbackURL
+		session.AuthorizeURL, err = oauth.AuthorizationURL(&oauthConfig, session.RedirectURI, id, session.pkce)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		session.ExpiresAt = time.Now().Add(oauthLoginTimeout)
+	} else {
+		auth, err := oauth.StartDeviceAuthorization(&oauthConfig, client)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		session.UserCode = auth.UserCode
+		session.VerificationURI = auth.VerificationURI
+		session.VerificationURIComplete = auth.VerificationURIComplete
+		session.deviceCode = auth.DeviceCode
+		session.interval = time.Duration(auth.Interval) * time.Second
+		session.ExpiresAt = time.Now().Add(oauthLoginTimeout)
+		if auth.ExpiresIn > 0 {
+			session.ExpiresAt = time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+		}
+	}
+
+	s.oauthLogins.add(session)
+	if flow == "device" {
+		go s.pollDeviceAuthorization(session)
+	}
+	s.logger.Info(fmt.Sprintf("Started OAuth %s authorization for endpoint %s", flow, endpointName))
+
+	snapshot, _ := s.oauthLogins.snapshot(id)
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// pollDeviceAuthorization 在后台轮询设备码令牌，直到用户完成授权、会话过期或被取消
+func (s *AdminServer) pollDeviceAuthorization(session *oauthLoginSession) {
+	interval := session.interval
+	for {
+		select {
+		case <-session.done:
+			return
+		case <-time.After(interval):
+		}
+		if time.Now().After(session.ExpiresAt) {
+			s.oauthLogins.finish(session.ID, 0, fmt.Errorf("authorization expired"))
+			return
+		}
+
+		tokens, err := oauth.PollDeviceToken(session.oauthConfig, session.deviceCode, session.client)
+		switch err {
+		case nil:
+			s.oauthLogins.finish(session.ID, tokens.ExpiresAt, s.storeOAuthTokens(session.Endpoint, tokens))
+			return
+		case oauth.ErrAuthorizationPending:
+		case oauth.ErrSlowDown:
+			interval += 5 * time.Second
+		default:
+			s.oauthLogins.finish(session.ID, 0, err)
+			return
+		}
+	}
+}
+
+// storeOAuthTokens 把授权获得的令牌写入端点，并通过令牌刷新回调保存到配置文件
+func (s *AdminServer) storeOAuthTokens(endpointName string, tokens *config.OAuthConfig) error {
+	ep := s.findEndpoint(endpointName)
+	if ep == nil {
+		return fmt.Errorf("endpoint %s no longer exists", endpointName)
+	}
+	if err := ep.SetOAuthTokens(tokens, s.oauthTokenCallback); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to store OAuth tokens for endpoint %s", endpointName), err)
+		return err
+	}
+	s.logger.Info(fmt.Sprintf("OAuth authorization completed for endpoint %s", endpointName))
+	return s.reenableAfterAuthorization(endpointName)
+}
+
+// reenableAfterAuthorization 因刷新令牌被拒绝而自动禁用的端点，重新授权后恢复启用
+// 在授权轮询 goroutine 中执行，在配置锁内修改端点配置的副本，不影响管理接口正在读取的配置
+func (s *AdminServer) reenableAfterAuthorization(endpointName string) error {
+	reenabled := false
+	err := s.hotUpdateEndpointsWith(nil, func(current []config.EndpointConfig) ([]config.EndpointConfig, error) {
+		for i, ep := range current {
+			if ep.Name != endpointName || ep.DisabledReason == "" {
+				continue
+			}
+			current[i].Enabled = true
+			current[i].DisabledReason = ""
+			reenabled = true
+			return current, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to re-enable endpoint %s after authorization", endpointName), err)
+		return err
+	}
+	if reenabled {
+		s.logger.Info(fmt.Sprintf("Endpoint %s re-enabled after authorization", endpointName))
+	}
+	return nil
+}
+
+// exchangeOAuthCode 用授权码换取令牌并结束会话
+func (s *AdminServer) exchangeOAuthCode(sessionID, code string) error {
+	session, err := s.oauthLogins.claim(sessionID)
+	if err != nil {
+		return err
+	}
+	tokens, err := oauth.ExchangeCode(session.oauthConfig, code, sessionID, session.RedirectURI, session.pkce, session.client)
+	if err == nil {
+		err = s.storeOAuthTokens(session.Endpoint, tokens)
+	}
+	var expiresAt int64
+	if tokens != nil {
+		expiresAt = tokens.ExpiresAt
+	}
+	s.oauthLogins.finish(sessionID, expiresAt, err)
+	return err
+}
+
+// handleGetOAuthLogin 查询授权会话状态，管理界面轮询此接口
+func (s *AdminServer) handleGetOAuthLogin(c *gin.Context) {
+	session, ok := s.oauthLogins.snapshot(c.Param("session"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Authorization session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// handleSubmitOAuthCode 提交手动粘贴的授权码，支持 code#state 格式
+func (s *AdminServer) handleSubmitOAuthCode(c *gin.Context) {
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	sessionID := c.Param("session")
+	code := strings.TrimSpace(request.Code)
+	if parts := strings.SplitN(code, "#", 2); len(parts) == 2 {
+		if parts[1] != sessionID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code belongs to a different session"})
+			return
+		}
+		code = parts[0]
+	}
+
+	if err := s.exchangeOAuthCode(sessionID, code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, _ := s.oauthLogins.snapshot(sessionID)
+	c.JSON(http.StatusOK, session)
+}
+
+// handleCancelOAuthLogin 取消授权会话
+func (s *AdminServer) handleCancelOAuthLogin(c *gin.Context) {
+	if !s.oauthLogins.cancel(c.Param("session")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Authorization session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Authorization cancelled"})
+}
+
+// handleOAuthCallback 授权服务器重定向回来的回调地址，用 state 找到会话并换取令牌
+func (s *AdminServer) handleOAuthCallback(c *gin.Context) {
+	state := c.Query("state")
+	if errCode := c.Query("error"); errCode != "" {
+		err := fmt.Errorf("authorization denied: %s %s", errCode, c.Query("error_description"))
+		if _, ok := s.oauthLogins.snapshot(state); ok {
+			s.oauthLogins.finish(state, 0, err)
+		}
+		renderOAuthCallbackPage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.exchangeOAuthCode(state, c.Query("code")); err != nil {
+		renderOAuthCallbackPage(c, http.StatusBadRequest, "Authorization failed: "+err.Error())
+		return
+	}
+	renderOAuthCallbackPage(c, http.StatusOK, "Authorization completed. You can close this window and return to the endpoint list.")
+}
+
+func renderOAuthCallbackPage(c *gin.Context, status int, message string) {
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>OAuth</title></head>
+<body style="font-family: sans-serif; padding: 2rem;"><p>%s</p><p><a href="/admin/endpoints">/admin/endpoints</a></p></body></html>`, html.EscapeString(message))
+	c.Data(status, "text/html; charset=utf-8", []byte(page))
+}
+
+// registerOAuthLoginRoutes 注册 OAuth 授权相关的 API 路由
+func (s *AdminServer) registerOAuthLoginRoutes(api *gin.RouterGroup) {
+	api.POST("/endpoints/:id/oauth/login", s.handleStartOAuthLogin)
+	api.GET("/oauth/login/:session", s.handleGetOAuthLogin)
+	api.POST("/oauth/login/:session/code", s.handleSubmitOAuthCode)
+	api.DELETE("/oauth/login/:session", s.handleCancelOAuthLogin)
+}
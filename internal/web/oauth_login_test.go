@@ -0,0 +1,104 @@
+package web
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/logger"
+)
+
+// recordingHotUpdater 记录热更新收到的配置
+type recordingHotUpdater struct {
+	configs []*config.Config
+}
+
+func (h *recordingHotUpdater) HotUpdateConfig(newConfig *config.Config) error {
+	h.configs = append(h.configs, newConfig)
+	return nil
+}
+
+func newOAuthTestAdminServer(t *testing.T) (*AdminServer, *recordingHotUpdater) {
+	dir := t.TempDir()
+	log, err := logger.NewLogger(logger.LogConfig{Level: "error", LogDirectory: dir})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Host: "127.0.0.1", Port: 8080},
+		Endpoints: []config.EndpointConfig{
+			{Name: "oauth", URL: "https://api.anthropic.com", EndpointType: "anthropic", AuthType: "oauth", Enabled: false, Priority: 1, DisabledReason: "refresh token rejected",
+				OAuthConfig: &config.OAuthConfig{AccessToken: "at", RefreshToken: "rt", ExpiresAt: 1, TokenURL: "https://console.anthropic.com/v1/oauth/token"}},
+		},
+		Logging:       config.LoggingConfig{Level: "error", LogDirectory: dir},
+		ConfigHistory: config.ConfigHistoryConfig{Disabled: true},
+	}
+	updater := &recordingHotUpdater{}
+	return &AdminServer{config: cfg, logger: log, configFilePath: filepath.Join(dir, "config.yaml"), hotUpdateHandler: updater}, updater
+}
+
+func TestReenableAfterAuthorizationUpdatesCopy(t *testing.T) {
+	s, updater := newOAuthTestAdminServer(t)
+	previous := s.config.Endpoints
+
+	if err := s.reenableAfterAuthorization("oauth"); err != nil {
+		t.Fatalf("Failed to re-enable endpoint: %v", err)
+	}
+	if len(updater.configs) != 1 || !s.config.Endpoints[0].Enabled || s.config.Endpoints[0].DisabledReason != "" {
+		t.Fatalf("Expected the endpoint to be re-enabled through a hot update, got %+v", s.config.Endpoints[0])
+	}
+	// 管理接口可能仍在读取旧配置，旧的端点切片不能被原地修改
+	if previous[0].Enabled || previous[0].DisabledReason == "" {
+		t.Error("Expected the previous endpoint slice to stay unchanged")
+	}
+}
+
+func TestHotUpdateEndpointsWithKeepsConcurrentEdits(t *testing.T) {
+	s, _ := newOAuthTestAdminServer(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := s.hotUpdateEndpointsWith(nil, func(current []config.EndpointConfig) ([]config.EndpointConfig, error) {
+				return applyBulkEndpointAction(current, endpointBulkRequest{Names: []string{"oauth"}, Action: "add_tags", Tags: []string{fmt.Sprintf("tag-%d", i)}})
+			})
+			if err != nil {
+				t.Errorf("Failed to update endpoints: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if tags := s.endpointConfigsSnapshot()[0].Tags; len(tags) != 10 {
+		t.Errorf("Expected every concurrent edit to be kept, got tags %v", tags)
+	}
+}
+
+func TestUpdateEndpointConfigSurvivesLaterHotUpdate(t *testing.T) {
+	s, _ := newOAuthTestAdminServer(t)
+
+	// 代理刷新令牌后经管理服务器持久化，之后的热更新基于最新配置，不会回退令牌
+	err := s.UpdateEndpointConfig("oauth", func(cfg *config.EndpointConfig) error {
+		cfg.OAuthConfig = &config.OAuthConfig{AccessToken: "at-2", RefreshToken: "rt-2", ExpiresAt: 2, TokenURL: cfg.OAuthConfig.TokenURL}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to update endpoint config: %v", err)
+	}
+	if err := s.reenableAfterAuthorization("oauth"); err != nil {
+		t.Fatalf("Failed to re-enable endpoint: %v", err)
+	}
+	if got := s.currentConfig().Endpoints[0].OAuthConfig; got.RefreshToken != "rt-2" {
+		t.Errorf("Expected the rotated refresh token to be kept, got %+v", got)
+	}
+
+	if err := s.UpdateEndpointConfig("missing", func(*config.EndpointConfig) error { return nil }); err == nil {
+		t.Error("Expected an error for an unknown endpoint")
+	}
+}
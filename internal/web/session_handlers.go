@@ -0,0 +1,323 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-code-companion/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionPreviewLength 新增消息摘要的最大字符数
+const sessionPreviewLength = 200
+
+// SessionTimelineEntry 会话时间线中的一个请求，同一 request_id 的多次尝试合并为一条
+type SessionTimelineEntry struct {
+	RequestID                string       `json:"request_id"`
+	Timestamp                time.Time    `json:"timestamp"`
+	Model                    string       `json:"model,omitempty"`
+	Endpoint                 string       `json:"endpoint"`  // 最后一次尝试的端点
+	Endpoints                []string     `json:"endpoints"` // 按顺序尝试过的端点
+	Attempts                 int          `json:"attempts"`
+	StatusCode               int          `json:"status_code"`
+	Error                    string       `json:"error,omitempty"`
+	DurationMs               int64        `json:"duration_ms"`
+	FallbackChain            string       `json:"fallback_chain,omitempty"`
+	FallbackLevel            int          `json:"fallback_level"`
+	InputTokens              int          `json:"input_tokens"`
+	OutputTokens             int          `json:"output_tokens"`
+	CacheCreationInputTokens int          `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int          `json:"cache_read_input_tokens"`
+	Diff                     *MessageDiff `json:"diff"`
+}
+
+// MessageDiff 与同一对话上一轮请求相比的消息变化
+type MessageDiff struct {
+	BaseRequestID string           `json:"base_request_id,omitempty"` // 对比的上一轮请求
+	Unavailable   bool             `json:"unavailable"`               // 请求体未记录或无法解析
+	NewThread     bool             `json:"new_thread"`                // 没有找到同一对话的上一轮请求
+	Repeated      bool             `json:"repeated"`                  // 消息与上一轮完全相同
+	MessageCount  int              `json:"message_count"`
+	CommonPrefix  int              `json:"common_prefix"`
+	Removed       int              `json:"removed"`
+	Added         []MessagePreview `json:"added"`
+}
+
+// MessagePreview 消息摘要
+type MessagePreview struct {
+	Role    string `json:"role"`
+	Summary string `json:"summary"`
+}
+
+// sessionTurn 已解析的一轮请求消息
+type sessionTurn struct {
+	requestID string
+	messages  []string // 规范化后的消息JSON
+	raw       []interface{}
+}
+
+// handleSessionsPage 显示会话页面
+func (s *AdminServer) handleSessionsPage(c *gin.Context) {
+	data := s.mergeTemplateData(c, "sessions", map[string]interface{}{
+		"Title":     "Sessions",
+		"SessionID": c.Query("session_id"),
+	})
+	s.renderHTML(c, "sessions.html", data)
+}
+
+// handleGetSessions 获取会话列表，支持按项目过滤
+func (s *AdminServer) handleGetSessions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	project := strings.TrimSpace(c.Query("project"))
+
+	sessions, total, err := s.logger.GetSessions(limit, offset, project)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    total,
+	})
+}
+
+// handleGetTokenStats 按项目汇总 token 用量，过滤参数与日志查询相同
+func (s *AdminServer) handleGetTokenStats(c *gin.Context) {
+	stats, err := s.logger.GetTokenStats(parseLogQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve token stats: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects": stats,
+	})
+}
+
+// handleGetSession 获取会话汇总和请求时间线
+func (s *AdminServer) handleGetSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	logs, err := s.logger.GetLogsBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve session: " + err.Error()})
+		return
+	}
+	if len(logs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":  logger.SummarizeSession(sessionID, logs),
+		"timeline": buildSessionTimeline(logs),
+	})
+}
+
+// buildSessionTimeline 按请求合并日志并计算相邻轮次之间的消息差异，logs 需按时间顺序排列
+func buildSessionTimeline(logs []*logger.RequestLog) []*SessionTimelineEntry {
+	var timeline []*SessionTimelineEntry
+	entries := make(map[string]*SessionTimelineEntry)
+	bodies := make(map[string]string)
+
+	for _, log := range logs {
+		entry, exists := entries[log.RequestID]
+		if !exists {
+			entry = &SessionTimelineEntry{
+				RequestID: log.RequestID,
+				Timestamp: log.Timestamp,
+				Model:     log.Model,
+				Endpoints: []string{},
+			}
+			entries[log.RequestID] = entry
+			timeline = append(timeline, entry)
+		}
+
+		entry.Attempts++
+		entry.Endpoint = log.Endpoint
+		entry.Endpoints = append(entry.Endpoints, log.Endpoint)
+		entry.StatusCode = log.StatusCode
+		entry.Error = log.Error
+		entry.DurationMs += log.DurationMs
+		entry.FallbackChain = log.FallbackChain
+		entry.FallbackLevel = log.FallbackLevel
+		entry.InputTokens += log.InputTokens
+		entry.OutputTokens += log.OutputTokens
+		entry.CacheCreationInputTokens += log.CacheCreationInputTokens
+		entry.CacheReadInputTokens += log.CacheReadInputTokens
+
+		if bodies[log.RequestID] == "" {
+			bodies[log.RequestID] = log.OriginalRequestBody
+			if bodies[log.RequestID] == "" {
+				bodies[log.RequestID] = log.RequestBody
+			}
+		}
+	}
+
+	var turns []*sessionTurn
+	for _, entry := range timeline {
+		turn := parseSessionTurn(entry.RequestID, bodies[entry.RequestID])
+		if turn == nil {
+			entry.Diff = &MessageDiff{Unavailable: true, Added: []MessagePreview{}}
+			continue
+		}
+		entry.Diff = diffSessionTurn(findPreviousTurn(turns, turn), turn)
+		turns = append(turns, turn)
+	}
+
+	return timeline
+}
+
+// parseSessionTurn 解析请求体中的消息列表，请求体缺失、被截断或没有消息时返回nil
+func parseSessionTurn(requestID, body string) *sessionTurn {
+	if body == "" {
+		return nil
+	}
+
+	var request struct {
+		Messages []interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(body), &request); err != nil || len(request.Messages) == 0 {
+		return nil
+	}
+
+	turn := &sessionTurn{requestID: requestID, raw: request.Messages}
+	for _, message := range request.Messages {
+		turn.messages = append(turn.messages, normalizeMessage(message))
+	}
+	return turn
+}
+
+// normalizeMessage 生成用于比较的消息JSON
+// Claude Code 每轮都会把 cache_control 移到最新的消息上，比较时需要忽略
+func normalizeMessage(message interface{}) string {
+	data, err := json.Marshal(stripCacheControl(message))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func stripCacheControl(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key == "cache_control" {
+				continue
+			}
+			result[key] = stripCacheControl(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = stripCacheControl(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// findPreviousTurn 查找同一对话的最近一轮请求（首条消息相同）
+// 同一会话中还有标题生成等辅助请求，它们的首条消息不同，不参与对比
+func findPreviousTurn(turns []*sessionTurn, current *sessionTurn) *sessionTurn {
+	for i := len(turns) - 1; i >= 0; i-- {
+		if turns[i].messages[0] == current.messages[0] {
+			return turns[i]
+		}
+	}
+	return nil
+}
+
+// diffSessionTurn 计算当前轮次相对上一轮新增和移除的消息
+func diffSessionTurn(previous, current *sessionTurn) *MessageDiff {
+	diff := &MessageDiff{
+		MessageCount: len(current.messages),
+		Added:        []MessagePreview{},
+	}
+
+	if previous == nil {
+		diff.NewThread = true
+		for _, message := range current.raw {
+			diff.Added = append(diff.Added, previewMessage(message))
+		}
+		return diff
+	}
+
+	diff.BaseRequestID = previous.requestID
+	prefix := 0
+	for prefix < len(previous.messages) && prefix < len(current.messages) &&
+		previous.messages[prefix] == current.messages[prefix] {
+		prefix++
+	}
+
+	diff.CommonPrefix = prefix
+	diff.Removed = len(previous.messages) - prefix
+	for _, message := range current.raw[prefix:] {
+		diff.Added = append(diff.Added, previewMessage(message))
+	}
+	diff.Repeated = diff.Removed == 0 && len(diff.Added) == 0
+	return diff
+}
+
+// previewMessage 生成消息摘要：文本内容截断显示，工具调用显示工具名
+func previewMessage(message interface{}) MessagePreview {
+	msg, _ := message.(map[string]interface{})
+	role, _ := msg["role"].(string)
+
+	var parts []string
+	switch content := msg["content"].(type) {
+	case string:
+		parts = append(parts, content)
+	case []interface{}:
+		for _, item := range content {
+			block, _ := item.(map[string]interface{})
+			blockType, _ := block["type"].(string)
+			switch blockType {
+			case "text":
+				text, _ := block["text"].(string)
+				parts = append(parts, text)
+			case "tool_use":
+				name, _ := block["name"].(string)
+				parts = append(parts, "[tool_use: "+name+"]")
+			case "tool_result":
+				parts = append(parts, "[tool_result] "+toolResultText(block["content"]))
+			default:
+				parts = append(parts, "["+blockType+"]")
+			}
+		}
+	}
+
+	summary := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	if runes := []rune(summary); len(runes) > sessionPreviewLength {
+		summary = string(runes[:sessionPreviewLength]) + "..."
+	}
+	return MessagePreview{Role: role, Summary: summary}
+}
+
+// toolResultText 提取工具结果中的文本
+func toolResultText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var texts []string
+		for _, item := range v {
+			if block, ok := item.(map[string]interface{}); ok {
+				if text, ok := block["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, " ")
+	}
+	return ""
+}
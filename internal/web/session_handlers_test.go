@@ -0,0 +1,54 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"claude-code-companion/internal/logger"
+)
+
+func TestBuildSessionTimelineDiff(t *testing.T) {
+	now := time.Now()
+	turn1 := `{"messages":[{"role":"user","content":[{"type":"text","text":"fix the bug","cache_control":{"type":"ephemeral"}}]}]}`
+	turn2 := `{"messages":[{"role":"user","content":[{"type":"text","text":"fix the bug"}]},{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}]},{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"file contents","cache_control":{"type":"ephemeral"}}]}]}`
+	title := `{"messages":[{"role":"user","content":"generate a title"}]}`
+
+	logs := []*logger.RequestLog{
+		{RequestID: "r1", Timestamp: now, Endpoint: "ep-a", AttemptNumber: 1, StatusCode: 200, RequestBody: turn1},
+		{RequestID: "r2", Timestamp: now.Add(time.Second), Endpoint: "ep-a", AttemptNumber: 1, StatusCode: 200, RequestBody: title},
+		{RequestID: "r3", Timestamp: now.Add(2 * time.Second), Endpoint: "ep-a", AttemptNumber: 1, StatusCode: 502, RequestBody: turn2},
+		{RequestID: "r3", Timestamp: now.Add(3 * time.Second), Endpoint: "ep-b", AttemptNumber: 2, StatusCode: 200, RequestBody: turn2},
+		{RequestID: "r4", Timestamp: now.Add(4 * time.Second), Endpoint: "ep-b", AttemptNumber: 1, StatusCode: 200, RequestBody: turn2},
+		{RequestID: "r5", Timestamp: now.Add(5 * time.Second), Endpoint: "ep-b", AttemptNumber: 1, StatusCode: 200, RequestBody: `{"messages":[{"role":"user","content":"trunc`},
+	}
+
+	timeline := buildSessionTimeline(logs)
+	if len(timeline) != 5 {
+		t.Fatalf("Expected 5 timeline entries, got %d", len(timeline))
+	}
+
+	if !timeline[0].Diff.NewThread || len(timeline[0].Diff.Added) != 1 {
+		t.Errorf("Expected first turn to start a new thread with 1 message, got %+v", timeline[0].Diff)
+	}
+	if !timeline[1].Diff.NewThread {
+		t.Errorf("Expected title request to be a separate thread, got %+v", timeline[1].Diff)
+	}
+
+	retried := timeline[2]
+	if retried.Attempts != 2 || retried.Endpoint != "ep-b" || len(retried.Endpoints) != 2 {
+		t.Errorf("Expected attempts to be merged, got %+v", retried)
+	}
+	if retried.Diff.BaseRequestID != "r1" || retried.Diff.CommonPrefix != 1 || len(retried.Diff.Added) != 2 {
+		t.Errorf("Expected 2 messages added since r1 (ignoring cache_control), got %+v", retried.Diff)
+	}
+	if retried.Diff.Added[0].Summary != "[tool_use: Read]" || retried.Diff.Added[1].Summary != "[tool_result] file contents" {
+		t.Errorf("Unexpected message previews: %+v", retried.Diff.Added)
+	}
+
+	if !timeline[3].Diff.Repeated {
+		t.Errorf("Expected identical resend to be marked repeated, got %+v", timeline[3].Diff)
+	}
+	if !timeline[4].Diff.Unavailable {
+		t.Errorf("Expected truncated body to be unavailable, got %+v", timeline[4].Diff)
+	}
+}
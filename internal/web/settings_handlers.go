@@ -18,6 +18,21 @@ func deepCopyConfig(src *config.Config) config.Config {
 		Validation:  src.Validation,
 		Timeouts:    src.Timeouts, // 新的TimeoutConfig是值类型，可以直接赋值
 		I18n:        src.I18n,
+		Tracing:     src.Tracing,
+		HTTPClient:  src.HTTPClient,
+		LatencyScoring: src.LatencyScoring,
+		Secrets:     src.Secrets,
+		OAuthRefresh: src.OAuthRefresh,
+		ConfigHistory: src.ConfigHistory,
+	}
+	if src.Secrets.Command != nil {
+		dst.Secrets.Command = append([]string(nil), src.Secrets.Command...)
+	}
+	if src.Tracing.Headers != nil {
+		dst.Tracing.Headers = make(map[string]string, len(src.Tracing.Headers))
+		for k, v := range src.Tracing.Headers {
+			dst.Tracing.Headers[k] = v
+		}
 	}
 	
 	// 深拷贝 Tagging.Taggers slice
@@ -26,6 +41,10 @@ func deepCopyConfig(src *config.Config) config.Config {
 		dst.Tagging.Taggers = make([]config.TaggerConfig, len(src.Tagging.Taggers))
 		copy(dst.Tagging.Taggers, src.Tagging.Taggers)
 	}
+	if src.Tagging.FallbackChains != nil {
+		dst.Tagging.FallbackChains = make([]config.FallbackChainConfig, len(src.Tagging.FallbackChains))
+		copy(dst.Tagging.FallbackChains, src.Tagging.FallbackChains)
+	}
 	
 	// 深拷贝 Endpoints slice
 	dst.Endpoints = make([]config.EndpointConfig, len(src.Endpoints))
@@ -117,6 +136,10 @@ func (s *AdminServer) handleUpdateSettings(c *gin.Context) {
 	newConfig := deepCopyConfig(s.config)
 	newConfig.Server = request.Server
 	newConfig.Logging = request.Logging
+	// 存储后端可能包含数据库凭据，写入队列需要重启生效，脱敏规则只在配置文件中维护，设置页面均不提供修改，保留原配置
+	newConfig.Logging.Storage = s.config.Logging.Storage
+	newConfig.Logging.Writer = s.config.Logging.Writer
+	newConfig.Logging.Redaction = s.config.Logging.Redaction
 	newConfig.Validation = request.Validation
 	newConfig.Timeouts = request.Timeouts
 
@@ -129,7 +152,7 @@ func (s *AdminServer) handleUpdateSettings(c *gin.Context) {
 	}
 
 	// 保存配置到文件
-	if err := config.SaveConfig(&newConfig, s.configFilePath); err != nil {
+	if err := s.saveConfig(c, &newConfig); err != nil {
 		s.logger.Error("Failed to save configuration file", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to save configuration file: " + err.Error(),
@@ -140,6 +163,9 @@ func (s *AdminServer) handleUpdateSettings(c *gin.Context) {
 	// 更新内存中的配置
 	s.config = &newConfig
 
+	// 保留策略无需重启即可生效
+	s.logger.SetRetentionPolicy(newConfig.Logging.Retention)
+
 	s.logger.Info("Settings updated successfully")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Settings updated successfully",
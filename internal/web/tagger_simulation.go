@@ -0,0 +1,262 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/logger"
+	"claude-code-companion/internal/tagging"
+	"claude-code-companion/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimulationSampleRequest 手动粘贴的试运行请求
+type SimulationSampleRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// SimulateTaggerRequest tagger试运行请求结构
+type SimulateTaggerRequest struct {
+	Tagger  TaggerResponse           `json:"tagger"`
+	Limit   int                      `json:"limit"`
+	Request *SimulationSampleRequest `json:"request,omitempty"`
+}
+
+// SimulationEntry 单个请求的试运行结果
+type SimulationEntry struct {
+	RequestID        string     `json:"request_id,omitempty"`
+	Timestamp        *time.Time `json:"timestamp,omitempty"` // 仅来自日志的样本有时间戳，手动样本省略
+	Method           string     `json:"method"`
+	Path             string     `json:"path"`
+	Model            string     `json:"model,omitempty"`
+	Matched          bool       `json:"matched"`
+	Error            string     `json:"error,omitempty"`
+	Tags             []string   `json:"tags"`
+	OriginalTags     []string   `json:"original_tags,omitempty"`
+	EstimatedTokens  int        `json:"estimated_tokens"`
+	SelectedEndpoint string     `json:"selected_endpoint,omitempty"`
+	EndpointError    string     `json:"endpoint_error,omitempty"`
+}
+
+// handleSimulateTagger 使用候选tagger试运行最近的请求日志或手动粘贴的请求，不保存tagger
+func (s *AdminServer) handleSimulateTagger(c *gin.Context) {
+	var req SimulateTaggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	candidate := config.TaggerConfig{
+		Name:        req.Tagger.Name,
+		Type:        req.Tagger.Type,
+		Tag:         req.Tagger.Tag,
+		BuiltinType: req.Tagger.BuiltinType,
+		Enabled:     true, // 试运行时始终启用候选tagger
+		Priority:    req.Tagger.Priority,
+		Stage:       req.Tagger.Stage,
+		DependsOn:   req.Tagger.DependsOn,
+		Config:      req.Tagger.Config,
+	}
+
+	if err := validateTaggerConfig(candidate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tagger configuration: " + err.Error()})
+		return
+	}
+
+	simulator, err := s.taggingManager.NewSimulator(candidate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create tagger: " + err.Error()})
+		return
+	}
+
+	var entries []SimulationEntry
+	if req.Request != nil {
+		httpReq, err := buildSimulationRequest(req.Request.Method, req.Request.Path, req.Request.Headers, req.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sample request: " + err.Error()})
+			return
+		}
+		entry := SimulationEntry{
+			Method: httpReq.Method,
+			Path:   httpReq.URL.Path,
+		}
+		s.runSimulation(simulator, httpReq, &entry)
+		entries = append(entries, entry)
+	} else {
+		limit := req.Limit
+		if limit <= 0 {
+			limit = config.Default.Tagging.SimulationSampleSize
+		}
+		if limit > config.Default.Tagging.SimulationMaxSamples {
+			limit = config.Default.Tagging.SimulationMaxSamples
+		}
+
+		logs, err := s.recentDistinctRequestLogs(limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs: " + err.Error()})
+			return
+		}
+
+		for _, log := range logs {
+			timestamp := log.Timestamp
+			entry := SimulationEntry{
+				RequestID:    log.RequestID,
+				Timestamp:    &timestamp,
+				Method:       log.Method,
+				Path:         log.Path,
+				Model:        log.Model,
+				OriginalTags: log.Tags,
+			}
+
+			httpReq, err := buildSimulationRequestFromLog(log)
+			if err != nil {
+				entry.Error = err.Error()
+				entries = append(entries, entry)
+				continue
+			}
+
+			s.runSimulation(simulator, httpReq, &entry)
+			entries = append(entries, entry)
+		}
+	}
+
+	matchedCount := 0
+	errorCount := 0
+	for _, entry := range entries {
+		if entry.Matched {
+			matchedCount++
+		}
+		if entry.Error != "" {
+			errorCount++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": entries,
+		"summary": gin.H{
+			"total":   len(entries),
+			"matched": matchedCount,
+			"errors":  errorCount,
+		},
+	})
+}
+
+// runSimulation 执行试运行并补充端点选择结果
+func (s *AdminServer) runSimulation(simulator *tagging.Simulator, httpReq *http.Request, entry *SimulationEntry) {
+	if httpReq.Body != nil {
+		body, err := io.ReadAll(httpReq.Body)
+		if err != nil {
+			entry.Error = err.Error()
+			return
+		}
+		httpReq.Body = io.NopCloser(bytes.NewReader(body))
+		entry.EstimatedTokens = utils.EstimateRequestTokens(body)
+		httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), "estimated_tokens", entry.EstimatedTokens))
+	}
+
+	result, err := simulator.Run(httpReq)
+	if err != nil {
+		entry.Error = err.Error()
+		return
+	}
+
+	entry.Matched = result.Matched
+	entry.Tags = result.Tags
+	if entry.Tags == nil {
+		entry.Tags = []string{}
+	}
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+
+	// 与代理的端点选择逻辑保持一致：按tag和估算的上下文长度选择
+	selected, err := s.endpointManager.GetEndpointForContext(result.Tags, entry.EstimatedTokens, entry.Model)
+	if err != nil {
+		entry.EndpointError = err.Error()
+		return
+	}
+	entry.SelectedEndpoint = selected.Name
+}
+
+// recentDistinctRequestLogs 获取最近的日志，每个request_id只保留最新的一条尝试记录
+func (s *AdminServer) recentDistinctRequestLogs(limit int) ([]*logger.RequestLog, error) {
+	// 每个请求可能包含多次尝试记录，多取一些以保证去重后数量足够
+	logs, _, err := s.logger.GetLogs(limit*3, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var distinct []*logger.RequestLog
+	for _, log := range logs {
+		if seen[log.RequestID] {
+			continue
+		}
+		seen[log.RequestID] = true
+		distinct = append(distinct, log)
+		if len(distinct) >= limit {
+			break
+		}
+	}
+
+	return distinct, nil
+}
+
+// buildSimulationRequestFromLog 根据日志记录重建客户端原始请求
+func buildSimulationRequestFromLog(log *logger.RequestLog) (*http.Request, error) {
+	path := log.OriginalRequestURL
+	if path == "" {
+		path = log.Path
+	}
+
+	headers := log.OriginalRequestHeaders
+	if len(headers) == 0 {
+		headers = log.RequestHeaders
+	}
+
+	body := log.OriginalRequestBody
+	if body == "" {
+		body = log.RequestBody
+	}
+
+	return buildSimulationRequest(log.Method, path, headers, body)
+}
+
+// buildSimulationRequest 构造用于试运行的HTTP请求
+func buildSimulationRequest(method, path string, headers map[string]string, body string) (*http.Request, error) {
+	if method == "" {
+		method = http.MethodPost
+	}
+	if path == "" {
+		path = "/v1/messages"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	httpReq, err := http.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	// 未提供Content-Type时，对JSON请求体补充默认值，以便基于请求体的tagger生效
+	trimmed := strings.TrimSpace(body)
+	if httpReq.Header.Get("Content-Type") == "" && strings.HasPrefix(trimmed, "{") {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	return httpReq, nil
+}
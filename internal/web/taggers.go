@@ -17,6 +17,8 @@ type TaggerResponse struct {
 	BuiltinType string                 `json:"builtin_type,omitempty"`
 	Enabled     bool                   `json:"enabled"`
 	Priority    int                    `json:"priority"`
+	Stage       int                    `json:"stage"`
+	DependsOn   []string               `json:"depends_on,omitempty"`
 	Config      map[string]interface{} `json:"config"`
 }
 
@@ -56,6 +58,8 @@ func (s *AdminServer) handleGetTaggers(c *gin.Context) {
 			BuiltinType: taggerConfig.BuiltinType,
 			Enabled:     taggerConfig.Enabled,
 			Priority:    taggerConfig.Priority,
+			Stage:       taggerConfig.Stage,
+			DependsOn:   taggerConfig.DependsOn,
 			Config:      taggerConfig.Config,
 		}
 		taggers = append(taggers, tagger)
@@ -140,6 +144,8 @@ func (s *AdminServer) handleCreateTagger(c *gin.Context) {
 		BuiltinType: req.BuiltinType,
 		Enabled:     req.Enabled,
 		Priority:    req.Priority,
+		Stage:       req.Stage,
+		DependsOn:   req.DependsOn,
 		Config:      req.Config,
 	}
 
@@ -150,7 +156,7 @@ func (s *AdminServer) handleCreateTagger(c *gin.Context) {
 	}
 
 	// 使用公共的配置更新函数
-	err := s.updateConfigWithRollback(
+	err := s.updateConfigWithRollback(c,
 		// 更新函数
 		func() error {
 			s.config.Tagging.Taggers = append(s.config.Tagging.Taggers, newTagger)
@@ -193,7 +199,7 @@ func (s *AdminServer) handleUpdateTagger(c *gin.Context) {
 	var originalConfig config.TaggerConfig
 	
 	// 使用公共的配置更新函数
-	err := s.updateConfigWithRollback(
+	err := s.updateConfigWithRollback(c,
 		// 更新函数
 		func() error {
 			for i, tagger := range s.config.Tagging.Taggers {
@@ -208,6 +214,8 @@ func (s *AdminServer) handleUpdateTagger(c *gin.Context) {
 						BuiltinType: req.BuiltinType,
 						Enabled:     req.Enabled,
 						Priority:    req.Priority,
+						Stage:       req.Stage,
+						DependsOn:   req.DependsOn,
 						Config:      req.Config,
 					}
 					
@@ -266,7 +274,7 @@ func (s *AdminServer) handleDeleteTagger(c *gin.Context) {
 	var deletedIndex int
 	
 	// 使用公共的配置更新函数
-	err := s.updateConfigWithRollback(
+	err := s.updateConfigWithRollback(c,
 		// 更新函数
 		func() error {
 			for i, tagger := range s.config.Tagging.Taggers {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"html/template"
@@ -14,6 +15,8 @@ import (
 	"claude-code-companion/internal/common/httpclient"
 	"claude-code-companion/internal/config"
 	"claude-code-companion/internal/proxy"
+	"claude-code-companion/internal/secrets"
+	"claude-code-companion/internal/tracing"
 	"claude-code-companion/internal/webres"
 )
 
@@ -87,6 +90,10 @@ func main() {
 		os.Exit(0)
 	}
 
+	if flag.NArg() > 0 && flag.Arg(0) == "secret" {
+		os.Exit(runSecretCommand(*configFile, flag.Args()[1:]))
+	}
+
 	// Initialize embedded web assets
 	webres.SetProvider(NewEmbeddedAssetProvider())
 
@@ -99,6 +106,14 @@ func main() {
 		cfg.Server.Port = *port
 	}
 
+	// 解析配置中的凭据引用，引用无法解析时拒绝启动
+	if err := secrets.Configure(cfg.Secrets); err != nil {
+		log.Fatalf("Failed to configure secrets backend: %v", err)
+	}
+	if err := secrets.CheckReferences(cfg); err != nil {
+		log.Fatalf("Failed to resolve secrets: %v", err)
+	}
+
 	// Initialize HTTP clients with configured timeouts
 	if err := initHTTPClientsFromConfig(cfg); err != nil {
 		log.Fatalf("Failed to initialize HTTP clients: %v", err)
@@ -127,6 +142,13 @@ func main() {
 	<-quit
 	fmt.Println("\nShutting down servers...")
 	
+	// Flush pending trace spans before closing the logger
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := tracing.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error flushing trace spans: %v", err)
+	}
+	cancel()
+
 	// Graceful shutdown: close logger and database connections
 	if logger := proxyServer.GetLogger(); logger != nil {
 		if err := logger.Close(); err != nil {
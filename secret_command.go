@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"claude-code-companion/internal/config"
+	"claude-code-companion/internal/secrets"
+)
+
+const secretUsage = `Usage:
+  claude-code-companion -config config.yaml secret set <name>     read the value from stdin and store it
+  claude-code-companion -config config.yaml secret delete <name>
+  claude-code-companion -config config.yaml secret list`
+
+// runSecretCommand 管理配置中 secrets 指定的可写凭据存储（keystore），返回进程退出码
+func runSecretCommand(configFile string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, secretUsage)
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	provider, err := secrets.NewProvider(cfg.Secrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open secrets backend: %v\n", err)
+		return 1
+	}
+	store, ok := provider.(secrets.Writer)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Secrets backend '%s' is not writable, configure secrets.backend: keystore\n", cfg.Secrets.Backend)
+		return 1
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		names, err := store.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list secrets: %v\n", err)
+			return 1
+		}
+		for _, name := range names {
+			fmt.Printf("%s%s\n", config.SecretReferencePrefix, name)
+		}
+	case args[0] == "set" && len(args) == 2:
+		if err := config.ValidateSecretName(args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		value, err := readSecretValue(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read secret value: %v\n", err)
+			return 1
+		}
+		if err := store.Set(args[1], value); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to store secret: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Stored %s%s\n", config.SecretReferencePrefix, args[1])
+	case args[0] == "delete" && len(args) == 2:
+		if err := store.Delete(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to delete secret: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Deleted %s%s\n", config.SecretReferencePrefix, args[1])
+	default:
+		fmt.Fprintln(os.Stderr, secretUsage)
+		return 2
+	}
+	return 0
+}
+
+// readSecretValue 从标准输入读取第一行作为凭据值，避免凭据出现在命令行参数和 shell 历史中
+func readSecretValue(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("empty value")
+	}
+	return value, nil
+}
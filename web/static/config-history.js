@@ -0,0 +1,145 @@
+// Config History JavaScript - 配置修订历史、对比和回滚
+
+let configRevisions = [];
+
+function loadConfigHistory() {
+    apiRequest('/admin/api/config/history')
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                throw new Error(data.error);
+            }
+            configRevisions = data.revisions || [];
+            renderConfigHistory(data);
+        })
+        .catch(error => {
+            console.error('Failed to load config history:', error);
+            showAlert(`${T('config_history_load_failed', '加载配置历史失败')}: ${error.message}`, 'danger');
+        });
+}
+
+function renderConfigHistory(data) {
+    const tbody = document.getElementById('config-history-list');
+    if (configRevisions.length === 0) {
+        tbody.innerHTML = `<tr><td colspan="7" class="text-muted text-center">${T('no_config_revisions', '暂无配置修订')}</td></tr>`;
+    } else {
+        // 默认对比最新的两个修订
+        tbody.innerHTML = configRevisions.map((revision, index) => {
+            const sections = (revision.sections || []).map(section => `<span class="badge bg-secondary">${escapeHtml(section)}</span>`).join(' ');
+            let label = `#${revision.id}`;
+            if (revision.rollback_of) {
+                label += ` <small class="text-muted">${T('rollback_of', '回滚到 #{0}').replace('{0}', revision.rollback_of)}</small>`;
+            }
+            const rollbackButton = index === 0
+                ? `<span class="badge bg-success">${T('current_revision', '当前')}</span>`
+                : `<button type="button" class="btn btn-outline-warning btn-sm" data-action="rollback-config" data-revision="${revision.id}">
+                       <i class="fas fa-undo"></i> ${T('rollback', '回滚')}
+                   </button>`;
+            return `<tr>
+                <td><input class="form-check-input" type="radio" name="config-diff-from" value="${revision.id}" ${index === 1 ? 'checked' : ''}></td>
+                <td><input class="form-check-input" type="radio" name="config-diff-to" value="${revision.id}" ${index === 0 ? 'checked' : ''}></td>
+                <td>${label}</td>
+                <td>${new Date(revision.timestamp).toLocaleString()}</td>
+                <td>${escapeHtml(revision.author)}</td>
+                <td>${sections}</td>
+                <td class="text-end">${rollbackButton}</td>
+            </tr>`;
+        }).join('');
+    }
+
+    const summary = data.disabled
+        ? T('config_history_disabled', '配置历史已关闭（config_history.disabled）')
+        : T('config_history_retention', '保留最近 {0} 个修订').replace('{0}', data.max_revisions);
+    document.getElementById('config-history-summary').textContent = summary;
+    StyleUtils.hide(document.getElementById('config-diff'));
+}
+
+function compareConfigRevisions() {
+    const from = document.querySelector('input[name="config-diff-from"]:checked');
+    const to = document.querySelector('input[name="config-diff-to"]:checked');
+    if (!from || !to) {
+        showAlert(T('select_two_revisions', '请选择要对比的两个修订'), 'warning');
+        return;
+    }
+
+    apiRequest(`/admin/api/config/history/diff?from=${from.value}&to=${to.value}`)
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                throw new Error(data.error);
+            }
+            renderConfigDiff(data);
+        })
+        .catch(error => {
+            console.error('Failed to compare config revisions:', error);
+            showAlert(`${T('compare_revisions_failed', '对比修订失败')}: ${error.message}`, 'danger');
+        });
+}
+
+function renderConfigDiff(data) {
+    let title = `#${data.from.id} <i class="fas fa-arrow-right"></i> #${data.to.id}`;
+    if (data.sections && data.sections.length > 0) {
+        title += ` <small class="text-muted">${data.sections.map(escapeHtml).join(', ')}</small>`;
+    }
+    document.getElementById('config-diff-title').innerHTML = title;
+
+    const changed = data.lines.some(line => line.op === '+' || line.op === '-');
+    const container = document.getElementById('config-diff-lines');
+    if (!changed) {
+        container.innerHTML = `<span class="text-muted">${T('no_differences', '两个修订内容相同')}</span>`;
+    } else {
+        container.innerHTML = data.lines.map(line => {
+            if (line.op === '...') {
+                return `<div class="config-diff-skip">@@ ${T('unchanged_lines', '{0} 行未变化').replace('{0}', line.skipped)} @@</div>`;
+            }
+            const className = line.op === '+' ? 'config-diff-add' : (line.op === '-' ? 'config-diff-remove' : '');
+            return `<div class="${className}">${line.op} ${escapeHtml(line.text)}</div>`;
+        }).join('');
+    }
+    StyleUtils.show(document.getElementById('config-diff'));
+}
+
+function rollbackConfig(revisionId) {
+    if (!confirm(T('confirm_config_rollback', '确定要把配置回滚到修订 #{0} 吗？').replace('{0}', revisionId))) {
+        return;
+    }
+
+    apiRequest(`/admin/api/config/history/${revisionId}/rollback`, { method: 'POST' })
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                throw new Error(data.error);
+            }
+            let message = data.message;
+            if (data.restart_required) {
+                message += ' ' + T('rollback_restart_required', '服务器监听配置需要重启后生效。');
+            }
+            showAlert(message, 'success');
+            // 表单中的设置已经过期，重新加载页面
+            setTimeout(() => window.location.reload(), 1500);
+        })
+        .catch(error => {
+            console.error('Failed to rollback config:', error);
+            showAlert(`${T('rollback_failed', '回滚失败')}: ${error.message}`, 'danger');
+        });
+}
+
+document.addEventListener('click', function(e) {
+    const target = e.target.closest('[data-action]');
+    if (!target) {
+        return;
+    }
+    switch (target.dataset.action) {
+        case 'refresh-config-history':
+            loadConfigHistory();
+            break;
+        case 'compare-config-revisions':
+            compareConfigRevisions();
+            break;
+        case 'rollback-config':
+            rollbackConfig(target.dataset.revision);
+            break;
+    }
+});
+
+document.addEventListener('DOMContentLoaded', loadConfigHistory);
@@ -12,8 +12,158 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     });
     
+    initializeLiveRequests();
+
     // Auto-refresh every 30 seconds
     setInterval(function() {
         location.reload();
     }, 30000);
-});
\ No newline at end of file
+});
+
+// Live requests: in-flight requests pushed over SSE from /admin/api/events/stream
+const liveRequests = new Map();
+let liveRenderTimer = null;
+
+function initializeLiveRequests() {
+    if (!window.EventSource || !document.getElementById('liveRequestsBody')) {
+        return;
+    }
+
+    const source = new EventSource('/admin/api/events/stream');
+    source.onopen = function() {
+        setLiveStreamStatus('bg-success', T('connected', '已连接'));
+    };
+    source.onerror = function() {
+        // EventSource reconnects automatically and receives a fresh snapshot
+        setLiveStreamStatus('bg-secondary', T('connecting', '连接中'));
+    };
+
+    source.addEventListener('snapshot', function(e) {
+        const data = JSON.parse(e.data);
+        liveRequests.clear();
+        (data.in_flight || []).forEach(function(state) {
+            liveRequests.set(state.request_id, state);
+        });
+        scheduleLiveRender();
+    });
+    source.addEventListener('request_received', function(e) {
+        const event = JSON.parse(e.data);
+        liveRequests.set(event.request_id, {
+            request_id: event.request_id,
+            start_time: event.time,
+            method: event.method,
+            path: event.path,
+            model: event.model
+        });
+        scheduleLiveRender();
+    });
+    ['request_tagged', 'attempt_started', 'attempt_failed', 'stream_progress'].forEach(function(type) {
+        source.addEventListener(type, function(e) {
+            const event = JSON.parse(e.data);
+            const state = liveRequests.get(event.request_id);
+            if (!state) {
+                return;
+            }
+            applyLiveEvent(state, type, event);
+            scheduleLiveRender();
+        });
+    });
+    source.addEventListener('request_completed', function(e) {
+        const event = JSON.parse(e.data);
+        liveRequests.delete(event.request_id);
+        scheduleLiveRender();
+    });
+
+    // Keep elapsed time moving between events
+    setInterval(scheduleLiveRender, 1000);
+    window.addEventListener('beforeunload', function() {
+        source.close();
+    });
+}
+
+function applyLiveEvent(state, type, event) {
+    switch (type) {
+        case 'request_tagged':
+            state.tags = event.tags || [];
+            break;
+        case 'attempt_started':
+            state.endpoint = event.endpoint;
+            state.attempt = event.attempt;
+            state.streaming = false;
+            state.bytes = 0;
+            state.input_tokens = 0;
+            state.output_tokens = 0;
+            break;
+        case 'attempt_failed':
+            state.last_error = event.error;
+            break;
+        case 'stream_progress':
+            state.streaming = !!event.streaming;
+            state.bytes = event.bytes || 0;
+            state.input_tokens = event.input_tokens || 0;
+            state.output_tokens = event.output_tokens || 0;
+            break;
+    }
+}
+
+function setLiveStreamStatus(className, text) {
+    const badge = document.getElementById('liveStreamStatus');
+    if (!badge) {
+        return;
+    }
+    badge.className = 'badge ' + className;
+    badge.removeAttribute('data-t');
+    badge.textContent = text;
+}
+
+function scheduleLiveRender() {
+    if (liveRenderTimer) {
+        return;
+    }
+    liveRenderTimer = setTimeout(function() {
+        liveRenderTimer = null;
+        renderLiveRequests();
+    }, 100);
+}
+
+function renderLiveRequests() {
+    const tbody = document.getElementById('liveRequestsBody');
+    if (liveRequests.size === 0) {
+        tbody.innerHTML = `<tr><td colspan="7" class="text-center text-muted">${escapeHtml(T('no_live_requests', '当前没有正在处理的请求'))}</td></tr>`;
+        return;
+    }
+
+    const now = Date.now();
+    const rows = Array.from(liveRequests.values())
+        .sort((a, b) => new Date(a.start_time) - new Date(b.start_time))
+        .map(function(state) {
+            const elapsed = ((now - new Date(state.start_time).getTime()) / 1000).toFixed(1);
+            const tags = (state.tags || []).map(tag => `<span class="badge bg-secondary me-1">${escapeHtml(tag)}</span>`).join('');
+            let endpoint = state.endpoint ? escapeHtml(state.endpoint) : '-';
+            if (state.attempt > 1) {
+                endpoint += ` <span class="badge bg-warning text-dark">${escapeHtml(T('attempt', '尝试'))} ${state.attempt}</span>`;
+            }
+            let status;
+            if (!state.endpoint) {
+                status = `<span class="badge bg-secondary">${escapeHtml(T('live_status_pending', '排队中'))}</span>`;
+            } else if (state.bytes > 0) {
+                status = `<span class="badge bg-info">${escapeHtml(T('live_status_receiving', '接收中'))}</span>`;
+            } else {
+                status = `<span class="badge bg-primary">${escapeHtml(T('live_status_waiting', '等待响应'))}</span>`;
+            }
+            if (state.last_error) {
+                status += ` <i class="fas fa-exclamation-triangle text-warning" title="${escapeHtml(state.last_error)}"></i>`;
+            }
+            const tokens = state.bytes > 0 ? `${state.input_tokens || 0} / ${state.output_tokens || 0}` : '-';
+            return `<tr>
+                <td><code>${escapeHtml(state.request_id)}</code></td>
+                <td>${escapeHtml(state.model || '-')}</td>
+                <td>${tags || '-'}</td>
+                <td>${endpoint}</td>
+                <td>${status}</td>
+                <td>${tokens}</td>
+                <td>${elapsed}s</td>
+            </tr>`;
+        });
+    tbody.innerHTML = rows.join('');
+}
@@ -8,6 +8,12 @@ document.getElementById('proxy-enabled').addEventListener('change', function() {
     this.checked ? StyleUtils.show(proxyConfigDiv) : StyleUtils.hide(proxyConfigDiv);
 });
 
+// Health check probe customization toggle
+document.getElementById('health-check-enabled').addEventListener('change', function() {
+    const healthCheckDiv = document.getElementById('health-check-config');
+    this.checked ? StyleUtils.show(healthCheckDiv) : StyleUtils.hide(healthCheckDiv);
+});
+
 // Model rewrite enable/disable toggle
 document.getElementById('model-rewrite-enabled').addEventListener('change', function() {
     const rulesDiv = document.getElementById('model-rewrite-rules');
@@ -210,6 +216,51 @@ function loadProxyConfig(config) {
     }
 }
 
+// Collect health check probe configuration data
+function collectHealthCheckData() {
+    if (!document.getElementById('health-check-enabled').checked) {
+        return null;
+    }
+
+    const healthCheck = {
+        mode: document.getElementById('health-check-mode').value,
+        probe: document.getElementById('health-check-probe').value,
+        model: document.getElementById('health-check-model').value.trim(),
+        prompt: document.getElementById('health-check-prompt').value.trim(),
+        expect_contains: document.getElementById('health-check-expect-contains').value,
+        interval: document.getElementById('health-check-interval').value.trim(),
+        timeout: document.getElementById('health-check-timeout').value.trim()
+    };
+    const statuses = document.getElementById('health-check-expect-status').value
+        .split(',')
+        .map(status => parseInt(status.trim(), 10))
+        .filter(status => !isNaN(status));
+    if (statuses.length > 0) {
+        healthCheck.expect_status = statuses;
+    }
+
+    return healthCheck;
+}
+
+// Load health check probe configuration to form
+function loadHealthCheckConfig(config) {
+    const checkbox = document.getElementById('health-check-enabled');
+    const configDiv = document.getElementById('health-check-config');
+    config = config || {};
+
+    checkbox.checked = Object.keys(config).length > 0;
+    checkbox.checked ? StyleUtils.show(configDiv) : StyleUtils.hide(configDiv);
+
+    document.getElementById('health-check-mode').value = config.mode || 'active';
+    document.getElementById('health-check-probe').value = config.probe || 'message';
+    document.getElementById('health-check-model').value = config.model || '';
+    document.getElementById('health-check-prompt').value = config.prompt || '';
+    document.getElementById('health-check-expect-status').value = (config.expect_status || []).join(', ');
+    document.getElementById('health-check-expect-contains').value = config.expect_contains || '';
+    document.getElementById('health-check-interval').value = config.interval || '';
+    document.getElementById('health-check-timeout').value = config.timeout || '';
+}
+
 // Collect model rewrite configuration data
 function collectModelRewriteData() {
     const enabled = document.getElementById('model-rewrite-enabled').checked;
@@ -387,6 +438,170 @@ document.addEventListener('DOMContentLoaded', function() {
     }
 });
 
+// ===== Credential Pool Functions =====
+
+// Credential pool enable/disable toggle; the single auth value becomes optional while the pool is enabled
+document.getElementById('credential-pool-enabled').addEventListener('change', function() {
+    const configDiv = document.getElementById('credential-pool-config');
+    this.checked ? StyleUtils.show(configDiv) : StyleUtils.hide(configDiv);
+    if (this.checked && document.getElementById('credential-list').children.length === 0) {
+        addCredentialRow();
+    }
+    updateAuthValueRequirement();
+});
+
+// Credentials use the endpoint auth type, so the pool is hidden for OAuth endpoints
+function updateAuthValueRequirement() {
+    const authType = document.getElementById('endpoint-auth-type').value;
+    const section = document.getElementById('credential-pool-section');
+    const poolEnabled = document.getElementById('credential-pool-enabled').checked;
+    authType === 'oauth' ? StyleUtils.hide(section) : StyleUtils.show(section);
+    document.getElementById('endpoint-auth-value').required = authType !== 'oauth' && !poolEnabled;
+}
+
+// Render runtime state of a saved credential
+function credentialStateBadge(status) {
+    if (!status) {
+        return '';
+    }
+    const classes = {
+        active: 'bg-success',
+        cooldown: 'bg-warning text-dark',
+        blacklisted: 'bg-danger',
+        disabled: 'bg-secondary'
+    };
+    let title = `${T('connection_stats_requests', '请求数')}: ${status.requests}, ${T('success', '成功')}: ${status.successes}, ${T('credential_failures', '失败')}: ${status.failures}`;
+    if (status.last_error) {
+        title += `, ${status.last_error}`;
+    }
+    if (status.cooldown_until) {
+        title += `, ${T('cooldown_until', '冷却至')} ${new Date(status.cooldown_until).toLocaleTimeString()}`;
+    }
+    let html = `<span class="badge ${classes[status.state] || 'bg-secondary'}" title="${escapeHtml(title)}">${escapeHtml(T('credential_state_' + status.state, status.state))} · ${status.requests}</span>`;
+    if (status.state === 'blacklisted' || status.state === 'cooldown') {
+        html += ` <button type="button" class="btn btn-link btn-sm p-0" onclick="resetCredential('${escapeHtml(status.name)}')" title="${escapeHtml(T('reset', '重置'))}"><i class="fas fa-undo"></i></button>`;
+    }
+    return html;
+}
+
+// Add credential row
+function addCredentialRow(credential = {}) {
+    const list = document.getElementById('credential-list');
+    const index = list.children.length + 1;
+    const stats = editingEndpointName ? (currentCredentialStats[editingEndpointName] || []) : [];
+    const status = stats.find(item => item.name === credential.name);
+
+    const row = document.createElement('div');
+    row.className = 'row mb-2 g-2 align-items-center credential-row';
+    row.innerHTML = `
+        <div class="col-2">
+            <input type="text" class="form-control form-control-sm credential-name-input"
+                   placeholder="${escapeHtml(T('name', '名称'))}" value="${escapeHtml(credential.name || 'key-' + index)}">
+        </div>
+        <div class="col-4">
+            <input type="password" class="form-control form-control-sm credential-value-input"
+                   placeholder="${escapeHtml(T('enter_api_key_or_token', '输入您的 API Key 或 Token'))}" value="${escapeHtml(credential.value || '')}">
+        </div>
+        <div class="col-1">
+            <input type="number" min="1" class="form-control form-control-sm credential-weight-input"
+                   title="${escapeHtml(T('credential_weight', '权重'))}" placeholder="1" value="${credential.weight || ''}">
+        </div>
+        <div class="col-1">
+            <input type="text" class="form-control form-control-sm credential-cooldown-input"
+                   title="${escapeHtml(T('credential_cooldown', '冷却时长'))}" placeholder="60s" value="${escapeHtml(credential.cooldown || '')}">
+        </div>
+        <div class="col-1">
+            <div class="form-check">
+                <input class="form-check-input credential-enabled-input" type="checkbox" ${credential.disabled ? '' : 'checked'}
+                       title="${escapeHtml(T('enabled', '已启用'))}">
+            </div>
+        </div>
+        <div class="col-2 small">${credentialStateBadge(status)}</div>
+        <div class="col-1">
+            <button type="button" class="btn btn-outline-danger btn-sm" onclick="this.closest('.credential-row').remove()">
+                <i class="fas fa-trash"></i>
+            </button>
+        </div>
+    `;
+    list.appendChild(row);
+}
+
+// Collect credential pool configuration data
+function collectCredentialData() {
+    if (!document.getElementById('credential-pool-enabled').checked ||
+        document.getElementById('endpoint-auth-type').value === 'oauth') {
+        return { credentials: null, strategy: '' };
+    }
+
+    const credentials = [];
+    document.querySelectorAll('.credential-row').forEach(row => {
+        const name = row.querySelector('.credential-name-input').value.trim();
+        const value = row.querySelector('.credential-value-input').value.trim();
+        if (!name || !value) {
+            return;
+        }
+        const credential = { name: name, value: value };
+        const weight = parseInt(row.querySelector('.credential-weight-input').value, 10);
+        if (weight > 0) {
+            credential.weight = weight;
+        }
+        const cooldown = row.querySelector('.credential-cooldown-input').value.trim();
+        if (cooldown) {
+            credential.cooldown = cooldown;
+        }
+        if (!row.querySelector('.credential-enabled-input').checked) {
+            credential.disabled = true;
+        }
+        credentials.push(credential);
+    });
+
+    const strategy = document.getElementById('credential-strategy').value;
+    return {
+        credentials: credentials.length > 0 ? credentials : null,
+        strategy: strategy === 'round_robin' ? '' : strategy
+    };
+}
+
+// Load credential pool configuration to form
+function loadCredentialConfig(credentials, strategy) {
+    const checkbox = document.getElementById('credential-pool-enabled');
+    const configDiv = document.getElementById('credential-pool-config');
+    const list = document.getElementById('credential-list');
+    list.innerHTML = '';
+
+    checkbox.checked = Array.isArray(credentials) && credentials.length > 0;
+    checkbox.checked ? StyleUtils.show(configDiv) : StyleUtils.hide(configDiv);
+    document.getElementById('credential-strategy').value = strategy || 'round_robin';
+    (credentials || []).forEach(credential => addCredentialRow(credential));
+    updateAuthValueRequirement();
+}
+
+// Clear blacklist or cooldown of a single credential
+function resetCredential(credentialName) {
+    apiRequest(`/admin/api/endpoints/${encodeURIComponent(editingEndpointName)}/credentials/${encodeURIComponent(credentialName)}/reset`, {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            showAlert(data.error, 'danger');
+            return;
+        }
+        showAlert(data.message, 'success');
+        return apiRequest('/admin/api/endpoints')
+            .then(response => response.json())
+            .then(result => {
+                // Re-render rows from the form so unsaved edits are kept
+                currentCredentialStats = result.credential_stats || {};
+                loadCredentialConfig(collectCredentialData().credentials, document.getElementById('credential-strategy').value);
+            });
+    })
+    .catch(error => {
+        console.error('Failed to reset credential:', error);
+        showAlert(T('reset_credential_failed', '重置凭据失败'), 'danger');
+    });
+}
+
 // ===== HTTP Header Override Functions =====
 
 // Header override enable/disable toggle
@@ -558,6 +773,14 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
     
+    // Add credential button event listener
+    const addCredentialBtn = document.querySelector('[data-action="add-credential"]');
+    if (addCredentialBtn) {
+        addCredentialBtn.addEventListener('click', function() {
+            addCredentialRow();
+        });
+    }
+    
     // Add header override rule button event listener
     const addHeaderRuleBtn = document.querySelector('[data-action="add-header-override-rule"]');
     if (addHeaderRuleBtn) {
@@ -0,0 +1,277 @@
+// Endpoints Bundle JavaScript - 端点批量操作、导出和导入
+
+let endpointBulkModal = null;
+let endpointImportModal = null;
+let importPreviewResults = null;
+
+function showEndpointBulkModal() {
+    if (!endpointBulkModal) {
+        endpointBulkModal = new bootstrap.Modal(document.getElementById('endpointBulkModal'));
+    }
+    const list = document.getElementById('bulk-endpoint-list');
+    list.innerHTML = currentEndpoints.map((endpoint, index) => {
+        const enabledBadge = endpoint.enabled
+            ? `<span class="badge bg-success">${T('enabled', '已启用')}</span>`
+            : `<span class="badge bg-secondary">${T('disabled', '已禁用')}</span>`;
+        const tags = (endpoint.tags || []).map(tag => `<span class="badge bg-info text-dark">${escapeHtml(tag)}</span>`).join(' ');
+        return `<div class="form-check">
+            <input class="form-check-input bulk-endpoint-checkbox" type="checkbox" id="bulk-endpoint-${index}" value="${escapeHtml(endpoint.name)}">
+            <label class="form-check-label" for="bulk-endpoint-${index}">${escapeHtml(endpoint.name)} ${enabledBadge} ${tags}</label>
+        </div>`;
+    }).join('');
+    document.getElementById('bulk-select-all').checked = false;
+    document.getElementById('bulk-tags').value = '';
+    endpointBulkModal.show();
+}
+
+function getSelectedBulkEndpoints() {
+    return Array.from(document.querySelectorAll('.bulk-endpoint-checkbox:checked')).map(checkbox => checkbox.value);
+}
+
+function runBulkEndpointAction(action) {
+    const names = getSelectedBulkEndpoints();
+    if (names.length === 0) {
+        showAlert(T('no_endpoints_selected', '请先选择端点'), 'warning');
+        return;
+    }
+    const tags = document.getElementById('bulk-tags').value.split(',').map(tag => tag.trim()).filter(tag => tag);
+    if ((action === 'add_tags' || action === 'remove_tags') && tags.length === 0) {
+        showAlert(T('bulk_tags_required', '请输入标签'), 'warning');
+        return;
+    }
+    if (action === 'delete' && !confirm(T('confirm_bulk_delete', '确定要删除选中的 {0} 个端点吗？').replace('{0}', names.length))) {
+        return;
+    }
+
+    apiRequest('/admin/api/endpoints/bulk', {
+        method: 'POST',
+        body: JSON.stringify({ names: names, action: action, tags: tags })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            showAlert(data.error, 'danger');
+            return;
+        }
+        showAlert(data.message, 'success');
+        endpointBulkModal.hide();
+        loadEndpoints();
+    })
+    .catch(error => {
+        console.error('Failed to run bulk action:', error);
+        showAlert(T('bulk_action_failed', '批量操作失败'), 'danger');
+    });
+}
+
+function exportEndpoints() {
+    const params = new URLSearchParams();
+    getSelectedBulkEndpoints().forEach(name => params.append('name', name));
+    params.set('format', document.getElementById('export-format').value);
+    params.set('strip_credentials', document.getElementById('export-strip-credentials').checked ? 'true' : 'false');
+
+    apiRequest(`/admin/api/endpoints/export?${params.toString()}`)
+        .then(async response => {
+            if (!response.ok) {
+                const data = await response.json().catch(() => ({}));
+                throw new Error(data.error || response.statusText);
+            }
+            const disposition = response.headers.get('Content-Disposition') || '';
+            const match = disposition.match(/filename="([^"]+)"/);
+            const blob = await response.blob();
+            const link = document.createElement('a');
+            link.href = URL.createObjectURL(blob);
+            link.download = match ? match[1] : `endpoints.${params.get('format')}`;
+            document.body.appendChild(link);
+            link.click();
+            link.remove();
+            URL.revokeObjectURL(link.href);
+        })
+        .catch(error => {
+            console.error('Failed to export endpoints:', error);
+            showAlert(`${T('export_failed', '导出失败')}: ${error.message}`, 'danger');
+        });
+}
+
+function showEndpointImportModal() {
+    if (!endpointImportModal) {
+        endpointImportModal = new bootstrap.Modal(document.getElementById('endpointImportModal'));
+    }
+    document.getElementById('import-file').value = '';
+    document.getElementById('import-content').value = '';
+    document.getElementById('import-conflict').value = 'rename';
+    resetImportPreview();
+    endpointImportModal.show();
+}
+
+function resetImportPreview() {
+    importPreviewResults = null;
+    document.getElementById('import-preview-list').innerHTML = '';
+    StyleUtils.hide(document.getElementById('import-preview'));
+    document.getElementById('import-submit').disabled = true;
+    setImportStatus('', '');
+}
+
+function setImportStatus(message, className) {
+    const status = document.getElementById('import-status');
+    status.className = `small ${className}`;
+    status.textContent = message;
+}
+
+// 收集预览表格中每个同名端点的处理方式和补充的凭据
+function buildImportRequest(dryRun) {
+    const request = {
+        content: document.getElementById('import-content').value,
+        conflict: document.getElementById('import-conflict').value,
+        resolutions: {},
+        auth_values: {},
+        dry_run: dryRun
+    };
+    document.querySelectorAll('.import-resolution').forEach(select => {
+        request.resolutions[select.dataset.endpoint] = select.value;
+    });
+    document.querySelectorAll('.import-auth-value').forEach(input => {
+        if (input.value.trim()) {
+            request.auth_values[input.dataset.endpoint] = input.value.trim();
+        }
+    });
+    return request;
+}
+
+// keepResolutions 为 false 时按默认处理方式重新计算所有同名端点
+function previewImport(keepResolutions) {
+    const request = buildImportRequest(true);
+    if (!keepResolutions) {
+        request.resolutions = {};
+    }
+    apiRequest('/admin/api/endpoints/import', {
+        method: 'POST',
+        body: JSON.stringify(request)
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            setImportStatus(data.error, 'text-danger');
+            return;
+        }
+        renderImportPreview(data.results);
+    })
+    .catch(error => {
+        console.error('Failed to preview import:', error);
+        setImportStatus(T('import_failed', '导入失败'), 'text-danger');
+    });
+}
+
+function renderImportPreview(results) {
+    const previous = buildImportRequest(true);
+    importPreviewResults = results;
+    const actionLabels = {
+        created: T('import_action_created', '新建'),
+        renamed: T('import_action_renamed', '重命名'),
+        overwritten: T('import_action_overwritten', '覆盖'),
+        skipped: T('import_action_skipped', '跳过')
+    };
+    document.getElementById('import-preview-list').innerHTML = results.map(result => {
+        const original = escapeHtml(result.original_name);
+        let name = escapeHtml(result.name);
+        if (result.name !== result.original_name) {
+            name = `${original} <i class="fas fa-arrow-right"></i> ${name}`;
+        }
+        let conflict = '';
+        if (result.conflict) {
+            const selected = result.action === 'skipped' ? 'skip' : (result.action === 'overwritten' ? 'overwrite' : 'rename');
+            conflict = `<select class="form-select form-select-sm import-resolution" data-endpoint="${original}">
+                ${['rename', 'overwrite', 'skip'].map(value =>
+                    `<option value="${value}" ${value === selected ? 'selected' : ''}>${T('import_conflict_' + value, value)}</option>`).join('')}
+            </select>`;
+        }
+        let credentials = `<span class="text-success"><i class="fas fa-check"></i></span>`;
+        if (result.missing_credentials) {
+            const value = escapeHtml(previous.auth_values[result.original_name] || '');
+            credentials = `<input type="password" class="form-control form-control-sm import-auth-value" data-endpoint="${original}" value="${value}" placeholder="${escapeHtml(T('import_auth_value_placeholder', '填写 API Key / Token'))}">`;
+        } else if (result.action === 'skipped') {
+            credentials = '';
+        }
+        if (result.dropped_credentials) {
+            credentials += ` <small class="text-muted">${T('import_dropped_credentials', '丢弃了 {0} 个空凭据').replace('{0}', result.dropped_credentials)}</small>`;
+        }
+        return `<tr><td>${name}</td><td>${actionLabels[result.action] || result.action}</td><td>${conflict}</td><td>${credentials}</td></tr>`;
+    }).join('');
+    StyleUtils.show(document.getElementById('import-preview'));
+    document.getElementById('import-submit').disabled = false;
+    setImportStatus(T('import_preview_ready', '确认预览结果后点击导入'), 'text-muted');
+}
+
+function submitImport() {
+    apiRequest('/admin/api/endpoints/import', {
+        method: 'POST',
+        body: JSON.stringify(buildImportRequest(false))
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            setImportStatus(data.error, 'text-danger');
+            return;
+        }
+        showAlert(data.message, 'success');
+        endpointImportModal.hide();
+        loadEndpoints();
+    })
+    .catch(error => {
+        console.error('Failed to import endpoints:', error);
+        setImportStatus(T('import_failed', '导入失败'), 'text-danger');
+    });
+}
+
+document.addEventListener('click', function(e) {
+    const target = e.target.closest('[data-action]');
+    if (!target) {
+        return;
+    }
+    switch (target.dataset.action) {
+        case 'show-endpoint-bulk-modal':
+            showEndpointBulkModal();
+            break;
+        case 'show-endpoint-import-modal':
+            showEndpointImportModal();
+            break;
+        case 'bulk-endpoints':
+            runBulkEndpointAction(target.dataset.bulkAction);
+            break;
+        case 'export-endpoints':
+            exportEndpoints();
+            break;
+        case 'preview-import':
+            previewImport(false);
+            break;
+        case 'submit-import':
+            submitImport();
+            break;
+    }
+});
+
+document.addEventListener('change', function(e) {
+    if (e.target.id === 'bulk-select-all') {
+        document.querySelectorAll('.bulk-endpoint-checkbox').forEach(checkbox => {
+            checkbox.checked = e.target.checked;
+        });
+    } else if (e.target.id === 'import-file' && e.target.files.length > 0) {
+        e.target.files[0].text().then(text => {
+            document.getElementById('import-content').value = text;
+            resetImportPreview();
+        });
+    } else if (e.target.id === 'import-conflict') {
+        // 修改默认处理方式后重新预览，清除逐个端点的选择
+        if (importPreviewResults !== null) {
+            previewImport(false);
+        }
+    } else if (e.target.classList.contains('import-resolution')) {
+        // 覆盖时会沿用本地凭据，重新预览以更新凭据列
+        previewImport(true);
+    }
+});
+
+document.addEventListener('input', function(e) {
+    if (e.target.id === 'import-content') {
+        resetImportPreview();
+    }
+});
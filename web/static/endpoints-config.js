@@ -59,6 +59,9 @@ function loadOAuthConfig(oauthConfig) {
         document.getElementById('oauth-client-id').value = '';
         document.getElementById('oauth-scopes').value = '';
         document.getElementById('oauth-auto-refresh').checked = true;
+        document.getElementById('oauth-authorize-url').value = '';
+        document.getElementById('oauth-device-authorization-url').value = '';
+        document.getElementById('oauth-redirect-uri').value = '';
         return;
     }
     
@@ -69,6 +72,9 @@ function loadOAuthConfig(oauthConfig) {
     document.getElementById('oauth-token-url').value = oauthConfig.token_url || '';
     document.getElementById('oauth-client-id').value = oauthConfig.client_id || '';
     document.getElementById('oauth-auto-refresh').checked = oauthConfig.auto_refresh !== false;
+    document.getElementById('oauth-authorize-url').value = oauthConfig.authorize_url || '';
+    document.getElementById('oauth-device-authorization-url').value = oauthConfig.device_authorization_url || '';
+    document.getElementById('oauth-redirect-uri').value = oauthConfig.redirect_uri || '';
     
     // Load scopes
     if (oauthConfig.scopes && Array.isArray(oauthConfig.scopes)) {
@@ -164,10 +170,8 @@ function onAuthTypeChange() {
         StyleUtils.show(oauthConfigGroup);
         authValueInput.required = false;
         
-        // OAuth 必填字段设置为必填
-        document.getElementById('oauth-access-token').required = true;
-        document.getElementById('oauth-refresh-token').required = true;
-        document.getElementById('oauth-expires-at').required = true;
+        // OAuth 必填字段设置为必填，配置了登录地址时令牌可以留空
+        updateOAuthTokenRequirement();
         document.getElementById('oauth-token-url').required = true;
     } else {
         // 显示认证值输入，隐藏 OAuth 配置
@@ -181,8 +185,30 @@ function onAuthTypeChange() {
         document.getElementById('oauth-expires-at').required = false;
         document.getElementById('oauth-token-url').required = false;
     }
+    
+    // 凭据池启用时认证值可以为空，OAuth 端点不支持凭据池
+    if (typeof updateAuthValueRequirement === 'function') {
+        updateAuthValueRequirement();
+    }
+}
+
+// 配置了授权地址或设备码授权地址时令牌可以在端点列表中登录获取
+function updateOAuthTokenRequirement() {
+    const isOAuth = document.getElementById('endpoint-auth-type').value === 'oauth';
+    const canLogin = document.getElementById('oauth-authorize-url').value.trim() !== '' ||
+        document.getElementById('oauth-device-authorization-url').value.trim() !== '';
+    const required = isOAuth && !canLogin;
+    document.getElementById('oauth-access-token').required = required;
+    document.getElementById('oauth-refresh-token').required = required;
+    document.getElementById('oauth-expires-at').required = required;
 }
 
+document.addEventListener('input', function(e) {
+    if (e.target.id === 'oauth-authorize-url' || e.target.id === 'oauth-device-authorization-url') {
+        updateOAuthTokenRequirement();
+    }
+});
+
 // Add event delegation for endpoint modal
 document.addEventListener('click', function(e) {
     const action = e.target.dataset.action || e.target.closest('[data-action]')?.dataset.action;
@@ -1,6 +1,9 @@
 // Endpoints Core JavaScript - 核心功能和数据管理
 
 let currentEndpoints = [];
+let currentConnectionStats = {};
+let currentCredentialStats = {};
+let currentAvailability = {};
 let editingEndpointName = null;
 let endpointModal = null;
 let originalAuthValue = '';
@@ -83,7 +86,10 @@ function loadEndpoints() {
         .then(response => response.json())
         .then(data => {
             currentEndpoints = data.endpoints;
+            currentConnectionStats = data.connection_stats || {};
+            currentCredentialStats = data.credential_stats || {};
             rebuildTable(currentEndpoints);
+            loadAvailability();
         })
         .catch(error => {
             console.error('Failed to load endpoints:', error);
@@ -96,6 +102,7 @@ function refreshEndpointStatus() {
         .then(response => response.json())
         .then(data => {
             // Only update status and statistics, not the full table
+            currentCredentialStats = data.credential_stats || {};
             data.endpoints.forEach(endpoint => {
                 // Try to find in special endpoint list
                 let row = document.querySelector(`#special-endpoint-list tr[data-endpoint-name="${endpoint.name}"]`);
@@ -107,6 +114,7 @@ function refreshEndpointStatus() {
                     updateEndpointRowStatus(row, endpoint);
                 }
             });
+            loadAvailability();
         })
         .catch(error => console.error('Failed to refresh endpoint status:', error));
 }
@@ -129,7 +137,26 @@ function updateEndpointRowStatus(row, endpoint) {
         // 其他状态（如检测中）
         statusBadge = '<span class="badge bg-warning"><i class="fas fa-clock"></i> 检测中</span>';
     }
+    // Keep the uptime bar, it is refreshed separately by loadAvailability
+    const uptimeBar = statusCell.querySelector('.uptime-bar');
     statusCell.innerHTML = statusBadge;
+    if (uptimeBar) {
+        statusCell.appendChild(uptimeBar);
+    }
+}
+
+// Load availability history and render the uptime bar of each endpoint
+function loadAvailability() {
+    apiRequest('/admin/api/endpoints/availability')
+        .then(response => response.json())
+        .then(data => {
+            currentAvailability = {};
+            (data.availability || []).forEach(item => { currentAvailability[item.name] = item; });
+            document.querySelectorAll('.uptime-bar').forEach(container => {
+                renderUptimeBar(container, currentAvailability[container.dataset.uptimeFor]);
+            });
+        })
+        .catch(error => console.error('Failed to load endpoint availability:', error));
 }
 
 function refreshTable() {
@@ -35,6 +35,13 @@ function showAddEndpointModal() {
     
     // Clear max tokens field name configuration
     document.getElementById('max-tokens-field-name').value = '';
+    document.getElementById('max-context-tokens').value = '';
+    document.getElementById('disable-body-logging').checked = false;
+    loadHealthCheckConfig(null);
+    loadCredentialConfig(null, '');
+    
+    // New endpoints have no connection pool yet
+    loadConnectionStats(null);
     
     // Clear enhanced protection configuration
     document.getElementById('enhanced-protection-enabled').checked = false;
@@ -113,6 +120,21 @@ function showEditEndpointModal(endpointName) {
     const maxTokensFieldName = endpoint.max_tokens_field_name || '';
     document.getElementById('max-tokens-field-name').value = maxTokensFieldName;
     
+    // Load max context tokens configuration
+    document.getElementById('max-context-tokens').value = endpoint.max_context_tokens || '';
+    
+    // Load body logging configuration
+    document.getElementById('disable-body-logging').checked = endpoint.disable_body_logging || false;
+    
+    // Load health check probe configuration
+    loadHealthCheckConfig(endpoint.health_check);
+    
+    // Load credential pool configuration
+    loadCredentialConfig(endpoint.credentials, endpoint.credential_strategy);
+    
+    // Show connection reuse statistics
+    loadConnectionStats(currentConnectionStats[endpoint.name]);
+    
     // Load enhanced protection configuration
     const enhancedProtection = endpoint.enhanced_protection || false;
     document.getElementById('enhanced-protection-enabled').checked = enhancedProtection;
@@ -126,6 +148,31 @@ function showEditEndpointModal(endpointName) {
     endpointModal.show();
 }
 
+// Show connection pool statistics of an existing endpoint (read-only)
+function loadConnectionStats(stats) {
+    const section = document.getElementById('connection-stats-section');
+    const content = document.getElementById('connection-stats');
+    if (!section || !content) {
+        return;
+    }
+    if (editingEndpointName === null) {
+        section.style.display = 'none';
+        return;
+    }
+    section.style.display = '';
+    if (!stats) {
+        content.textContent = T('connection_stats_empty', '尚未发出请求');
+        return;
+    }
+    const reuseRate = (stats.reuse_rate * 100).toFixed(1) + '%';
+    content.innerHTML = `
+        <span class="me-3">${escapeHtml(T('connection_stats_requests', '请求数'))}: <strong>${stats.requests}</strong></span>
+        <span class="me-3">${escapeHtml(T('connection_stats_new', '新建连接'))}: <strong>${stats.new_connections}</strong></span>
+        <span class="me-3">${escapeHtml(T('connection_stats_reused', '复用连接'))}: <strong>${stats.reused_connections}</strong> (${reuseRate})</span>
+        <span class="me-3">HTTP/2: <strong>${stats.http2_requests}</strong></span>
+        <span class="text-muted">${escapeHtml(T('connection_stats_since', '统计起始'))}: ${escapeHtml(new Date(stats.created_at).toLocaleString())}</span>`;
+}
+
 // Reset modal tabs to basic configuration
 function resetModalTabs() {
     // Reset tab state
@@ -174,16 +221,22 @@ function saveEndpoint() {
         oauthConfig = {
             access_token: document.getElementById('oauth-access-token').value,
             refresh_token: document.getElementById('oauth-refresh-token').value,
-            expires_at: parseInt(document.getElementById('oauth-expires-at').value),
+            expires_at: parseInt(document.getElementById('oauth-expires-at').value) || 0,
             token_url: document.getElementById('oauth-token-url').value,
             client_id: document.getElementById('oauth-client-id').value || '',
             scopes: scopes,
-            auto_refresh: document.getElementById('oauth-auto-refresh').checked
+            auto_refresh: document.getElementById('oauth-auto-refresh').checked,
+            authorize_url: document.getElementById('oauth-authorize-url').value.trim(),
+            device_authorization_url: document.getElementById('oauth-device-authorization-url').value.trim(),
+            redirect_uri: document.getElementById('oauth-redirect-uri').value.trim()
         };
         
         // Remove empty optional fields
         if (!oauthConfig.client_id) delete oauthConfig.client_id;
         if (oauthConfig.scopes.length === 0) delete oauthConfig.scopes;
+        if (!oauthConfig.authorize_url) delete oauthConfig.authorize_url;
+        if (!oauthConfig.device_authorization_url) delete oauthConfig.device_authorization_url;
+        if (!oauthConfig.redirect_uri) delete oauthConfig.redirect_uri;
     } else {
         // Get regular auth value
         authValue = document.getElementById('endpoint-auth-value').value;
@@ -193,6 +246,8 @@ function saveEndpoint() {
         }
     }
 
+    const credentialData = collectCredentialData();
+
     // Parse tags field
     const tagsInput = document.getElementById('endpoint-tags').value.trim();
     const tags = tagsInput ? tagsInput.split(',').map(tag => tag.trim()).filter(tag => tag) : [];
@@ -207,6 +262,11 @@ function saveEndpoint() {
         enabled: document.getElementById('endpoint-enabled').checked,
         tags: tags,
         max_tokens_field_name: document.getElementById('max-tokens-field-name').value || '', // New: max tokens field name
+        max_context_tokens: parseInt(document.getElementById('max-context-tokens').value, 10) || 0, // New: max context tokens
+        disable_body_logging: document.getElementById('disable-body-logging').checked, // New: never store bodies for this endpoint
+        health_check: collectHealthCheckData(), // New: per-endpoint health check probe
+        credentials: credentialData.credentials, // New: rotated credential pool
+        credential_strategy: credentialData.strategy,
         proxy: collectProxyData(), // New: collect proxy configuration
         header_overrides: collectHeaderOverrideData(), // New: collect header override configuration
         parameter_overrides: collectParameterOverrideData(), // New: collect parameter override configuration
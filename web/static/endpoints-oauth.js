@@ -0,0 +1,194 @@
+// Endpoints OAuth JavaScript - 在管理界面完成 OAuth 授权（授权码 + PKCE / 设备码）
+
+let oauthLoginModal = null;
+let oauthLoginEndpointName = null;
+let oauthLoginSession = null;
+let oauthLoginPollTimer = null;
+
+// 配置了授权地址或设备码授权地址的 OAuth 端点可以在管理界面登录
+function canOAuthLogin(endpoint) {
+    const cfg = endpoint.oauth_config;
+    return endpoint.auth_type === 'oauth' && cfg && (cfg.authorize_url || cfg.device_authorization_url);
+}
+
+function formatOAuthRemaining(ms) {
+    const minutes = Math.floor(ms / 60000);
+    if (minutes < 60) {
+        return `${minutes}m`;
+    }
+    const hours = Math.floor(minutes / 60);
+    if (hours < 48) {
+        return `${hours}h`;
+    }
+    return `${Math.floor(hours / 24)}d`;
+}
+
+// Build the token expiry badge shown next to the oauth auth type
+function buildOAuthExpiryBadge(endpoint) {
+    const cfg = endpoint.oauth_config;
+    if (endpoint.auth_type !== 'oauth' || !cfg) {
+        return '';
+    }
+    if (!cfg.access_token) {
+        return ` <span class="badge bg-warning text-dark"><i class="fas fa-user-lock"></i> ${T('oauth_awaiting_authorization', '待授权')}</span>`;
+    }
+    if (!cfg.expires_at) {
+        return '';
+    }
+    const expiresAt = new Date(cfg.expires_at);
+    const title = escapeHtml(`${T('oauth_token_expires_at', '令牌过期时间')}: ${expiresAt.toLocaleString()}`);
+    const remaining = cfg.expires_at - Date.now();
+    if (remaining <= 0) {
+        return ` <span class="badge bg-danger" title="${title}"><i class="fas fa-clock"></i> ${T('oauth_token_expired', '已过期')}</span>`;
+    }
+    return ` <span class="badge bg-light text-dark" title="${title}"><i class="fas fa-clock"></i> ${formatOAuthRemaining(remaining)}</span>`;
+}
+
+function showOAuthLoginModal(endpointName) {
+    const endpoint = currentEndpoints.find(ep => ep.name === endpointName);
+    if (!endpoint || !canOAuthLogin(endpoint)) {
+        return;
+    }
+    if (!oauthLoginModal) {
+        const modalElement = document.getElementById('oauthLoginModal');
+        oauthLoginModal = new bootstrap.Modal(modalElement);
+        modalElement.addEventListener('hidden.bs.modal', cancelOAuthLogin);
+    }
+
+    oauthLoginEndpointName = endpointName;
+    oauthLoginSession = null;
+    document.getElementById('oauth-login-endpoint').textContent = endpointName;
+    document.getElementById('oauth-login-code').value = '';
+    document.getElementById('oauth-login-status').textContent = '';
+    const cfg = endpoint.oauth_config;
+    const pkceButton = document.getElementById('oauth-login-pkce-button');
+    const deviceButton = document.getElementById('oauth-login-device-button');
+    cfg.authorize_url ? StyleUtils.show(pkceButton) : StyleUtils.hide(pkceButton);
+    cfg.device_authorization_url ? StyleUtils.show(deviceButton) : StyleUtils.hide(deviceButton);
+    StyleUtils.show(document.getElementById('oauth-login-start'));
+    StyleUtils.hide(document.getElementById('oauth-login-pkce'));
+    StyleUtils.hide(document.getElementById('oauth-login-device'));
+    oauthLoginModal.show();
+}
+
+function startOAuthLogin(flow) {
+    setOAuthLoginStatus(T('oauth_starting', '正在发起授权...'), 'text-muted');
+    apiRequest(`/admin/api/endpoints/${encodeURIComponent(oauthLoginEndpointName)}/oauth/login`, {
+        method: 'POST',
+        body: JSON.stringify({ flow: flow })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            setOAuthLoginStatus(data.error, 'text-danger');
+            return;
+        }
+        oauthLoginSession = data;
+        StyleUtils.hide(document.getElementById('oauth-login-start'));
+        if (data.flow === 'pkce') {
+            document.getElementById('oauth-login-authorize-link').href = data.authorize_url;
+            StyleUtils.show(document.getElementById('oauth-login-pkce'));
+            window.open(data.authorize_url, '_blank', 'noopener');
+        } else {
+            document.getElementById('oauth-login-user-code').textContent = data.user_code;
+            document.getElementById('oauth-login-verification-link').href = data.verification_uri_complete || data.verification_uri;
+            StyleUtils.show(document.getElementById('oauth-login-device'));
+        }
+        setOAuthLoginStatus(T('oauth_waiting', '等待授权完成...'), 'text-muted');
+        oauthLoginPollTimer = setInterval(pollOAuthLogin, 2000);
+    })
+    .catch(error => {
+        console.error('Failed to start OAuth login:', error);
+        setOAuthLoginStatus(T('oauth_login_failed', '授权失败'), 'text-danger');
+    });
+}
+
+function pollOAuthLogin() {
+    if (!oauthLoginSession) {
+        return;
+    }
+    apiRequest(`/admin/api/oauth/login/${encodeURIComponent(oauthLoginSession.id)}`)
+        .then(response => response.json())
+        .then(handleOAuthLoginState)
+        .catch(error => console.error('Failed to poll OAuth login:', error));
+}
+
+function submitOAuthCode() {
+    const code = document.getElementById('oauth-login-code').value.trim();
+    if (!code || !oauthLoginSession) {
+        return;
+    }
+    apiRequest(`/admin/api/oauth/login/${encodeURIComponent(oauthLoginSession.id)}/code`, {
+        method: 'POST',
+        body: JSON.stringify({ code: code })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.error) {
+            setOAuthLoginStatus(data.error, 'text-danger');
+        } else {
+            handleOAuthLoginState(data);
+        }
+    })
+    .catch(error => {
+        console.error('Failed to submit OAuth code:', error);
+        setOAuthLoginStatus(T('oauth_login_failed', '授权失败'), 'text-danger');
+    });
+}
+
+function handleOAuthLoginState(data) {
+    if (data.error && !data.status) {
+        stopOAuthLoginPolling();
+        setOAuthLoginStatus(data.error, 'text-danger');
+        return;
+    }
+    if (data.status === 'completed') {
+        stopOAuthLoginPolling();
+        oauthLoginSession = null;
+        oauthLoginModal.hide();
+        showAlert(T('oauth_login_success', '端点 "{0}" 授权成功').replace('{0}', data.endpoint), 'success');
+        loadEndpoints();
+    } else if (data.status === 'failed' || data.status === 'cancelled') {
+        stopOAuthLoginPolling();
+        oauthLoginSession = null;
+        setOAuthLoginStatus(`${T('oauth_login_failed', '授权失败')}: ${data.error || data.status}`, 'text-danger');
+    }
+}
+
+function setOAuthLoginStatus(message, className) {
+    const status = document.getElementById('oauth-login-status');
+    status.className = `mt-3 small ${className}`;
+    status.textContent = message;
+}
+
+function stopOAuthLoginPolling() {
+    if (oauthLoginPollTimer) {
+        clearInterval(oauthLoginPollTimer);
+        oauthLoginPollTimer = null;
+    }
+}
+
+// 关闭对话框时取消未完成的授权
+function cancelOAuthLogin() {
+    stopOAuthLoginPolling();
+    if (oauthLoginSession) {
+        apiRequest(`/admin/api/oauth/login/${encodeURIComponent(oauthLoginSession.id)}`, { method: 'DELETE' })
+            .catch(error => console.error('Failed to cancel OAuth login:', error));
+        oauthLoginSession = null;
+    }
+}
+
+document.addEventListener('click', function(e) {
+    const target = e.target.closest('[data-action]');
+    if (!target) {
+        return;
+    }
+    switch (target.dataset.action) {
+        case 'oauth-login-start':
+            startOAuthLogin(target.dataset.flow);
+            break;
+        case 'oauth-login-submit-code':
+            submitOAuthCode();
+            break;
+    }
+});
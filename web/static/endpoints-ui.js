@@ -39,6 +39,11 @@ function rebuildTable(endpoints) {
         if (!endpoint.enabled) {
             // 如果端点被禁用，显示灰色的"禁用"状态
             statusBadge = `<span class="badge bg-secondary"><i class="fas fa-ban"></i> ${T('disabled', '禁用')}</span>`;
+            if (endpoint.disabled_reason) {
+                // 自动禁用的端点显示原因，例如 OAuth 刷新令牌被拒绝
+                const reason = escapeHtml(`${T('disabled_reason', '禁用原因')}: ${endpoint.disabled_reason}`);
+                statusBadge += ` <span class="badge bg-warning text-dark" title="${reason}"><i class="fas fa-exclamation-triangle"></i> ${T('auto_disabled', '自动禁用')}</span>`;
+            }
         } else if (endpoint.status === 'active') {
             // 如果端点已启用且状态为活跃，显示绿色的"正常"状态
             statusBadge = `<span class="badge bg-success"><i class="fas fa-check-circle"></i> ${T('normal', '正常')}</span>`;
@@ -79,11 +84,19 @@ function rebuildTable(endpoints) {
         if (endpoint.auth_type === 'api_key') {
             authTypeBadge = '<span class="badge bg-primary">api_key</span>';
         } else if (endpoint.auth_type === 'oauth') {
-            authTypeBadge = '<span class="badge bg-success">oauth</span>';
+            authTypeBadge = '<span class="badge bg-success">oauth</span>' + buildOAuthExpiryBadge(endpoint);
         } else {
             authTypeBadge = '<span class="badge bg-secondary">auth_token</span>';
         }
         
+        // Show usable / total keys for endpoints with a credential pool
+        const credentialStats = currentCredentialStats[endpoint.name];
+        if (credentialStats && credentialStats.length > 0) {
+            const usable = credentialStats.filter(item => item.state === 'active').length;
+            const badgeClass = usable === 0 ? 'bg-danger' : (usable < credentialStats.length ? 'bg-warning text-dark' : 'bg-light text-dark');
+            authTypeBadge += ` <span class="badge ${badgeClass}" title="${escapeHtml(T('credential_pool_usable', '可用凭据'))}"><i class="fas fa-key"></i> ${usable}/${credentialStats.length}</span>`;
+        }
+        
         // Build proxy status display
         let proxyDisplay = '';
         if (endpoint.proxy && endpoint.proxy.type && endpoint.proxy.address) {
@@ -116,7 +129,7 @@ function rebuildTable(endpoints) {
             <td>${authTypeBadge}</td>
             <td>${proxyDisplay}</td>
             <td>${tagsDisplay}</td>
-            <td>${statusBadge}</td>
+            <td>${statusBadge}<div class="uptime-bar" data-uptime-for="${escapeHtml(endpoint.name)}"></div></td>
             <td>${enabledBadge}</td>
             <td class="action-buttons">
                 <div class="btn-group btn-group-sm" role="group">
@@ -135,6 +148,11 @@ function rebuildTable(endpoints) {
                             title="复制">
                         <i class="fas fa-copy"></i>
                     </button>
+                    ${canOAuthLogin(endpoint) ? `<button class="btn btn-outline-success btn-sm" 
+                            onclick="event.stopPropagation(); showOAuthLoginModal('${escapeHtml(endpoint.name)}')"
+                            title="${escapeHtml(T('oauth_reauthorize', '重新授权'))}">
+                        <i class="fas fa-sign-in-alt"></i>
+                    </button>` : ''}
                     <button class="btn btn-outline-warning btn-sm" 
                             onclick="event.stopPropagation(); resetEndpointStatus('${escapeHtml(endpoint.name)}')"
                             title="重置状态">
@@ -238,4 +256,44 @@ function updateEndpointStatusBadge(endpointName, enabled, status) {
         }
         statusCell.innerHTML = statusBadge;
     }
-}
\ No newline at end of file
+}
+// Format seconds as a short human readable duration (e.g. 45s, 12m, 3.5h)
+function formatShortDuration(seconds) {
+    if (seconds < 60) return Math.round(seconds) + 's';
+    if (seconds < 3600) return Math.round(seconds / 60) + 'm';
+    return (seconds / 3600).toFixed(1) + 'h';
+}
+
+// Render the 24h uptime bar and availability summary below the status badge
+function renderUptimeBar(container, availability) {
+    if (!availability) {
+        container.innerHTML = '';
+        return;
+    }
+
+    const segments = availability.uptime.map(bucket => {
+        let level = 'up';
+        if (bucket.availability < 90) {
+            level = 'down';
+        } else if (bucket.availability < 100) {
+            level = 'degraded';
+        }
+        const start = new Date(bucket.start).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+        return `<span class="uptime-segment uptime-${level}" title="${start} ${bucket.availability.toFixed(1)}%"></span>`;
+    }).join('');
+
+    const windows = {};
+    availability.windows.forEach(window => { windows[window.window] = window; });
+    const details = availability.windows.map(window => {
+        let line = `${window.window}: ${window.availability.toFixed(2)}%, ${T('incidents', '故障次数')} ${window.incidents}`;
+        if (window.mttr_seconds > 0) {
+            line += `, MTTR ${formatShortDuration(window.mttr_seconds)}`;
+        }
+        return line;
+    }).join('\n');
+
+    container.innerHTML = `
+        <div class="uptime-segments" title="${T('uptime_last_24h', '最近24小时可用率')}">${segments}</div>
+        <small class="text-muted" title="${details}">24h ${windows['24h'].availability.toFixed(1)}% · 7d ${windows['7d'].availability.toFixed(1)}%</small>
+    `;
+}
@@ -2,7 +2,10 @@
 
 let autoRefreshEnabled = false;
 let autoRefreshInterval = null;
-let autoRefreshTimer = 5000; // 5 seconds
+let autoRefreshTimer = 5000; // 5 seconds, polling fallback
+let autoRefreshSource = null;
+let autoRefreshDebounce = null;
+let autoRefreshDebounceDelay = 1000;
 
 // Initialize auto-refresh state on page load
 document.addEventListener('DOMContentLoaded', function() {
@@ -52,28 +55,62 @@ function toggleAutoRefresh() {
 }
 
 function startAutoRefresh() {
-    if (autoRefreshInterval) {
-        clearInterval(autoRefreshInterval);
+    stopAutoRefresh();
+
+    // Prefer server push: refresh when a request log is written, fall back to polling if the stream fails
+    if (window.EventSource) {
+        autoRefreshSource = new EventSource('/admin/api/events/stream');
+        autoRefreshSource.addEventListener('log_written', scheduleLogsRefresh);
+        autoRefreshSource.onerror = function() {
+            console.warn('Log event stream unavailable, falling back to polling');
+            stopAutoRefresh();
+            startPollingRefresh();
+        };
+        return;
     }
-    
-    autoRefreshInterval = setInterval(function() {
-        // Check if any modal is currently open
-        if (isAnyModalOpen()) {
-            console.log('Modal is open, skipping auto-refresh');
-            return;
-        }
-        
-        // Get current page parameters
-        const urlParams = new URLSearchParams(window.location.search);
-        const currentPage = urlParams.get('page') || '1';
-        const failedOnly = urlParams.get('failed_only') === 'true';
-        
-        // Refresh the page
-        refreshLogs(currentPage, failedOnly);
-    }, autoRefreshTimer);
+    startPollingRefresh();
+}
+
+function startPollingRefresh() {
+    autoRefreshInterval = setInterval(refreshCurrentLogsPage, autoRefreshTimer);
+}
+
+// Coalesce bursts of log events into a single refresh
+function scheduleLogsRefresh() {
+    if (autoRefreshDebounce) {
+        return;
+    }
+    autoRefreshDebounce = setTimeout(function() {
+        autoRefreshDebounce = null;
+        refreshCurrentLogsPage();
+    }, autoRefreshDebounceDelay);
+}
+
+function refreshCurrentLogsPage() {
+    // Check if any modal is currently open
+    if (isAnyModalOpen()) {
+        console.log('Modal is open, skipping auto-refresh');
+        return;
+    }
+
+    // Get current page parameters
+    const urlParams = new URLSearchParams(window.location.search);
+    const currentPage = urlParams.get('page') || '1';
+    const failedOnly = urlParams.get('failed_only') === 'true';
+
+    // Refresh the page
+    refreshLogs(currentPage, failedOnly);
 }
 
 function stopAutoRefresh() {
+    if (autoRefreshSource) {
+        autoRefreshSource.close();
+        autoRefreshSource = null;
+    }
+    if (autoRefreshDebounce) {
+        clearTimeout(autoRefreshDebounce);
+        autoRefreshDebounce = null;
+    }
     if (autoRefreshInterval) {
         clearInterval(autoRefreshInterval);
         autoRefreshInterval = null;
@@ -1,10 +1,35 @@
 // Logs Page Navigation and Refresh Functions
 
+// Build a logs page URL that keeps the current filter parameters
+function buildLogsUrl(page, failedOnly) {
+    const params = new URLSearchParams(window.location.search);
+    params.set('page', page);
+    if (failedOnly) {
+        params.set('failed_only', 'true');
+    } else {
+        params.delete('failed_only');
+    }
+    return `/admin/logs?${params.toString()}`;
+}
+
 function toggleFailedOnly(failedOnly, currentPage) {
     failedOnly = !failedOnly;
-    window.location.href = `/admin/logs?page=1&failed_only=${failedOnly}`;
+    window.location.href = buildLogsUrl(1, failedOnly);
 }
 
 function refreshLogs(currentPage, failedOnly) {
-    window.location.href = `/admin/logs?page=${currentPage}&failed_only=${failedOnly}`;
-}
\ No newline at end of file
+    window.location.href = buildLogsUrl(currentPage, failedOnly);
+}
+
+// Drop empty filter fields so the URL only carries active filters
+document.addEventListener('DOMContentLoaded', function() {
+    const form = document.getElementById('logFilterForm');
+    if (!form) return;
+    form.addEventListener('submit', function() {
+        form.querySelectorAll('input, select').forEach(function(field) {
+            if (field.name && field.value === '') {
+                field.disabled = true;
+            }
+        });
+    });
+});
\ No newline at end of file
@@ -0,0 +1,248 @@
+// Session View JavaScript
+
+const SESSIONS_PAGE_SIZE = 50;
+let sessionsOffset = 0;
+let sessionsProject = '';
+
+document.addEventListener('DOMContentLoaded', function() {
+    initializeCommonFeatures();
+
+    const page = document.getElementById('sessionsPage');
+    const sessionId = page ? page.dataset.sessionId : '';
+
+    if (sessionId) {
+        loadSessionDetail(sessionId);
+        return;
+    }
+
+    document.getElementById('refreshSessionsBtn').addEventListener('click', () => {
+        loadProjectTokenStats();
+        loadSessions();
+    });
+    document.getElementById('clearProjectFilterBtn').addEventListener('click', () => filterSessionsByProject(''));
+    document.getElementById('prevSessionsBtn').addEventListener('click', () => {
+        sessionsOffset = Math.max(0, sessionsOffset - SESSIONS_PAGE_SIZE);
+        loadSessions();
+    });
+    document.getElementById('nextSessionsBtn').addEventListener('click', () => {
+        sessionsOffset += SESSIONS_PAGE_SIZE;
+        loadSessions();
+    });
+    loadProjectTokenStats();
+    loadSessions();
+});
+
+async function loadProjectTokenStats() {
+    const tbody = document.querySelector('#projectTokenTable tbody');
+    try {
+        const response = await apiRequest('/admin/api/tokens/stats');
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || response.statusText);
+        }
+        renderProjectTokenStats(data.projects || []);
+    } catch (error) {
+        tbody.innerHTML = `<tr><td colspan="3" class="text-center text-danger">${escapeHtml(T('load_token_stats_failed', '加载 Token 统计失败'))}: ${escapeHtml(error.message)}</td></tr>`;
+    }
+}
+
+function renderProjectTokenStats(projects) {
+    const tbody = document.querySelector('#projectTokenTable tbody');
+    if (projects.length === 0) {
+        tbody.innerHTML = `<tr><td colspan="3" class="text-center text-muted">${escapeHtml(T('no_token_stats', '暂无 Token 用量'))}</td></tr>`;
+        return;
+    }
+
+    // 未识别出项目的请求不能用于过滤会话
+    tbody.innerHTML = projects.map(item => `
+        <tr${item.project ? ` class="cursor-pointer" data-project="${escapeHtml(item.project)}"` : ''}>
+            <td>${item.project ? `<span class="badge bg-info text-dark">${escapeHtml(item.project)}</span>` : `<small class="text-muted">${escapeHtml(T('unknown_project', '未识别项目'))}</small>`}</td>
+            <td>${item.request_count}</td>
+            <td><small>${formatTokenTotals(item)}</small></td>
+        </tr>
+    `).join('');
+
+    tbody.querySelectorAll('tr[data-project]').forEach(row => {
+        row.addEventListener('click', () => filterSessionsByProject(row.dataset.project));
+    });
+}
+
+function filterSessionsByProject(project) {
+    sessionsProject = project;
+    sessionsOffset = 0;
+    const clearButton = document.getElementById('clearProjectFilterBtn');
+    document.getElementById('projectFilterLabel').textContent = project;
+    clearButton.classList.toggle('d-none', !project);
+    loadSessions();
+}
+
+async function loadSessions() {
+    const tbody = document.querySelector('#sessionTable tbody');
+    try {
+        let url = `/admin/api/sessions?limit=${SESSIONS_PAGE_SIZE}&offset=${sessionsOffset}`;
+        if (sessionsProject) {
+            url += `&project=${encodeURIComponent(sessionsProject)}`;
+        }
+        const response = await apiRequest(url);
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || response.statusText);
+        }
+        renderSessions(data.sessions || [], data.total || 0);
+    } catch (error) {
+        tbody.innerHTML = `<tr><td colspan="8" class="text-center text-danger">${escapeHtml(T('load_sessions_failed', '加载会话失败'))}: ${escapeHtml(error.message)}</td></tr>`;
+    }
+}
+
+function renderSessions(sessions, total) {
+    const tbody = document.querySelector('#sessionTable tbody');
+    document.getElementById('sessionTotal').textContent = `(${total})`;
+    document.getElementById('prevSessionsBtn').disabled = sessionsOffset === 0;
+    document.getElementById('nextSessionsBtn').disabled = sessionsOffset + SESSIONS_PAGE_SIZE >= total;
+
+    if (sessions.length === 0) {
+        tbody.innerHTML = `<tr><td colspan="8" class="text-center text-muted">${escapeHtml(T('no_sessions', '暂无会话记录'))}</td></tr>`;
+        return;
+    }
+
+    tbody.innerHTML = sessions.map(session => `
+        <tr class="cursor-pointer" data-session-id="${escapeHtml(session.session_id)}">
+            <td>${renderSessionBadge(session.session_id)}</td>
+            <td>${session.project ? `<span class="badge bg-info text-dark">${escapeHtml(session.project)}</span>` : '<small class="text-muted">-</small>'}</td>
+            <td><small>${formatSessionTime(session.start_time)}</small></td>
+            <td><small>${formatSessionTime(session.end_time)}</small></td>
+            <td>${session.request_count}${session.failed_count > 0 ? ` <span class="badge bg-danger" title="${escapeHtml(T('failed_attempts', '失败的尝试'))}">${session.failed_count}</span>` : ''}</td>
+            <td>${session.failover_count > 0 ? `<span class="badge bg-warning text-dark">${session.failover_count}</span>` : '0'}</td>
+            <td>${(session.endpoints || []).map(ep => `<span class="badge bg-secondary me-1">${escapeHtml(ep)}</span>`).join('')}</td>
+            <td><small>${formatTokenTotals(session)}</small></td>
+        </tr>
+    `).join('');
+
+    tbody.querySelectorAll('tr[data-session-id]').forEach(row => {
+        row.addEventListener('click', () => {
+            window.location.href = `/admin/sessions?session_id=${encodeURIComponent(row.dataset.sessionId)}`;
+        });
+    });
+}
+
+async function loadSessionDetail(sessionId) {
+    document.getElementById('projectTokenCard').classList.add('d-none');
+    document.getElementById('sessionListCard').classList.add('d-none');
+    document.getElementById('sessionDetail').classList.remove('d-none');
+    document.getElementById('sessionDetailId').textContent = sessionId;
+    document.getElementById('sessionLogsLink').href = `/admin/logs?session_id=${encodeURIComponent(sessionId)}`;
+
+    const tbody = document.querySelector('#sessionTimelineTable tbody');
+    try {
+        const response = await apiRequest(`/admin/api/sessions/${encodeURIComponent(sessionId)}`);
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || response.statusText);
+        }
+        renderSessionSummary(data.session);
+        renderTimeline(data.timeline || []);
+    } catch (error) {
+        tbody.innerHTML = `<tr><td colspan="8" class="text-center text-danger">${escapeHtml(T('load_sessions_failed', '加载会话失败'))}: ${escapeHtml(error.message)}</td></tr>`;
+    }
+}
+
+function renderSessionSummary(session) {
+    const items = [
+        [T('start_time', '开始时间'), formatSessionTime(session.start_time)],
+        [T('end_time', '结束时间'), formatSessionTime(session.end_time)],
+        [T('session_requests', '请求数'), `${session.request_count} / ${session.attempt_count}`],
+        [T('session_failovers', '故障转移'), session.failover_count],
+        [T('failed_attempts', '失败的尝试'), session.failed_count],
+        [T('token_totals', 'Token（输入/输出/缓存读）'), formatTokenTotals(session)],
+    ];
+    document.getElementById('sessionSummary').innerHTML = items.map(([label, value]) => `
+        <div class="col-md-2">
+            <div class="fw-bold">${escapeHtml(String(value))}</div>
+            <small class="text-muted">${escapeHtml(label)}</small>
+        </div>
+    `).join('');
+}
+
+function renderTimeline(timeline) {
+    const tbody = document.querySelector('#sessionTimelineTable tbody');
+    if (timeline.length === 0) {
+        tbody.innerHTML = `<tr><td colspan="8" class="text-center text-muted">${escapeHtml(T('no_sessions', '暂无会话记录'))}</td></tr>`;
+        return;
+    }
+
+    tbody.innerHTML = timeline.map((entry, index) => {
+        const failed = entry.status_code >= 400 || entry.error;
+        const statusClass = failed ? 'bg-danger' : 'bg-success';
+        const endpoints = entry.attempts > 1
+            ? entry.endpoints.map(ep => escapeHtml(ep)).join(' → ')
+            : escapeHtml(entry.endpoint);
+        return `
+            <tr class="${entry.diff && entry.diff.repeated ? 'table-warning' : ''}">
+                <td><small>${formatSessionTime(entry.timestamp)}</small></td>
+                <td><small><code>${escapeHtml(entry.request_id)}</code></small></td>
+                <td><small>${escapeHtml(entry.model || '-')}</small></td>
+                <td><small>${endpoints}</small>${entry.fallback_level > 0 ? ` <span class="badge bg-warning text-dark">↓ ${escapeHtml(entry.fallback_chain)}#${entry.fallback_level}</span>` : ''}</td>
+                <td><span class="badge ${statusClass}">${entry.status_code || '-'}</span>${entry.error ? `<div><small class="text-danger">${escapeHtml(entry.error.substring(0, 60))}</small></div>` : ''}</td>
+                <td><small>${formatDuration(entry.duration_ms)}</small></td>
+                <td><small>${formatTokenTotals(entry)}</small></td>
+                <td>${renderDiffSummary(entry.diff, index)}</td>
+            </tr>
+            ${renderDiffDetail(entry.diff, index)}
+        `;
+    }).join('');
+
+    tbody.querySelectorAll('[data-diff-toggle]').forEach(button => {
+        button.addEventListener('click', () => {
+            document.getElementById(`diff-${button.dataset.diffToggle}`).classList.toggle('d-none');
+        });
+    });
+}
+
+function renderDiffSummary(diff, index) {
+    if (!diff || diff.unavailable) {
+        return `<small class="text-muted">${escapeHtml(T('request_body_not_logged', '未记录请求体'))}</small>`;
+    }
+    if (diff.repeated) {
+        return `<span class="badge bg-warning text-dark">${escapeHtml(T('repeated_request', '与上一轮相同'))}</span>`;
+    }
+
+    const parts = [];
+    if (diff.new_thread) {
+        parts.push(`<span class="badge bg-info text-dark">${escapeHtml(T('new_conversation', '新对话'))}</span>`);
+    }
+    if (diff.added.length > 0) {
+        parts.push(`<span class="badge bg-success">+${diff.added.length}</span>`);
+    }
+    if (diff.removed > 0) {
+        parts.push(`<span class="badge bg-danger">-${diff.removed}</span>`);
+    }
+    parts.push(`<small class="text-muted">${diff.message_count} ${escapeHtml(T('messages_count_suffix', '条消息'))}</small>`);
+    if (diff.added.length > 0) {
+        parts.push(`<button class="btn btn-link btn-sm p-0 ms-1" data-diff-toggle="${index}"><i class="fas fa-chevron-down"></i></button>`);
+    }
+    return parts.join(' ');
+}
+
+function renderDiffDetail(diff, index) {
+    if (!diff || diff.unavailable || diff.added.length === 0) {
+        return '';
+    }
+    const messages = diff.added.map(message => `
+        <div class="mb-1"><span class="badge ${message.role === 'user' ? 'bg-primary' : 'bg-secondary'}">${escapeHtml(message.role)}</span>
+        <small>${escapeHtml(message.summary)}</small></div>
+    `).join('');
+    return `<tr class="d-none" id="diff-${index}"><td colspan="8" class="bg-light">${messages}</td></tr>`;
+}
+
+function renderSessionBadge(sessionId) {
+    return `<span class="session-id-badge" style="background-color: ${generateSessionIdColor(sessionId)}" title="${escapeHtml(sessionId)}">${escapeHtml(getSessionIdDisplayText(sessionId))}</span> <small class="text-muted">${escapeHtml(sessionId.substring(0, 8))}</small>`;
+}
+
+function formatTokenTotals(item) {
+    return `${item.input_tokens || 0} / ${item.output_tokens || 0} / ${item.cache_read_input_tokens || 0}`;
+}
+
+function formatSessionTime(timestamp) {
+    if (!timestamp) return '-';
+    return new Date(timestamp).toLocaleString();
+}
\ No newline at end of file
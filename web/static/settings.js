@@ -70,7 +70,15 @@ function collectFormData() {
             log_request_types: document.getElementById('logRequestTypes').value,
             log_request_body: document.getElementById('logRequestBody').value,
             log_response_body: document.getElementById('logResponseBody').value,
-            log_directory: document.getElementById('logDirectory').value
+            log_directory: document.getElementById('logDirectory').value,
+            retention: {
+                max_age_days: parseInt(document.getElementById('retentionMaxAgeDays').value) || 0,
+                failed_max_age_days: parseInt(document.getElementById('retentionFailedMaxAgeDays').value) || 0,
+                body_max_age_days: parseInt(document.getElementById('retentionBodyMaxAgeDays').value) || 0,
+                max_rows: parseInt(document.getElementById('retentionMaxRows').value) || 0,
+                max_database_size_mb: parseInt(document.getElementById('retentionMaxDatabaseSizeMB').value) || 0,
+                cleanup_interval: document.getElementById('retentionCleanupInterval').value
+            }
         },
         validation: {
         },
@@ -160,6 +168,12 @@ function resetSettings() {
     document.getElementById('logRequestBody').value = originalConfig.logging.log_request_body;
     document.getElementById('logResponseBody').value = originalConfig.logging.log_response_body;
     document.getElementById('logDirectory').value = originalConfig.logging.log_directory;
+    document.getElementById('retentionMaxAgeDays').value = originalConfig.logging.retention.max_age_days;
+    document.getElementById('retentionFailedMaxAgeDays').value = originalConfig.logging.retention.failed_max_age_days;
+    document.getElementById('retentionBodyMaxAgeDays').value = originalConfig.logging.retention.body_max_age_days;
+    document.getElementById('retentionMaxRows').value = originalConfig.logging.retention.max_rows;
+    document.getElementById('retentionMaxDatabaseSizeMB').value = originalConfig.logging.retention.max_database_size_mb;
+    document.getElementById('retentionCleanupInterval').value = originalConfig.logging.retention.cleanup_interval;
     document.getElementById('tlsHandshake').value = originalConfig.timeouts.tls_handshake;
     document.getElementById('responseHeader').value = originalConfig.timeouts.response_header;
     document.getElementById('idleConnection').value = originalConfig.timeouts.idle_connection;
@@ -50,6 +50,11 @@ document.addEventListener('DOMContentLoaded', function() {
         saveBtn.addEventListener('click', saveTagger);
         typeSelect.addEventListener('change', onTypeChange);
         builtinSelect.addEventListener('change', onBuiltinTypeChange);
+        document.getElementById('simulateTaggerBtn').addEventListener('click', showSimulateModal);
+        document.getElementById('runSimulationBtn').addEventListener('click', runSimulation);
+        document.querySelectorAll('input[name="simulateSource"]').forEach(radio => {
+            radio.addEventListener('change', onSimulateSourceChange);
+        });
     }
     
     initializeEventListeners();
@@ -105,7 +110,7 @@ function renderTaggers() {
     tbody.innerHTML = '';
     
     if (taggers.length === 0) {
-        tbody.innerHTML = '<tr><td colspan="7" class="text-center text-muted">No taggers configured</td></tr>';
+        tbody.innerHTML = '<tr><td colspan="8" class="text-center text-muted">No taggers configured</td></tr>';
         return;
     }
     
@@ -123,6 +128,10 @@ function renderTaggers() {
             </td>
             <td><span class="badge bg-secondary">${escapeHtml(tagger.tag)}</span></td>
             <td>${tagger.priority}</td>
+            <td>
+                ${tagger.stage || 0}
+                ${tagger.depends_on && tagger.depends_on.length > 0 ? `<br><small class="text-muted">← ${tagger.depends_on.map(dep => escapeHtml(dep)).join(', ')}</small>` : ''}
+            </td>
             <td>
                 <span class="badge ${tagger.enabled ? 'bg-success' : 'bg-warning'}">
                     ${tagger.enabled ? T('enabled', '已启用') : T('disabled', '已禁用')}
@@ -193,6 +202,8 @@ function editTagger(name) {
     document.getElementById('taggerType').value = editingTagger.type;
     document.getElementById('taggerTag').value = editingTagger.tag;
     document.getElementById('taggerPriority').value = editingTagger.priority;
+    document.getElementById('taggerStage').value = editingTagger.stage || 0;
+    document.getElementById('taggerDependsOn').value = (editingTagger.depends_on || []).join(', ');
     document.getElementById('taggerEnabled').checked = editingTagger.enabled;
     
     if (editingTagger.type === 'builtin') {
@@ -232,17 +243,20 @@ async function deleteTagger(name) {
     }
 }
 
-// Save tagger
-async function saveTagger() {
+// Collect tagger data from the modal form, returns null if invalid
+function collectTaggerFormData() {
     const name = document.getElementById('taggerName').value.trim();
     const type = document.getElementById('taggerType').value;
     const tag = document.getElementById('taggerTag').value.trim();
     const priority = parseInt(document.getElementById('taggerPriority').value);
+    const stage = parseInt(document.getElementById('taggerStage').value) || 0;
+    const dependsOnInput = document.getElementById('taggerDependsOn').value.trim();
+    const dependsOn = dependsOnInput ? dependsOnInput.split(',').map(dep => dep.trim()).filter(dep => dep) : [];
     const enabled = document.getElementById('taggerEnabled').checked;
     
     if (!name || !type || !tag) {
         showAlert(T('please_fill_required_fields', 'Please fill in all required fields'), 'warning');
-        return;
+        return null;
     }
     
     const taggerData = {
@@ -250,6 +264,8 @@ async function saveTagger() {
         type,
         tag,
         priority,
+        stage,
+        depends_on: dependsOn,
         enabled,
         config: collectConfigFields()
     };
@@ -258,11 +274,21 @@ async function saveTagger() {
         const builtinType = document.getElementById('builtinType').value;
         if (!builtinType) {
             showAlert(T('please_select_builtin_type', 'Please select a built-in type'), 'warning');
-            return;
+            return null;
         }
         taggerData.builtin_type = builtinType;
     }
     
+    return taggerData;
+}
+
+// Save tagger
+async function saveTagger() {
+    const taggerData = collectTaggerFormData();
+    if (!taggerData) {
+        return;
+    }
+    
     try {
         const url = editingTagger ? 
             `/admin/api/taggers/${encodeURIComponent(editingTagger.name)}` : 
@@ -293,6 +319,128 @@ async function saveTagger() {
     }
 }
 
+// Show dry run modal for the tagger currently in the form
+function showSimulateModal() {
+    const taggerData = collectTaggerFormData();
+    if (!taggerData) {
+        return;
+    }
+    
+    document.getElementById('simulateTaggerName').textContent = taggerData.name;
+    document.getElementById('simulateSummary').innerHTML = '';
+    document.querySelector('#simulateResultsTable tbody').innerHTML = '';
+    new bootstrap.Modal(document.getElementById('simulateModal')).show();
+}
+
+// Handle dry run source change
+function onSimulateSourceChange() {
+    const source = document.querySelector('input[name="simulateSource"]:checked').value;
+    if (source === 'paste') {
+        StyleUtils.show(document.getElementById('simulatePasteGroup'));
+        StyleUtils.hide(document.getElementById('simulateLimitGroup'));
+    } else {
+        StyleUtils.hide(document.getElementById('simulatePasteGroup'));
+        StyleUtils.show(document.getElementById('simulateLimitGroup'));
+    }
+}
+
+// Run tagger dry run against logs or pasted request
+async function runSimulation() {
+    const taggerData = collectTaggerFormData();
+    if (!taggerData) {
+        return;
+    }
+    
+    const payload = { tagger: taggerData };
+    const source = document.querySelector('input[name="simulateSource"]:checked').value;
+    
+    if (source === 'paste') {
+        let headers = {};
+        const headersText = document.getElementById('simulateHeaders').value.trim();
+        if (headersText) {
+            try {
+                headers = JSON.parse(headersText);
+            } catch (e) {
+                showAlert(T('invalid_headers_json', 'Headers must be a valid JSON object'), 'warning');
+                return;
+            }
+        }
+        payload.request = {
+            method: document.getElementById('simulateMethod').value.trim(),
+            path: document.getElementById('simulatePath').value.trim(),
+            headers,
+            body: document.getElementById('simulateBody').value
+        };
+    } else {
+        payload.limit = parseInt(document.getElementById('simulateLimit').value) || 20;
+    }
+    
+    const runBtn = document.getElementById('runSimulationBtn');
+    runBtn.disabled = true;
+    
+    try {
+        const response = await apiRequest('/admin/api/taggers/simulate', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(payload)
+        });
+        
+        const data = await response.json();
+        
+        if (response.ok) {
+            renderSimulationResults(data);
+        } else {
+            showAlert(data.error || T('simulate_failed', 'Dry run failed'), 'danger');
+        }
+    } catch (error) {
+        console.error('Failed to run tagger dry run:', error);
+        showAlert(T('simulate_failed', 'Dry run failed'), 'danger');
+    } finally {
+        runBtn.disabled = false;
+    }
+}
+
+// Render dry run results
+function renderSimulationResults(data) {
+    const results = data.results || [];
+    const summary = data.summary || { total: 0, matched: 0, errors: 0 };
+    const tbody = document.querySelector('#simulateResultsTable tbody');
+    tbody.innerHTML = '';
+    
+    document.getElementById('simulateSummary').textContent = T('simulate_summary', '{0} requests, {1} matched, {2} errors')
+        .replace('{0}', summary.total)
+        .replace('{1}', summary.matched)
+        .replace('{2}', summary.errors);
+    
+    if (results.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="8" class="text-center text-muted">' + escapeHtml(T('simulate_no_results', 'No requests to simulate')) + '</td></tr>';
+        return;
+    }
+    
+    const renderTags = (list) => (list || []).map(tag => `<span class="badge bg-secondary me-1">${escapeHtml(tag)}</span>`).join('');
+    
+    results.forEach(result => {
+        const row = document.createElement('tr');
+        row.innerHTML = `
+            <td class="text-nowrap">${result.timestamp ? escapeHtml(new Date(result.timestamp).toLocaleString()) : '-'}</td>
+            <td><code>${escapeHtml(result.request_id || '-')}</code></td>
+            <td>${escapeHtml(result.model || '')}</td>
+            <td>
+                <span class="badge ${result.matched ? 'bg-success' : 'bg-secondary'}">
+                    ${result.matched ? '<i class="fas fa-check"></i>' : '<i class="fas fa-minus"></i>'}
+                </span>
+            </td>
+            <td>${renderTags(result.tags)}</td>
+            <td>${renderTags(result.original_tags)}</td>
+            <td>${result.selected_endpoint ? escapeHtml(result.selected_endpoint) : `<span class="text-danger">${escapeHtml(result.endpoint_error || '-')}</span>`}</td>
+            <td class="text-danger small">${escapeHtml(result.error || '')}</td>
+        `;
+        tbody.appendChild(row);
+    });
+}
+
 // Handle type change
 function onTypeChange() {
     const type = document.getElementById('taggerType').value;
@@ -340,6 +488,12 @@ function onBuiltinTypeChange() {
         case 'thinking':
             addConfigField('min_budget_tokens', 'number', T('min_budget_tokens_label', '最小 Budget Tokens (optional, default: 0)'), '0');
             break;
+        case 'project':
+            addConfigField('expected_value', 'text', T('project_pattern_label', '项目名或工作目录(支持通配符)'), '*/work/acme-*');
+            break;
+        case 'context-length':
+            addConfigField('min_tokens', 'number', T('min_context_tokens_label', '最小估算 Tokens (optional, default: 150000)'), '150000');
+            break;
     }
 }
 